@@ -1,42 +1,64 @@
 package main
 
 import (
+	"birthday_congrats/databases"
 	alertmanager "birthday_congrats/internal/pkg/alert_manager"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/config"
+	"birthday_congrats/internal/pkg/cron"
 	"birthday_congrats/internal/pkg/handlers"
+	"birthday_congrats/internal/pkg/ldap"
 	"birthday_congrats/internal/pkg/middlware"
-	"birthday_congrats/internal/pkg/session"
-	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/migrate"
+	"birthday_congrats/internal/pkg/oidc"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/password"
 	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
 	service "birthday_congrats/internal/services/congrats_service"
 	"context"
+	"crypto/rand"
+	"crypto/tls"
 	"database/sql"
 	"flag"
 	"fmt"
 	"html/template"
+	"net"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"sync"
-	"time"
+	_ "time/tzdata" // база часовых поясов на случай, если ее нет в системе
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
-const (
-	// port                     = ":8080" // порт
-	minutesBeforeStartAlerts = 5  // время до запуска сервиса оповещений с момента старта программы в минутах
-	logoutTimeoutMinutes     = 60 // время жизни сессии в минутах
-	alertPeriodHours         = 24 // период отправки почтовых сообщений в часах
-)
-
 var (
-	port = flag.Int("port", 8000, "port to listen and serve")
+	configPath  = flag.String("config", "", "path to yaml config")
+	printConfig = flag.Bool("print-config", false, "print config with secrets redacted and exit")
 )
 
 func main() {
+	configFlags := config.RegisterFlags(flag.CommandLine)
 	flag.Parse()
 
-	templates := template.Must(template.ParseGlob("./templates/*"))
+	// конфигурация
+	cfg, err := config.Load(*configPath, os.LookupEnv, configFlags)
+	if err != nil {
+		fmt.Printf("Error while loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *printConfig {
+		err = cfg.Print(os.Stdout)
+		if err != nil {
+			fmt.Printf("Error while printing config: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// логгер
 	zapLogger, err := zap.NewProduction()
@@ -45,68 +67,169 @@ func main() {
 	}
 	logger := zapLogger.Sugar()
 
-	// база данных
-	dsn := "root:root@tcp(localhost:3306)/golang?" +
-		"&charset=utf8" +
-		"&interpolateParams=true"
+	// хэширование паролей
+	hasher := password.NewPBKDF2Hasher(
+		cfg.Password.Iterations,
+		cfg.Password.SaltLength,
+		cfg.Password.KeyLength,
+	)
 
-	dbMySQL, err := sql.Open("mysql", dsn)
-	if err != nil {
-		logger.Errorf("Cant open connection to usersDB: %v", err)
-		return
-	}
-	defer dbMySQL.Close()
-	logger.Infow("Connected to MySQL database")
+	// хранилища
+	var store *storage
+	if cfg.Storage.Backend == config.StorageMemory {
+		if flag.Arg(0) == "migrate" {
+			logger.Errorf("Migrations are only needed for mysql storage")
+			os.Exit(1)
+		}
+		if flag.Arg(0) == "role" {
+			// данные в памяти есть только у процесса сервера, отдельный запуск их не видит
+			logger.Errorf("Roles can only be assigned from the command line in mysql storage")
+			os.Exit(1)
+		}
+		if flag.Arg(0) == "verify-emails" {
+			// письма из очереди в памяти отдельного запуска никто не отправит
+			logger.Errorf("Verification emails can only be queued from the command line in mysql storage")
+			os.Exit(1)
+		}
 
-	dbMySQL.SetMaxOpenConns(10)
+		logger.Warnf("Using in-memory storage, all data will be lost on restart")
+		store = newMemoryStorage(cfg, hasher, logger)
+	} else {
+		// база данных
+		dbMySQL, err := sql.Open("mysql", cfg.DSN())
+		if err != nil {
+			logger.Errorf("Cant open connection to usersDB: %v", err)
+			return
+		}
+		defer dbMySQL.Close()
+		logger.Infow("Connected to MySQL database")
 
-	err = dbMySQL.Ping()
-	if err != nil {
-		logger.Errorf("No connection to dbMySQL: %v", err)
-		return
+		dbMySQL.SetMaxOpenConns(cfg.MySQL.MaxOpenConns)
+
+		err = dbMySQL.Ping()
+		if err != nil {
+			logger.Errorf("No connection to dbMySQL: %v", err)
+			return
+		}
+
+		// миграции схемы бд
+		migrations, err := migrate.Load(databases.Migrations())
+		if err != nil {
+			logger.Errorf("Error while loading migrations: %v", err)
+			return
+		}
+
+		migrator := migrate.NewMySQLMigrator(
+			dbMySQL,
+			migrations,
+			logger,
+		)
+
+		if flag.Arg(0) == "migrate" {
+			err = runMigrate(context.Background(), migrator, flag.Args()[1:], os.Stdout)
+			if err != nil {
+				logger.Errorf("Migration error: %v", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		pending, err := pendingMigrations(context.Background(), migrator)
+		if err != nil {
+			logger.Errorf("Error while checking migrations: %v", err)
+			return
+		}
+		if pending > 0 {
+			logger.Errorf("Database schema is out of date: %d pending migrations, run `migrate up`", pending)
+			return
+		}
+
+		store = newMySQLStorage(dbMySQL, cfg, hasher, logger)
+
+		if flag.Arg(0) == "role" {
+			err = runRole(context.Background(), store.users, flag.Args()[1:], os.Stdout)
+			if err != nil {
+				logger.Errorf("Role error: %v", err)
+				os.Exit(1)
+			}
+			return
+		}
 	}
 
-	// репозитории
-	usersRepo := user.NewUsersMySQLRepo(
-		dbMySQL,
-		logger,
-	)
+	templates := template.Must(template.ParseGlob(cfg.Server.Templates))
 
-	subscriptionsRepo := subscription.NewSubscriptionsMySQLRepo(
-		dbMySQL,
+	// менеджер отправки писем
+	am := alertmanager.NewEmailAlertManager(
+		cfg.SMTP.From,
+		cfg.SMTP.Password,
+		cfg.SMTP.Host,
+		cfg.SMTP.Port,
 		logger,
 	)
 
-	// менеджер сессий
-	sm := session.NewMySQLSessionsManager(
-		dbMySQL,
+	// воркер очереди исходящих писем
+	outboxWorker := outbox.NewWorker(
+		store.outbox,
+		am,
+		cfg.Outbox.MaxAttempts,
+		cfg.Outbox.BaseDelay,
+		cfg.Outbox.MaxDelay,
+		cfg.Outbox.BatchSize,
 		logger,
-		int64(time.Minute*logoutTimeoutMinutes/time.Second),
-		16,
 	)
 
-	// менеджер отправки писем
-	am := alertmanager.NewEmailAlertManager(
-		// Информация об отправителе (в продакшене я бы закинул это в credentials на github/gitlab)
-		"birthday.congratulations@yandex.ru",
-		"ucgcgejoiguychfa",
+	// ключ подписи ссылок подтверждения почты
+	verificationKey := []byte(cfg.Verification.Secret)
+	if len(verificationKey) == 0 {
+		logger.Warnf("verification.secret is not set: verification links will stop working after restart")
 
-		// smtp сервер конфигурация
-		"smtp.yandex.ru",
-		"587",
-		logger,
-	)
+		verificationKey = make([]byte, 32)
+		_, err = rand.Read(verificationKey)
+		if err != nil {
+			logger.Errorf("Error while generating verification key: %v", err)
+			return
+		}
+	}
 
 	// сам сервис
-	service := service.NewCongratulationsServiceImpl(
-		usersRepo,
-		subscriptionsRepo,
-		sm,
-		am,
+	congratsService := service.NewCongratulationsServiceImpl(
+		store.users,
+		store.subscriptions,
+		store.sessions,
+		store.outbox,
+		store.deliveries,
+		store.resets,
+		store.audit,
+		birthday.LeapDayPolicy(cfg.Birthdays.LeapDay), // значение уже проверено в cfg.Validate()
+		verification.NewSigner(verificationKey, verification.PurposeEmail, cfg.Verification.TTL),
+		cfg.Server.BaseURL,
+		cfg.Password.ResetTTL,
+		cfg.Password.InviteTTL,
 		logger,
 	)
 
+	if flag.Arg(0) == "verify-emails" {
+		// ссылки проверяет сервер: со случайным ключом этого запуска они бы не подошли
+		if cfg.Verification.Secret == "" {
+			logger.Errorf("verification.secret must be set to send verification links from the command line")
+			os.Exit(1)
+		}
+
+		err = runVerifyEmails(context.Background(), congratsService, flag.Args()[1:], os.Stdout)
+		if err != nil {
+			logger.Errorf("Verification error: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// запускаем сервис оповещений
+	schedule, err := cron.Parse(cfg.Alerts.Schedule)
+	if err != nil {
+		logger.Errorf("Bad alerts schedule: %v", err)
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	wg := &sync.WaitGroup{}
 	defer func(wg *sync.WaitGroup) {
@@ -115,41 +238,221 @@ func main() {
 	}(wg)
 
 	wg.Add(1)
-	go service.StartAlert(ctx, time.Now().Add(time.Minute*minutesBeforeStartAlerts), time.Hour*alertPeriodHours, wg)
+	go congratsService.StartAlert(ctx, schedule, wg)
+
+	wg.Add(1)
+	go outboxWorker.Run(ctx, cfg.Outbox.PollInterval, wg)
+
+	// синхронизация с каталогом сотрудников
+	if cfg.LDAP.Addr != "" {
+		var tlsConfig *tls.Config
+		if cfg.LDAP.TLS {
+			host, _, _ := net.SplitHostPort(cfg.LDAP.Addr) // значение уже проверено в cfg.Validate()
+			tlsConfig = &tls.Config{ServerName: host}
+		}
+
+		directory := ldap.NewDirectory(
+			cfg.LDAP.Addr,
+			tlsConfig,
+			cfg.LDAP.BindDN,
+			cfg.LDAP.BindPassword,
+			cfg.LDAP.BaseDN,
+			cfg.LDAP.Filter,
+			ldap.Mapping{
+				ID:            cfg.LDAP.IDAttribute,
+				Name:          cfg.LDAP.NameAttribute,
+				Email:         cfg.LDAP.EmailAttribute,
+				Birthday:      cfg.LDAP.BirthdayAttribute,
+				Department:    cfg.LDAP.DepartmentAttribute,
+				Status:        cfg.LDAP.StatusAttribute,
+				InactiveValue: cfg.LDAP.InactiveValue,
+			},
+			cfg.LDAP.Timeout,
+			logger,
+		)
+
+		wg.Add(1)
+		go congratsService.StartDirectorySync(ctx, directory, cfg.LDAP.Interval, wg)
+	}
+
+	sm := store.sessions
+
+	// вход через провайдера OpenID Connect
+	var oidcClient *oidc.Client
+	if cfg.OIDC.Issuer != "" {
+		oidcClient = oidc.NewClient(
+			cfg.OIDC.Issuer,
+			cfg.OIDC.ClientID,
+			cfg.OIDC.ClientSecret,
+			strings.TrimSuffix(cfg.Server.BaseURL, "/")+"/login/oidc/callback",
+			strings.Fields(cfg.OIDC.Scopes),
+			cfg.OIDC.Timeout,
+			logger,
+		)
+	}
+
+	// без https браузер не вернет cookie с флагом Secure, поэтому он ставится только для https
+	secureCookies := strings.HasPrefix(cfg.Server.BaseURL, "https://")
 
 	// хендлеры
 	serviceHandler := handlers.NewServiceHandler(
 		templates,
-		service,
+		congratsService,
 		sm,
+		oidcClient,
+		secureCookies,
 		logger,
 	)
 
 	// роутер
 	router := mux.NewRouter()
-	router.HandleFunc("/", serviceHandler.Index).Methods("GET")
-	router.HandleFunc("/register", serviceHandler.Register).Methods("POST")
-	router.HandleFunc("/login", serviceHandler.Login).Methods("POST")
-	router.HandleFunc("/error", serviceHandler.ErrorPage).Methods("GET")
+
+	// html-страницы: изменяющие запросы из форм проверяются на CSRF. API их не проходит: оно
+	// принимает сессию только из заголовка Authorization, который браузер сам не подставляет
+	pages := router.NewRoute().Subrouter()
+	pages.Use(func(next http.Handler) http.Handler {
+		return middlware.CSRF(secureCookies, logger, next)
+	})
+	pages.HandleFunc("/", serviceHandler.Index).Methods("GET")
+	pages.HandleFunc("/register", serviceHandler.Register).Methods("POST")
+	pages.HandleFunc("/login", serviceHandler.Login).Methods("POST")
+	pages.HandleFunc("/error", serviceHandler.ErrorPage).Methods("GET")
+	pages.HandleFunc("/verify", serviceHandler.VerifyEmail).Methods("GET")
+	pages.HandleFunc("/forgot", serviceHandler.ForgotPassword).Methods("POST")
+	pages.HandleFunc("/reset", serviceHandler.ResetPasswordForm).Methods("GET")
+	pages.HandleFunc("/reset", serviceHandler.ResetPassword).Methods("POST")
+	pages.HandleFunc("/account/delete", serviceHandler.DeleteAccountForm).Methods("GET")
+	pages.HandleFunc("/account/delete", serviceHandler.DeleteAccountByLink).Methods("POST")
+	if oidcClient != nil {
+		pages.HandleFunc("/login/oidc", serviceHandler.LoginOIDC).Methods("GET")
+		pages.HandleFunc("/login/oidc/callback", serviceHandler.OIDCCallback).Methods("GET")
+	}
 
 	// хендлеры, требующие авторизации
-	router.Handle("/users",
+	pages.Handle("/users",
 		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.Users))).Methods("GET")
-	router.Handle("/subscribe/{user_id}",
+	pages.Handle("/subscribe/{user_id}",
 		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.Subscribe))).Methods("POST")
-	router.Handle("/unsubscribe/{user_id}",
+	pages.Handle("/update/{user_id}",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.UpdateSubscription))).Methods("POST")
+	pages.Handle("/unsubscribe/{user_id}",
 		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.Unsubscribe))).Methods("POST")
-	router.Handle("/logout",
-		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.Logout))).Methods("GET")
+	pages.Handle("/unsubscribe/{user_id}/{days_alert}",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.RemoveDaysAlert))).Methods("POST")
+	pages.Handle("/verify/resend",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.ResendVerification))).Methods("POST")
+	pages.Handle("/privacy",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.UpdatePrivacy))).Methods("POST")
+	pages.Handle("/profile",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.Profile))).Methods("GET")
+	pages.Handle("/profile",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.UpdateProfile))).Methods("POST")
+	pages.Handle("/profile/password",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.ChangePassword))).Methods("POST")
+	pages.Handle("/profile/export",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.ExportData))).Methods("GET")
+	pages.Handle("/profile/delete",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.DeleteAccount))).Methods("POST")
+	pages.Handle("/profile/delete/link",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.RequestAccountDeletion))).Methods("POST")
+	pages.Handle("/logout",
+		middlware.Auth(sm, logger, http.HandlerFunc(serviceHandler.Logout))).Methods("POST")
+
+	// администрирование: роль проверяется и здесь, и в сервисе
+	adminOnly := func(h http.HandlerFunc) http.Handler {
+		return middlware.Auth(sm, logger, middlware.RequireRole(congratsService, user.RoleAdmin, logger, h))
+	}
+	pages.Handle("/admin", adminOnly(serviceHandler.Admin)).Methods("GET")
+	pages.Handle("/admin/users/{user_id}/role", adminOnly(serviceHandler.AdminSetRole)).Methods("POST")
+	pages.Handle("/admin/users/{user_id}/deactivate", adminOnly(serviceHandler.AdminDeactivateUser)).Methods("POST")
+	pages.Handle("/admin/users/{user_id}/logout", adminOnly(serviceHandler.AdminLogoutUser)).Methods("POST")
+	pages.Handle("/admin/users/{user_id}/delete", adminOnly(serviceHandler.AdminDeleteUser)).Methods("POST")
+	pages.Handle("/admin/alerts", adminOnly(serviceHandler.AdminRunAlerts)).Methods("POST")
+	pages.Handle("/admin/import", adminOnly(serviceHandler.AdminImport)).Methods("POST")
+
+	// JSON API
+	apiHandler := handlers.NewAPIHandler(
+		congratsService,
+		logger,
+	)
+
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/openapi.yaml", apiHandler.OpenAPI).Methods("GET")
+	api.HandleFunc("/register", apiHandler.Register).Methods("POST")
+	api.HandleFunc("/login", apiHandler.Login).Methods("POST")
+	api.HandleFunc("/verify", apiHandler.VerifyEmail).Methods("POST")
+	api.HandleFunc("/password/forgot", apiHandler.ForgotPassword).Methods("POST")
+	api.HandleFunc("/password/reset", apiHandler.ResetPassword).Methods("POST")
+	api.HandleFunc("/account/delete", apiHandler.DeleteAccountByLink).Methods("POST")
+
+	api.Handle("/logout",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.Logout))).Methods("POST")
+	api.Handle("/users",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.Users))).Methods("GET")
+	api.Handle("/users/{user_id}/subscription",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.Subscribe))).Methods("POST")
+	api.Handle("/users/{user_id}/subscription",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.UpdateSubscription))).Methods("PUT")
+	api.Handle("/users/{user_id}/subscription",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.Unsubscribe))).Methods("DELETE")
+	api.Handle("/users/{user_id}/subscription/{days_alert}",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.RemoveDaysAlert))).Methods("DELETE")
+	api.Handle("/me",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.Profile))).Methods("GET")
+	api.Handle("/me",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.UpdateProfile))).Methods("PATCH")
+	api.Handle("/me",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.DeleteAccount))).Methods("DELETE")
+	api.Handle("/me/deletion",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.RequestAccountDeletion))).Methods("POST")
+	api.Handle("/me/export",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.ExportData))).Methods("GET")
+	api.Handle("/me/password",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.ChangePassword))).Methods("PUT")
+	api.Handle("/me/verification",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.ResendVerification))).Methods("POST")
+	api.Handle("/me/privacy",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.GetPrivacy))).Methods("GET")
+	api.Handle("/me/privacy",
+		middlware.APIAuth(sm, logger, http.HandlerFunc(apiHandler.UpdatePrivacy))).Methods("PUT")
+
+	// администрирование: как и на html-страницах, по сессии администратора
+	apiAdminOnly := func(h http.HandlerFunc) http.Handler {
+		return middlware.APIAuth(sm, logger, middlware.APIRequireRole(congratsService, user.RoleAdmin, logger, h))
+	}
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Handle("/users/import", apiAdminOnly(apiHandler.AdminImportUsers)).Methods("POST")
+	admin.Handle("/users/{user_id}", apiAdminOnly(apiHandler.AdminDeleteUser)).Methods("DELETE")
+	admin.Handle("/users/{user_id}/export", apiAdminOnly(apiHandler.AdminExportUser)).Methods("GET")
+
+	// SCIM для HR-систем, включается заданием токена
+	if cfg.SCIM.Token != "" {
+		scimHandler := handlers.NewSCIMHandler(
+			congratsService,
+			logger,
+		)
+
+		scim := router.PathPrefix("/scim/v2").Subrouter()
+		scim.Handle("/Users",
+			middlware.SCIMAuth(cfg.SCIM.Token, logger, http.HandlerFunc(scimHandler.ListUsers))).Methods("GET")
+		scim.Handle("/Users",
+			middlware.SCIMAuth(cfg.SCIM.Token, logger, http.HandlerFunc(scimHandler.CreateUser))).Methods("POST")
+		scim.Handle("/Users/{id}",
+			middlware.SCIMAuth(cfg.SCIM.Token, logger, http.HandlerFunc(scimHandler.GetUser))).Methods("GET")
+		scim.Handle("/Users/{id}",
+			middlware.SCIMAuth(cfg.SCIM.Token, logger, http.HandlerFunc(scimHandler.PatchUser))).Methods("PATCH")
+		scim.Handle("/Users/{id}",
+			middlware.SCIMAuth(cfg.SCIM.Token, logger, http.HandlerFunc(scimHandler.DeleteUser))).Methods("DELETE")
+	}
 
 	// добавляем миддлверы
-	mux := middlware.Logger(logger, router)
-	mux = middlware.Panic(logger, mux)
+	handler := middlware.Logger(logger, router)
+	handler = middlware.Panic(logger, handler)
 
 	// сервер
 	server := &http.Server{
-		Addr:    ":" + strconv.Itoa(*port),
-		Handler: mux,
+		Addr:    ":" + strconv.Itoa(cfg.Server.Port),
+		Handler: handler,
 	}
 
 	ctx, stopServer := context.WithCancel(context.Background())
@@ -182,25 +485,25 @@ func startServer(ctx context.Context, server *http.Server, logger *zap.SugaredLo
 		if err != nil {
 			logger.Errorw("Error while shutting down server",
 				"type", "ERROR",
-				"addr", port)
+				"addr", server.Addr)
 		}
 	}()
 
 	// запуск сервера
 	logger.Infow("Starting server",
 		"type", "START",
-		"addr", port)
+		"addr", server.Addr)
 	err := server.ListenAndServe()
 	if err == http.ErrServerClosed {
 		logger.Infow("Shutting down server",
 			"type", "STOP",
-			"addr", port)
+			"addr", server.Addr)
 		return
 	}
 	if err != nil {
 		logger.Errorw("Error while starting server",
 			"type", "ERROR",
-			"addr", port,
+			"addr", server.Addr,
 			"error", err)
 	}
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"birthday_congrats/internal/pkg/migrate"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const migrateUsage = "usage: birthday_congrats [flags] migrate up|down|status"
+
+// runMigrate выполняет подкоманду migrate: up - применить все новые миграции,
+// down - откатить последнюю, status - вывести состояние миграций
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, m := range done {
+			fmt.Fprintf(out, "applied %s\n", m)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "rolled back %s\n", m)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + st.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%04d_%s\t%s\n", st.Version, st.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q; %s", strings.Join(args, " "), migrateUsage)
+	}
+
+	return nil
+}
+
+// pendingMigrations возвращает число не примененных миграций
+func pendingMigrations(ctx context.Context, migrator *migrate.Migrator) (int, error) {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, st := range statuses {
+		if !st.Applied {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"io"
+)
+
+const roleUsage = "usage: birthday_congrats [flags] role <username> employee|admin"
+
+// runRole выполняет подкоманду role: назначает роль пользователю. Так заводится первый
+// администратор, дальше роли меняются на странице /admin.
+func runRole(ctx context.Context, users user.UsersRepo, args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf(roleUsage)
+	}
+
+	username, role := args[0], args[1]
+	if !user.ValidRole(role) {
+		return fmt.Errorf("unknown role %q; %s", role, roleUsage)
+	}
+
+	u, err := users.GetByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("user %q: %v", username, err)
+	}
+
+	u.Role = role
+
+	err = users.Update(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "user %s now has role %s\n", username, role)
+
+	return nil
+}
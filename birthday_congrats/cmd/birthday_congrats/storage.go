@@ -0,0 +1,61 @@
+package main
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/config"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/password"
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// storage - хранилища сервиса, выбранные настройкой storage.backend
+type storage struct {
+	users         user.UsersRepo
+	subscriptions subscription.SubscriptionsRepo
+	sessions      session.SessionsManager
+	deliveries    delivery.DeliveriesRepo
+	resets        reset.ResetsRepo
+	audit         audit.AuditRepo
+	outbox        outbox.Outbox
+}
+
+func newMySQLStorage(db *sql.DB, cfg *config.Config, hasher password.Hasher, logger *zap.SugaredLogger) *storage {
+	return &storage{
+		users:         user.NewUsersMySQLRepo(db, hasher, logger),
+		subscriptions: subscription.NewSubscriptionsMySQLRepo(db, logger),
+		sessions: session.NewMySQLSessionsManager(
+			db,
+			logger,
+			int64(cfg.Sessions.TTL/time.Second),
+			cfg.Sessions.TokenBytes,
+		),
+		deliveries: delivery.NewDeliveriesMySQLRepo(db, logger),
+		resets:     reset.NewResetsMySQLRepo(db, logger),
+		audit:      audit.NewAuditMySQLRepo(db, logger),
+		outbox:     outbox.NewOutboxMySQLRepo(db, logger),
+	}
+}
+
+func newMemoryStorage(cfg *config.Config, hasher password.Hasher, logger *zap.SugaredLogger) *storage {
+	return &storage{
+		users:         user.NewUsersMemoryRepo(hasher, logger),
+		subscriptions: subscription.NewSubscriptionsMemoryRepo(logger),
+		sessions: session.NewMemorySessionsManager(
+			logger,
+			int64(cfg.Sessions.TTL/time.Second),
+			cfg.Sessions.TokenBytes,
+		),
+		deliveries: delivery.NewDeliveriesMemoryRepo(logger),
+		resets:     reset.NewResetsMemoryRepo(logger),
+		audit:      audit.NewAuditMemoryRepo(logger),
+		outbox:     outbox.NewOutboxMemoryRepo(logger),
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"birthday_congrats/internal/pkg/session"
+	service "birthday_congrats/internal/services/congrats_service"
+	"context"
+	"fmt"
+	"io"
+)
+
+const verifyUsage = "usage: birthday_congrats [flags] verify-emails"
+
+// runVerifyEmails выполняет подкоманду verify-emails: кладет в очередь писем ссылки подтверждения
+// для всех, у кого почта не подтверждена (например, после переноса базы из init-скриптов).
+// Письма отправит запущенный сервер.
+func runVerifyEmails(ctx context.Context, congratsService service.CongratulationsService, args []string, out io.Writer) error {
+	if len(args) != 0 {
+		return fmt.Errorf(verifyUsage)
+	}
+
+	sent, err := congratsService.SendVerifications(session.ContextAsSystem(ctx))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "queued %d verification emails\n", sent)
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package databases
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations возвращает файлы миграций схемы бд (см. пакет migrate)
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(err) // каталог встроен при сборке, ошибки быть не может
+	}
+
+	return sub
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS `alert_deliveries`;
+DROP TABLE IF EXISTS `alert_runs`;
+DROP TABLE IF EXISTS `alerts_outbox`;
+DROP TABLE IF EXISTS `subscriptions`;
+DROP TABLE IF EXISTS `sessions`;
+DROP TABLE IF EXISTS `users`;
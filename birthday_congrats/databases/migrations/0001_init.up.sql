@@ -0,0 +1,81 @@
+-- исходная схема. Базы, развернутые прежними init-скриптами из databases/sql, принимаются
+-- вместе с данными: users, sessions и subscriptions создаются в прежнем виде, только если
+-- их еще нет, а дальше и старая, и пустая база доводятся до одной и той же схемы
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `username` text NOT NULL COLLATE utf8_bin,
+  `password` text NOT NULL COLLATE utf8_bin, -- у старых пользователей в открытом виде, хэшируется при входе
+  `email` text NOT NULL,
+  `year` int NOT NULL,
+  `month` int NOT NULL,
+  `day` int NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+ALTER TABLE `users`
+  ADD COLUMN `timezone` varchar(64) NOT NULL DEFAULT 'UTC' AFTER `email`;
+
+-- в старых базах в sess_id лежат сами идентификаторы сессий, а не хэши: такие сессии
+-- не переносятся, пользователи просто войдут заново
+DROP TABLE IF EXISTS `sessions`;
+
+CREATE TABLE `sessions` (
+  `sess_id` char(64) NOT NULL COLLATE utf8_bin, -- sha256 от идентификатора сессии
+  `user_id` int NOT NULL,
+  `expires` bigint,
+  PRIMARY KEY (`sess_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS `subscriptions` (
+  `subscriber_id` int NOT NULL,
+  `subscription_id` int NOT NULL,
+  `days_alert` int NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- старый код мог сохранить одно напоминание дважды или оставить подписку на удаленного
+-- пользователя: при переносе такие строки отбрасываются, иначе не добавить ключи
+CREATE TABLE `subscriptions_new` (
+  `subscriber_id` int NOT NULL,
+  `subscription_id` int NOT NULL,
+  `days_alert` int NOT NULL, -- за сколько дней напоминать, 0 - в сам день рождения
+  PRIMARY KEY (`subscriber_id`, `subscription_id`, `days_alert`) -- повторная отправка формы не создает дублей
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+INSERT IGNORE INTO `subscriptions_new` (`subscriber_id`, `subscription_id`, `days_alert`)
+  SELECT `subscriber_id`, `subscription_id`, `days_alert` FROM `subscriptions`
+  WHERE `subscriber_id` IN (SELECT `id` FROM `users`) AND `subscription_id` IN (SELECT `id` FROM `users`);
+
+DROP TABLE `subscriptions`;
+
+RENAME TABLE `subscriptions_new` TO `subscriptions`;
+
+CREATE TABLE `alerts_outbox` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `recipients` text NOT NULL, -- json-массив адресов
+  `subject` text NOT NULL,
+  `body` text NOT NULL,
+  `status` varchar(16) NOT NULL, -- pending, sent, dead
+  `attempts` int NOT NULL DEFAULT 0,
+  `next_attempt_at` bigint NOT NULL, -- unix-время, не раньше которого пробовать отправить
+  `last_error` text NOT NULL,
+  `created_at` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `status_next_attempt` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- дата последней рассылки в каждом часовом поясе
+CREATE TABLE `alert_runs` (
+  `zone` varchar(64) NOT NULL, -- часовой пояс IANA
+  `last_run_date` char(10) NOT NULL, -- YYYY-MM-DD по местному календарю
+  PRIMARY KEY (`zone`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- журнал отправленных напоминаний: каждое напоминание отправляется один раз в году
+CREATE TABLE `alert_deliveries` (
+  `subscriber_id` int NOT NULL,
+  `subject_id` int NOT NULL,
+  `birthday_year` int NOT NULL,
+  `days_before` int NOT NULL,
+  `created_at` bigint NOT NULL,
+  PRIMARY KEY (`subscriber_id`, `subject_id`, `birthday_year`, `days_before`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
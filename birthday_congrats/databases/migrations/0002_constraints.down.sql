@@ -0,0 +1,16 @@
+ALTER TABLE `subscriptions`
+  DROP FOREIGN KEY `subscriptions_subject`,
+  DROP FOREIGN KEY `subscriptions_subscriber`;
+
+ALTER TABLE `subscriptions`
+  DROP KEY `subscription_id`;
+
+ALTER TABLE `sessions`
+  DROP FOREIGN KEY `sessions_user`;
+
+ALTER TABLE `sessions`
+  DROP KEY `user_id`;
+
+ALTER TABLE `users`
+  DROP KEY `username`,
+  MODIFY `username` text NOT NULL COLLATE utf8_bin;
@@ -0,0 +1,14 @@
+-- уникальные имена пользователей: раньше это проверялось только в коде
+ALTER TABLE `users`
+  MODIFY `username` varchar(255) NOT NULL COLLATE utf8_bin,
+  ADD UNIQUE KEY `username` (`username`);
+
+-- сессии и подписки удаляются вместе с пользователем
+ALTER TABLE `sessions`
+  ADD KEY `user_id` (`user_id`),
+  ADD CONSTRAINT `sessions_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+
+ALTER TABLE `subscriptions`
+  ADD KEY `subscription_id` (`subscription_id`),
+  ADD CONSTRAINT `subscriptions_subscriber` FOREIGN KEY (`subscriber_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+  ADD CONSTRAINT `subscriptions_subject` FOREIGN KEY (`subscription_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
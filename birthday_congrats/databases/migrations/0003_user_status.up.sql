@@ -0,0 +1,4 @@
+-- уволенные сотрудники и идентификатор во внешней системе (SCIM)
+ALTER TABLE `users`
+  ADD COLUMN `deactivated` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `external_id` varchar(255) NOT NULL DEFAULT '';
@@ -0,0 +1,14 @@
+-- скрытый год возвращается как 0
+ALTER TABLE `users`
+  ADD COLUMN `year` int NOT NULL DEFAULT 0,
+  ADD COLUMN `month` int NOT NULL DEFAULT 0,
+  ADD COLUMN `day` int NOT NULL DEFAULT 0;
+
+UPDATE `users`
+  SET `year` = IF(`birth_year_known`, YEAR(`birthday`), 0),
+      `month` = MONTH(`birthday`),
+      `day` = DAYOFMONTH(`birthday`);
+
+ALTER TABLE `users`
+  DROP COLUMN `birth_year_known`,
+  DROP COLUMN `birthday`;
@@ -0,0 +1,17 @@
+-- дата рождения одним столбцом DATE; год можно скрыть, тогда дата хранится с 2000 годом
+-- (високосный, чтобы 29 февраля было корректной датой), а birth_year_known = 0
+ALTER TABLE `users`
+  ADD COLUMN `birthday` date NULL,
+  ADD COLUMN `birth_year_known` tinyint(1) NOT NULL DEFAULT 1;
+
+-- раньше при регистрации принимался любой год, в том числе 0001: такие годы считаем неизвестными
+UPDATE `users` SET `birth_year_known` = 0 WHERE `year` < 1900;
+
+UPDATE `users`
+  SET `birthday` = STR_TO_DATE(CONCAT(IF(`birth_year_known`, `year`, 2000), '-', `month`, '-', `day`), '%Y-%m-%d');
+
+ALTER TABLE `users`
+  MODIFY `birthday` date NOT NULL,
+  DROP COLUMN `year`,
+  DROP COLUMN `month`,
+  DROP COLUMN `day`;
@@ -0,0 +1,4 @@
+ALTER TABLE `users`
+  DROP COLUMN `hide_year`,
+  DROP COLUMN `hide_from_directory`,
+  DROP COLUMN `not_subscribable`;
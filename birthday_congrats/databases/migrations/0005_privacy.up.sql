@@ -0,0 +1,5 @@
+-- настройки приватности пользователя; по умолчанию все видно коллегам
+ALTER TABLE `users`
+  ADD COLUMN `hide_year` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `hide_from_directory` tinyint(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `not_subscribable` tinyint(1) NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- подтверждена ли почта. Пользователи, зарегистрированные до появления подтверждения,
+-- почту не подтверждали: она остается неподтвержденной, ссылки им рассылает команда
+-- verify-emails, а до подтверждения напоминания на эту почту не отправляются
+ALTER TABLE `users`
+  ADD COLUMN `email_verified` tinyint(1) NOT NULL DEFAULT 0;
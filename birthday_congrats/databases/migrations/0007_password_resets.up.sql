@@ -0,0 +1,9 @@
+-- одноразовые токены сброса пароля
+CREATE TABLE `password_resets` (
+  `token_hash` char(64) NOT NULL COLLATE utf8_bin, -- sha256 от токена
+  `user_id` int NOT NULL,
+  `expires` bigint NOT NULL, -- unix-время, после которого токен недействителен
+  PRIMARY KEY (`token_hash`),
+  KEY `password_resets_user` (`user_id`),
+  CONSTRAINT `password_resets_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,11 @@
+-- журнал изменений профилей: кто и какое поле менял (без самих значений)
+CREATE TABLE `audit_log` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `actor_id` int NOT NULL, -- кто изменил
+  `user_id` int NOT NULL,  -- чей профиль изменен
+  `field` varchar(32) NOT NULL,
+  `created_at` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `audit_log_user` (`user_id`),
+  CONSTRAINT `audit_log_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
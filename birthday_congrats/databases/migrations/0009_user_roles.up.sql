@@ -0,0 +1,3 @@
+-- роль пользователя: employee - обычный сотрудник, admin - HR/администратор
+ALTER TABLE `users`
+  ADD COLUMN `role` varchar(16) NOT NULL DEFAULT 'employee';
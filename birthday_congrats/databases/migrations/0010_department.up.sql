@@ -0,0 +1,3 @@
+-- отдел сотрудника, заполняется при импорте из HR-выгрузки
+ALTER TABLE `users`
+  ADD COLUMN `department` varchar(255) NOT NULL DEFAULT '';
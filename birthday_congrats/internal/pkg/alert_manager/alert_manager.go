@@ -1,5 +1,7 @@
 package alertmanager
 
+// AlertManager отправляет сообщение получателям. Ошибка означает, что сообщение
+// не доставлено никому, и отправку можно повторить.
 type AlertManager interface {
-	Send(to []string, subject, message string)
+	Send(to []string, subject, message string) error
 }
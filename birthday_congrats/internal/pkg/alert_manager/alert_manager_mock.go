@@ -34,9 +34,11 @@ func (m *MockAlertManager) EXPECT() *MockAlertManagerMockRecorder {
 }
 
 // Send mocks base method.
-func (m *MockAlertManager) Send(to []string, subject, message string) {
+func (m *MockAlertManager) Send(to []string, subject, message string) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Send", to, subject, message)
+	ret := m.ctrl.Call(m, "Send", to, subject, message)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // Send indicates an expected call of Send.
@@ -1,6 +1,7 @@
 package alertmanager
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/emersion/go-sasl"
@@ -26,7 +27,7 @@ func NewEmailAlertManager(
 	smtpPort string,
 	logger *zap.SugaredLogger,
 ) *EmailAlertManager {
-	auth := sasl.NewPlainClient("", "birthday.congratulations@yandex.ru", "ucgcgejoiguychfa")
+	auth := sasl.NewPlainClient("", from, password)
 
 	return &EmailAlertManager{
 		auth:     auth,
@@ -37,7 +38,7 @@ func NewEmailAlertManager(
 	}
 }
 
-func (am *EmailAlertManager) Send(to []string, subject, message string) {
+func (am *EmailAlertManager) Send(to []string, subject, message string) error {
 	// Сообщение.
 	msg := strings.NewReader(
 		"From: " + am.from + "\r\n" +
@@ -50,8 +51,9 @@ func (am *EmailAlertManager) Send(to []string, subject, message string) {
 	err := smtp.SendMail(am.smtpHost+":"+am.smtpPort, am.auth, am.from, to, msg)
 	if err != nil {
 		am.logger.Warnf("Error while sending emails: %v", err)
-		return
+		return fmt.Errorf("smtp error: %v", err)
 	}
 
 	am.logger.Infof("Emails were sent to: %v", to)
+	return nil
 }
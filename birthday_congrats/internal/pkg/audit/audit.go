@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+// Изменяемые поля профиля
+const (
+	FieldUsername   = "username"
+	FieldEmail      = "email"
+	FieldBirthday   = "birthday"
+	FieldPassword   = "password"
+	FieldRole       = "role"       // меняет только администратор
+	FieldDepartment = "department" // меняется только импортом и синхронизацией с LDAP
+)
+
+// Entry - запись журнала изменений: пользователь ActorID изменил поле Field пользователя UserID.
+// Сами значения не записываются, чтобы в журнале не оседали пароли и почта.
+type Entry struct {
+	ActorID uint32
+	UserID  uint32
+	Field   string
+	At      time.Time
+}
+
+type AuditRepo interface {
+	Record(ctx context.Context, entries []Entry) error
+	// ListByUser возвращает изменения пользователя userID в порядке записи
+	ListByUser(ctx context.Context, userID uint32) ([]Entry, error)
+	RemoveByUser(ctx context.Context, userID uint32) error
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AuditMemoryRepo хранит журнал изменений в памяти процесса (для разработки без базы данных)
+type AuditMemoryRepo struct {
+	mu      *sync.Mutex
+	entries []Entry
+	logger  *zap.SugaredLogger
+}
+
+var _ AuditRepo = &AuditMemoryRepo{}
+
+func NewAuditMemoryRepo(logger *zap.SugaredLogger) *AuditMemoryRepo {
+	return &AuditMemoryRepo{
+		mu:     &sync.Mutex{},
+		logger: logger,
+	}
+}
+
+func (repo *AuditMemoryRepo) Record(ctx context.Context, entries []Entry) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, e := range entries {
+		// как и в MySQL, время хранится с точностью до секунды
+		e.At = time.Unix(e.At.Unix(), 0)
+		repo.entries = append(repo.entries, e)
+	}
+
+	return nil
+}
+
+func (repo *AuditMemoryRepo) ListByUser(ctx context.Context, userID uint32) ([]Entry, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	entries := make([]Entry, 0)
+	for _, e := range repo.entries {
+		if e.UserID == userID {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+func (repo *AuditMemoryRepo) RemoveByUser(ctx context.Context, userID uint32) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	entries := repo.entries[:0]
+	for _, e := range repo.entries {
+		if e.UserID != userID {
+			entries = append(entries, e)
+		}
+	}
+	repo.entries = entries
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+type AuditMySQLRepo struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+var _ AuditRepo = &AuditMySQLRepo{}
+
+func NewAuditMySQLRepo(db *sql.DB, logger *zap.SugaredLogger) *AuditMySQLRepo {
+	return &AuditMySQLRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *AuditMySQLRepo) Record(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO audit_log (`actor_id`, `user_id`, `field`, `created_at`) VALUES " +
+		strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?), ", len(entries)), ", ")
+
+	args := make([]interface{}, 0, 4*len(entries))
+	for _, e := range entries {
+		args = append(args, e.ActorID, e.UserID, e.Field, e.At.Unix())
+	}
+
+	_, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		repo.logger.Errorf("Error while INSERT into db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *AuditMySQLRepo) ListByUser(ctx context.Context, userID uint32) ([]Entry, error) {
+	rows, err := repo.db.QueryContext(
+		ctx,
+		"SELECT actor_id, user_id, field, created_at FROM audit_log WHERE user_id = ? ORDER BY id",
+		userID,
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while SELECT from db: %v", err)
+		return nil, fmt.Errorf("db error: %v", err)
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		e := Entry{}
+		createdAt := int64(0)
+
+		err = rows.Scan(&e.ActorID, &e.UserID, &e.Field, &createdAt)
+		if err != nil {
+			repo.logger.Errorf("Error while scanning from sql row: %v", err)
+			return nil, fmt.Errorf("db error: %v", err)
+		}
+
+		e.At = time.Unix(createdAt, 0)
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+func (repo *AuditMySQLRepo) RemoveByUser(ctx context.Context, userID uint32) error {
+	_, err := repo.db.ExecContext(ctx, "DELETE FROM audit_log WHERE user_id = ?", userID)
+	if err != nil {
+		repo.logger.Errorf("Error while DELETE from db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestRecord(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewAuditMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	at := time.Unix(1000, 0)
+	entries := []Entry{
+		{ActorID: 1, UserID: 1, Field: FieldEmail, At: at},
+		{ActorID: 1, UserID: 1, Field: FieldUsername, At: at},
+	}
+
+	// нормальная работа: все записи одним запросом
+	mock.
+		ExpectExec("INSERT INTO audit_log").
+		WithArgs(1, 1, FieldEmail, 1000, 1, 1, FieldUsername, 1000).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+
+	err = testRepo.Record(ctx, entries)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// записывать нечего
+	err = testRepo.Record(ctx, nil)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectExec("INSERT INTO audit_log").
+		WithArgs(1, 1, FieldEmail, 1000, 1, 1, FieldUsername, 1000).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.Record(ctx, entries)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestListByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewAuditMySQLRepo(db, zap.NewNop().Sugar())
+
+	// нормальная работа
+	rows := sqlmock.NewRows([]string{"actor_id", "user_id", "field", "created_at"}).
+		AddRow(1, 1, FieldEmail, 1000).
+		AddRow(1, 1, FieldPassword, 1001)
+
+	mock.
+		ExpectQuery("SELECT actor_id, user_id, field, created_at FROM audit_log WHERE").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	entries, err := testRepo.ListByUser(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []Entry{
+		{ActorID: 1, UserID: 1, Field: FieldEmail, At: time.Unix(1000, 0)},
+		{ActorID: 1, UserID: 1, Field: FieldPassword, At: time.Unix(1001, 0)},
+	}, entries)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectQuery("SELECT actor_id, user_id, field, created_at FROM audit_log WHERE").
+		WithArgs(1).
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testRepo.ListByUser(ctx, 1)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка scan
+	rows = sqlmock.NewRows([]string{"actor_id"}).AddRow(1)
+
+	mock.
+		ExpectQuery("SELECT actor_id, user_id, field, created_at FROM audit_log WHERE").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	_, err = testRepo.ListByUser(ctx, 1)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestRemoveByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewAuditMySQLRepo(db, zap.NewNop().Sugar())
+
+	// нормальная работа
+	mock.
+		ExpectExec("DELETE FROM audit_log WHERE").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = testRepo.RemoveByUser(ctx, 1)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectExec("DELETE FROM audit_log WHERE").
+		WithArgs(1).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.RemoveByUser(ctx, 1)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
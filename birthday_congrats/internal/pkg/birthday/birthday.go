@@ -0,0 +1,128 @@
+package birthday
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadBirthday      = errors.New("bad birthday")
+	ErrBirthdayInFuture = errors.New("birthday is in the future")
+	ErrBadAge           = errors.New("age is out of range")
+)
+
+// допустимый возраст сотрудника в полных годах
+const (
+	MinAge = 14
+	MaxAge = 120
+)
+
+const (
+	layout       = "2006-01-02"
+	noYearPrefix = "--" // дата без года по ISO 8601: --MM-DD
+)
+
+// Birthday - дата рождения. Год можно не указывать (Year = 0), чтобы не раскрывать возраст:
+// поздравления от этого не зависят.
+type Birthday struct {
+	Year  int // 0 - год не указан
+	Month time.Month
+	Day   int
+}
+
+// New проверяет, что дата существует в календаре. Без года допустимо 29 февраля.
+func New(year int, month time.Month, day int) (Birthday, error) {
+	b := Birthday{Year: year, Month: month, Day: day}
+
+	// несуществующие даты (31 апреля и т.п.) time.Date переносит на следующий месяц
+	check := b.date(2000) // 2000 - високосный
+	if year < 0 || year > 9999 || check.Month() != month || check.Day() != day {
+		return Birthday{}, ErrBadBirthday
+	}
+
+	return b, nil
+}
+
+// Parse разбирает дату в формате YYYY-MM-DD или --MM-DD (без года)
+func Parse(s string) (Birthday, error) {
+	if rest, ok := strings.CutPrefix(s, noYearPrefix); ok {
+		month, day, ok := strings.Cut(rest, "-")
+		if !ok || len(month) != 2 || len(day) != 2 {
+			return Birthday{}, ErrBadBirthday
+		}
+
+		m, errM := strconv.Atoi(month)
+		d, errD := strconv.Atoi(day)
+		if errM != nil || errD != nil {
+			return Birthday{}, ErrBadBirthday
+		}
+
+		return New(0, time.Month(m), d)
+	}
+
+	t, err := time.Parse(layout, s)
+	if err != nil || t.Year() == 0 {
+		return Birthday{}, ErrBadBirthday
+	}
+
+	return Birthday{Year: t.Year(), Month: t.Month(), Day: t.Day()}, nil
+}
+
+// HasYear сообщает, указан ли год рождения
+func (b Birthday) HasYear() bool {
+	return b.Year != 0
+}
+
+// String возвращает дату в формате YYYY-MM-DD или --MM-DD, если год не указан
+func (b Birthday) String() string {
+	if !b.HasYear() {
+		return fmt.Sprintf("%s%02d-%02d", noYearPrefix, b.Month, b.Day)
+	}
+
+	return fmt.Sprintf("%04d-%02d-%02d", b.Year, b.Month, b.Day)
+}
+
+// date возвращает дату рождения в году year, если год не указан
+func (b Birthday) date(year int) time.Time {
+	if b.HasYear() {
+		year = b.Year
+	}
+
+	return date(year, b.Month, b.Day)
+}
+
+// Validate проверяет, что дата рождения не в будущем и возраст в пределах [MinAge, MaxAge].
+// Сегодняшняя дата берется из now в его часовом поясе. Без года проверяется только сама дата.
+func (b Birthday) Validate(now time.Time) error {
+	_, err := New(b.Year, b.Month, b.Day)
+	if err != nil {
+		return err
+	}
+
+	if !b.HasYear() {
+		return nil
+	}
+
+	today := date(now.Year(), now.Month(), now.Day())
+	born := b.date(0)
+
+	if born.After(today) {
+		return ErrBirthdayInFuture
+	}
+
+	// возраст в полных годах
+	age := today.Year() - born.Year()
+	if today.Month() < born.Month() || (today.Month() == born.Month() && today.Day() < born.Day()) {
+		age--
+	}
+
+	if age < MinAge || age > MaxAge {
+		return ErrBadAge
+	}
+
+	return nil
+}
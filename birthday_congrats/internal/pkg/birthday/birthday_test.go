@@ -0,0 +1,84 @@
+package birthday
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected Birthday
+		err      error
+	}{
+		{"2000-06-30", Birthday{2000, time.June, 30}, nil},
+		{"2000-02-29", Birthday{2000, time.February, 29}, nil},
+		{"--02-29", Birthday{0, time.February, 29}, nil},
+		{"--12-01", Birthday{0, time.December, 1}, nil},
+		{"2001-02-29", Birthday{}, ErrBadBirthday},
+		{"2000-04-31", Birthday{}, ErrBadBirthday},
+		{"0000-01-01", Birthday{}, ErrBadBirthday},
+		{"--04-31", Birthday{}, ErrBadBirthday},
+		{"--13-01", Birthday{}, ErrBadBirthday},
+		{"--1-01", Birthday{}, ErrBadBirthday},
+		{"--ab-01", Birthday{}, ErrBadBirthday},
+		{"30.06.2000", Birthday{}, ErrBadBirthday},
+		{"", Birthday{}, ErrBadBirthday},
+	}
+
+	for _, c := range cases {
+		b, err := Parse(c.in)
+
+		assert.ErrorIs(t, err, c.err, c.in)
+		assert.EqualValues(t, c.expected, b, c.in)
+	}
+}
+
+func TestString(t *testing.T) {
+	assert.EqualValues(t, "2000-06-30", Birthday{2000, time.June, 30}.String())
+	assert.EqualValues(t, "0987-01-02", Birthday{987, time.January, 2}.String())
+	assert.EqualValues(t, "--02-29", Birthday{0, time.February, 29}.String())
+
+	// String и Parse взаимно обратны
+	for _, s := range []string{"1990-12-31", "--07-04"} {
+		b, err := Parse(s)
+
+		assert.NoError(t, err)
+		assert.EqualValues(t, s, b.String())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		birthday Birthday
+		err      error
+	}{
+		{"обычная дата", Birthday{1990, time.May, 6}, nil},
+		{"год не указан", Birthday{0, time.February, 29}, nil},
+		{"в будущем", Birthday{2026, time.October, 17}, ErrBirthdayInFuture},
+		{"в будущем через год", Birthday{2030, time.January, 1}, ErrBirthdayInFuture},
+		{"родился сегодня", Birthday{2026, time.October, 16}, ErrBadAge},
+		{"13 лет", Birthday{2012, time.October, 17}, ErrBadAge},
+		{"ровно 14 лет", Birthday{2012, time.October, 16}, nil},
+		{"ровно 120 лет", Birthday{1906, time.October, 16}, nil},
+		{"121 год", Birthday{1905, time.October, 16}, ErrBadAge},
+		{"год 0001", Birthday{1, time.January, 1}, ErrBadAge},
+		{"несуществующая дата", Birthday{1990, time.April, 31}, ErrBadBirthday},
+	}
+
+	for _, c := range cases {
+		assert.ErrorIs(t, c.birthday.Validate(now), c.err, c.name)
+	}
+
+	// сегодня считается по часовому поясу now: в Токио уже 17 октября
+	tokyo := now.In(mustLoadLocation(t, "Asia/Tokyo")).Add(12 * time.Hour)
+
+	assert.NoError(t, Birthday{2000, time.October, 17}.Validate(tokyo))
+	assert.ErrorIs(t, Birthday{2026, time.October, 17}.Validate(tokyo), ErrBadAge)
+	assert.ErrorIs(t, Birthday{2026, time.October, 17}.Validate(now), ErrBirthdayInFuture)
+}
@@ -0,0 +1,75 @@
+package birthday
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadLeapDayPolicy = errors.New("bad leap day policy")
+)
+
+// LeapDayPolicy - в какой день отмечают день рождения 29 февраля в невисокосный год
+type LeapDayPolicy string
+
+const (
+	LeapDayFeb28 LeapDayPolicy = "feb28" // накануне, 28 февраля
+	LeapDayMar1  LeapDayPolicy = "mar1"  // на следующий день, 1 марта
+)
+
+func ParseLeapDayPolicy(s string) (LeapDayPolicy, error) {
+	switch p := LeapDayPolicy(s); p {
+	case LeapDayFeb28, LeapDayMar1:
+		return p, nil
+	default:
+		return "", ErrBadLeapDayPolicy
+	}
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// date - полночь даты в UTC. В UTC нет переходов на летнее время,
+// поэтому разница двух таких дат всегда кратна 24 часам.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Observed возвращает дату, в которую день рождения month/day отмечается в году year.
+// Отличается от самой даты только для 29 февраля в невисокосный год.
+func (p LeapDayPolicy) Observed(year int, month time.Month, day int) time.Time {
+	if month == time.February && day == 29 && !isLeap(year) {
+		if p == LeapDayMar1 {
+			return date(year, time.March, 1)
+		}
+
+		return date(year, time.February, 28)
+	}
+
+	return date(year, month, day)
+}
+
+// Next возвращает ближайшую (начиная с сегодняшней) дату празднования
+// для человека, находящегося в часовом поясе loc
+func (p LeapDayPolicy) Next(now time.Time, loc *time.Location, month time.Month, day int) time.Time {
+	local := now.In(loc)
+	today := date(local.Year(), local.Month(), local.Day())
+
+	next := p.Observed(today.Year(), month, day)
+	if next.Before(today) {
+		next = p.Observed(today.Year()+1, month, day)
+	}
+
+	return next
+}
+
+// DaysUntil возвращает, через сколько календарных дней наступит ближайший
+// день рождения (0 - сегодня) для человека, находящегося в часовом поясе loc
+func (p LeapDayPolicy) DaysUntil(now time.Time, loc *time.Location, month time.Month, day int) int {
+	local := now.In(loc)
+	today := date(local.Year(), local.Month(), local.Day())
+
+	return int(p.Next(now, loc, month, day).Sub(today) / (24 * time.Hour))
+}
@@ -0,0 +1,193 @@
+package birthday
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLeapDayPolicy(t *testing.T) {
+	p, err := ParseLeapDayPolicy("feb28")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, LeapDayFeb28, p)
+
+	p, err = ParseLeapDayPolicy("mar1")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, LeapDayMar1, p)
+
+	_, err = ParseLeapDayPolicy("feb29")
+
+	assert.ErrorIs(t, err, ErrBadLeapDayPolicy)
+}
+
+func TestObserved(t *testing.T) {
+	// 29 февраля в 2023..2030 и на рубежах веков
+	cases := []struct {
+		year  int
+		feb28 time.Time
+		mar1  time.Time
+	}{
+		{2023, date(2023, time.February, 28), date(2023, time.March, 1)},
+		{2024, date(2024, time.February, 29), date(2024, time.February, 29)},
+		{2025, date(2025, time.February, 28), date(2025, time.March, 1)},
+		{2026, date(2026, time.February, 28), date(2026, time.March, 1)},
+		{2027, date(2027, time.February, 28), date(2027, time.March, 1)},
+		{2028, date(2028, time.February, 29), date(2028, time.February, 29)},
+		{2029, date(2029, time.February, 28), date(2029, time.March, 1)},
+		{2100, date(2100, time.February, 28), date(2100, time.March, 1)},
+		{2000, date(2000, time.February, 29), date(2000, time.February, 29)},
+	}
+
+	for _, c := range cases {
+		assert.EqualValues(t, c.feb28, LeapDayFeb28.Observed(c.year, time.February, 29), "feb28 %d", c.year)
+		assert.EqualValues(t, c.mar1, LeapDayMar1.Observed(c.year, time.February, 29), "mar1 %d", c.year)
+	}
+
+	// остальные даты не меняются
+	assert.EqualValues(t, date(2025, time.February, 28), LeapDayMar1.Observed(2025, time.February, 28))
+	assert.EqualValues(t, date(2025, time.March, 1), LeapDayFeb28.Observed(2025, time.March, 1))
+}
+
+func TestNextLeapDay(t *testing.T) {
+	// день за днем с 2023 по 2029 год: ближайший праздник всегда один в году
+	// и совпадает с Observed, а дней до него не больше 366
+	for _, p := range []LeapDayPolicy{LeapDayFeb28, LeapDayMar1} {
+		for day := date(2023, time.January, 1); day.Year() < 2030; day = day.AddDate(0, 0, 1) {
+			now := day.Add(12 * time.Hour)
+			next := p.Next(now, time.UTC, time.February, 29)
+			days := p.DaysUntil(now, time.UTC, time.February, 29)
+
+			observed := p.Observed(day.Year(), time.February, 29)
+			if observed.Before(day) {
+				observed = p.Observed(day.Year()+1, time.February, 29)
+			}
+
+			assert.EqualValues(t, observed, next, "%s %s", p, day.Format("2006-01-02"))
+			assert.EqualValues(t, int(next.Sub(day)/(24*time.Hour)), days, "%s %s", p, day.Format("2006-01-02"))
+			assert.True(t, days >= 0 && days <= 366, "%s %s: %d", p, day.Format("2006-01-02"), days)
+		}
+	}
+
+	// 28 февраля невисокосного года
+	now := time.Date(2027, time.February, 28, 9, 0, 0, 0, time.UTC)
+
+	assert.EqualValues(t, 0, LeapDayFeb28.DaysUntil(now, time.UTC, time.February, 29))
+	assert.EqualValues(t, 1, LeapDayMar1.DaysUntil(now, time.UTC, time.February, 29))
+
+	// 1 марта невисокосного года: по feb28 праздник уже прошел, следующий - в високосном 2028
+	now = time.Date(2027, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	assert.EqualValues(t, 0, LeapDayMar1.DaysUntil(now, time.UTC, time.February, 29))
+	assert.EqualValues(t, 365, LeapDayFeb28.DaysUntil(now, time.UTC, time.February, 29))
+	assert.EqualValues(t, date(2028, time.February, 29), LeapDayFeb28.Next(now, time.UTC, time.February, 29))
+
+	// 28 февраля високосного года: 29-е еще впереди
+	now = time.Date(2028, time.February, 28, 9, 0, 0, 0, time.UTC)
+
+	assert.EqualValues(t, 1, LeapDayFeb28.DaysUntil(now, time.UTC, time.February, 29))
+	assert.EqualValues(t, 1, LeapDayMar1.DaysUntil(now, time.UTC, time.February, 29))
+
+	// в Токио уже 28 февраля, в UTC еще 27-е
+	now = time.Date(2027, time.February, 27, 20, 0, 0, 0, time.UTC)
+
+	assert.EqualValues(t, 0, LeapDayFeb28.DaysUntil(now, mustLoadLocation(t, "Asia/Tokyo"), time.February, 29))
+	assert.EqualValues(t, 1, LeapDayFeb28.DaysUntil(now, time.UTC, time.February, 29))
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("cant load location %s: %v", name, err)
+	}
+
+	return loc
+}
+
+func TestDaysUntil(t *testing.T) {
+	cases := []struct {
+		name     string
+		now      time.Time
+		location string
+		month    time.Month
+		day      int
+		expected int
+	}{
+		{
+			name:     "день рождения сегодня",
+			now:      time.Date(2024, time.June, 30, 10, 0, 0, 0, time.UTC),
+			location: "UTC",
+			month:    time.June,
+			day:      30,
+			expected: 0,
+		},
+		{
+			name:     "день рождения прошел в этом году",
+			now:      time.Date(2024, time.July, 1, 10, 0, 0, 0, time.UTC),
+			location: "UTC",
+			month:    time.June,
+			day:      30,
+			expected: 364,
+		},
+		{
+			name:     "переход на летнее время в Берлине",
+			now:      time.Date(2024, time.March, 30, 22, 30, 0, 0, time.UTC), // 23:30 30 марта по Берлину
+			location: "Europe/Berlin",
+			month:    time.April,
+			day:      1,
+			expected: 2,
+		},
+		{
+			name:     "переход на зимнее время в Нью-Йорке",
+			now:      time.Date(2024, time.November, 3, 4, 30, 0, 0, time.UTC), // 00:30 3 ноября по Нью-Йорку
+			location: "America/New_York",
+			month:    time.November,
+			day:      4,
+			expected: 1,
+		},
+		{
+			name:     "в Токио уже новый год",
+			now:      time.Date(2024, time.December, 31, 20, 0, 0, 0, time.UTC),
+			location: "Asia/Tokyo",
+			month:    time.January,
+			day:      1,
+			expected: 0,
+		},
+		{
+			name:     "в UTC еще старый год",
+			now:      time.Date(2024, time.December, 31, 20, 0, 0, 0, time.UTC),
+			location: "UTC",
+			month:    time.January,
+			day:      1,
+			expected: 1,
+		},
+		{
+			name:     "в Нью-Йорке еще старый год",
+			now:      time.Date(2024, time.December, 31, 20, 0, 0, 0, time.UTC),
+			location: "America/New_York",
+			month:    time.January,
+			day:      1,
+			expected: 1,
+		},
+		{
+			name:     "31 декабря уже прошло в Токио",
+			now:      time.Date(2024, time.December, 31, 20, 0, 0, 0, time.UTC),
+			location: "Asia/Tokyo",
+			month:    time.December,
+			day:      31,
+			expected: 364,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			loc := mustLoadLocation(t, c.location)
+
+			assert.EqualValues(t, c.expected, LeapDayFeb28.DaysUntil(c.now, loc, c.month, c.day))
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package config
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/cron"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix = "BIRTHDAY_" // префикс переменных окружения, например BIRTHDAY_SMTP_PASSWORD
+	redacted  = "******"    // чем заменяются секреты при выводе конфигурации
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid config")
+)
+
+// Config - настройки приложения. Значения берутся (в порядке возрастания приоритета)
+// из значений по умолчанию, yaml-файла, переменных окружения и флагов командной строки.
+// Поля с тегом `secret:"true"` не выводятся в -print-config.
+type Config struct {
+	Server       ServerConfig       `yaml:"server"`
+	Storage      StorageConfig      `yaml:"storage"`
+	MySQL        MySQLConfig        `yaml:"mysql"`
+	SMTP         SMTPConfig         `yaml:"smtp"`
+	Alerts       AlertsConfig       `yaml:"alerts"`
+	Outbox       OutboxConfig       `yaml:"outbox"`
+	Birthdays    BirthdaysConfig    `yaml:"birthdays"`
+	Sessions     SessionsConfig     `yaml:"sessions"`
+	Password     PasswordConfig     `yaml:"password"`
+	SCIM         SCIMConfig         `yaml:"scim"`
+	Verification VerificationConfig `yaml:"verification"`
+	LDAP         LDAPConfig         `yaml:"ldap"`
+	OIDC         OIDCConfig         `yaml:"oidc"`
+}
+
+type ServerConfig struct {
+	Port      int    `yaml:"port"`      // порт
+	Templates string `yaml:"templates"` // glob html-шаблонов
+	BaseURL   string `yaml:"base_url"`  // внешний адрес сервиса, из него строятся ссылки в письмах
+}
+
+// Хранилища данных
+const (
+	StorageMySQL  = "mysql"  // MySQL, схема создается миграциями
+	StorageMemory = "memory" // в памяти процесса, данные теряются при перезапуске (для разработки)
+)
+
+type StorageConfig struct {
+	Backend string `yaml:"backend"` // mysql или memory
+}
+
+type MySQLConfig struct {
+	Addr         string `yaml:"addr"`
+	User         string `yaml:"user"`
+	Password     string `yaml:"password" secret:"true"`
+	Database     string `yaml:"database"`
+	MaxOpenConns int    `yaml:"max_open_conns"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	From     string `yaml:"from"` // адрес отправителя, он же логин на smtp-сервере
+	Password string `yaml:"password" secret:"true"`
+}
+
+type AlertsConfig struct {
+	Schedule string `yaml:"schedule"` // cron-расписание рассылки напоминаний, время местное для получателя
+}
+
+type OutboxConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval"` // как часто проверять очередь исходящих писем
+	MaxAttempts  int           `yaml:"max_attempts"`  // после стольких неудачных попыток письмо больше не отправляется
+	BaseDelay    time.Duration `yaml:"base_delay"`    // задержка после первой неудачной попытки, дальше удваивается
+	MaxDelay     time.Duration `yaml:"max_delay"`     // максимальная задержка между попытками
+	BatchSize    int           `yaml:"batch_size"`    // сколько писем забирать из очереди за раз
+}
+
+type BirthdaysConfig struct {
+	LeapDay string `yaml:"leap_day"` // когда поздравлять родившихся 29 февраля в невисокосный год: feb28 или mar1
+}
+
+type SessionsConfig struct {
+	TTL        time.Duration `yaml:"ttl"`         // время жизни сессии
+	TokenBytes int           `yaml:"token_bytes"` // энтропия идентификатора сессии в байтах
+}
+
+type PasswordConfig struct {
+	Iterations int           `yaml:"iterations"`  // число итераций PBKDF2 при хэшировании паролей
+	SaltLength int           `yaml:"salt_length"` // длина соли в байтах
+	KeyLength  int           `yaml:"key_length"`  // длина хэша пароля в байтах
+	ResetTTL   time.Duration `yaml:"reset_ttl"`   // сколько действует ссылка сброса пароля
+	InviteTTL  time.Duration `yaml:"invite_ttl"`  // сколько действует ссылка из приглашения импортированным сотрудникам
+}
+
+type SCIMConfig struct {
+	Token string `yaml:"token" secret:"true"` // bearer-токен HR-системы для /scim/v2; пустой - SCIM выключен
+}
+
+type VerificationConfig struct {
+	Secret string        `yaml:"secret" secret:"true"` // ключ подписи ссылок подтверждения почты; пустой - случайный при каждом запуске
+	TTL    time.Duration `yaml:"ttl"`                  // сколько действует ссылка подтверждения
+}
+
+type LDAPConfig struct {
+	Addr         string        `yaml:"addr"` // host:port каталога сотрудников; пустой - синхронизация выключена
+	TLS          bool          `yaml:"tls"`  // подключаться по TLS (ldaps)
+	BindDN       string        `yaml:"bind_dn"`
+	BindPassword string        `yaml:"bind_password" secret:"true"`
+	BaseDN       string        `yaml:"base_dn"`  // где искать сотрудников
+	Filter       string        `yaml:"filter"`   // фильтр поиска сотрудников (RFC 4515)
+	Interval     time.Duration `yaml:"interval"` // как часто синхронизировать
+	Timeout      time.Duration `yaml:"timeout"`  // на подключение и каждую операцию
+
+	// атрибуты записи сотрудника
+	IDAttribute         string `yaml:"id_attribute"` // неизменный идентификатор, по нему сотрудник узнается при смене почты
+	NameAttribute       string `yaml:"name_attribute"`
+	EmailAttribute      string `yaml:"email_attribute"`
+	BirthdayAttribute   string `yaml:"birthday_attribute"` // YYYY-MM-DD или --MM-DD
+	DepartmentAttribute string `yaml:"department_attribute"`
+	StatusAttribute     string `yaml:"status_attribute"` // пустой - все найденные считаются работающими
+	InactiveValue       string `yaml:"inactive_value"`   // значение status_attribute у уволенных
+}
+
+type OIDCConfig struct {
+	Issuer       string        `yaml:"issuer"` // адрес провайдера OpenID Connect; пустой - вход через провайдера выключен
+	ClientID     string        `yaml:"client_id"`
+	ClientSecret string        `yaml:"client_secret" secret:"true"` // пустой - публичный клиент, защищенный только PKCE
+	Scopes       string        `yaml:"scopes"`                      // через пробел, нужен openid
+	Timeout      time.Duration `yaml:"timeout"`                     // на каждый запрос к провайдеру
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:      8000,
+			Templates: "./templates/*",
+			BaseURL:   "http://localhost:8000",
+		},
+		Storage: StorageConfig{
+			Backend: StorageMySQL,
+		},
+		MySQL: MySQLConfig{
+			Addr:         "localhost:3306",
+			User:         "root",
+			Database:     "golang",
+			MaxOpenConns: 10,
+		},
+		SMTP: SMTPConfig{
+			Host: "smtp.yandex.ru",
+			Port: "587",
+		},
+		Alerts: AlertsConfig{
+			Schedule: "0 9 * * *",
+		},
+		Outbox: OutboxConfig{
+			PollInterval: time.Minute,
+			MaxAttempts:  8,
+			BaseDelay:    time.Minute,
+			MaxDelay:     6 * time.Hour,
+			BatchSize:    50,
+		},
+		Birthdays: BirthdaysConfig{
+			LeapDay: string(birthday.LeapDayFeb28),
+		},
+		Sessions: SessionsConfig{
+			TTL:        60 * time.Minute,
+			TokenBytes: 32,
+		},
+		Password: PasswordConfig{
+			Iterations: 210000,
+			SaltLength: 16,
+			KeyLength:  32,
+			ResetTTL:   time.Hour,
+			InviteTTL:  7 * 24 * time.Hour,
+		},
+		Verification: VerificationConfig{
+			TTL: 48 * time.Hour,
+		},
+		LDAP: LDAPConfig{
+			Filter:              "(objectClass=inetOrgPerson)",
+			Interval:            time.Hour,
+			Timeout:             30 * time.Second,
+			IDAttribute:         "uid",
+			NameAttribute:       "uid",
+			EmailAttribute:      "mail",
+			BirthdayAttribute:   "birthDate",
+			DepartmentAttribute: "departmentNumber",
+		},
+		OIDC: OIDCConfig{
+			Scopes:  "openid email profile birthdate zoneinfo",
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Load собирает конфигурацию: значения по умолчанию, затем файл path (если задан),
+// затем переменные окружения, затем флаги, явно указанные в командной строке.
+func Load(path string, lookupEnv func(string) (string, bool), flags *Flags) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config: %v", err)
+		}
+
+		err = cfg.decodeYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse config %s: %v", path, err)
+		}
+	}
+
+	if lookupEnv != nil {
+		for _, f := range fieldsOf(cfg) {
+			raw, ok := lookupEnv(f.env)
+			if !ok {
+				continue
+			}
+
+			err := f.set(raw)
+			if err != nil {
+				return nil, fmt.Errorf("env %s: %v", f.env, err)
+			}
+		}
+	}
+
+	if flags != nil {
+		err := flags.apply(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) decodeYAML(data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	err := dec.Decode(cfg)
+	if err == io.EOF { // пустой файл
+		return nil
+	}
+
+	return err
+}
+
+func (cfg *Config) Validate() error {
+	problems := make([]string, 0)
+
+	if cfg.Server.Port <= 0 || cfg.Server.Port > 65535 {
+		problems = append(problems, "server.port must be in 1..65535")
+	}
+	if cfg.Server.Templates == "" {
+		problems = append(problems, "server.templates must not be empty")
+	}
+	if !strings.HasPrefix(cfg.Server.BaseURL, "http://") && !strings.HasPrefix(cfg.Server.BaseURL, "https://") {
+		problems = append(problems, "server.base_url must be an http(s) URL")
+	}
+
+	switch cfg.Storage.Backend {
+	case StorageMySQL:
+		if cfg.MySQL.Addr == "" {
+			problems = append(problems, "mysql.addr must not be empty")
+		}
+		if cfg.MySQL.User == "" {
+			problems = append(problems, "mysql.user must not be empty")
+		}
+		if cfg.MySQL.Database == "" {
+			problems = append(problems, "mysql.database must not be empty")
+		}
+		if cfg.MySQL.MaxOpenConns <= 0 {
+			problems = append(problems, "mysql.max_open_conns must be positive")
+		}
+	case StorageMemory:
+		// настройки mysql не нужны
+	default:
+		problems = append(problems, "storage.backend must be mysql or memory")
+	}
+
+	if cfg.SMTP.Host == "" || cfg.SMTP.Port == "" {
+		problems = append(problems, "smtp.host and smtp.port must not be empty")
+	}
+	if !strings.Contains(cfg.SMTP.From, "@") {
+		problems = append(problems, "smtp.from must be an email address")
+	}
+
+	_, err := cron.Parse(cfg.Alerts.Schedule)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("alerts.schedule: %v", err))
+	}
+
+	if cfg.Outbox.PollInterval <= 0 {
+		problems = append(problems, "outbox.poll_interval must be positive")
+	}
+	if cfg.Outbox.MaxAttempts <= 0 {
+		problems = append(problems, "outbox.max_attempts must be positive")
+	}
+	if cfg.Outbox.BaseDelay <= 0 || cfg.Outbox.MaxDelay < cfg.Outbox.BaseDelay {
+		problems = append(problems, "outbox.base_delay must be positive and not greater than outbox.max_delay")
+	}
+	if cfg.Outbox.BatchSize <= 0 {
+		problems = append(problems, "outbox.batch_size must be positive")
+	}
+
+	_, err = birthday.ParseLeapDayPolicy(cfg.Birthdays.LeapDay)
+	if err != nil {
+		problems = append(problems, "birthdays.leap_day must be feb28 or mar1")
+	}
+
+	if cfg.Sessions.TTL <= 0 {
+		problems = append(problems, "sessions.ttl must be positive")
+	}
+	if cfg.Sessions.TokenBytes < 16 {
+		problems = append(problems, "sessions.token_bytes must be at least 16")
+	}
+
+	if cfg.Password.Iterations <= 0 {
+		problems = append(problems, "password.iterations must be positive")
+	}
+	if cfg.Password.SaltLength < 8 {
+		problems = append(problems, "password.salt_length must be at least 8")
+	}
+	if cfg.Password.KeyLength < 16 {
+		problems = append(problems, "password.key_length must be at least 16")
+	}
+	if cfg.Password.ResetTTL <= 0 {
+		problems = append(problems, "password.reset_ttl must be positive")
+	}
+	if cfg.Password.InviteTTL <= 0 {
+		problems = append(problems, "password.invite_ttl must be positive")
+	}
+
+	if cfg.SCIM.Token != "" && len(cfg.SCIM.Token) < 32 {
+		problems = append(problems, "scim.token must be at least 32 characters")
+	}
+
+	if cfg.Verification.Secret != "" && len(cfg.Verification.Secret) < 32 {
+		problems = append(problems, "verification.secret must be at least 32 characters")
+	}
+	if cfg.Verification.TTL <= 0 {
+		problems = append(problems, "verification.ttl must be positive")
+	}
+
+	if cfg.LDAP.Addr != "" {
+		if _, _, err := net.SplitHostPort(cfg.LDAP.Addr); err != nil {
+			problems = append(problems, "ldap.addr must be host:port")
+		}
+		if cfg.LDAP.BaseDN == "" {
+			problems = append(problems, "ldap.base_dn must not be empty")
+		}
+		if cfg.LDAP.Interval <= 0 || cfg.LDAP.Timeout <= 0 {
+			problems = append(problems, "ldap.interval and ldap.timeout must be positive")
+		}
+		if cfg.LDAP.IDAttribute == "" || cfg.LDAP.NameAttribute == "" || cfg.LDAP.EmailAttribute == "" ||
+			cfg.LDAP.BirthdayAttribute == "" || cfg.LDAP.DepartmentAttribute == "" {
+			problems = append(problems, "ldap id, name, email, birthday and department attributes must not be empty")
+		}
+		if cfg.LDAP.StatusAttribute != "" && cfg.LDAP.InactiveValue == "" {
+			problems = append(problems, "ldap.inactive_value must be set with ldap.status_attribute")
+		}
+	}
+
+	if cfg.OIDC.Issuer != "" {
+		if !strings.HasPrefix(cfg.OIDC.Issuer, "https://") && !strings.HasPrefix(cfg.OIDC.Issuer, "http://") {
+			problems = append(problems, "oidc.issuer must be an http(s) URL")
+		}
+		if cfg.OIDC.ClientID == "" {
+			problems = append(problems, "oidc.client_id must not be empty")
+		}
+		if !slices.Contains(strings.Fields(cfg.OIDC.Scopes), "openid") {
+			problems = append(problems, "oidc.scopes must contain openid")
+		}
+		if cfg.OIDC.Timeout <= 0 {
+			problems = append(problems, "oidc.timeout must be positive")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// DSN возвращает строку подключения к MySQL
+func (cfg *Config) DSN() string {
+	mysqlCfg := mysql.NewConfig()
+	mysqlCfg.Net = "tcp"
+	mysqlCfg.Addr = cfg.MySQL.Addr
+	mysqlCfg.User = cfg.MySQL.User
+	mysqlCfg.Passwd = cfg.MySQL.Password
+	mysqlCfg.DBName = cfg.MySQL.Database
+	mysqlCfg.InterpolateParams = true
+	mysqlCfg.Params = map[string]string{
+		"charset": "utf8",
+	}
+
+	return mysqlCfg.FormatDSN()
+}
+
+// Print выводит конфигурацию в формате yaml, заменяя секреты
+func (cfg *Config) Print(w io.Writer) error {
+	cp := *cfg
+	for _, f := range fieldsOf(&cp) {
+		if f.secret && !f.value.IsZero() {
+			f.value.SetString(redacted)
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	err := enc.Encode(&cp)
+	if err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// Flags - флаги командной строки для каждого поля конфигурации (например -smtp.host)
+type Flags struct {
+	fs     *flag.FlagSet
+	values map[string]*string
+}
+
+// flagAliases - старые имена флагов, которые продолжают работать
+var flagAliases = map[string]string{
+	"port": "server.port",
+}
+
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{
+		fs:     fs,
+		values: make(map[string]*string),
+	}
+
+	for _, f := range fieldsOf(Default()) {
+		flags.values[f.key] = fs.String(f.key, "", fmt.Sprintf("overrides %s (env %s)", f.key, f.env))
+	}
+
+	for alias, key := range flagAliases {
+		flags.values[alias] = fs.String(alias, "", "same as -"+key)
+	}
+
+	return flags
+}
+
+func (flags *Flags) apply(cfg *Config) error {
+	set := make(map[string]string)
+	flags.fs.Visit(func(fl *flag.Flag) {
+		if _, ok := flags.values[fl.Name]; ok {
+			set[fl.Name] = *flags.values[fl.Name]
+		}
+	})
+
+	for alias, key := range flagAliases {
+		if value, ok := set[alias]; ok {
+			if _, ok := set[key]; !ok {
+				set[key] = value
+			}
+		}
+	}
+
+	for _, f := range fieldsOf(cfg) {
+		raw, ok := set[f.key]
+		if !ok {
+			continue
+		}
+
+		err := f.set(raw)
+		if err != nil {
+			return fmt.Errorf("flag -%s: %v", f.key, err)
+		}
+	}
+
+	return nil
+}
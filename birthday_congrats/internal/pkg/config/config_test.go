@@ -0,0 +1,252 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("cant write config: %v", err)
+	}
+
+	return path
+}
+
+func envFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	// данные для теста
+	path := writeConfig(t, `
+server:
+  port: 9000
+mysql:
+  password: secret_db_pass
+smtp:
+  from: sender@example.com
+  password: secret_smtp_pass
+alerts:
+  schedule: "30 8 * * 1-5"
+birthdays:
+  leap_day: mar1
+`)
+
+	// значения из файла поверх значений по умолчанию
+	cfg, err := Load(path, nil, nil)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 9000, cfg.Server.Port)
+	assert.EqualValues(t, "secret_db_pass", cfg.MySQL.Password)
+	assert.EqualValues(t, "sender@example.com", cfg.SMTP.From)
+	assert.EqualValues(t, "30 8 * * 1-5", cfg.Alerts.Schedule)
+	assert.EqualValues(t, "mar1", cfg.Birthdays.LeapDay)
+	assert.EqualValues(t, Default().Outbox.BatchSize, cfg.Outbox.BatchSize)
+	assert.EqualValues(t, Default().MySQL.Addr, cfg.MySQL.Addr)
+
+	// переменные окружения поверх файла
+	env := envFrom(map[string]string{
+		"BIRTHDAY_SERVER_PORT":    "9100",
+		"BIRTHDAY_SMTP_PASSWORD":  "env_smtp_pass",
+		"BIRTHDAY_SESSIONS_TTL":   "30m",
+		"BIRTHDAY_UNKNOWN_OPTION": "ignored",
+	})
+
+	cfg, err = Load(path, env, nil)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 9100, cfg.Server.Port)
+	assert.EqualValues(t, "env_smtp_pass", cfg.SMTP.Password)
+	assert.EqualValues(t, 30*time.Minute, cfg.Sessions.TTL)
+
+	// флаги поверх переменных окружения
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs)
+
+	err = fs.Parse([]string{"-server.port=9200", "-smtp.host=smtp.example.com"})
+	if err != nil {
+		t.Fatalf("cant parse flags: %v", err)
+	}
+
+	cfg, err = Load(path, env, flags)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 9200, cfg.Server.Port)
+	assert.EqualValues(t, "smtp.example.com", cfg.SMTP.Host)
+	assert.EqualValues(t, "env_smtp_pass", cfg.SMTP.Password)
+
+	// старый флаг -port
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	flags = RegisterFlags(fs)
+
+	err = fs.Parse([]string{"-port=8080"})
+	if err != nil {
+		t.Fatalf("cant parse flags: %v", err)
+	}
+
+	cfg, err = Load(path, nil, flags)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8080, cfg.Server.Port)
+
+	// нет файла
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"), nil, nil)
+
+	assert.Error(t, err)
+
+	// неизвестное поле в файле
+	_, err = Load(writeConfig(t, "smtp:\n  pasword: typo\n"), nil, nil)
+
+	assert.Error(t, err)
+
+	// некорректное значение в окружении
+	_, err = Load(path, envFrom(map[string]string{"BIRTHDAY_OUTBOX_BASE_DELAY": "minute"}), nil)
+
+	assert.Error(t, err)
+
+	// некорректное значение флага
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	flags = RegisterFlags(fs)
+
+	err = fs.Parse([]string{"-server.port=http"})
+	if err != nil {
+		t.Fatalf("cant parse flags: %v", err)
+	}
+
+	_, err = Load(path, nil, flags)
+
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	// значения по умолчанию + отправитель валидны
+	cfg := Default()
+	cfg.SMTP.From = "sender@example.com"
+
+	assert.NoError(t, cfg.Validate())
+
+	// без отправителя
+	cfg = Default()
+
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidConfig)
+
+	// все ошибки собираются в одну
+	cfg = Default()
+	cfg.SMTP.From = "sender@example.com"
+	cfg.Server.Port = 0
+	cfg.Alerts.Schedule = "0 25 * * *"
+	cfg.Sessions.TokenBytes = 8
+	cfg.Birthdays.LeapDay = "feb29"
+	cfg.Outbox.MaxDelay = time.Second
+	cfg.SCIM.Token = "short"
+	cfg.Server.BaseURL = "localhost:8000"
+	cfg.Verification.Secret = "short"
+	cfg.Verification.TTL = 0
+	cfg.Password.ResetTTL = 0
+	cfg.Password.InviteTTL = 0
+	cfg.LDAP.Addr = "ldap.example.com"
+	cfg.LDAP.Interval = 0
+	cfg.LDAP.StatusAttribute = "employeeStatus"
+	cfg.OIDC.Issuer = "accounts.example.com"
+	cfg.OIDC.Scopes = "email profile"
+
+	err := cfg.Validate()
+
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "server.port")
+	assert.Contains(t, err.Error(), "alerts.schedule")
+	assert.Contains(t, err.Error(), "sessions.token_bytes")
+	assert.Contains(t, err.Error(), "birthdays.leap_day")
+	assert.Contains(t, err.Error(), "outbox.base_delay")
+	assert.Contains(t, err.Error(), "scim.token")
+	assert.Contains(t, err.Error(), "server.base_url")
+	assert.Contains(t, err.Error(), "verification.secret")
+	assert.Contains(t, err.Error(), "verification.ttl")
+	assert.Contains(t, err.Error(), "password.reset_ttl")
+	assert.Contains(t, err.Error(), "password.invite_ttl")
+	assert.Contains(t, err.Error(), "ldap.addr")
+	assert.Contains(t, err.Error(), "ldap.base_dn")
+	assert.Contains(t, err.Error(), "ldap.interval")
+	assert.Contains(t, err.Error(), "ldap.inactive_value")
+	assert.Contains(t, err.Error(), "oidc.issuer")
+	assert.Contains(t, err.Error(), "oidc.client_id")
+	assert.Contains(t, err.Error(), "oidc.scopes")
+
+	// для хранилища в памяти настройки mysql не проверяются
+	cfg = Default()
+	cfg.SMTP.From = "sender@example.com"
+	cfg.Storage.Backend = StorageMemory
+	cfg.MySQL.Addr = ""
+
+	assert.NoError(t, cfg.Validate())
+
+	// неизвестное хранилище
+	cfg.Storage.Backend = "sqlite"
+
+	err = cfg.Validate()
+
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "storage.backend")
+}
+
+func TestDSN(t *testing.T) {
+	cfg := Default()
+	cfg.MySQL.Password = "root"
+
+	assert.EqualValues(t, "root:root@tcp(localhost:3306)/golang?interpolateParams=true&charset=utf8", cfg.DSN())
+}
+
+func TestPrint(t *testing.T) {
+	cfg := Default()
+	cfg.SMTP.From = "sender@example.com"
+	cfg.SMTP.Password = "secret_smtp_pass"
+	cfg.MySQL.Password = "secret_db_pass"
+
+	// нормальная работа
+	buf := &bytes.Buffer{}
+
+	err := cfg.Print(buf)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "secret_smtp_pass")
+	assert.NotContains(t, buf.String(), "secret_db_pass")
+	assert.Contains(t, buf.String(), redacted)
+	assert.Contains(t, buf.String(), "sender@example.com")
+	assert.Contains(t, buf.String(), "schedule: 0 9 * * *")
+
+	// сама конфигурация не меняется
+	assert.EqualValues(t, "secret_smtp_pass", cfg.SMTP.Password)
+
+	// выведенный конфиг читается обратно
+	printed := Default()
+
+	err = printed.decodeYAML(buf.Bytes())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, cfg.Alerts, printed.Alerts)
+
+	// пустой секрет не подменяется
+	cfg.MySQL.Password = ""
+	buf.Reset()
+
+	err = cfg.Print(buf)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, strings.Count(buf.String(), redacted))
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field - лист структуры Config
+type field struct {
+	key    string // путь в yaml, он же имя флага (smtp.password)
+	env    string // имя переменной окружения (BIRTHDAY_SMTP_PASSWORD)
+	secret bool
+	value  reflect.Value
+}
+
+func fieldsOf(cfg *Config) []field {
+	return collectFields(reflect.ValueOf(cfg).Elem(), "")
+}
+
+func collectFields(v reflect.Value, prefix string) []field {
+	fields := make([]field, 0, v.NumField())
+
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, collectFields(v.Field(i), key)...)
+			continue
+		}
+
+		fields = append(fields, field{
+			key:    key,
+			env:    envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_")),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+
+	return fields
+}
+
+func (f field) set(raw string) error {
+	if f.value.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		f.value.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+
+		f.value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		f.value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %v", f.value.Type())
+	}
+
+	return nil
+}
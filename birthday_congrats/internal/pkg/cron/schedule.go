@@ -0,0 +1,232 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadSchedule = errors.New("bad cron expression")
+)
+
+// maxSearchYears - сколько лет вперед искать ближайшее время по расписанию.
+// Самое редкое осмысленное расписание ("29 февраля, если это понедельник") срабатывает раз в 28 лет.
+const maxSearchYears = 30
+
+// Schedule - расписание в формате cron из пяти полей: минута, час, день месяца, месяц, день недели.
+// Поддерживаются *, списки (1,15), диапазоны (1-5), шаги (*/15, 8-18/2), названия месяцев
+// и дней недели (jan, mon), а также @hourly, @daily (@midnight), @weekly, @monthly, @yearly (@annually).
+// Как и в классическом cron, если заданы и день месяца, и день недели, подходит любой из них.
+type Schedule struct {
+	expr    string
+	minutes uint64
+	hours   uint64
+	dom     uint64
+	months  uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{ // 0 и 7 - воскресенье
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w %q: expected 5 fields, got %d", ErrBadSchedule, expr, len(parts))
+	}
+
+	s := &Schedule{
+		expr:    expr,
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}
+
+	var err error
+	for i, dst := range []*uint64{&s.minutes, &s.hours, &s.dom, &s.months, &s.dow} {
+		f := []field{minuteField, hourField, domField, monthField, dowField}[i]
+
+		*dst, err = f.parse(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrBadSchedule, expr, err)
+		}
+	}
+
+	// воскресенье можно записать и как 0, и как 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+// parse разбирает одно поле в битовую маску допустимых значений
+func (f field) parse(spec string) (uint64, error) {
+	mask := uint64(0)
+
+	for _, item := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step %q in %s", stepSpec, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangeSpec == "*":
+		case strings.Contains(rangeSpec, "-"):
+			loSpec, hiSpec, _ := strings.Cut(rangeSpec, "-")
+
+			var err error
+			lo, err = f.value(loSpec)
+			if err != nil {
+				return 0, err
+			}
+			hi, err = f.value(hiSpec)
+			if err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("bad range %q in %s", rangeSpec, f.name)
+			}
+		default:
+			v, err := f.value(rangeSpec)
+			if err != nil {
+				return 0, err
+			}
+
+			// "5/15" означает "с 5 до конца с шагом 15"
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << v
+		}
+	}
+
+	return mask, nil
+}
+
+func (f field) value(spec string) (int, error) {
+	if v, ok := f.names[strings.ToLower(spec)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(spec)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("bad value %q in %s, expected %d..%d", spec, f.name, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+func (s *Schedule) matchDay(t time.Time) bool {
+	if s.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowMatch
+	case s.dowStar:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// Next возвращает ближайшее время по расписанию строго после after. Время считается
+// по часам в часовом поясе after: "0 9 * * *" для времени в Europe/Moscow - это 9:00 по Москве.
+// Если в день перехода на летнее время нужного времени не существует, этот день пропускается.
+// Нулевое время - расписание никогда не срабатывает (например, "0 0 30 2 *").
+func (s *Schedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	local := after.In(loc)
+
+	// полдень, чтобы переходы на летнее время не сдвигали дату
+	day := time.Date(local.Year(), local.Month(), local.Day(), 12, 0, 0, 0, loc)
+	end := day.AddDate(maxSearchYears, 0, 0)
+
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !s.matchDay(day) {
+			continue
+		}
+
+		for h := 0; h < 24; h++ {
+			if s.hours&(1<<uint(h)) == 0 {
+				continue
+			}
+
+			for m := 0; m < 60; m++ {
+				if s.minutes&(1<<uint(m)) == 0 {
+					continue
+				}
+
+				t := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, loc)
+				if t.Hour() != h || t.Minute() != m {
+					continue // такого времени в этот день нет
+				}
+				if !t.After(after) {
+					continue
+				}
+
+				return t
+			}
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,155 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("cant load location %s: %v", name, err)
+	}
+
+	return loc
+}
+
+func TestParse(t *testing.T) {
+	// корректные выражения
+	for _, expr := range []string{
+		"0 9 * * *",
+		"*/15 8-18 * * mon-fri",
+		"0,30 9 1,15 jan,JUL *",
+		"5/10 * * * *",
+		"0 0 * * 7",
+		"@daily",
+		"@Hourly",
+	} {
+		_, err := Parse(expr)
+
+		assert.NoError(t, err, expr)
+	}
+
+	// некорректные выражения
+	for _, expr := range []string{
+		"",
+		"0 9 * *",
+		"0 9 * * * *",
+		"60 9 * * *",
+		"0 24 * * *",
+		"0 9 0 * *",
+		"0 9 * 13 *",
+		"0 9 * * 8",
+		"0 9 * * funday",
+		"*/0 9 * * *",
+		"0 18-9 * * *",
+		"@sometimes",
+	} {
+		_, err := Parse(expr)
+
+		assert.ErrorIs(t, err, ErrBadSchedule, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	moscow := mustLoadLocation(t, "Europe/Moscow")
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	cases := []struct {
+		name     string
+		expr     string
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "сегодня в 9:00",
+			expr:     "0 9 * * *",
+			after:    time.Date(2024, time.May, 10, 8, 30, 0, 0, moscow),
+			expected: time.Date(2024, time.May, 10, 9, 0, 0, 0, moscow),
+		},
+		{
+			name:     "9:00 уже прошло - завтра",
+			expr:     "0 9 * * *",
+			after:    time.Date(2024, time.May, 10, 9, 0, 0, 0, moscow),
+			expected: time.Date(2024, time.May, 11, 9, 0, 0, 0, moscow),
+		},
+		{
+			name:     "время считается в поясе after",
+			expr:     "0 9 * * *",
+			after:    time.Date(2024, time.May, 10, 7, 0, 0, 0, time.UTC).In(newYork),
+			expected: time.Date(2024, time.May, 10, 9, 0, 0, 0, newYork),
+		},
+		{
+			name:     "каждые 15 минут",
+			expr:     "*/15 * * * *",
+			after:    time.Date(2024, time.May, 10, 10, 7, 30, 0, time.UTC),
+			expected: time.Date(2024, time.May, 10, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "по будням: с пятницы на понедельник",
+			expr:     "0 9 * * mon-fri",
+			after:    time.Date(2024, time.May, 10, 10, 0, 0, 0, time.UTC), // пятница
+			expected: time.Date(2024, time.May, 13, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "воскресенье как 7",
+			expr:     "0 9 * * 7",
+			after:    time.Date(2024, time.May, 10, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, time.May, 12, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "день месяца или день недели",
+			expr:     "0 9 15 * sun",
+			after:    time.Date(2024, time.May, 13, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, time.May, 15, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "конец года",
+			expr:     "@yearly",
+			after:    time.Date(2024, time.December, 31, 23, 59, 0, 0, time.UTC),
+			expected: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "29 февраля",
+			expr:     "0 9 29 feb *",
+			after:    time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2028, time.February, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "2:30 в день перехода на летнее время не существует",
+			expr:     "30 2 * * *",
+			after:    time.Date(2024, time.March, 30, 12, 0, 0, 0, berlin),
+			expected: time.Date(2024, time.April, 1, 2, 30, 0, 0, berlin),
+		},
+		{
+			name:     "9:00 в день перехода на летнее время",
+			expr:     "0 9 * * *",
+			after:    time.Date(2024, time.March, 30, 12, 0, 0, 0, berlin),
+			expected: time.Date(2024, time.March, 31, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:     "никогда",
+			expr:     "0 0 30 2 *",
+			after:    time.Date(2024, time.May, 10, 10, 0, 0, 0, time.UTC),
+			expected: time.Time{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := Parse(c.expr)
+			if err != nil {
+				t.Fatalf("cant parse %q: %v", c.expr, err)
+			}
+
+			next := s.Next(c.after)
+
+			assert.True(t, c.expected.Equal(next), "expected %v, got %v", c.expected, next)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package cron
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxSleep - как долго планировщик может спать, не перечитывая список часовых поясов
+// (чтобы заметить пользователей из новых поясов)
+const maxSleep = time.Hour
+
+// ZonesFunc возвращает часовые пояса, для которых нужно запускать задачу
+type ZonesFunc func(ctx context.Context) ([]*time.Location, error)
+
+// Job - задача, которую нужно выполнить в момент now для часовых поясов zones
+type Job func(ctx context.Context, now time.Time, zones []*time.Location)
+
+// Scheduler запускает задачу по расписанию отдельно в каждом часовом поясе:
+// для расписания "0 9 * * *" задача выполняется в 9:00 по Москве для пояса Europe/Moscow,
+// в 9:00 по Токио для Asia/Tokyo и т.д. Пояса с одинаковым временем срабатывания
+// обрабатываются одним вызовом.
+type Scheduler struct {
+	schedule *Schedule
+	logger   *zap.SugaredLogger
+
+	// подменяются в тестах
+	now   func() time.Time
+	after func(d time.Duration) <-chan time.Time
+}
+
+func NewScheduler(schedule *Schedule, logger *zap.SugaredLogger) *Scheduler {
+	return &Scheduler{
+		schedule: schedule,
+		logger:   logger,
+		now:      time.Now,
+		after:    time.After,
+	}
+}
+
+// Run выполняет job по расписанию, пока не отменен ctx
+func (s *Scheduler) Run(ctx context.Context, zones ZonesFunc, job Job, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	s.logger.Infof("Scheduler started with schedule %q", s.schedule)
+
+	last := time.Time{} // время последнего срабатывания
+	for {
+		// часы могли отстать от времени срабатывания - не запускаем его второй раз
+		now := s.now()
+		if now.Before(last) {
+			now = last
+		}
+
+		locations, err := zones(ctx)
+		if err != nil {
+			s.logger.Errorf("Error getting time zones: %v", err)
+			locations = nil
+		}
+
+		next, due := s.next(now, locations)
+
+		wait := maxSleep
+		if len(due) > 0 && next.Sub(now) <= maxSleep {
+			wait = next.Sub(now)
+		} else {
+			due = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Infof("Scheduler was stopped")
+			return
+		case <-s.after(wait):
+		}
+
+		// если готовы оба случая, select выбирает любой - отмену проверяем еще раз
+		if ctx.Err() != nil {
+			s.logger.Infof("Scheduler was stopped")
+			return
+		}
+
+		if len(due) > 0 {
+			job(ctx, next, due)
+			last = next
+		}
+	}
+}
+
+// next возвращает ближайшее время срабатывания среди поясов zones и пояса, в которых оно наступает
+func (s *Scheduler) next(now time.Time, zones []*time.Location) (time.Time, []*time.Location) {
+	var (
+		next time.Time
+		due  []*time.Location
+	)
+
+	for _, loc := range zones {
+		t := s.schedule.Next(now.In(loc))
+
+		switch {
+		case t.IsZero():
+		case next.IsZero() || t.Before(next):
+			next = t
+			due = []*time.Location{loc}
+		case t.Equal(next):
+			due = append(due, loc)
+		}
+	}
+
+	return next, due
+}
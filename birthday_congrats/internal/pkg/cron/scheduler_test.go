@@ -0,0 +1,111 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeClock - часы, которые мгновенно "проматываются" на время ожидания
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+type call struct {
+	now   time.Time
+	zones []string
+}
+
+func TestSchedulerRun(t *testing.T) {
+	moscow := mustLoadLocation(t, "Europe/Moscow")
+	tokyo := mustLoadLocation(t, "Asia/Tokyo")
+	kaliningrad := mustLoadLocation(t, "Europe/Kaliningrad")
+
+	schedule, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatalf("cant parse schedule: %v", err)
+	}
+
+	clock := &fakeClock{now: time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC)}
+
+	testScheduler := NewScheduler(schedule, zap.NewNop().Sugar())
+	testScheduler.now = clock.Now
+	testScheduler.after = clock.After
+
+	// данные для теста
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+
+	zonesCalls := 0
+	zones := func(ctx context.Context) ([]*time.Location, error) {
+		zonesCalls++
+		if zonesCalls == 2 {
+			return nil, fmt.Errorf("repo error")
+		}
+
+		return []*time.Location{moscow, tokyo, time.UTC}, nil
+	}
+
+	calls := make([]call, 0)
+	job := func(ctx context.Context, now time.Time, zones []*time.Location) {
+		names := make([]string, 0, len(zones))
+		for _, loc := range zones {
+			names = append(names, loc.String())
+		}
+
+		calls = append(calls, call{now: now, zones: names})
+		if len(calls) == 4 {
+			cancel()
+		}
+	}
+
+	// нормальная работа: 9:00 в Токио (00:00 UTC) уже прошло, дальше Москва, UTC,
+	// после ошибки получения поясов - час простоя, затем снова Токио
+	wg.Add(1)
+	testScheduler.Run(ctx, zones, job, wg)
+
+	assert.EqualValues(t, []call{
+		{now: time.Date(2024, time.May, 10, 9, 0, 0, 0, moscow), zones: []string{"Europe/Moscow"}},
+		{now: time.Date(2024, time.May, 10, 9, 0, 0, 0, time.UTC), zones: []string{"UTC"}},
+		{now: time.Date(2024, time.May, 11, 9, 0, 0, 0, tokyo), zones: []string{"Asia/Tokyo"}},
+		{now: time.Date(2024, time.May, 11, 9, 0, 0, 0, moscow), zones: []string{"Europe/Moscow"}},
+	}, calls)
+
+	// пояса с одинаковым временем срабатывания обрабатываются вместе
+	istanbul := mustLoadLocation(t, "Europe/Istanbul")
+
+	next, due := testScheduler.next(time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC), []*time.Location{moscow, time.UTC, kaliningrad, istanbul})
+
+	assert.True(t, time.Date(2024, time.May, 10, 6, 0, 0, 0, time.UTC).Equal(next))
+	assert.True(t, len(due) == 2 && due[0] == moscow && due[1] == istanbul, "due: %v", due)
+
+	// нет поясов - нечего запускать
+	next, due = testScheduler.next(time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC), nil)
+
+	assert.True(t, next.IsZero())
+	assert.Empty(t, due)
+}
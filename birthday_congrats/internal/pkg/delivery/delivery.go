@@ -0,0 +1,29 @@
+package delivery
+
+import (
+	"context"
+	"time"
+)
+
+// Key - одно напоминание: подписчик subscriber получает напоминание о дне рождения
+// subject за daysBefore дней до праздника в году birthdayYear. Каждое напоминание
+// отправляется не больше одного раза.
+type Key struct {
+	Subscriber   uint32
+	Subject      uint32
+	BirthdayYear int
+	DaysBefore   int
+}
+
+// DeliveriesRepo - журнал отправленных напоминаний и даты последних запусков рассылки.
+// Рассылка запускается отдельно для каждого часового пояса, поэтому и дата запуска
+// (по местному календарю) хранится для каждого пояса своя.
+type DeliveriesRepo interface {
+	// LastRun возвращает дату (полночь UTC) последнего запуска в поясе zone; нулевое время, если запусков не было
+	LastRun(ctx context.Context, zone string) (time.Time, error)
+	SetLastRun(ctx context.Context, zone string, day time.Time) error
+	// Record записывает напоминание в журнал и возвращает false, если оно там уже было
+	Record(ctx context.Context, key Key) (bool, error)
+	// Forget удаляет напоминания из журнала (если их не удалось поставить в очередь отправки)
+	Forget(ctx context.Context, keys []Key) error
+}
@@ -0,0 +1,68 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DeliveriesMemoryRepo хранит журнал напоминаний в памяти процесса (для разработки без базы данных)
+type DeliveriesMemoryRepo struct {
+	mu       *sync.Mutex
+	lastRuns map[string]time.Time
+	records  map[Key]struct{}
+	logger   *zap.SugaredLogger
+}
+
+var _ DeliveriesRepo = &DeliveriesMemoryRepo{}
+
+func NewDeliveriesMemoryRepo(logger *zap.SugaredLogger) *DeliveriesMemoryRepo {
+	return &DeliveriesMemoryRepo{
+		mu:       &sync.Mutex{},
+		lastRuns: make(map[string]time.Time),
+		records:  make(map[Key]struct{}),
+		logger:   logger,
+	}
+}
+
+func (repo *DeliveriesMemoryRepo) LastRun(ctx context.Context, zone string) (time.Time, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.lastRuns[zone], nil
+}
+
+func (repo *DeliveriesMemoryRepo) SetLastRun(ctx context.Context, zone string, day time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// как и в MySQL, хранится только дата
+	repo.lastRuns[zone] = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	return nil
+}
+
+func (repo *DeliveriesMemoryRepo) Record(ctx context.Context, key Key) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.records[key]; ok {
+		return false, nil
+	}
+	repo.records[key] = struct{}{}
+
+	return true, nil
+}
+
+func (repo *DeliveriesMemoryRepo) Forget(ctx context.Context, keys []Key) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, key := range keys {
+		delete(repo.records, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package delivery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+const (
+	dateLayout = "2006-01-02"
+)
+
+type DeliveriesMySQLRepo struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+var _ DeliveriesRepo = &DeliveriesMySQLRepo{}
+
+func NewDeliveriesMySQLRepo(db *sql.DB, logger *zap.SugaredLogger) *DeliveriesMySQLRepo {
+	return &DeliveriesMySQLRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *DeliveriesMySQLRepo) LastRun(ctx context.Context, zone string) (time.Time, error) {
+	lastRun := ""
+
+	err := repo.db.
+		QueryRowContext(ctx, "SELECT last_run_date FROM alert_runs WHERE zone = ?", zone).
+		Scan(&lastRun)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		repo.logger.Errorf("Error while SELECT from db: %v", err)
+		return time.Time{}, fmt.Errorf("db error: %v", err)
+	}
+
+	day, err := time.Parse(dateLayout, lastRun)
+	if err != nil {
+		repo.logger.Errorf("Error while parsing last run date %q: %v", lastRun, err)
+		return time.Time{}, fmt.Errorf("bad last run date: %v", err)
+	}
+
+	return day, nil
+}
+
+func (repo *DeliveriesMySQLRepo) SetLastRun(ctx context.Context, zone string, day time.Time) error {
+	// при повторной записи той же даты RowsAffected = 0, поэтому результат не проверяем
+	_, err := repo.db.ExecContext(
+		ctx,
+		"INSERT INTO alert_runs (`zone`, `last_run_date`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `last_run_date` = VALUES(`last_run_date`)",
+		zone,
+		day.Format(dateLayout),
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while INSERT into db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *DeliveriesMySQLRepo) Record(ctx context.Context, key Key) (bool, error) {
+	result, err := repo.db.ExecContext(
+		ctx,
+		"INSERT IGNORE INTO alert_deliveries (`subscriber_id`, `subject_id`, `birthday_year`, `days_before`, `created_at`) VALUES (?, ?, ?, ?, ?)",
+		key.Subscriber,
+		key.Subject,
+		key.BirthdayYear,
+		key.DaysBefore,
+		time.Now().Unix(),
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while INSERT into db: %v", err)
+		return false, fmt.Errorf("db error: %v", err)
+	}
+
+	// запись не добавлена - напоминание уже отправлялось
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.Errorf("Error in RowsAffected(): %v", err)
+		return false, fmt.Errorf("db error: %v", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (repo *DeliveriesMySQLRepo) Forget(ctx context.Context, keys []Key) error {
+	for _, key := range keys {
+		_, err := repo.db.ExecContext(
+			ctx,
+			"DELETE FROM alert_deliveries WHERE subscriber_id = ? AND subject_id = ? AND birthday_year = ? AND days_before = ?",
+			key.Subscriber,
+			key.Subject,
+			key.BirthdayYear,
+			key.DaysBefore,
+		)
+		if err != nil {
+			repo.logger.Errorf("Error while DELETE from db: %v", err)
+			return fmt.Errorf("db error: %v", err)
+		}
+	}
+
+	return nil
+}
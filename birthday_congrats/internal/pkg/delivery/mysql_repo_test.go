@@ -0,0 +1,257 @@
+package delivery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestLastRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewDeliveriesMySQLRepo(db, zap.NewNop().Sugar())
+
+	// нормальная работа
+	rows := sqlmock.NewRows([]string{"last_run_date"}).AddRow("2024-05-10")
+
+	mock.
+		ExpectQuery("SELECT last_run_date FROM alert_runs WHERE zone = ?").
+		WithArgs("Europe/Moscow").
+		WillReturnRows(rows)
+
+	lastRun, err := testRepo.LastRun(ctx, "Europe/Moscow")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC), lastRun)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// запусков еще не было
+	mock.
+		ExpectQuery("SELECT last_run_date FROM alert_runs WHERE zone = ?").
+		WithArgs("Europe/Moscow").
+		WillReturnError(sql.ErrNoRows)
+
+	lastRun, err = testRepo.LastRun(ctx, "Europe/Moscow")
+
+	assert.NoError(t, err)
+	assert.True(t, lastRun.IsZero())
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectQuery("SELECT last_run_date FROM alert_runs WHERE zone = ?").
+		WithArgs("Europe/Moscow").
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testRepo.LastRun(ctx, "Europe/Moscow")
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// некорректная дата в бд
+	rows = sqlmock.NewRows([]string{"last_run_date"}).AddRow("10.05.2024")
+
+	mock.
+		ExpectQuery("SELECT last_run_date FROM alert_runs WHERE zone = ?").
+		WithArgs("Europe/Moscow").
+		WillReturnRows(rows)
+
+	_, err = testRepo.LastRun(ctx, "Europe/Moscow")
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestSetLastRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewDeliveriesMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	day := time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC)
+
+	// нормальная работа
+	mock.
+		ExpectExec("INSERT INTO alert_runs").
+		WithArgs("Europe/Moscow", "2024-05-10").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = testRepo.SetLastRun(ctx, "Europe/Moscow", day)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// та же дата повторно
+	mock.
+		ExpectExec("INSERT INTO alert_runs").
+		WithArgs("Europe/Moscow", "2024-05-10").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = testRepo.SetLastRun(ctx, "Europe/Moscow", day)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectExec("INSERT INTO alert_runs").
+		WithArgs("Europe/Moscow", "2024-05-10").
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.SetLastRun(ctx, "Europe/Moscow", day)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestRecord(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewDeliveriesMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	key := Key{
+		Subscriber:   1,
+		Subject:      2,
+		BirthdayYear: 2024,
+		DaysBefore:   5,
+	}
+
+	// новое напоминание
+	mock.
+		ExpectExec("INSERT IGNORE INTO alert_deliveries").
+		WithArgs(key.Subscriber, key.Subject, key.BirthdayYear, key.DaysBefore, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	isNew, err := testRepo.Record(ctx, key)
+
+	assert.NoError(t, err)
+	assert.True(t, isNew)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// напоминание уже было
+	mock.
+		ExpectExec("INSERT IGNORE INTO alert_deliveries").
+		WithArgs(key.Subscriber, key.Subject, key.BirthdayYear, key.DaysBefore, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	isNew, err = testRepo.Record(ctx, key)
+
+	assert.NoError(t, err)
+	assert.False(t, isNew)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectExec("INSERT IGNORE INTO alert_deliveries").
+		WithArgs(key.Subscriber, key.Subject, key.BirthdayYear, key.DaysBefore, sqlmock.AnyArg()).
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testRepo.Record(ctx, key)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка в RowsAffected
+	mock.
+		ExpectExec("INSERT IGNORE INTO alert_deliveries").
+		WithArgs(key.Subscriber, key.Subject, key.BirthdayYear, key.DaysBefore, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("result error")))
+
+	_, err = testRepo.Record(ctx, key)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestForget(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewDeliveriesMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	keys := []Key{
+		{Subscriber: 1, Subject: 2, BirthdayYear: 2024, DaysBefore: 5},
+		{Subscriber: 3, Subject: 2, BirthdayYear: 2024, DaysBefore: 5},
+	}
+
+	// нормальная работа
+	for _, key := range keys {
+		mock.
+			ExpectExec("DELETE FROM alert_deliveries WHERE").
+			WithArgs(key.Subscriber, key.Subject, key.BirthdayYear, key.DaysBefore).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	err = testRepo.Forget(ctx, keys)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectExec("DELETE FROM alert_deliveries WHERE").
+		WithArgs(keys[0].Subscriber, keys[0].Subject, keys[0].BirthdayYear, keys[0].DaysBefore).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.Forget(ctx, keys)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
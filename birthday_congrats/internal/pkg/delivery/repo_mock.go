@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: delivery.go
+
+// Package delivery is a generated GoMock package.
+package delivery
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDeliveriesRepo is a mock of DeliveriesRepo interface.
+type MockDeliveriesRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeliveriesRepoMockRecorder
+}
+
+// MockDeliveriesRepoMockRecorder is the mock recorder for MockDeliveriesRepo.
+type MockDeliveriesRepoMockRecorder struct {
+	mock *MockDeliveriesRepo
+}
+
+// NewMockDeliveriesRepo creates a new mock instance.
+func NewMockDeliveriesRepo(ctrl *gomock.Controller) *MockDeliveriesRepo {
+	mock := &MockDeliveriesRepo{ctrl: ctrl}
+	mock.recorder = &MockDeliveriesRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeliveriesRepo) EXPECT() *MockDeliveriesRepoMockRecorder {
+	return m.recorder
+}
+
+// Forget mocks base method.
+func (m *MockDeliveriesRepo) Forget(ctx context.Context, keys []Key) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Forget", ctx, keys)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Forget indicates an expected call of Forget.
+func (mr *MockDeliveriesRepoMockRecorder) Forget(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forget", reflect.TypeOf((*MockDeliveriesRepo)(nil).Forget), ctx, keys)
+}
+
+// LastRun mocks base method.
+func (m *MockDeliveriesRepo) LastRun(ctx context.Context, zone string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastRun", ctx, zone)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastRun indicates an expected call of LastRun.
+func (mr *MockDeliveriesRepoMockRecorder) LastRun(ctx, zone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastRun", reflect.TypeOf((*MockDeliveriesRepo)(nil).LastRun), ctx, zone)
+}
+
+// Record mocks base method.
+func (m *MockDeliveriesRepo) Record(ctx context.Context, key Key) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockDeliveriesRepoMockRecorder) Record(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockDeliveriesRepo)(nil).Record), ctx, key)
+}
+
+// SetLastRun mocks base method.
+func (m *MockDeliveriesRepo) SetLastRun(ctx context.Context, zone string, day time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLastRun", ctx, zone, day)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLastRun indicates an expected call of SetLastRun.
+func (mr *MockDeliveriesRepoMockRecorder) SetLastRun(ctx, zone, day interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLastRun", reflect.TypeOf((*MockDeliveriesRepo)(nil).SetLastRun), ctx, zone, day)
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/middlware"
+	"birthday_congrats/internal/pkg/user"
+	service "birthday_congrats/internal/services/congrats_service"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// adminSubscription - строка таблицы подписок на странице администратора
+type adminSubscription struct {
+	Subscriber string
+	Subject    string
+	DaysAlert  []int
+}
+
+// importRow - строка отчета импорта
+type importRow struct {
+	Line  int
+	Email string
+	Text  string
+}
+
+func (h *ServiceHandler) Admin(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.ListUsers(r.Context())
+	if err != nil {
+		h.adminError(w, r, err)
+		return
+	}
+
+	subscriptions, err := h.service.ListSubscriptions(r.Context())
+	if err != nil {
+		h.adminError(w, r, err)
+		return
+	}
+
+	names := make(map[uint32]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+
+	rows := make([]adminSubscription, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		rows = append(rows, adminSubscription{
+			Subscriber: names[s.Subscriber],
+			Subject:    names[s.Subscription],
+			DaysAlert:  s.DaysAlert,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = h.tmpl.ExecuteTemplate(w, "admin.html", struct {
+		Users         []*user.User
+		Subscriptions []adminSubscription
+		CSRFToken     string
+	}{
+		Users:         users,
+		Subscriptions: rows,
+		CSRFToken:     middlware.CSRFToken(r.Context()),
+	})
+	if err != nil {
+		h.logger.Errorf("Template error: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+// adminError показывает известные ошибки административных действий, остальные - на /error
+func (h *ServiceHandler) adminError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case service.ErrForbidden:
+		h.execErrorTemplate(w, "Недостаточно прав", http.StatusForbidden)
+	case user.ErrNoUser:
+		h.execErrorTemplate(w, "Пользователь не найден", http.StatusNotFound)
+	case user.ErrBadRole:
+		h.execErrorTemplate(w, "Неизвестная роль", http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Error in admin action: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+// adminAction выполняет действие над пользователем из пути и возвращает на страницу администратора
+func (h *ServiceHandler) adminAction(w http.ResponseWriter, r *http.Request, action func(userID uint32) error) {
+	userID, err := strconv.ParseUint(mux.Vars(r)["user_id"], 10, 32)
+	if err != nil {
+		h.logger.Errorf("Error converting string to int: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	err = action(uint32(userID))
+	if err != nil {
+		h.adminError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusFound)
+}
+
+func (h *ServiceHandler) AdminSetRole(w http.ResponseWriter, r *http.Request) {
+	h.adminAction(w, r, func(userID uint32) error {
+		_, err := h.service.SetRole(r.Context(), userID, r.FormValue("role"))
+		return err
+	})
+}
+
+func (h *ServiceHandler) AdminDeactivateUser(w http.ResponseWriter, r *http.Request) {
+	h.adminAction(w, r, func(userID uint32) error {
+		_, err := h.service.DeactivateUser(r.Context(), userID)
+		return err
+	})
+}
+
+func (h *ServiceHandler) AdminLogoutUser(w http.ResponseWriter, r *http.Request) {
+	h.adminAction(w, r, func(userID uint32) error {
+		return h.service.ForceLogout(r.Context(), userID)
+	})
+}
+
+func (h *ServiceHandler) AdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	h.adminAction(w, r, func(userID uint32) error {
+		return h.service.DeleteUser(r.Context(), userID)
+	})
+}
+
+func (h *ServiceHandler) AdminRunAlerts(w http.ResponseWriter, r *http.Request) {
+	err := h.service.RunAlerts(r.Context())
+	if err != nil {
+		h.adminError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusFound)
+}
+
+func (h *ServiceHandler) AdminImport(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	// форму мог уже разобрать миддлвер CSRF со своим ограничением, размер файла проверяем отдельно
+	file, header, err := r.FormFile("file")
+	if err == nil && header.Size > maxImportBytes {
+		file.Close()
+		err = fmt.Errorf("file is too large: %d bytes", header.Size)
+	}
+	if err != nil {
+		h.logger.Warnf("Error reading import file: %v", err)
+		h.execErrorTemplate(w, "Не удалось прочитать файл", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	isJSON := strings.EqualFold(filepath.Ext(header.Filename), ".json")
+
+	records, err := parseRoster(file, isJSON)
+	if err != nil {
+		h.logger.Warnf("Bad import file %q: %v", header.Filename, err)
+		h.execErrorTemplate(w, "Файл не разобран: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.ImportUsers(r.Context(), records, r.FormValue("dry_run") != "")
+	if err != nil {
+		h.adminError(w, r, err)
+		return
+	}
+
+	rows := make([]importRow, 0, len(report.Results))
+	for _, res := range report.Results {
+		rows = append(rows, importRow{
+			Line:  res.Line,
+			Email: res.Email,
+			Text:  importResultText(res, report.DryRun),
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = h.tmpl.ExecuteTemplate(w, "import.html", struct {
+		Report  *service.ImportReport
+		Results []importRow
+	}{
+		Report:  report,
+		Results: rows,
+	})
+	if err != nil {
+		h.logger.Errorf("Template error: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func importResultText(res service.ImportResult, dryRun bool) string {
+	switch {
+	case res.Action == service.ImportCreated && dryRun:
+		return "будет заведен новый сотрудник"
+	case res.Action == service.ImportCreated:
+		return "заведен новый сотрудник, отправлено приглашение"
+	case res.Action == service.ImportUpdated && dryRun:
+		return "данные будут обновлены"
+	case res.Action == service.ImportUpdated:
+		return "данные обновлены"
+	case res.Action == service.ImportUnchanged:
+		return "без изменений"
+	}
+
+	switch res.Err {
+	case service.ErrEmptyUsername:
+		return "не указано имя"
+	case service.ErrBadEmail:
+		return "некорректный адрес почты"
+	case service.ErrDuplicateEmail:
+		return "почта уже встречалась в файле"
+	case service.ErrAmbiguousEmail:
+		return "эта почта у нескольких сотрудников"
+	case service.ErrUnverifiedEmail:
+		return "сотрудник с этой почтой еще не подтвердил ее"
+	case user.ErrUserExists:
+		return "имя занято другим сотрудником"
+	case service.ErrBadDateFormat:
+		return "некорректная дата рождения"
+	case birthday.ErrBirthdayInFuture:
+		return "дата рождения в будущем"
+	case birthday.ErrBadAge:
+		return fmt.Sprintf("возраст должен быть от %d до %d лет", birthday.MinAge, birthday.MaxAge)
+	default:
+		return "ошибка"
+	}
+}
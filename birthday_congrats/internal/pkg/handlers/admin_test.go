@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/services/congrats_service"
+	"bytes"
+	"fmt"
+	"html/template"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// данные для теста
+	users := []*user.User{
+		{ID: 1, Username: "alice", Email: "alice@email.net", Role: user.RoleAdmin},
+		{ID: 2, Username: "bob", Email: "bob@email.net", Role: user.RoleEmployee, Deactivated: true},
+	}
+	subscriptions := []*subscription.Subscription{
+		{Subscriber: 1, Subscription: 2, DaysAlert: []int{0, 7}},
+	}
+
+	// страница администратора: подписки показываются по именам
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+
+	service.EXPECT().ListUsers(r.Context()).Return(users, nil)
+	service.EXPECT().ListSubscriptions(r.Context()).Return(subscriptions, nil)
+
+	testHandler.Admin(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "bob@email.net")
+	assert.Contains(t, w.Body.String(), "уволен")
+	assert.Contains(t, w.Body.String(), "<td>alice</td>\n            <td>bob</td>\n            <td>0, 7</td>")
+
+	// не администратор
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/admin", nil)
+
+	service.EXPECT().ListUsers(r.Context()).Return(nil, congrats_service.ErrForbidden)
+
+	testHandler.Admin(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/admin", nil)
+
+	service.EXPECT().ListUsers(r.Context()).Return(users, nil)
+	service.EXPECT().ListSubscriptions(r.Context()).Return(nil, fmt.Errorf("service error"))
+
+	testHandler.Admin(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// смена роли
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/admin/users/2/role", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "2"})
+	r.ParseForm()
+	r.Form.Set("role", user.RoleAdmin)
+
+	service.EXPECT().SetRole(r.Context(), uint32(2), user.RoleAdmin).Return(users[1], nil)
+
+	testHandler.AdminSetRole(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/admin", w.Header().Get("Location"))
+
+	// неизвестная роль
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/admin/users/2/role", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "2"})
+
+	service.EXPECT().SetRole(r.Context(), uint32(2), "").Return(nil, user.ErrBadRole)
+
+	testHandler.AdminSetRole(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// увольнение
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/admin/users/2/deactivate", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "2"})
+
+	service.EXPECT().DeactivateUser(r.Context(), uint32(2)).Return(users[1], nil)
+
+	testHandler.AdminDeactivateUser(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/admin", w.Header().Get("Location"))
+
+	// завершение сессий несуществующего пользователя
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/admin/users/3/logout", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "3"})
+
+	service.EXPECT().ForceLogout(r.Context(), uint32(3)).Return(user.ErrNoUser)
+
+	testHandler.AdminLogoutUser(w, r)
+
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+
+	// удаление
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/admin/users/2/delete", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "2"})
+
+	service.EXPECT().DeleteUser(r.Context(), uint32(2)).Return(nil)
+
+	testHandler.AdminDeleteUser(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/admin", w.Header().Get("Location"))
+
+	// неверный id
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/admin/users/bad_id/delete", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "bad_id"})
+
+	testHandler.AdminDeleteUser(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// запуск рассылки
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/admin/alerts", nil)
+
+	service.EXPECT().RunAlerts(r.Context()).Return(nil)
+
+	testHandler.AdminRunAlerts(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/admin", w.Header().Get("Location"))
+
+	// запуск рассылки без прав
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/admin/alerts", nil)
+
+	service.EXPECT().RunAlerts(r.Context()).Return(congrats_service.ErrForbidden)
+
+	testHandler.AdminRunAlerts(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+}
+
+// importRequest - загрузка файла выгрузки формой со страницы администратора
+func importRequest(t *testing.T, filename, content string, dryRun bool) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("cant create form file: %v", err)
+	}
+	fw.Write([]byte(content))
+
+	if dryRun {
+		mw.WriteField("dry_run", "true")
+	}
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/admin/import", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return r
+}
+
+func TestAdminImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// данные для теста
+	report := &congrats_service.ImportReport{
+		DryRun: true,
+		Results: []congrats_service.ImportResult{
+			{Line: 2, Email: "alice@example.com", Action: congrats_service.ImportCreated},
+			{Line: 3, Email: "bob@example.com", Action: congrats_service.ImportFailed, Err: congrats_service.ErrDuplicateEmail},
+		},
+		Created: 1,
+		Failed:  1,
+	}
+
+	// пробный прогон CSV
+	w := httptest.NewRecorder()
+	r := importRequest(t, "staff.csv", "name,email,birthday\nalice,alice@example.com,1990-05-10\nbob,bob@example.com,1990-05-10\n", true)
+
+	service.EXPECT().ImportUsers(r.Context(), gomock.Len(2), true).Return(report, nil)
+
+	testHandler.AdminImport(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Ничего не изменено")
+	assert.Contains(t, w.Body.String(), "будет заведен новый сотрудник")
+	assert.Contains(t, w.Body.String(), "почта уже встречалась в файле")
+
+	// JSON определяется по расширению
+	w = httptest.NewRecorder()
+	r = importRequest(t, "staff.JSON", `[{"name":"alice","email":"alice@example.com","birthday":"1990-05-10"}]`, false)
+
+	service.EXPECT().ImportUsers(r.Context(), gomock.Len(1), false).Return(&congrats_service.ImportReport{}, nil)
+
+	testHandler.AdminImport(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	// файл не разобран
+	w = httptest.NewRecorder()
+	r = importRequest(t, "staff.csv", "name,phone\n", true)
+
+	testHandler.AdminImport(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// без файла
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/admin/import", nil)
+
+	testHandler.AdminImport(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// не администратор
+	w = httptest.NewRecorder()
+	r = importRequest(t, "staff.csv", "name,email,birthday\n", true)
+
+	service.EXPECT().ImportUsers(r.Context(), gomock.Len(0), true).Return(nil, congrats_service.ErrForbidden)
+
+	testHandler.AdminImport(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+}
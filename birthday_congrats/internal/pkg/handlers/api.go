@@ -0,0 +1,813 @@
+package handlers
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/roster"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
+	service "birthday_congrats/internal/services/congrats_service"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	maxDaysAlert   = 365
+	maxImportBytes = 1 << 20 // выгрузка HR на несколько тысяч сотрудников
+)
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// APIHandler - JSON API (/api/v1) поверх того же сервиса, что и html-хендлеры.
+// Cookie API не выдает и не принимает: сессия передается в заголовке Authorization.
+type APIHandler struct {
+	service service.CongratulationsService
+	logger  *zap.SugaredLogger
+}
+
+func NewAPIHandler(
+	service service.CongratulationsService,
+	logger *zap.SugaredLogger,
+) *APIHandler {
+	return &APIHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type apiErrorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type apiCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+	Birthday string `json:"birthday,omitempty"` // YYYY-MM-DD или --MM-DD, чтобы скрыть год
+	Timezone string `json:"timezone,omitempty"` // IANA, например Europe/Moscow
+}
+
+type apiSession struct {
+	SessionID string    `json:"session_id"`
+	UserID    uint32    `json:"user_id"`
+	Expires   time.Time `json:"expires"`
+}
+
+type apiUser struct {
+	ID            uint32 `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email,omitempty"` // только у самого пользователя
+	EmailVerified bool   `json:"email_verified,omitempty"`
+	Birthday      string `json:"birthday"`      // --MM-DD, если год скрыт
+	NextBirthday  string `json:"next_birthday"` // YYYY-MM-DD, с учетом 29 февраля
+	Timezone      string `json:"timezone"`
+	Department    string `json:"department,omitempty"`
+	Subscribable  bool   `json:"subscribable"` // можно ли подписаться
+	Subscribed    bool   `json:"subscribed"`
+	DaysAlert     []int  `json:"days_alert,omitempty"`
+}
+
+// apiProfile - данные самого пользователя
+type apiProfile struct {
+	ID            uint32 `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Birthday      string `json:"birthday"` // --MM-DD, если год не указан
+	Timezone      string `json:"timezone"`
+	Department    string `json:"department,omitempty"`
+}
+
+// apiProfileUpdate - отсутствующее поле не меняется
+type apiProfileUpdate struct {
+	Username *string `json:"username,omitempty"`
+	Email    *string `json:"email,omitempty"`
+	Birthday *string `json:"birthday,omitempty"` // YYYY-MM-DD или --MM-DD, чтобы скрыть год
+
+	CurrentPassword string `json:"current_password,omitempty"` // нужен только для смены почты
+}
+
+type apiPasswordChange struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type apiAccountDeletion struct {
+	Password string `json:"password"`
+}
+
+// apiExport - выгрузка всего, что хранится о пользователе; хэш пароля и сессии не выгружаются
+type apiExport struct {
+	User          apiExportUser           `json:"user"`
+	Subscriptions []apiExportSubscription `json:"subscriptions"`
+	Changes       []apiExportChange       `json:"changes"`
+}
+
+type apiExportUser struct {
+	apiProfile
+	Deactivated bool       `json:"deactivated"`
+	ExternalID  string     `json:"external_id,omitempty"`
+	Privacy     apiPrivacy `json:"privacy"`
+}
+
+type apiExportSubscription struct {
+	UserID    uint32 `json:"user_id"`
+	DaysAlert []int  `json:"days_alert"`
+}
+
+type apiExportChange struct {
+	ActorID uint32    `json:"actor_id"`
+	Field   string    `json:"field"`
+	At      time.Time `json:"at"`
+}
+
+// apiImportReport - отчет импорта сотрудников, по строке на запись файла
+type apiImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Results   []apiImportResult `json:"results"`
+}
+
+type apiImportResult struct {
+	Line   int       `json:"line"`
+	Email  string    `json:"email"`
+	Action string    `json:"action"`
+	UserID uint32    `json:"user_id,omitempty"`
+	Error  *apiError `json:"error,omitempty"`
+}
+
+type apiPrivacy struct {
+	HideYear          bool `json:"hide_year"`
+	HideFromDirectory bool `json:"hide_from_directory"`
+	NotSubscribable   bool `json:"not_subscribable"`
+}
+
+type apiVerification struct {
+	Token string `json:"token"`
+}
+
+type apiForgotPassword struct {
+	Username string `json:"username"`
+}
+
+type apiResetPassword struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type apiSubscription struct {
+	DaysAlert int `json:"days_alert"`
+}
+
+type apiSubscriptionUpdate struct {
+	DaysAlert []int `json:"days_alert"`
+}
+
+func writeJSON(w http.ResponseWriter, logger *zap.SugaredLogger, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		logger.Errorf("Error while encoding json: %v", err)
+	}
+}
+
+func (h *APIHandler) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	writeJSON(w, h.logger, statusCode, apiErrorResponse{
+		Error: apiError{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
+
+// serviceError переводит ошибки сервиса в http-статус и код ошибки API
+func serviceError(err error) (statusCode int, code, message string) {
+	switch err {
+	case service.ErrBadDateFormat:
+		return http.StatusBadRequest, "bad_birthday", "birthday must be in YYYY-MM-DD or --MM-DD format"
+	case birthday.ErrBirthdayInFuture:
+		return http.StatusBadRequest, "bad_birthday", "birthday must not be in the future"
+	case birthday.ErrBadAge:
+		return http.StatusBadRequest, "bad_birthday", fmt.Sprintf("age must be between %d and %d", birthday.MinAge, birthday.MaxAge)
+	case user.ErrBadTimezone:
+		return http.StatusBadRequest, "bad_timezone", "timezone must be an IANA time zone name"
+	case user.ErrUserExists:
+		return http.StatusConflict, "user_exists", "user with this username already exists"
+	case user.ErrNoUser:
+		return http.StatusUnauthorized, "bad_credentials", "wrong username or password"
+	case session.ErrNoSession:
+		return http.StatusUnauthorized, "unauthorized", "no valid session"
+	case subscription.ErrAddSubscription:
+		return http.StatusConflict, "subscription_not_added", "subscription was not added"
+	case subscription.ErrRemoveSubscription:
+		return http.StatusNotFound, "no_subscription", "no subscription to remove"
+	case subscription.ErrNoSubscription:
+		return http.StatusNotFound, "no_subscription", "no subscription to update"
+	case verification.ErrBadToken:
+		return http.StatusBadRequest, "bad_token", "verification token is invalid"
+	case verification.ErrTokenExpired:
+		return http.StatusBadRequest, "token_expired", "verification token has expired"
+	case service.ErrNotSubscribable:
+		return http.StatusForbidden, "not_subscribable", "user does not accept subscriptions"
+	case service.ErrBadResetToken:
+		return http.StatusBadRequest, "bad_token", "password reset token is invalid, used or expired"
+	case service.ErrEmptyPassword:
+		return http.StatusBadRequest, "bad_password", "password must not be empty"
+	case service.ErrEmptyUsername:
+		return http.StatusBadRequest, "bad_username", "username must not be empty"
+	case service.ErrBadEmail:
+		return http.StatusBadRequest, "bad_email", "email must be a plain address like name@example.com"
+	case user.ErrBadPassword:
+		return http.StatusForbidden, "wrong_password", "current password is wrong"
+	case service.ErrForbidden:
+		return http.StatusForbidden, "forbidden", "admin role required"
+	case service.ErrDuplicateEmail:
+		return http.StatusBadRequest, "duplicate_email", "email already appeared earlier in the file"
+	case service.ErrAmbiguousEmail:
+		return http.StatusConflict, "ambiguous_email", "several users have this email"
+	case service.ErrUnverifiedEmail:
+		return http.StatusForbidden, "unverified_email", "email is not verified"
+	default:
+		return http.StatusInternalServerError, "internal", "internal error"
+	}
+}
+
+func (h *APIHandler) writeServiceError(w http.ResponseWriter, err error) {
+	statusCode, code, message := serviceError(err)
+	if statusCode == http.StatusInternalServerError {
+		h.logger.Errorf("Service error: %v", err)
+	}
+
+	h.writeError(w, statusCode, code, message)
+}
+
+func (h *APIHandler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("bad json body: %v", err))
+		return false
+	}
+
+	return true
+}
+
+func (h *APIHandler) userIDFromPath(w http.ResponseWriter, r *http.Request) (uint32, bool) {
+	userID, err := strconv.ParseUint(mux.Vars(r)["user_id"], 10, 32)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "bad_user_id", "user id must be a positive integer")
+		return 0, false
+	}
+
+	return uint32(userID), true
+}
+
+func (h *APIHandler) writeSession(w http.ResponseWriter, statusCode int, sess *session.Session) {
+	writeJSON(w, h.logger, statusCode, apiSession{
+		SessionID: sess.SessID,
+		UserID:    sess.UserID,
+		Expires:   time.Unix(sess.Expires, 0).UTC(),
+	})
+}
+
+func (h *APIHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(openAPISpec)
+	if err != nil {
+		h.logger.Errorf("Error while writing openapi spec: %v", err)
+	}
+}
+
+func (h *APIHandler) Register(w http.ResponseWriter, r *http.Request) {
+	req := &apiCredentials{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	if req.Username == "" || req.Password == "" || req.Email == "" || req.Birthday == "" {
+		h.writeError(w, http.StatusBadRequest, "bad_request", "username, password, email and birthday are required")
+		return
+	}
+
+	sess, err := h.service.Register(r.Context(), req.Username, req.Password, req.Email, req.Birthday, req.Timezone)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeSession(w, http.StatusCreated, sess)
+}
+
+func (h *APIHandler) Login(w http.ResponseWriter, r *http.Request) {
+	req := &apiCredentials{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	sess, err := h.service.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeSession(w, http.StatusOK, sess)
+}
+
+func (h *APIHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	err := h.service.Logout(r.Context())
+	if err != nil && err != session.ErrNotDestroyed {
+		h.writeServiceError(w, err)
+		return
+	}
+	if err == session.ErrNotDestroyed {
+		h.logger.Warnf("Session was not destroyed")
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) Users(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.GetSubscriptionsByUser(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	resp := make([]apiUser, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, apiUser{
+			ID:            u.ID,
+			Username:      u.Username,
+			Email:         u.Email,
+			EmailVerified: u.EmailVerified,
+			Birthday:      u.Birthday.String(),
+			NextBirthday:  u.NextBirthday.Format("2006-01-02"),
+			Timezone:      u.Timezone,
+			Department:    u.Department,
+			Subscribable:  u.Subscribable(),
+			Subscribed:    u.Subscription,
+			DaysAlert:     u.DaysAlert,
+		})
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, resp)
+}
+
+func (h *APIHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, ok := h.userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	req := &apiSubscription{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	if req.DaysAlert < 0 || req.DaysAlert > maxDaysAlert {
+		h.writeError(w, http.StatusBadRequest, "bad_days_alert", fmt.Sprintf("days_alert must be in 0..%d", maxDaysAlert))
+		return
+	}
+
+	err := h.service.Subscribe(r.Context(), subscriptionID, req.DaysAlert)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, h.logger, http.StatusCreated, req)
+}
+
+func (h *APIHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, ok := h.userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	req := &apiSubscriptionUpdate{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	if len(req.DaysAlert) == 0 {
+		h.writeError(w, http.StatusBadRequest, "bad_days_alert", "days_alert must not be empty")
+		return
+	}
+	for _, d := range req.DaysAlert {
+		if d < 0 || d > maxDaysAlert {
+			h.writeError(w, http.StatusBadRequest, "bad_days_alert", fmt.Sprintf("days_alert must be in 0..%d", maxDaysAlert))
+			return
+		}
+	}
+
+	err := h.service.UpdateSubscription(r.Context(), subscriptionID, req.DaysAlert)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, req)
+}
+
+func (h *APIHandler) RemoveDaysAlert(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, ok := h.userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	daysAlert, err := strconv.Atoi(mux.Vars(r)["days_alert"])
+	if err != nil || daysAlert < 0 || daysAlert > maxDaysAlert {
+		h.writeError(w, http.StatusBadRequest, "bad_days_alert", fmt.Sprintf("days_alert must be in 0..%d", maxDaysAlert))
+		return
+	}
+
+	err = h.service.RemoveDaysAlert(r.Context(), subscriptionID, daysAlert)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, ok := h.userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.service.Unsubscribe(r.Context(), subscriptionID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) GetPrivacy(w http.ResponseWriter, r *http.Request) {
+	p, err := h.service.GetPrivacy(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, apiPrivacy{
+		HideYear:          p.HideYear,
+		HideFromDirectory: p.HideFromDirectory,
+		NotSubscribable:   p.NotSubscribable,
+	})
+}
+
+func (h *APIHandler) UpdatePrivacy(w http.ResponseWriter, r *http.Request) {
+	req := &apiPrivacy{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	err := h.service.UpdatePrivacy(r.Context(), user.Privacy{
+		HideYear:          req.HideYear,
+		HideFromDirectory: req.HideFromDirectory,
+		NotSubscribable:   req.NotSubscribable,
+	})
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, req)
+}
+
+func (h *APIHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	req := &apiVerification{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	err := h.service.VerifyEmail(r.Context(), req.Token)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	err := h.service.ResendVerification(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *APIHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	req := &apiForgotPassword{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	// ответ не зависит от того, есть ли такой пользователь
+	err := h.service.RequestPasswordReset(r.Context(), req.Username)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *APIHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	req := &apiResetPassword{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	err := h.service.ResetPassword(r.Context(), req.Token, req.Password)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) writeProfile(w http.ResponseWriter, u *user.User) {
+	writeJSON(w, h.logger, http.StatusOK, newAPIProfile(u))
+}
+
+func (h *APIHandler) Profile(w http.ResponseWriter, r *http.Request) {
+	us, err := h.service.GetProfile(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeProfile(w, us)
+}
+
+func (h *APIHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	req := &apiProfileUpdate{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	us, err := h.service.UpdateProfile(r.Context(), service.ProfileUpdate{
+		Username:        req.Username,
+		Email:           req.Email,
+		Birthday:        req.Birthday,
+		CurrentPassword: req.CurrentPassword,
+	})
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeProfile(w, us)
+}
+
+func (h *APIHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	req := &apiPasswordChange{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	// старая сессия завершена вместе с остальными, клиенту нужна новая
+	sess, err := h.service.ChangePassword(r.Context(), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeSession(w, http.StatusOK, sess)
+}
+
+func newAPIProfile(u *user.User) apiProfile {
+	return apiProfile{
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Birthday:      u.Birthday.String(),
+		Timezone:      u.Timezone,
+		Department:    u.Department,
+	}
+}
+
+// parseRoster разбирает выгрузку HR в CSV или JSON
+func parseRoster(r io.Reader, isJSON bool) ([]roster.Record, error) {
+	if isJSON {
+		return roster.ParseJSON(r)
+	}
+
+	return roster.ParseCSV(r)
+}
+
+// writeExport отдает выгрузку файлом; используется и API, и html-хендлерами
+func writeExport(w http.ResponseWriter, logger *zap.SugaredLogger, exp *service.Export) {
+	body := apiExport{
+		User: apiExportUser{
+			apiProfile:  newAPIProfile(exp.User),
+			Deactivated: exp.User.Deactivated,
+			ExternalID:  exp.User.ExternalID,
+			Privacy: apiPrivacy{
+				HideYear:          exp.User.Privacy.HideYear,
+				HideFromDirectory: exp.User.Privacy.HideFromDirectory,
+				NotSubscribable:   exp.User.Privacy.NotSubscribable,
+			},
+		},
+		Subscriptions: []apiExportSubscription{},
+		Changes:       []apiExportChange{},
+	}
+	for _, s := range exp.Subscriptions {
+		body.Subscriptions = append(body.Subscriptions, apiExportSubscription{
+			UserID:    s.Subscription,
+			DaysAlert: s.DaysAlert,
+		})
+	}
+	for _, c := range exp.Changes {
+		body.Changes = append(body.Changes, apiExportChange{
+			ActorID: c.ActorID,
+			Field:   c.Field,
+			At:      c.At.UTC(),
+		})
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d.json"`, exp.User.ID))
+	writeJSON(w, logger, http.StatusOK, body)
+}
+
+func (h *APIHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	exp, err := h.service.ExportData(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	writeExport(w, h.logger, exp)
+}
+
+func (h *APIHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	req := &apiAccountDeletion{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	err := h.service.DeleteAccount(r.Context(), req.Password)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) RequestAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	err := h.service.RequestAccountDeletion(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *APIHandler) DeleteAccountByLink(w http.ResponseWriter, r *http.Request) {
+	req := &apiVerification{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	err := h.service.DeleteAccountByLink(r.Context(), req.Token)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) AdminExportUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	exp, err := h.service.ExportUser(r.Context(), userID)
+	if err == user.ErrNoUser {
+		// для входа это неверные учетные данные, а здесь - просто нет такого пользователя
+		h.writeError(w, http.StatusNotFound, "no_user", "user not found")
+		return
+	}
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	writeExport(w, h.logger, exp)
+}
+
+func (h *APIHandler) AdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.service.DeleteUser(r.Context(), userID)
+	if err == user.ErrNoUser {
+		// для входа это неверные учетные данные, а здесь - просто нет такого пользователя
+		h.writeError(w, http.StatusNotFound, "no_user", "user not found")
+		return
+	}
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) AdminImportUsers(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "bad_request", "dry_run must be true or false")
+			return
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "text/csv" && mediaType != "application/json" {
+		h.writeError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "body must be text/csv or application/json")
+		return
+	}
+
+	records, err := parseRoster(http.MaxBytesReader(w, r.Body, maxImportBytes), mediaType == "application/json")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "bad_file", err.Error())
+		return
+	}
+
+	report, err := h.service.ImportUsers(r.Context(), records, dryRun)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	resp := apiImportReport{
+		DryRun:    report.DryRun,
+		Created:   report.Created,
+		Updated:   report.Updated,
+		Unchanged: report.Unchanged,
+		Failed:    report.Failed,
+		Results:   make([]apiImportResult, 0, len(report.Results)),
+	}
+	for _, res := range report.Results {
+		item := apiImportResult{
+			Line:   res.Line,
+			Email:  res.Email,
+			Action: res.Action,
+			UserID: res.UserID,
+		}
+		if res.Err != nil {
+			_, code, message := serviceError(res.Err)
+			item.Error = &apiError{
+				Code:    code,
+				Message: message,
+			}
+		}
+
+		resp.Results = append(resp.Results, item)
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, resp)
+}
@@ -0,0 +1,1174 @@
+package handlers
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/roster"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
+	"birthday_congrats/internal/services/congrats_service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func decodeAPIError(t *testing.T, w *httptest.ResponseRecorder) apiError {
+	t.Helper()
+
+	resp := apiErrorResponse{}
+
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	if err != nil {
+		t.Fatalf("cant decode error: %v", err)
+	}
+
+	return resp.Error
+}
+
+func TestAPIOpenAPI(t *testing.T) {
+	testHandler := NewAPIHandler(nil, zap.NewNop().Sugar())
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/openapi.yaml", nil)
+
+	testHandler.OpenAPI(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "openapi: 3"))
+}
+
+func TestAPIRegister(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	body := `{"username":"some_user","password":"some_pass","email":"some@email.com","birthday":"2000-01-02","timezone":"Europe/Moscow"}`
+
+	sessExpected := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  42,
+		Expires: time.Now().Unix() + 60,
+	}
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/register", strings.NewReader(body))
+
+	service.EXPECT().Register(r.Context(), "some_user", "some_pass", "some@email.com", "2000-01-02", "Europe/Moscow").Return(sessExpected, nil)
+
+	testHandler.Register(w, r)
+
+	assert.EqualValues(t, http.StatusCreated, w.Code)
+
+	sessRecv := apiSession{}
+	err := json.NewDecoder(w.Body).Decode(&sessRecv)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, sessExpected.SessID, sessRecv.SessionID)
+	assert.EqualValues(t, sessExpected.UserID, sessRecv.UserID)
+	assert.Empty(t, w.Result().Cookies())
+
+	// некорректный json
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/register", strings.NewReader("{"))
+
+	testHandler.Register(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_request", decodeAPIError(t, w).Code)
+
+	// не все поля заполнены
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/register", strings.NewReader(`{"username":"some_user"}`))
+
+	testHandler.Register(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// пользователь уже существует
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/register", strings.NewReader(body))
+
+	service.EXPECT().Register(r.Context(), "some_user", "some_pass", "some@email.com", "2000-01-02", "Europe/Moscow").Return(nil, user.ErrUserExists)
+
+	testHandler.Register(w, r)
+
+	assert.EqualValues(t, http.StatusConflict, w.Code)
+	assert.EqualValues(t, "user_exists", decodeAPIError(t, w).Code)
+
+	// некорректная дата
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/register", strings.NewReader(body))
+
+	service.EXPECT().Register(r.Context(), "some_user", "some_pass", "some@email.com", "2000-01-02", "Europe/Moscow").Return(nil, congrats_service.ErrBadDateFormat)
+
+	testHandler.Register(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_birthday", decodeAPIError(t, w).Code)
+
+	// дата в будущем или неподходящий возраст
+	for _, errSent := range []error{birthday.ErrBirthdayInFuture, birthday.ErrBadAge} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPost, "/api/v1/register", strings.NewReader(body))
+
+		service.EXPECT().Register(r.Context(), "some_user", "some_pass", "some@email.com", "2000-01-02", "Europe/Moscow").Return(nil, errSent)
+
+		testHandler.Register(w, r)
+
+		assert.EqualValues(t, http.StatusBadRequest, w.Code, errSent)
+		assert.EqualValues(t, "bad_birthday", decodeAPIError(t, w).Code, errSent)
+	}
+
+	// неизвестный часовой пояс
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/register", strings.NewReader(body))
+
+	service.EXPECT().Register(r.Context(), "some_user", "some_pass", "some@email.com", "2000-01-02", "Europe/Moscow").Return(nil, user.ErrBadTimezone)
+
+	testHandler.Register(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_timezone", decodeAPIError(t, w).Code)
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/register", strings.NewReader(body))
+
+	service.EXPECT().Register(r.Context(), "some_user", "some_pass", "some@email.com", "2000-01-02", "Europe/Moscow").Return(nil, fmt.Errorf("service error"))
+
+	testHandler.Register(w, r)
+
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+	assert.EqualValues(t, "internal", decodeAPIError(t, w).Code)
+}
+
+func TestAPILogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	body := `{"username":"some_user","password":"some_pass"}`
+
+	sessExpected := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  42,
+		Expires: time.Now().Unix() + 60,
+	}
+
+	// нормальная работа: токен только в теле, cookie не выдается
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/login", strings.NewReader(body))
+
+	service.EXPECT().Login(r.Context(), "some_user", "some_pass").Return(sessExpected, nil)
+
+	testHandler.Login(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+
+	sessRecv := apiSession{}
+	err := json.NewDecoder(w.Body).Decode(&sessRecv)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, sessExpected.SessID, sessRecv.SessionID)
+
+	// неизвестное поле
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/login", strings.NewReader(`{"login":"some_user"}`))
+
+	testHandler.Login(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// неверный логин или пароль
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/login", strings.NewReader(body))
+
+	service.EXPECT().Login(r.Context(), "some_user", "some_pass").Return(nil, user.ErrNoUser)
+
+	testHandler.Login(w, r)
+
+	assert.EqualValues(t, http.StatusUnauthorized, w.Code)
+	assert.EqualValues(t, "bad_credentials", decodeAPIError(t, w).Code)
+	assert.EqualValues(t, 0, len(w.Result().Cookies()))
+}
+
+func TestAPILogout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/logout", nil)
+
+	service.EXPECT().Logout(r.Context()).Return(nil)
+
+	testHandler.Logout(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+
+	// сессия не уничтожена на сервере
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/logout", nil)
+
+	service.EXPECT().Logout(r.Context()).Return(session.ErrNotDestroyed)
+
+	testHandler.Logout(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/logout", nil)
+
+	service.EXPECT().Logout(r.Context()).Return(fmt.Errorf("service error"))
+
+	testHandler.Logout(w, r)
+
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestAPIUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	usersSent := []*user.User{
+		{
+			ID:            1,
+			Username:      "one",
+			Email:         "one@one.net",
+			EmailVerified: true,
+			Timezone:      "Europe/Moscow",
+			Birthday:      birthday.Birthday{Year: 2000, Month: time.June, Day: 30},
+			Subscription:  true,
+			DaysAlert:     []int{0, 3},
+			NextBirthday:  time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:           2,
+			Username:     "two",
+			Birthday:     birthday.Birthday{Year: 2000, Month: time.February, Day: 29},
+			NextBirthday: time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:           3,
+			Username:     "three",
+			Email:        "three@three.net",
+			Birthday:     birthday.Birthday{Month: time.December, Day: 31},
+			NextBirthday: time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC),
+			Privacy:      user.Privacy{NotSubscribable: true},
+		},
+	}
+
+	usersExpected := []apiUser{
+		{
+			ID:            1,
+			Username:      "one",
+			Email:         "one@one.net",
+			EmailVerified: true,
+			Birthday:      "2000-06-30",
+			NextBirthday:  "2025-06-30",
+			Timezone:      "Europe/Moscow",
+			Subscribable:  true,
+			Subscribed:    true,
+			DaysAlert:     []int{0, 3},
+		},
+		{
+			ID:           2,
+			Username:     "two",
+			Birthday:     "2000-02-29", // почта коллеги скрыта
+			NextBirthday: "2025-02-28",
+			Subscribable: true,
+		},
+		{
+			ID:           3,
+			Username:     "three",
+			Email:        "three@three.net",
+			Birthday:     "--12-31", // год скрыт
+			NextBirthday: "2024-12-31",
+		},
+	}
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+
+	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return(usersSent, nil)
+
+	testHandler.Users(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	usersRecv := make([]apiUser, 0)
+	err := json.NewDecoder(w.Body).Decode(&usersRecv)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, usersExpected, usersRecv)
+
+	// нет сессии
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+
+	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return(nil, session.ErrNoSession)
+
+	testHandler.Users(w, r)
+
+	assert.EqualValues(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAPISubscribe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	userID := uint32(42)
+	daysAlert := 7
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":7}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	service.EXPECT().Subscribe(r.Context(), userID, daysAlert).Return(nil)
+
+	testHandler.Subscribe(w, r)
+
+	assert.EqualValues(t, http.StatusCreated, w.Code)
+
+	// некорректный id
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/users/bad_id/subscription", strings.NewReader(`{"days_alert":7}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "bad_id"})
+
+	testHandler.Subscribe(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_user_id", decodeAPIError(t, w).Code)
+
+	// напоминание в сам день рождения
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":0}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	service.EXPECT().Subscribe(r.Context(), userID, 0).Return(nil)
+
+	testHandler.Subscribe(w, r)
+
+	assert.EqualValues(t, http.StatusCreated, w.Code)
+
+	// некорректный days_alert
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":-1}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	testHandler.Subscribe(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_days_alert", decodeAPIError(t, w).Code)
+
+	// подписка не добавлена
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":7}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	service.EXPECT().Subscribe(r.Context(), userID, daysAlert).Return(subscription.ErrAddSubscription)
+
+	testHandler.Subscribe(w, r)
+
+	assert.EqualValues(t, http.StatusConflict, w.Code)
+
+	// на пользователя нельзя подписаться
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":7}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	service.EXPECT().Subscribe(r.Context(), userID, daysAlert).Return(congrats_service.ErrNotSubscribable)
+
+	testHandler.Subscribe(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+	assert.EqualValues(t, "not_subscribable", decodeAPIError(t, w).Code)
+}
+
+func TestAPIUpdateSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	userID := uint32(42)
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":[0,7]}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	service.EXPECT().UpdateSubscription(r.Context(), userID, []int{0, 7}).Return(nil)
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"days_alert":[0,7]}`, w.Body.String())
+
+	// некорректный id
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/users/bad_id/subscription", strings.NewReader(`{"days_alert":[7]}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "bad_id"})
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_user_id", decodeAPIError(t, w).Code)
+
+	// пустой список
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":[]}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_days_alert", decodeAPIError(t, w).Code)
+
+	// значение вне диапазона
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":[0,400]}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_days_alert", decodeAPIError(t, w).Code)
+
+	// некорректное тело
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":7}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_request", decodeAPIError(t, w).Code)
+
+	// подписки нет
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/users/42/subscription", strings.NewReader(`{"days_alert":[7]}`))
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	service.EXPECT().UpdateSubscription(r.Context(), userID, []int{7}).Return(subscription.ErrNoSubscription)
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+	assert.EqualValues(t, "no_subscription", decodeAPIError(t, w).Code)
+}
+
+func TestAPIRemoveDaysAlert(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	userID := uint32(42)
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/api/v1/users/42/subscription/7", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42", "days_alert": "7"})
+
+	service.EXPECT().RemoveDaysAlert(r.Context(), userID, 7).Return(nil)
+
+	testHandler.RemoveDaysAlert(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+
+	// некорректный id
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/users/-1/subscription/7", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "-1", "days_alert": "7"})
+
+	testHandler.RemoveDaysAlert(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_user_id", decodeAPIError(t, w).Code)
+
+	// некорректный days_alert
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/users/42/subscription/week", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42", "days_alert": "week"})
+
+	testHandler.RemoveDaysAlert(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_days_alert", decodeAPIError(t, w).Code)
+
+	// такого напоминания нет
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/users/42/subscription/0", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42", "days_alert": "0"})
+
+	service.EXPECT().RemoveDaysAlert(r.Context(), userID, 0).Return(subscription.ErrRemoveSubscription)
+
+	testHandler.RemoveDaysAlert(w, r)
+
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+	assert.EqualValues(t, "no_subscription", decodeAPIError(t, w).Code)
+}
+
+func TestAPIUnsubscribe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	userID := uint32(42)
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/api/v1/users/42/subscription", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	service.EXPECT().Unsubscribe(r.Context(), userID).Return(nil)
+
+	testHandler.Unsubscribe(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+
+	// некорректный id
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/users/-1/subscription", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "-1"})
+
+	testHandler.Unsubscribe(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// подписки нет
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/users/42/subscription", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "42"})
+
+	service.EXPECT().Unsubscribe(r.Context(), userID).Return(subscription.ErrRemoveSubscription)
+
+	testHandler.Unsubscribe(w, r)
+
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+	assert.EqualValues(t, "no_subscription", decodeAPIError(t, w).Code)
+}
+
+func TestAPIPrivacy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// получение настроек
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/me/privacy", nil)
+
+	service.EXPECT().GetPrivacy(r.Context()).Return(&user.Privacy{HideYear: true}, nil)
+
+	testHandler.GetPrivacy(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"hide_year":true,"hide_from_directory":false,"not_subscribable":false}`, w.Body.String())
+
+	// нет сессии
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/me/privacy", nil)
+
+	service.EXPECT().GetPrivacy(r.Context()).Return(nil, session.ErrNoSession)
+
+	testHandler.GetPrivacy(w, r)
+
+	assert.EqualValues(t, http.StatusUnauthorized, w.Code)
+
+	// изменение настроек
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/me/privacy", strings.NewReader(`{"hide_from_directory":true,"not_subscribable":true}`))
+
+	service.EXPECT().UpdatePrivacy(r.Context(), user.Privacy{HideFromDirectory: true, NotSubscribable: true}).Return(nil)
+
+	testHandler.UpdatePrivacy(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"hide_year":false,"hide_from_directory":true,"not_subscribable":true}`, w.Body.String())
+
+	// неизвестное поле
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/me/privacy", strings.NewReader(`{"hide_email":true}`))
+
+	testHandler.UpdatePrivacy(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/me/privacy", strings.NewReader(`{"hide_year":true}`))
+
+	service.EXPECT().UpdatePrivacy(r.Context(), user.Privacy{HideYear: true}).Return(fmt.Errorf("service error"))
+
+	testHandler.UpdatePrivacy(w, r)
+
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+	assert.EqualValues(t, "internal", decodeAPIError(t, w).Code)
+}
+
+func TestAPIVerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/verify", strings.NewReader(`{"token":"some_token"}`))
+
+	service.EXPECT().VerifyEmail(r.Context(), "some_token").Return(nil)
+
+	testHandler.VerifyEmail(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+
+	// недействительный токен
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/verify", strings.NewReader(`{"token":"bad_token"}`))
+
+	service.EXPECT().VerifyEmail(r.Context(), "bad_token").Return(verification.ErrBadToken)
+
+	testHandler.VerifyEmail(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_token", decodeAPIError(t, w).Code)
+
+	// устаревший токен
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/verify", strings.NewReader(`{"token":"old_token"}`))
+
+	service.EXPECT().VerifyEmail(r.Context(), "old_token").Return(verification.ErrTokenExpired)
+
+	testHandler.VerifyEmail(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "token_expired", decodeAPIError(t, w).Code)
+
+	// повторная отправка письма
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/me/verification", nil)
+
+	service.EXPECT().ResendVerification(r.Context()).Return(nil)
+
+	testHandler.ResendVerification(w, r)
+
+	assert.EqualValues(t, http.StatusAccepted, w.Code)
+
+	// повторная отправка без сессии
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/me/verification", nil)
+
+	service.EXPECT().ResendVerification(r.Context()).Return(session.ErrNoSession)
+
+	testHandler.ResendVerification(w, r)
+
+	assert.EqualValues(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAPIPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// запрос ссылки
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/password/forgot", strings.NewReader(`{"username":"some_user"}`))
+
+	service.EXPECT().RequestPasswordReset(r.Context(), "some_user").Return(nil)
+
+	testHandler.ForgotPassword(w, r)
+
+	assert.EqualValues(t, http.StatusAccepted, w.Code)
+
+	// запрос ссылки: ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/password/forgot", strings.NewReader(`{"username":"some_user"}`))
+
+	service.EXPECT().RequestPasswordReset(r.Context(), "some_user").Return(fmt.Errorf("service error"))
+
+	testHandler.ForgotPassword(w, r)
+
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+
+	// запрос ссылки: некорректный json
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/password/forgot", strings.NewReader(`{"login":"some_user"}`))
+
+	testHandler.ForgotPassword(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_request", decodeAPIError(t, w).Code)
+
+	// сброс пароля
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/password/reset", strings.NewReader(`{"token":"some_token","password":"new_pass"}`))
+
+	service.EXPECT().ResetPassword(r.Context(), "some_token", "new_pass").Return(nil)
+
+	testHandler.ResetPassword(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+
+	// недействительный токен
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/password/reset", strings.NewReader(`{"token":"bad_token","password":"new_pass"}`))
+
+	service.EXPECT().ResetPassword(r.Context(), "bad_token", "new_pass").Return(congrats_service.ErrBadResetToken)
+
+	testHandler.ResetPassword(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_token", decodeAPIError(t, w).Code)
+
+	// пустой пароль
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/password/reset", strings.NewReader(`{"token":"some_token","password":""}`))
+
+	service.EXPECT().ResetPassword(r.Context(), "some_token", "").Return(congrats_service.ErrEmptyPassword)
+
+	testHandler.ResetPassword(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_password", decodeAPIError(t, w).Code)
+}
+
+func TestAPIProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	us := &user.User{
+		ID:            1,
+		Username:      "some_user",
+		Email:         "some@email.net",
+		EmailVerified: true,
+		Timezone:      "UTC",
+		Birthday:      birthday.Birthday{Year: 1990, Month: time.March, Day: 2},
+	}
+	expected := `{"id":1,"username":"some_user","email":"some@email.net","email_verified":true,"birthday":"1990-03-02","timezone":"UTC"}`
+
+	// профиль
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+
+	service.EXPECT().GetProfile(r.Context()).Return(us, nil)
+
+	testHandler.Profile(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, expected, w.Body.String())
+
+	// профиль без сессии
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+
+	service.EXPECT().GetProfile(r.Context()).Return(nil, session.ErrNoSession)
+
+	testHandler.Profile(w, r)
+
+	assert.EqualValues(t, http.StatusUnauthorized, w.Code)
+
+	// изменение: переданные поля передаются сервису, остальные - nil
+	email := "some@email.net"
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/api/v1/me", strings.NewReader(`{"email":"some@email.net","current_password":"some_pass"}`))
+
+	service.EXPECT().UpdateProfile(r.Context(), congrats_service.ProfileUpdate{
+		Email:           &email,
+		CurrentPassword: "some_pass",
+	}).Return(us, nil)
+
+	testHandler.UpdateProfile(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, expected, w.Body.String())
+
+	// известные ошибки
+	for _, tc := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{user.ErrBadPassword, http.StatusForbidden, "wrong_password"},
+		{user.ErrUserExists, http.StatusConflict, "user_exists"},
+		{congrats_service.ErrEmptyUsername, http.StatusBadRequest, "bad_username"},
+		{congrats_service.ErrBadEmail, http.StatusBadRequest, "bad_email"},
+		{congrats_service.ErrBadDateFormat, http.StatusBadRequest, "bad_birthday"},
+	} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPatch, "/api/v1/me", strings.NewReader(`{"username":"x"}`))
+
+		service.EXPECT().UpdateProfile(r.Context(), gomock.Any()).Return(nil, tc.err)
+
+		testHandler.UpdateProfile(w, r)
+
+		assert.EqualValues(t, tc.status, w.Code, tc.code)
+		assert.EqualValues(t, tc.code, decodeAPIError(t, w).Code)
+	}
+
+	// неизвестное поле
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/api/v1/me", strings.NewReader(`{"timezone":"UTC"}`))
+
+	testHandler.UpdateProfile(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// смена пароля
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/me/password", strings.NewReader(`{"current_password":"old_pass","new_password":"new_pass"}`))
+
+	service.EXPECT().ChangePassword(r.Context(), "old_pass", "new_pass").Return(&session.Session{
+		SessID:  "new_sess_id",
+		UserID:  1,
+		Expires: time.Now().Unix() + 60,
+	}, nil)
+
+	testHandler.ChangePassword(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"session_id":"new_sess_id"`)
+
+	// неверный текущий пароль
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/api/v1/me/password", strings.NewReader(`{"current_password":"wrong","new_password":"new_pass"}`))
+
+	service.EXPECT().ChangePassword(r.Context(), "wrong", "new_pass").Return(nil, user.ErrBadPassword)
+
+	testHandler.ChangePassword(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+	assert.EqualValues(t, "wrong_password", decodeAPIError(t, w).Code)
+}
+
+func TestAPIAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	exp := &congrats_service.Export{
+		User: &user.User{
+			ID:       1,
+			Username: "some_user",
+			Password: "some_hash",
+			Email:    "some@email.net",
+			Timezone: "UTC",
+			Birthday: birthday.Birthday{Year: 1990, Month: time.March, Day: 2},
+			Privacy:  user.Privacy{HideYear: true},
+		},
+		Subscriptions: []*subscription.Subscription{
+			{Subscriber: 1, Subscription: 2, DaysAlert: []int{1, 3}},
+		},
+		Changes: []audit.Entry{
+			{ActorID: 1, UserID: 1, Field: audit.FieldEmail, At: time.Unix(100, 0)},
+		},
+	}
+	expected := `{
+		"user":{"id":1,"username":"some_user","email":"some@email.net","email_verified":false,"birthday":"1990-03-02","timezone":"UTC",
+			"deactivated":false,"privacy":{"hide_year":true,"hide_from_directory":false,"not_subscribable":false}},
+		"subscriptions":[{"user_id":2,"days_alert":[1,3]}],
+		"changes":[{"actor_id":1,"field":"email","at":"1970-01-01T00:01:40Z"}]
+	}`
+
+	// выгрузка: хэш пароля не попадает
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil)
+
+	service.EXPECT().ExportData(r.Context()).Return(exp, nil)
+
+	testHandler.ExportData(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, expected, w.Body.String())
+	assert.EqualValues(t, `attachment; filename="user-1.json"`, w.Header().Get("Content-Disposition"))
+
+	// выгрузка без сессии
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil)
+
+	service.EXPECT().ExportData(r.Context()).Return(nil, session.ErrNoSession)
+
+	testHandler.ExportData(w, r)
+
+	assert.EqualValues(t, http.StatusUnauthorized, w.Code)
+
+	// удаление аккаунта
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/me", strings.NewReader(`{"password":"some_pass"}`))
+
+	service.EXPECT().DeleteAccount(r.Context(), "some_pass").Return(nil)
+
+	testHandler.DeleteAccount(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+
+	// неверный пароль
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/me", strings.NewReader(`{"password":"wrong"}`))
+
+	service.EXPECT().DeleteAccount(r.Context(), "wrong").Return(user.ErrBadPassword)
+
+	testHandler.DeleteAccount(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+	assert.EqualValues(t, "wrong_password", decodeAPIError(t, w).Code)
+
+	// без тела
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/me", nil)
+
+	testHandler.DeleteAccount(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// ссылка удаления на почту
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/me/deletion", nil)
+
+	service.EXPECT().RequestAccountDeletion(r.Context()).Return(nil)
+
+	testHandler.RequestAccountDeletion(w, r)
+
+	assert.EqualValues(t, http.StatusAccepted, w.Code)
+
+	// почта не подтверждена
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/me/deletion", nil)
+
+	service.EXPECT().RequestAccountDeletion(r.Context()).Return(congrats_service.ErrUnverifiedEmail)
+
+	testHandler.RequestAccountDeletion(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+	assert.EqualValues(t, "unverified_email", decodeAPIError(t, w).Code)
+
+	// удаление по токену из письма
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/account/delete", strings.NewReader(`{"token":"some_token"}`))
+
+	service.EXPECT().DeleteAccountByLink(r.Context(), "some_token").Return(nil)
+
+	testHandler.DeleteAccountByLink(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+
+	// токен устарел
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/account/delete", strings.NewReader(`{"token":"some_token"}`))
+
+	service.EXPECT().DeleteAccountByLink(r.Context(), "some_token").Return(verification.ErrTokenExpired)
+
+	testHandler.DeleteAccountByLink(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "token_expired", decodeAPIError(t, w).Code)
+
+	// выгрузка администратором
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/admin/users/1/export", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "1"})
+
+	service.EXPECT().ExportUser(r.Context(), uint32(1)).Return(exp, nil)
+
+	testHandler.AdminExportUser(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, expected, w.Body.String())
+
+	// удаление администратором
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/users/1", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "1"})
+
+	service.EXPECT().DeleteUser(r.Context(), uint32(1)).Return(nil)
+
+	testHandler.AdminDeleteUser(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+
+	// удаление несуществующего пользователя
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/users/1", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "1"})
+
+	service.EXPECT().DeleteUser(r.Context(), uint32(1)).Return(user.ErrNoUser)
+
+	testHandler.AdminDeleteUser(w, r)
+
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+
+	// неверный id
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/users/bad_id", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "bad_id"})
+
+	testHandler.AdminDeleteUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAPIAdminImportUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewAPIHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	csvBody := "name,email,birthday\nalice,alice@example.com,1990-05-10\nbob,bob@example.com,1990-13-01\n"
+	records := []roster.Record{
+		{Line: 2, Name: "alice", Email: "alice@example.com", Birthday: "1990-05-10"},
+		{Line: 3, Name: "bob", Email: "bob@example.com", Birthday: "1990-13-01"},
+	}
+	report := &congrats_service.ImportReport{
+		DryRun: true,
+		Results: []congrats_service.ImportResult{
+			{Line: 2, Email: "alice@example.com", Action: congrats_service.ImportUpdated, UserID: 1},
+			{Line: 3, Email: "bob@example.com", Action: congrats_service.ImportFailed, Err: congrats_service.ErrBadDateFormat},
+		},
+		Updated: 1,
+		Failed:  1,
+	}
+
+	// нормальная работа: пробный прогон CSV
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/import?dry_run=true", strings.NewReader(csvBody))
+	r.Header.Set("Content-Type", "text/csv; charset=utf-8")
+
+	service.EXPECT().ImportUsers(r.Context(), records, true).Return(report, nil)
+
+	testHandler.AdminImportUsers(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"dry_run":true,"created":0,"updated":1,"unchanged":0,"failed":1,
+		"results":[
+			{"line":2,"email":"alice@example.com","action":"updated","user_id":1},
+			{"line":3,"email":"bob@example.com","action":"failed",
+				"error":{"code":"bad_birthday","message":"birthday must be in YYYY-MM-DD or --MM-DD format"}}
+		]
+	}`, w.Body.String())
+
+	// JSON
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/import",
+		strings.NewReader(`[{"name":"alice","email":"alice@example.com","birthday":"1990-05-10"}]`))
+	r.Header.Set("Content-Type", "application/json")
+
+	service.EXPECT().ImportUsers(r.Context(), []roster.Record{
+		{Line: 1, Name: "alice", Email: "alice@example.com", Birthday: "1990-05-10"},
+	}, false).Return(&congrats_service.ImportReport{Results: []congrats_service.ImportResult{}}, nil)
+
+	testHandler.AdminImportUsers(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	// неизвестный формат
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/import", strings.NewReader(csvBody))
+	r.Header.Set("Content-Type", "application/vnd.ms-excel")
+
+	testHandler.AdminImportUsers(w, r)
+
+	assert.EqualValues(t, http.StatusUnsupportedMediaType, w.Code)
+
+	// файл не разобран
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/import", strings.NewReader("name,email\n"))
+	r.Header.Set("Content-Type", "text/csv")
+
+	testHandler.AdminImportUsers(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "bad_file", decodeAPIError(t, w).Code)
+
+	// неверный dry_run
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/import?dry_run=maybe", strings.NewReader(csvBody))
+	r.Header.Set("Content-Type", "text/csv")
+
+	testHandler.AdminImportUsers(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/import", strings.NewReader(csvBody))
+	r.Header.Set("Content-Type", "text/csv")
+
+	service.EXPECT().ImportUsers(r.Context(), records, false).Return(nil, fmt.Errorf("internal error"))
+
+	testHandler.AdminImportUsers(w, r)
+
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/oidc"
+	"birthday_congrats/internal/pkg/user"
+	service "birthday_congrats/internal/services/congrats_service"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	oidcCookie   = "oidc_login"
+	oidcLoginTTL = 10 * time.Minute // сколько ждем возвращения пользователя от провайдера
+)
+
+// LoginOIDC отправляет пользователя на страницу входа провайдера. state, nonce и code_verifier
+// живут в cookie до возвращения пользователя в OIDCCallback.
+func (h *ServiceHandler) LoginOIDC(w http.ResponseWriter, r *http.Request) {
+	state, errState := oidc.NewVerifier()
+	nonce, errNonce := oidc.NewVerifier()
+	verifier, errVerifier := oidc.NewVerifier()
+	if errState != nil || errNonce != nil || errVerifier != nil {
+		h.logger.Errorf("Error while generating OIDC login secrets")
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	authURL, err := h.oidc.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		h.logger.Errorf("Error while getting OIDC auth url: %v", err)
+		h.execErrorTemplate(w, "Вход через единую учетную запись сейчас недоступен", http.StatusBadGateway)
+		return
+	}
+
+	// base64url не содержит точек
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    state + "." + nonce + "." + verifier,
+		Path:     "/login/oidc",
+		MaxAge:   int(oidcLoginTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   h.secure,
+		// провайдер возвращает пользователя обычным переходом, Strict потерял бы cookie
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback - сюда провайдер возвращает пользователя с кодом авторизации
+func (h *ServiceHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(oidcCookie)
+
+	// вход одноразовый, даже если не удался
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    "",
+		Path:     "/login/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if err != nil {
+		h.execErrorTemplate(w, "Время на вход истекло, попробуйте еще раз", http.StatusBadRequest)
+		return
+	}
+
+	state, rest, _ := strings.Cut(cookie.Value, ".")
+	nonce, verifier, ok := strings.Cut(rest, ".")
+	if !ok || subtle.ConstantTimeCompare([]byte(state), []byte(r.FormValue("state"))) != 1 {
+		h.logger.Warnf("OIDC callback with wrong state")
+		h.execErrorTemplate(w, "Время на вход истекло, попробуйте еще раз", http.StatusBadRequest)
+		return
+	}
+
+	if reason := r.FormValue("error"); reason != "" {
+		h.logger.Warnf("OIDC provider refused login: %s %s", reason, r.FormValue("error_description"))
+		h.execErrorTemplate(w, "Провайдер отказал во входе", http.StatusForbidden)
+		return
+	}
+
+	claims, err := h.oidc.Exchange(r.Context(), r.FormValue("code"), verifier, nonce)
+	switch {
+	case err == nil:
+	case errors.Is(err, oidc.ErrExchange), errors.Is(err, oidc.ErrBadToken), errors.Is(err, oidc.ErrUnknownKey):
+		h.logger.Warnf("OIDC login failed: %v", err)
+		h.execErrorTemplate(w, "Не удалось войти через единую учетную запись", http.StatusForbidden)
+		return
+	default:
+		h.logger.Errorf("Error while exchanging OIDC code: %v", err)
+		h.execErrorTemplate(w, "Вход через единую учетную запись сейчас недоступен", http.StatusBadGateway)
+		return
+	}
+
+	sess, err := h.service.LoginOIDC(r.Context(), claims)
+	switch err {
+	case nil:
+	case service.ErrUnverifiedEmail:
+		h.execErrorTemplate(w, "Провайдер не подтвердил вашу почту, войдите с паролем", http.StatusForbidden)
+		return
+	case service.ErrNoBirthday, service.ErrBadDateFormat, birthday.ErrBirthdayInFuture, birthday.ErrBadAge:
+		h.execErrorTemplate(w, "Провайдер не передал корректную дату рождения, зарегистрируйтесь с паролем", http.StatusBadRequest)
+		return
+	case service.ErrAmbiguousEmail:
+		h.execErrorTemplate(w, "Почта принадлежит нескольким пользователям, обратитесь к администратору", http.StatusConflict)
+		return
+	case user.ErrUserExists:
+		h.execErrorTemplate(w, "Не удалось подобрать свободное имя пользователя, зарегистрируйтесь с паролем", http.StatusConflict)
+		return
+	case user.ErrNoUser:
+		h.execErrorTemplate(w, "Учетная запись отключена", http.StatusForbidden)
+		return
+	default:
+		h.logger.Errorf("Error while OIDC login: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	setSessionCookie(w, sess, h.secure)
+
+	http.Redirect(w, r, "/users", http.StatusFound)
+}
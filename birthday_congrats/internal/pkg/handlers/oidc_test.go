@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"birthday_congrats/internal/pkg/oidc"
+	"birthday_congrats/internal/pkg/oidctest"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/services/congrats_service"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// startOIDCLogin начинает вход и проходит его у провайдера: возвращает cookie входа
+// и адрес, на который провайдер вернул пользователя
+func startOIDCLogin(t *testing.T, h *ServiceHandler, issuer *oidctest.Issuer, claims map[string]interface{}) (*http.Cookie, string) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	h.LoginOIDC(w, httptest.NewRequest(http.MethodGet, "/login/oidc", nil))
+
+	if w.Code != http.StatusFound || len(w.Result().Cookies()) != 1 {
+		t.Fatalf("login was not started: %d", w.Code)
+	}
+
+	return w.Result().Cookies()[0], issuer.Authorize(t, w.Header().Get("Location"), claims)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	issuer := oidctest.NewIssuer(t, "birthday", "secret")
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		oidc.NewClient(issuer.URL(), "birthday", "secret", "http://birthday.example.com/login/oidc/callback",
+			[]string{"openid", "email"}, time.Second, zap.NewNop().Sugar()),
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// данные для теста
+	claims := map[string]interface{}{
+		"sub":            "42",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"birthdate":      "0000-05-10",
+	}
+	claimsExpected := &oidc.Claims{
+		Subject:       "42",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Birthdate:     "0000-05-10",
+	}
+	sessExpected := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  42,
+		Expires: time.Now().Unix() + 60,
+	}
+
+	// нормальная работа
+	cookie, callback := startOIDCLogin(t, testHandler, issuer, claims)
+
+	assert.EqualValues(t, oidcCookie, cookie.Name)
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, strings.HasPrefix(callback, "http://birthday.example.com/login/oidc/callback?"))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, callback, nil)
+	r.AddCookie(cookie)
+
+	service.EXPECT().LoginOIDC(r.Context(), claimsExpected).Return(sessExpected, nil)
+
+	testHandler.OIDCCallback(w, r)
+
+	cookies := w.Result().Cookies()
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/users", w.Header().Get("Location"))
+	assert.EqualValues(t, 2, len(cookies))
+	assert.EqualValues(t, oidcCookie, cookies[0].Name)
+	assert.EqualValues(t, -1, cookies[0].MaxAge)
+	assert.EqualValues(t, "session_id", cookies[1].Name)
+	assert.EqualValues(t, sessExpected.SessID, cookies[1].Value)
+
+	// повторный возврат с тем же кодом: провайдер его уже не примет
+	w = httptest.NewRecorder()
+
+	testHandler.OIDCCallback(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+
+	// нет cookie входа
+	_, callback = startOIDCLogin(t, testHandler, issuer, claims)
+
+	w = httptest.NewRecorder()
+
+	testHandler.OIDCCallback(w, httptest.NewRequest(http.MethodGet, callback, nil))
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// state от другого входа
+	cookie, _ = startOIDCLogin(t, testHandler, issuer, claims)
+	_, callback = startOIDCLogin(t, testHandler, issuer, claims)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, callback, nil)
+	r.AddCookie(cookie)
+
+	testHandler.OIDCCallback(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// провайдер отказал во входе
+	cookie, callback = startOIDCLogin(t, testHandler, issuer, claims)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, strings.Replace(callback, "code=", "error=access_denied&code=", 1), nil)
+	r.AddCookie(cookie)
+
+	testHandler.OIDCCallback(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+
+	// токен выдан другому клиенту
+	cookie, callback = startOIDCLogin(t, testHandler, issuer, map[string]interface{}{"sub": "42", "aud": "another"})
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, callback, nil)
+	r.AddCookie(cookie)
+
+	testHandler.OIDCCallback(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+
+	// ошибки сервиса
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{err: congrats_service.ErrUnverifiedEmail, status: http.StatusForbidden},
+		{err: congrats_service.ErrNoBirthday, status: http.StatusBadRequest},
+		{err: congrats_service.ErrAmbiguousEmail, status: http.StatusConflict},
+		{err: user.ErrNoUser, status: http.StatusForbidden},
+		{err: fmt.Errorf("internal error"), status: http.StatusFound},
+	}
+	for _, tc := range cases {
+		cookie, callback = startOIDCLogin(t, testHandler, issuer, claims)
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, callback, nil)
+		r.AddCookie(cookie)
+
+		service.EXPECT().LoginOIDC(r.Context(), claimsExpected).Return(nil, tc.err)
+
+		testHandler.OIDCCallback(w, r)
+
+		assert.EqualValues(t, tc.status, w.Code, tc.err.Error())
+		assert.EqualValues(t, 1, len(w.Result().Cookies()), tc.err.Error())
+	}
+
+	// провайдер недоступен
+	unavailable := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		oidc.NewClient("http://127.0.0.1:1", "birthday", "", "http://birthday.example.com/login/oidc/callback",
+			[]string{"openid"}, time.Second, zap.NewNop().Sugar()),
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	w = httptest.NewRecorder()
+
+	unavailable.LoginOIDC(w, httptest.NewRequest(http.MethodGet, "/login/oidc", nil))
+
+	assert.EqualValues(t, http.StatusBadGateway, w.Code)
+	assert.EqualValues(t, 0, len(w.Result().Cookies()))
+}
@@ -0,0 +1,529 @@
+package handlers
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/user"
+	service "birthday_congrats/internal/services/congrats_service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// схемы SCIM 2.0 (RFC 7643, RFC 7644)
+const (
+	scimUserSchema     = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimBirthdaySchema = "urn:birthday-congrats:scim:schemas:extension:birthday:2.0:User" // наше расширение с датой рождения
+	scimListSchema     = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimPatchSchema    = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	scimErrorSchema    = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	scimContentType     = "application/scim+json; charset=utf-8"
+	scimUsersPath       = "/scim/v2/Users"
+	scimDefaultCount    = 100 // сколько пользователей отдавать за раз, если count не задан
+	scimBirthdayPathKey = "birthday"
+)
+
+// SCIMHandler - SCIM 2.0 /scim/v2/Users для HR-систем: заведение, изменение и увольнение сотрудников
+type SCIMHandler struct {
+	service service.CongratulationsService
+	logger  *zap.SugaredLogger
+}
+
+func NewSCIMHandler(
+	service service.CongratulationsService,
+	logger *zap.SugaredLogger,
+) *SCIMHandler {
+	return &SCIMHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimBirthday struct {
+	Birthday string `json:"birthday"` // YYYY-MM-DD или --MM-DD, если год скрыт
+}
+
+type scimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location"`
+}
+
+// scimUser - ресурс User. Незнакомые атрибуты (name, displayName и т.д.) в запросах игнорируются.
+type scimUser struct {
+	Schemas    []string      `json:"schemas"`
+	ID         string        `json:"id,omitempty"`
+	ExternalID string        `json:"externalId,omitempty"`
+	UserName   string        `json:"userName"`
+	Active     *bool         `json:"active,omitempty"`
+	Emails     []scimEmail   `json:"emails,omitempty"`
+	Timezone   string        `json:"timezone,omitempty"`
+	Password   string        `json:"password,omitempty"` // только в запросах
+	Birthday   *scimBirthday `json:"urn:birthday-congrats:scim:schemas:extension:birthday:2.0:User,omitempty"`
+	Meta       *scimMeta     `json:"meta,omitempty"`
+}
+
+type scimListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    []*scimUser `json:"Resources"`
+}
+
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// scimBadRequest - ошибка в запросе, которая отдается клиенту как 400 с указанным scimType
+type scimBadRequest struct {
+	scimType string
+	detail   string
+}
+
+func (e *scimBadRequest) Error() string {
+	return e.detail
+}
+
+func badSCIMValue(format string, args ...interface{}) error {
+	return &scimBadRequest{scimType: "invalidValue", detail: fmt.Sprintf(format, args...)}
+}
+
+func (h *SCIMHandler) writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(statusCode)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		h.logger.Errorf("Error while encoding json: %v", err)
+	}
+}
+
+func (h *SCIMHandler) writeError(w http.ResponseWriter, statusCode int, scimType, detail string) {
+	h.writeJSON(w, statusCode, scimError{
+		Schemas:  []string{scimErrorSchema},
+		Status:   strconv.Itoa(statusCode),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// writeServiceError переводит ошибки сервиса и разбора запроса в ответы SCIM
+func (h *SCIMHandler) writeServiceError(w http.ResponseWriter, err error) {
+	if bad, ok := err.(*scimBadRequest); ok {
+		h.writeError(w, http.StatusBadRequest, bad.scimType, bad.detail)
+		return
+	}
+
+	switch err {
+	case user.ErrNoUser:
+		h.writeError(w, http.StatusNotFound, "", "user not found")
+	case user.ErrUserExists:
+		h.writeError(w, http.StatusConflict, "uniqueness", "userName is already taken")
+	case user.ErrBadTimezone:
+		h.writeError(w, http.StatusBadRequest, "invalidValue", "timezone must be an IANA time zone name")
+	case service.ErrBadDateFormat:
+		h.writeError(w, http.StatusBadRequest, "invalidValue", "birthday must be a valid YYYY-MM-DD or --MM-DD date")
+	case birthday.ErrBirthdayInFuture:
+		h.writeError(w, http.StatusBadRequest, "invalidValue", "birthday must not be in the future")
+	case birthday.ErrBadAge:
+		h.writeError(w, http.StatusBadRequest, "invalidValue", fmt.Sprintf("age must be between %d and %d", birthday.MinAge, birthday.MaxAge))
+	default:
+		h.logger.Errorf("Service error: %v", err)
+		h.writeError(w, http.StatusInternalServerError, "", "internal error")
+	}
+}
+
+func (h *SCIMHandler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalidSyntax", fmt.Sprintf("bad json body: %v", err))
+		return false
+	}
+
+	return true
+}
+
+func (h *SCIMHandler) userIDFromPath(w http.ResponseWriter, r *http.Request) (uint32, bool) {
+	userID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		h.writeError(w, http.StatusNotFound, "", "user not found")
+		return 0, false
+	}
+
+	return uint32(userID), true
+}
+
+func toSCIMUser(u *user.User) *scimUser {
+	active := !u.Deactivated
+	id := strconv.FormatUint(uint64(u.ID), 10)
+
+	res := &scimUser{
+		Schemas:    []string{scimUserSchema, scimBirthdaySchema},
+		ID:         id,
+		ExternalID: u.ExternalID,
+		UserName:   u.Username,
+		Active:     &active,
+		Timezone:   u.Timezone,
+		Birthday: &scimBirthday{
+			Birthday: u.Birthday.String(),
+		},
+		Meta: &scimMeta{
+			ResourceType: "User",
+			Location:     scimUsersPath + "/" + id,
+		},
+	}
+
+	if u.Email != "" {
+		res.Emails = []scimEmail{{Value: u.Email, Type: "work", Primary: true}}
+	}
+
+	return res
+}
+
+// primaryEmail возвращает основной адрес, а если он не отмечен - первый
+func primaryEmail(emails []scimEmail) string {
+	for _, e := range emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+
+	if len(emails) > 0 {
+		return emails[0].Value
+	}
+
+	return ""
+}
+
+func parseSCIMBirthday(value string) (birthday.Birthday, error) {
+	b, err := birthday.Parse(value)
+	if err != nil {
+		return birthday.Birthday{}, badSCIMValue("birthday must be a valid YYYY-MM-DD or --MM-DD date")
+	}
+
+	return b, nil
+}
+
+// CreateUser - POST /scim/v2/Users
+func (h *SCIMHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	req := &scimUser{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	email := primaryEmail(req.Emails)
+	if req.UserName == "" || email == "" || req.Birthday == nil || req.Birthday.Birthday == "" {
+		h.writeError(w, http.StatusBadRequest, "invalidValue", "userName, emails and "+scimBirthdaySchema+":birthday are required")
+		return
+	}
+
+	birth, err := parseSCIMBirthday(req.Birthday.Birthday)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	newUser := &user.User{
+		Username:      req.UserName,
+		Email:         email,
+		EmailVerified: true, // почте из HR-системы доверяем
+		Timezone:      req.Timezone,
+		Birthday:      birth,
+		ExternalID:    req.ExternalID,
+		Deactivated:   req.Active != nil && !*req.Active,
+	}
+
+	created, err := h.service.CreateUser(r.Context(), newUser, req.Password)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	res := toSCIMUser(created)
+	w.Header().Set("Location", res.Meta.Location)
+	h.writeJSON(w, http.StatusCreated, res)
+}
+
+// GetUser - GET /scim/v2/Users/{id}
+func (h *SCIMHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := h.service.GetUser(r.Context(), userID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toSCIMUser(u))
+}
+
+// ListUsers - GET /scim/v2/Users?filter=...&startIndex=...&count=...
+func (h *SCIMHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := parseSCIMFilter(query.Get("filter"))
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	// startIndex считается с 1, значения меньше 1 трактуются как 1 (RFC 7644, 3.4.2.4)
+	startIndex := 1
+	if s := query.Get("startIndex"); s != "" {
+		startIndex, err = strconv.Atoi(s)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalidValue", "startIndex must be an integer")
+			return
+		}
+		startIndex = max(startIndex, 1)
+	}
+
+	count := scimDefaultCount
+	if s := query.Get("count"); s != "" {
+		count, err = strconv.Atoi(s)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalidValue", "count must be an integer")
+			return
+		}
+		count = max(count, 0)
+	}
+
+	users, err := h.service.ListUsers(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	matched := make([]*user.User, 0, len(users))
+	for _, u := range users {
+		if filter.match(u) {
+			matched = append(matched, u)
+		}
+	}
+
+	resources := make([]*scimUser, 0)
+	for i := startIndex - 1; i < len(matched) && len(resources) < count; i++ {
+		resources = append(resources, toSCIMUser(matched[i]))
+	}
+
+	h.writeJSON(w, http.StatusOK, scimListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: len(matched),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// PatchUser - PATCH /scim/v2/Users/{id}. Поддерживаются операции add/replace для userName, externalId,
+// active, emails, timezone и даты рождения, remove - только для externalId.
+func (h *SCIMHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	req := &scimPatchRequest{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	if len(req.Operations) == 0 {
+		h.writeError(w, http.StatusBadRequest, "invalidValue", "no operations")
+		return
+	}
+
+	u, err := h.service.GetUser(r.Context(), userID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	for _, op := range req.Operations {
+		err = applySCIMPatch(u, op)
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+	}
+
+	updated, err := h.service.UpdateUser(r.Context(), u)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toSCIMUser(updated))
+}
+
+// DeleteUser - DELETE /scim/v2/Users/{id}. Сотрудник не удаляется, а увольняется (active = false):
+// удаляются его подписки и подписки на него, он больше не может войти.
+func (h *SCIMHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := h.service.DeactivateUser(r.Context(), userID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func applySCIMPatch(u *user.User, op scimPatchOperation) error {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+		// без пути значение - объект с атрибутами
+		if op.Path == "" {
+			attrs := make(map[string]json.RawMessage)
+			err := json.Unmarshal(op.Value, &attrs)
+			if err != nil {
+				return badSCIMValue("value must be an object when path is omitted")
+			}
+
+			for path, value := range attrs {
+				err = setSCIMAttribute(u, path, value)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		return setSCIMAttribute(u, op.Path, op.Value)
+	case "remove":
+		if strings.EqualFold(op.Path, "externalId") {
+			u.ExternalID = ""
+			return nil
+		}
+
+		return badSCIMValue("%q cannot be removed", op.Path)
+	default:
+		return &scimBadRequest{scimType: "invalidSyntax", detail: fmt.Sprintf("unknown op %q", op.Op)}
+	}
+}
+
+func setSCIMAttribute(u *user.User, path string, value json.RawMessage) error {
+	lower := strings.ToLower(path)
+
+	switch {
+	case lower == "username":
+		return unmarshalSCIMString(value, path, &u.Username, true)
+	case lower == "externalid":
+		return unmarshalSCIMString(value, path, &u.ExternalID, false)
+	case lower == "timezone":
+		return unmarshalSCIMString(value, path, &u.Timezone, false)
+	case lower == "active":
+		active, err := parseSCIMBool(value)
+		if err != nil {
+			return err
+		}
+		u.Deactivated = !active
+		return nil
+	case lower == "emails":
+		emails := make([]scimEmail, 0)
+		err := json.Unmarshal(value, &emails)
+		if err != nil || primaryEmail(emails) == "" {
+			return badSCIMValue("emails must be a non-empty list of {\"value\": ...}")
+		}
+		u.Email = primaryEmail(emails)
+		return nil
+	case strings.HasPrefix(lower, "emails[") && strings.HasSuffix(lower, "].value"), lower == "emails.value":
+		// у пользователя один адрес, поэтому фильтр вида emails[type eq "work"] не разбирается
+		return unmarshalSCIMString(value, path, &u.Email, true)
+	case lower == strings.ToLower(scimBirthdaySchema):
+		ext := &scimBirthday{}
+		err := json.Unmarshal(value, ext)
+		if err != nil {
+			return badSCIMValue("%s must be an object", scimBirthdaySchema)
+		}
+		return setSCIMBirthday(u, ext.Birthday)
+	case lower == strings.ToLower(scimBirthdaySchema+":"+scimBirthdayPathKey):
+		date := ""
+		err := unmarshalSCIMString(value, path, &date, true)
+		if err != nil {
+			return err
+		}
+		return setSCIMBirthday(u, date)
+	default:
+		return &scimBadRequest{scimType: "invalidPath", detail: fmt.Sprintf("unsupported path %q", path)}
+	}
+}
+
+func setSCIMBirthday(u *user.User, value string) error {
+	birth, err := parseSCIMBirthday(value)
+	if err != nil {
+		return err
+	}
+
+	u.Birthday = birth
+
+	return nil
+}
+
+func unmarshalSCIMString(value json.RawMessage, path string, dst *string, required bool) error {
+	s := ""
+	err := json.Unmarshal(value, &s)
+	if err != nil {
+		return badSCIMValue("%s must be a string", path)
+	}
+	if required && s == "" {
+		return badSCIMValue("%s must not be empty", path)
+	}
+
+	*dst = s
+
+	return nil
+}
+
+// parseSCIMBool разбирает булево значение; некоторые HR-системы присылают его строкой ("False")
+func parseSCIMBool(value json.RawMessage) (bool, error) {
+	var b bool
+	err := json.Unmarshal(value, &b)
+	if err == nil {
+		return b, nil
+	}
+
+	s := ""
+	err = json.Unmarshal(value, &s)
+	if err == nil {
+		b, err = strconv.ParseBool(s)
+		if err == nil {
+			return b, nil
+		}
+	}
+
+	return false, badSCIMValue("active must be a boolean")
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"birthday_congrats/internal/pkg/user"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// scimCondition - одно условие фильтра `атрибут eq значение`
+type scimCondition struct {
+	attr  string // имя атрибута в нижнем регистре
+	value string
+}
+
+// scimFilter - условия, объединенные через and. Пустой фильтр подходит всем.
+// HR-системы ищут пользователя перед созданием по userName или externalId,
+// поэтому поддерживается только оператор eq.
+type scimFilter []scimCondition
+
+var scimFilterAttrs = map[string]bool{
+	"id":           true,
+	"username":     true,
+	"externalid":   true,
+	"emails":       true,
+	"emails.value": true,
+	"active":       true,
+}
+
+func parseSCIMFilter(filter string) (scimFilter, error) {
+	tokens, err := scimFilterTokens(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make(scimFilter, 0)
+	for i := 0; i < len(tokens); {
+		if i > 0 {
+			if !strings.EqualFold(tokens[i], "and") {
+				return nil, badSCIMFilter("only \"and\" is supported between conditions, got %q", tokens[i])
+			}
+			i++
+		}
+
+		if i+3 > len(tokens) {
+			return nil, badSCIMFilter("incomplete condition")
+		}
+
+		attr := strings.ToLower(tokens[i])
+		if !scimFilterAttrs[attr] {
+			return nil, badSCIMFilter("filtering by %q is not supported", tokens[i])
+		}
+		if !strings.EqualFold(tokens[i+1], "eq") {
+			return nil, badSCIMFilter("only \"eq\" operator is supported, got %q", tokens[i+1])
+		}
+
+		value, err := scimFilterValue(tokens[i+2])
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, scimCondition{attr: attr, value: value})
+		i += 3
+	}
+
+	return conditions, nil
+}
+
+// scimFilterTokens делит фильтр на слова; строки в двойных кавычках остаются одним словом вместе с кавычками
+func scimFilterTokens(filter string) ([]string, error) {
+	tokens := make([]string, 0)
+
+	for i := 0; i < len(filter); {
+		switch {
+		case filter[i] == ' ':
+			i++
+		case filter[i] == '"':
+			end := i + 1
+			for end < len(filter) && filter[end] != '"' {
+				if filter[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(filter) {
+				return nil, badSCIMFilter("unterminated string")
+			}
+
+			tokens = append(tokens, filter[i:end+1])
+			i = end + 1
+		default:
+			end := i
+			for end < len(filter) && filter[end] != ' ' {
+				end++
+			}
+
+			tokens = append(tokens, filter[i:end])
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+// scimFilterValue разбирает значение: строку в кавычках, true или false
+func scimFilterValue(token string) (string, error) {
+	if strings.HasPrefix(token, `"`) {
+		s := ""
+		err := json.Unmarshal([]byte(token), &s)
+		if err != nil {
+			return "", badSCIMFilter("bad string %s", token)
+		}
+
+		return s, nil
+	}
+
+	b, err := strconv.ParseBool(token)
+	if err != nil {
+		return "", badSCIMFilter("bad value %q", token)
+	}
+
+	return strconv.FormatBool(b), nil
+}
+
+func badSCIMFilter(format string, args ...interface{}) error {
+	return &scimBadRequest{scimType: "invalidFilter", detail: fmt.Sprintf(format, args...)}
+}
+
+func (f scimFilter) match(u *user.User) bool {
+	for _, c := range f {
+		if !c.match(u) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// match сравнивает значение по правилам RFC 7643: userName и emails без учета регистра, externalId - с учетом
+func (c scimCondition) match(u *user.User) bool {
+	switch c.attr {
+	case "id":
+		return strconv.FormatUint(uint64(u.ID), 10) == c.value
+	case "username":
+		return strings.EqualFold(u.Username, c.value)
+	case "externalid":
+		return u.ExternalID == c.value
+	case "emails", "emails.value":
+		return strings.EqualFold(u.Email, c.value)
+	case "active":
+		return strconv.FormatBool(!u.Deactivated) == c.value
+	}
+
+	return false
+}
@@ -0,0 +1,619 @@
+package handlers
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/services/congrats_service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func decodeSCIMError(t *testing.T, w *httptest.ResponseRecorder) scimError {
+	t.Helper()
+
+	resp := scimError{}
+
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	if err != nil {
+		t.Fatalf("cant decode error: %v", err)
+	}
+
+	return resp
+}
+
+func TestSCIMCreateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewSCIMHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	body := fmt.Sprintf(`{
+		"schemas": ["%s", "%s"],
+		"externalId": "hr-42",
+		"userName": "some_user",
+		"name": {"givenName": "Some"},
+		"emails": [{"value": "home@email.com"}, {"value": "some@email.com", "primary": true}],
+		"timezone": "Europe/Moscow",
+		"%s": {"birthday": "2000-01-02"}
+	}`, scimUserSchema, scimBirthdaySchema, scimBirthdaySchema)
+
+	userSent := &user.User{
+		Username:      "some_user",
+		Email:         "some@email.com",
+		EmailVerified: true, // почте из HR-системы доверяем
+		Timezone:      "Europe/Moscow",
+		Birthday:      birthday.Birthday{Year: 2000, Month: time.January, Day: 2},
+		ExternalID:    "hr-42",
+	}
+
+	userCreated := *userSent
+	userCreated.ID = 42
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(body))
+
+	service.EXPECT().CreateUser(r.Context(), userSent, "").Return(&userCreated, nil)
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusCreated, w.Code)
+	assert.EqualValues(t, "/scim/v2/Users/42", w.Header().Get("Location"))
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "application/scim+json"))
+
+	userRecv := scimUser{}
+	err := json.NewDecoder(w.Body).Decode(&userRecv)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, "42", userRecv.ID)
+	assert.EqualValues(t, "hr-42", userRecv.ExternalID)
+	assert.EqualValues(t, "2000-01-02", userRecv.Birthday.Birthday)
+	assert.EqualValues(t, []scimEmail{{Value: "some@email.com", Type: "work", Primary: true}}, userRecv.Emails)
+	if assert.NotNil(t, userRecv.Active) {
+		assert.True(t, *userRecv.Active)
+	}
+
+	// сразу неактивный сотрудник с паролем
+	userSent.Deactivated = true
+	userCreated.Deactivated = true
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(
+		`{"userName":"some_user","externalId":"hr-42","active":false,"password":"some_pass","timezone":"Europe/Moscow",`+
+			`"emails":[{"value":"some@email.com"}],"`+scimBirthdaySchema+`":{"birthday":"2000-01-02"}}`,
+	))
+
+	service.EXPECT().CreateUser(r.Context(), userSent, "some_pass").Return(&userCreated, nil)
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusCreated, w.Code)
+
+	// нет даты рождения
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(
+		`{"userName":"some_user","emails":[{"value":"some@email.com"}]}`,
+	))
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "invalidValue", decodeSCIMError(t, w).ScimType)
+
+	// некорректная дата рождения
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(
+		`{"userName":"some_user","emails":[{"value":"some@email.com"}],"`+scimBirthdaySchema+`":{"birthday":"2001-02-29"}}`,
+	))
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "invalidValue", decodeSCIMError(t, w).ScimType)
+
+	// год рождения скрыт
+	userHidden := &user.User{
+		Username:      "some_user",
+		Email:         "some@email.com",
+		EmailVerified: true,
+		Birthday:      birthday.Birthday{Month: time.February, Day: 29},
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(
+		`{"userName":"some_user","emails":[{"value":"some@email.com"}],"`+scimBirthdaySchema+`":{"birthday":"--02-29"}}`,
+	))
+
+	service.EXPECT().CreateUser(r.Context(), userHidden, "").Return(&user.User{ID: 43, Username: "some_user", Birthday: userHidden.Birthday}, nil)
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusCreated, w.Code)
+
+	userRecv = scimUser{}
+	err = json.NewDecoder(w.Body).Decode(&userRecv)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, "--02-29", userRecv.Birthday.Birthday)
+
+	// возраст вне допустимого диапазона
+	userSent.Deactivated = false
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(body))
+
+	service.EXPECT().CreateUser(r.Context(), userSent, "").Return(nil, birthday.ErrBadAge)
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "invalidValue", decodeSCIMError(t, w).ScimType)
+
+	// некорректный json
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(`{"userName":`))
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "invalidSyntax", decodeSCIMError(t, w).ScimType)
+
+	// пользователь уже существует
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(body))
+
+	service.EXPECT().CreateUser(r.Context(), userSent, "").Return(nil, user.ErrUserExists)
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusConflict, w.Code)
+
+	scimErr := decodeSCIMError(t, w)
+	assert.EqualValues(t, "uniqueness", scimErr.ScimType)
+	assert.EqualValues(t, "409", scimErr.Status)
+	assert.EqualValues(t, []string{scimErrorSchema}, scimErr.Schemas)
+
+	// некорректный часовой пояс
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(body))
+
+	service.EXPECT().CreateUser(r.Context(), userSent, "").Return(nil, user.ErrBadTimezone)
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scim/v2/Users", strings.NewReader(body))
+
+	service.EXPECT().CreateUser(r.Context(), userSent, "").Return(nil, fmt.Errorf("db error"))
+
+	testHandler.CreateUser(w, r)
+
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestSCIMGetUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewSCIMHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	userSent := &user.User{
+		ID:          42,
+		Username:    "some_user",
+		Email:       "some@email.com",
+		Birthday:    birthday.Birthday{Year: 2000, Month: time.February, Day: 29},
+		Deactivated: true,
+	}
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/scim/v2/Users/42", nil)
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(userSent, nil)
+
+	testHandler.GetUser(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	userRecv := scimUser{}
+	err := json.NewDecoder(w.Body).Decode(&userRecv)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, "some_user", userRecv.UserName)
+	assert.EqualValues(t, "2000-02-29", userRecv.Birthday.Birthday)
+	assert.EqualValues(t, "/scim/v2/Users/42", userRecv.Meta.Location)
+	if assert.NotNil(t, userRecv.Active) {
+		assert.False(t, *userRecv.Active)
+	}
+
+	// некорректный id
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/scim/v2/Users/bad_id", nil)
+	r = mux.SetURLVars(r, map[string]string{"id": "bad_id"})
+
+	testHandler.GetUser(w, r)
+
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+
+	// пользователь не найден
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/scim/v2/Users/42", nil)
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(nil, user.ErrNoUser)
+
+	testHandler.GetUser(w, r)
+
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+	assert.EqualValues(t, "404", decodeSCIMError(t, w).Status)
+}
+
+func TestSCIMListUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewSCIMHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	usersSent := []*user.User{
+		{ID: 1, Username: "one", Email: "one@one.net", ExternalID: "hr-1", Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 1}},
+		{ID: 2, Username: "Two", Email: "two@two.net", ExternalID: "hr-2", Birthday: birthday.Birthday{Year: 2000, Month: time.February, Day: 2}},
+		{ID: 3, Username: "three", Email: "three@three.net", Birthday: birthday.Birthday{Year: 2000, Month: time.March, Day: 3}, Deactivated: true},
+	}
+
+	list := func(w *httptest.ResponseRecorder) scimListResponse {
+		t.Helper()
+
+		resp := scimListResponse{}
+		err := json.NewDecoder(w.Body).Decode(&resp)
+		assert.NoError(t, err)
+
+		return resp
+	}
+
+	ids := func(resp scimListResponse) []string {
+		res := make([]string, 0, len(resp.Resources))
+		for _, u := range resp.Resources {
+			res = append(res, u.ID)
+		}
+		return res
+	}
+
+	// все пользователи
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+
+	service.EXPECT().ListUsers(r.Context()).Return(usersSent, nil)
+
+	testHandler.ListUsers(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	resp := list(w)
+	assert.EqualValues(t, []string{scimListSchema}, resp.Schemas)
+	assert.EqualValues(t, 3, resp.TotalResults)
+	assert.EqualValues(t, 1, resp.StartIndex)
+	assert.EqualValues(t, 3, resp.ItemsPerPage)
+	assert.EqualValues(t, []string{"1", "2", "3"}, ids(resp))
+
+	// поиск по userName без учета регистра
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, `/scim/v2/Users?filter=userName+eq+"two"`, nil)
+
+	service.EXPECT().ListUsers(r.Context()).Return(usersSent, nil)
+
+	testHandler.ListUsers(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, []string{"2"}, ids(list(w)))
+
+	// несколько условий
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, `/scim/v2/Users?filter=externalId+eq+"hr-1"+and+active+eq+true`, nil)
+
+	service.EXPECT().ListUsers(r.Context()).Return(usersSent, nil)
+
+	testHandler.ListUsers(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, []string{"1"}, ids(list(w)))
+
+	// уволенные
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, `/scim/v2/Users?filter=active+eq+false`, nil)
+
+	service.EXPECT().ListUsers(r.Context()).Return(usersSent, nil)
+
+	testHandler.ListUsers(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, []string{"3"}, ids(list(w)))
+
+	// постранично
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/scim/v2/Users?startIndex=2&count=1", nil)
+
+	service.EXPECT().ListUsers(r.Context()).Return(usersSent, nil)
+
+	testHandler.ListUsers(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	resp = list(w)
+	assert.EqualValues(t, 3, resp.TotalResults)
+	assert.EqualValues(t, 2, resp.StartIndex)
+	assert.EqualValues(t, 1, resp.ItemsPerPage)
+	assert.EqualValues(t, []string{"2"}, ids(resp))
+
+	// неподдерживаемый фильтр
+	for _, filter := range []string{
+		`userName+co+"one"`,
+		`name.givenName+eq+"one"`,
+		`userName+eq+"one"+or+userName+eq+"two"`,
+		`userName+eq`,
+		`userName+eq+"one`,
+	} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/scim/v2/Users?filter="+filter, nil)
+
+		testHandler.ListUsers(w, r)
+
+		assert.EqualValues(t, http.StatusBadRequest, w.Code, filter)
+		assert.EqualValues(t, "invalidFilter", decodeSCIMError(t, w).ScimType, filter)
+	}
+
+	// некорректный count
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/scim/v2/Users?count=many", nil)
+
+	testHandler.ListUsers(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+
+	service.EXPECT().ListUsers(r.Context()).Return(nil, fmt.Errorf("db error"))
+
+	testHandler.ListUsers(w, r)
+
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestSCIMPatchUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewSCIMHandler(service, zap.NewNop().Sugar())
+
+	// данные для теста
+	current := func() *user.User {
+		return &user.User{
+			ID:         42,
+			Username:   "some_user",
+			Email:      "some@email.com",
+			Timezone:   "UTC",
+			Birthday:   birthday.Birthday{Year: 2000, Month: time.January, Day: 2},
+			ExternalID: "hr-42",
+		}
+	}
+
+	patch := func(ops string) string {
+		return `{"schemas":["` + scimPatchSchema + `"],"Operations":[` + ops + `]}`
+	}
+
+	// увольнение (Azure AD присылает active строкой)
+	userExpected := current()
+	userExpected.Deactivated = true
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/42", strings.NewReader(
+		patch(`{"op":"Replace","path":"active","value":"False"}`),
+	))
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(current(), nil)
+	service.EXPECT().UpdateUser(r.Context(), userExpected).Return(userExpected, nil)
+
+	testHandler.PatchUser(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	userRecv := scimUser{}
+	err := json.NewDecoder(w.Body).Decode(&userRecv)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, userRecv.Active) {
+		assert.False(t, *userRecv.Active)
+	}
+
+	// несколько атрибутов по путям
+	userExpected = current()
+	userExpected.Username = "new_user"
+	userExpected.Email = "new@email.com"
+	userExpected.Birthday = birthday.Birthday{Year: 1999, Month: time.December, Day: 31}
+	userExpected.ExternalID = ""
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/42", strings.NewReader(patch(
+		`{"op":"replace","path":"userName","value":"new_user"},`+
+			`{"op":"replace","path":"emails[type eq \"work\"].value","value":"new@email.com"},`+
+			`{"op":"add","path":"`+scimBirthdaySchema+`:birthday","value":"1999-12-31"},`+
+			`{"op":"remove","path":"externalId"}`,
+	)))
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(current(), nil)
+	service.EXPECT().UpdateUser(r.Context(), userExpected).Return(userExpected, nil)
+
+	testHandler.PatchUser(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	// операция без пути
+	userExpected = current()
+	userExpected.Timezone = "Europe/Moscow"
+	userExpected.Deactivated = true
+	userExpected.Birthday.Month, userExpected.Birthday.Day = time.March, 4
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/42", strings.NewReader(patch(
+		`{"op":"replace","value":{"timezone":"Europe/Moscow","active":false,"`+scimBirthdaySchema+`":{"birthday":"2000-03-04"}}}`,
+	)))
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(current(), nil)
+	service.EXPECT().UpdateUser(r.Context(), userExpected).Return(userExpected, nil)
+
+	testHandler.PatchUser(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	// неподдерживаемый путь
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/42", strings.NewReader(
+		patch(`{"op":"replace","path":"name.givenName","value":"Some"}`),
+	))
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(current(), nil)
+
+	testHandler.PatchUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "invalidPath", decodeSCIMError(t, w).ScimType)
+
+	// удалять можно только externalId
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/42", strings.NewReader(
+		patch(`{"op":"remove","path":"userName"}`),
+	))
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(current(), nil)
+
+	testHandler.PatchUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// некорректное значение active
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/42", strings.NewReader(
+		patch(`{"op":"replace","path":"active","value":"maybe"}`),
+	))
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(current(), nil)
+
+	testHandler.PatchUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.EqualValues(t, "invalidValue", decodeSCIMError(t, w).ScimType)
+
+	// нет операций
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/42", strings.NewReader(patch("")))
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	testHandler.PatchUser(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// пользователь не найден
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/42", strings.NewReader(
+		patch(`{"op":"replace","path":"active","value":false}`),
+	))
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(nil, user.ErrNoUser)
+
+	testHandler.PatchUser(w, r)
+
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+
+	// имя уже занято
+	userExpected = current()
+	userExpected.Username = "taken"
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPatch, "/scim/v2/Users/42", strings.NewReader(
+		patch(`{"op":"replace","path":"userName","value":"taken"}`),
+	))
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().GetUser(r.Context(), uint32(42)).Return(current(), nil)
+	service.EXPECT().UpdateUser(r.Context(), userExpected).Return(nil, user.ErrUserExists)
+
+	testHandler.PatchUser(w, r)
+
+	assert.EqualValues(t, http.StatusConflict, w.Code)
+}
+
+func TestSCIMDeleteUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	testHandler := NewSCIMHandler(service, zap.NewNop().Sugar())
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/scim/v2/Users/42", nil)
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().DeactivateUser(r.Context(), uint32(42)).Return(&user.User{ID: 42, Deactivated: true}, nil)
+
+	testHandler.DeleteUser(w, r)
+
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+	assert.EqualValues(t, 0, w.Body.Len())
+
+	// пользователь не найден
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/scim/v2/Users/42", nil)
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().DeactivateUser(r.Context(), uint32(42)).Return(nil, user.ErrNoUser)
+
+	testHandler.DeleteUser(w, r)
+
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/scim/v2/Users/42", nil)
+	r = mux.SetURLVars(r, map[string]string{"id": "42"})
+
+	service.EXPECT().DeactivateUser(r.Context(), uint32(42)).Return(nil, fmt.Errorf("db error"))
+
+	testHandler.DeleteUser(w, r)
+
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+}
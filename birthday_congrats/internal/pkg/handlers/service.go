@@ -1,13 +1,21 @@
 package handlers
 
 import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/middlware"
+	"birthday_congrats/internal/pkg/oidc"
 	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
 	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
 	service "birthday_congrats/internal/services/congrats_service"
+	"fmt"
 	"html/template"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
@@ -17,6 +25,8 @@ type ServiceHandler struct {
 	tmpl    *template.Template
 	service service.CongratulationsService
 	sm      session.SessionsManager
+	oidc    *oidc.Client // nil - вход через провайдера OpenID Connect выключен
+	secure  bool         // cookie только по https
 	logger  *zap.SugaredLogger
 }
 
@@ -24,16 +34,47 @@ func NewServiceHandler(
 	tmpl *template.Template,
 	service service.CongratulationsService,
 	sm session.SessionsManager,
+	oidc *oidc.Client,
+	secureCookies bool,
 	logger *zap.SugaredLogger,
 ) *ServiceHandler {
 	return &ServiceHandler{
 		tmpl:    tmpl,
 		service: service,
 		sm:      sm,
+		oidc:    oidc,
+		secure:  secureCookies,
 		logger:  logger,
 	}
 }
 
+// setSessionCookie выдает cookie сессии: недоступную скриптам (HttpOnly) и не отправляемую
+// с запросами с чужих сайтов, кроме обычных переходов по ссылке (SameSite=Lax)
+func setSessionCookie(w http.ResponseWriter, sess *session.Session, secure bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Value:    sess.SessID,
+		Path:     "/",
+		Expires:  time.Unix(sess.Expires, 0),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func expireSessionCookie(w http.ResponseWriter, secure bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
 func (h *ServiceHandler) execErrorTemplate(w http.ResponseWriter, message string, statusCode int) {
 	w.WriteHeader(statusCode)
 
@@ -57,7 +98,13 @@ func (h *ServiceHandler) Index(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.WriteHeader(http.StatusOK)
-	err = h.tmpl.ExecuteTemplate(w, "login.html", nil)
+	err = h.tmpl.ExecuteTemplate(w, "login.html", struct {
+		OIDC      bool
+		CSRFToken string
+	}{
+		OIDC:      h.oidc != nil,
+		CSRFToken: middlware.CSRFToken(r.Context()),
+	})
 	if err != nil {
 		h.logger.Errorf("template error: %v", err)
 		http.Redirect(w, r, "/error", http.StatusFound)
@@ -69,28 +116,48 @@ func (h *ServiceHandler) ErrorPage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ServiceHandler) Register(w http.ResponseWriter, r *http.Request) {
+	// поле type="date" всегда присылает год; если его попросили скрыть, передаем дату без года
+	birth := r.FormValue("birth")
+	if r.FormValue("hide_year") != "" {
+		b, err := birthday.Parse(birth)
+		if err == nil {
+			b.Year = 0
+			birth = b.String()
+		}
+	}
+
 	sess, err := h.service.Register(
 		r.Context(),
 		r.FormValue("username"),
 		r.FormValue("password"),
 		r.FormValue("email"),
-		r.FormValue("birth"),
+		birth,
+		r.FormValue("timezone"),
 	)
-	if err != nil && err != user.ErrUserExists {
+	switch err {
+	case nil:
+	case user.ErrUserExists:
+		h.execErrorTemplate(w, "Пользоваель с таким именем уже существует", http.StatusForbidden)
+		return
+	case user.ErrBadTimezone:
+		h.execErrorTemplate(w, "Неизвестный часовой пояс", http.StatusBadRequest)
+		return
+	case service.ErrBadDateFormat:
+		h.execErrorTemplate(w, "Некорректная дата рождения", http.StatusBadRequest)
+		return
+	case birthday.ErrBirthdayInFuture:
+		h.execErrorTemplate(w, "Дата рождения не может быть в будущем", http.StatusBadRequest)
+		return
+	case birthday.ErrBadAge:
+		h.execErrorTemplate(w, fmt.Sprintf("Возраст должен быть от %d до %d лет", birthday.MinAge, birthday.MaxAge), http.StatusBadRequest)
+		return
+	default:
 		h.logger.Errorf("Error while registration: %v", err)
 		http.Redirect(w, r, "/error", http.StatusFound)
 		return
 	}
-	if err == user.ErrUserExists {
-		h.execErrorTemplate(w, "Пользоваель с таким именем уже существует", http.StatusForbidden)
-		return
-	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:    "session_id",
-		Value:   sess.SessID,
-		Expires: time.Unix(sess.Expires, 0),
-	})
+	setSessionCookie(w, sess, h.secure)
 
 	http.Redirect(w, r, "/users", http.StatusFound)
 }
@@ -111,11 +178,7 @@ func (h *ServiceHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:    "session_id",
-		Value:   sess.SessID,
-		Expires: time.Unix(sess.Expires, 0),
-	})
+	setSessionCookie(w, sess, h.secure)
 
 	http.Redirect(w, r, "/users", http.StatusFound)
 }
@@ -128,11 +191,35 @@ func (h *ServiceHandler) Users(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	privacy, err := h.service.GetPrivacy(r.Context())
+	if err != nil {
+		h.logger.Errorf("Error getting privacy settings: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	// себя пользователь видит в списке всегда
+	emailVerified := true
+	sess, err := session.SessionFromContext(r.Context())
+	if err == nil {
+		for _, u := range users {
+			if u.ID == sess.UserID {
+				emailVerified = u.EmailVerified
+			}
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	err = h.tmpl.ExecuteTemplate(w, "users.html", struct {
-		Users []*user.User
+		Users         []*user.User
+		Privacy       *user.Privacy
+		EmailVerified bool
+		CSRFToken     string
 	}{
-		Users: users,
+		Users:         users,
+		Privacy:       privacy,
+		EmailVerified: emailVerified,
+		CSRFToken:     middlware.CSRFToken(r.Context()),
 	})
 	if err != nil {
 		h.logger.Errorf("Template error: %v", err)
@@ -154,8 +241,23 @@ func (h *ServiceHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/error", http.StatusFound)
 		return
 	}
+	if daysAlert < 0 || daysAlert > maxDaysAlert {
+		h.logger.Errorf("Days alert %d is out of range 0..%d", daysAlert, maxDaysAlert)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
 
 	err = h.service.Subscribe(r.Context(), uint32(subscriptionID), daysAlert)
+	if err == subscription.ErrAddSubscription {
+		// такое напоминание уже есть - например, форму отправили дважды
+		h.logger.Warnf("Days alert already exists")
+		http.Redirect(w, r, "/users", http.StatusFound)
+		return
+	}
+	if err == service.ErrNotSubscribable {
+		h.execErrorTemplate(w, "Сотрудник запретил подписываться на себя", http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		h.logger.Errorf("Error while subscribing: %v", err)
 		http.Redirect(w, r, "/error", http.StatusFound)
@@ -165,6 +267,83 @@ func (h *ServiceHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/users", http.StatusFound)
 }
 
+// parseDaysAlert разбирает список напоминаний из формы, например "0, 7"
+func parseDaysAlert(value string) ([]int, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty days alert list")
+	}
+
+	days := make([]int, 0, len(fields))
+	for _, f := range fields {
+		d, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, err
+		}
+		if d < 0 || d > maxDaysAlert {
+			return nil, fmt.Errorf("days alert %d is out of range 0..%d", d, maxDaysAlert)
+		}
+
+		days = append(days, d)
+	}
+
+	return days, nil
+}
+
+func (h *ServiceHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		h.logger.Errorf("Error converting string to int: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	daysAlert, err := parseDaysAlert(r.FormValue("days_alert"))
+	if err != nil {
+		h.logger.Errorf("Error parsing days alert: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	err = h.service.UpdateSubscription(r.Context(), uint32(subscriptionID), daysAlert)
+	if err == service.ErrNotSubscribable {
+		h.execErrorTemplate(w, "Сотрудник запретил подписываться на себя", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Error while updating subscription: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	http.Redirect(w, r, "/users", http.StatusFound)
+}
+
+func (h *ServiceHandler) RemoveDaysAlert(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		h.logger.Errorf("Error converting string to int: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	daysAlert, err := strconv.Atoi(mux.Vars(r)["days_alert"])
+	if err != nil {
+		h.logger.Errorf("Error converting string to int: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	err = h.service.RemoveDaysAlert(r.Context(), uint32(subscriptionID), daysAlert)
+	if err != nil {
+		h.logger.Errorf("Error while removing days alert: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	http.Redirect(w, r, "/users", http.StatusFound)
+}
+
 func (h *ServiceHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
 	subscriptionID, err := strconv.Atoi(mux.Vars(r)["user_id"])
 	if err != nil {
@@ -183,6 +362,263 @@ func (h *ServiceHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/users", http.StatusFound)
 }
 
+func (h *ServiceHandler) UpdatePrivacy(w http.ResponseWriter, r *http.Request) {
+	// неотмеченный флажок в форму не попадает
+	err := h.service.UpdatePrivacy(r.Context(), user.Privacy{
+		HideYear:          r.FormValue("hide_year") != "",
+		HideFromDirectory: r.FormValue("hide_from_directory") != "",
+		NotSubscribable:   r.FormValue("not_subscribable") != "",
+	})
+	if err != nil {
+		h.logger.Errorf("Error while updating privacy settings: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	http.Redirect(w, r, "/users", http.StatusFound)
+}
+
+func (h *ServiceHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	err := h.service.VerifyEmail(r.Context(), r.FormValue("token"))
+	switch err {
+	case nil:
+		http.Redirect(w, r, "/users", http.StatusFound)
+	case verification.ErrBadToken:
+		h.execErrorTemplate(w, "Ссылка подтверждения недействительна", http.StatusBadRequest)
+	case verification.ErrTokenExpired:
+		h.execErrorTemplate(w, "Ссылка подтверждения устарела, запросите новую на странице сотрудников", http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Error while verifying email: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func (h *ServiceHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	err := h.service.ResendVerification(r.Context())
+	if err != nil {
+		h.logger.Errorf("Error while resending verification email: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	http.Redirect(w, r, "/users", http.StatusFound)
+}
+
+func (h *ServiceHandler) Profile(w http.ResponseWriter, r *http.Request) {
+	us, err := h.service.GetProfile(r.Context())
+	if err != nil {
+		h.logger.Errorf("Error getting profile: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = h.tmpl.ExecuteTemplate(w, "profile.html", struct {
+		User      *user.User
+		CSRFToken string
+	}{
+		User:      us,
+		CSRFToken: middlware.CSRFToken(r.Context()),
+	})
+	if err != nil {
+		h.logger.Errorf("Template error: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func (h *ServiceHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	// форма присылает все поля, неизмененные сервис пропускает
+	username := r.FormValue("username")
+	email := r.FormValue("email")
+	birth := r.FormValue("birthday")
+
+	_, err := h.service.UpdateProfile(r.Context(), service.ProfileUpdate{
+		Username:        &username,
+		Email:           &email,
+		Birthday:        &birth,
+		CurrentPassword: r.FormValue("current_password"),
+	})
+	switch err {
+	case nil:
+		http.Redirect(w, r, "/profile", http.StatusFound)
+	case user.ErrBadPassword:
+		h.execErrorTemplate(w, "Для смены почты нужен верный текущий пароль", http.StatusForbidden)
+	case user.ErrUserExists:
+		h.execErrorTemplate(w, "Пользователь с таким именем уже существует", http.StatusConflict)
+	case service.ErrEmptyUsername:
+		h.execErrorTemplate(w, "Имя пользователя не может быть пустым", http.StatusBadRequest)
+	case service.ErrBadEmail:
+		h.execErrorTemplate(w, "Некорректный адрес почты", http.StatusBadRequest)
+	case service.ErrBadDateFormat:
+		h.execErrorTemplate(w, "Некорректная дата рождения", http.StatusBadRequest)
+	case birthday.ErrBirthdayInFuture:
+		h.execErrorTemplate(w, "Дата рождения не может быть в будущем", http.StatusBadRequest)
+	case birthday.ErrBadAge:
+		h.execErrorTemplate(w, fmt.Sprintf("Возраст должен быть от %d до %d лет", birthday.MinAge, birthday.MaxAge), http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Error while updating profile: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func (h *ServiceHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	sess, err := h.service.ChangePassword(r.Context(), r.FormValue("current_password"), r.FormValue("new_password"))
+	switch err {
+	case nil:
+		// старая сессия завершена вместе с остальными
+		setSessionCookie(w, sess, h.secure)
+		http.Redirect(w, r, "/profile", http.StatusFound)
+	case user.ErrBadPassword:
+		h.execErrorTemplate(w, "Неверный текущий пароль", http.StatusForbidden)
+	case service.ErrEmptyPassword:
+		h.execErrorTemplate(w, "Пароль не может быть пустым", http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Error while changing password: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func (h *ServiceHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	exp, err := h.service.ExportData(r.Context())
+	if err != nil {
+		h.logger.Errorf("Error while exporting data: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	writeExport(w, h.logger, exp)
+}
+
+func (h *ServiceHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	err := h.service.DeleteAccount(r.Context(), r.FormValue("password"))
+	switch err {
+	case nil:
+		expireSessionCookie(w, h.secure)
+		http.Redirect(w, r, "/", http.StatusFound)
+	case user.ErrBadPassword:
+		h.execErrorTemplate(w, "Неверный пароль", http.StatusForbidden)
+	default:
+		h.logger.Errorf("Error while deleting account: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func (h *ServiceHandler) execDeleteTemplate(w http.ResponseWriter, r *http.Request, token, message string) {
+	w.WriteHeader(http.StatusOK)
+
+	err := h.tmpl.ExecuteTemplate(w, "delete.html", struct {
+		Token     string
+		Message   string
+		CSRFToken string
+	}{
+		Token:     token,
+		Message:   message,
+		CSRFToken: middlware.CSRFToken(r.Context()),
+	})
+	if err != nil {
+		h.logger.Errorf("template error: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func (h *ServiceHandler) RequestAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	err := h.service.RequestAccountDeletion(r.Context())
+	switch err {
+	case nil:
+		h.execDeleteTemplate(w, r, "", "Ссылка для удаления аккаунта отправлена на вашу почту")
+	case service.ErrUnverifiedEmail:
+		h.execErrorTemplate(w, "Почта не подтверждена: подтвердите ее по ссылке из письма или удалите аккаунт с паролем", http.StatusForbidden)
+	default:
+		h.logger.Errorf("Error while requesting account deletion: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func (h *ServiceHandler) DeleteAccountForm(w http.ResponseWriter, r *http.Request) {
+	// удаляет только отправка формы: почтовые сервисы сами открывают ссылки из писем
+	token := r.FormValue("token")
+	if token == "" {
+		h.execErrorTemplate(w, "Ссылка удаления аккаунта недействительна", http.StatusBadRequest)
+		return
+	}
+
+	h.execDeleteTemplate(w, r, token, "")
+}
+
+func (h *ServiceHandler) DeleteAccountByLink(w http.ResponseWriter, r *http.Request) {
+	err := h.service.DeleteAccountByLink(r.Context(), r.FormValue("token"))
+	switch err {
+	case nil:
+		expireSessionCookie(w, h.secure)
+		http.Redirect(w, r, "/", http.StatusFound)
+	case verification.ErrBadToken:
+		h.execErrorTemplate(w, "Ссылка удаления аккаунта недействительна", http.StatusBadRequest)
+	case verification.ErrTokenExpired:
+		h.execErrorTemplate(w, "Ссылка удаления аккаунта устарела, запросите новую в профиле", http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Error while deleting account by link: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func (h *ServiceHandler) execResetTemplate(w http.ResponseWriter, r *http.Request, token, message string) {
+	w.WriteHeader(http.StatusOK)
+
+	err := h.tmpl.ExecuteTemplate(w, "reset.html", struct {
+		Token     string
+		Message   string
+		CSRFToken string
+	}{
+		Token:     token,
+		Message:   message,
+		CSRFToken: middlware.CSRFToken(r.Context()),
+	})
+	if err != nil {
+		h.logger.Errorf("template error: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
+func (h *ServiceHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	err := h.service.RequestPasswordReset(r.Context(), r.FormValue("username"))
+	if err != nil {
+		h.logger.Errorf("Error while requesting password reset: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+		return
+	}
+
+	// одинаковый ответ для всех имен, чтобы нельзя было узнать, кто зарегистрирован
+	h.execResetTemplate(w, r, "", "Если такой пользователь есть, ссылка для сброса пароля отправлена на его почту")
+}
+
+func (h *ServiceHandler) ResetPasswordForm(w http.ResponseWriter, r *http.Request) {
+	// токен проверяется только при отправке формы: проверка его бы израсходовала
+	token := r.FormValue("token")
+	if token == "" {
+		h.execErrorTemplate(w, "Ссылка сброса пароля недействительна", http.StatusBadRequest)
+		return
+	}
+
+	h.execResetTemplate(w, r, token, "")
+}
+
+func (h *ServiceHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	err := h.service.ResetPassword(r.Context(), r.FormValue("token"), r.FormValue("password"))
+	switch err {
+	case nil:
+		// все сессии пользователя завершены, входить нужно заново
+		expireSessionCookie(w, h.secure)
+		http.Redirect(w, r, "/", http.StatusFound)
+	case service.ErrBadResetToken:
+		h.execErrorTemplate(w, "Ссылка сброса пароля недействительна или устарела, запросите новую", http.StatusBadRequest)
+	case service.ErrEmptyPassword:
+		h.execErrorTemplate(w, "Пароль не может быть пустым", http.StatusBadRequest)
+	default:
+		h.logger.Errorf("Error while resetting password: %v", err)
+		http.Redirect(w, r, "/error", http.StatusFound)
+	}
+}
+
 func (h *ServiceHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	err := h.service.Logout(r.Context())
 	if err != nil && err != session.ErrNotDestroyed {
@@ -201,7 +637,12 @@ func (h *ServiceHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// из запроса приходят только имя и значение, остальные атрибуты выставляем заново
 	cookie.Expires = time.Now().AddDate(0, 0, -1)
+	cookie.Path = "/"
+	cookie.HttpOnly = true
+	cookie.Secure = h.secure
+	cookie.SameSite = http.SameSiteLaxMode
 	http.SetCookie(w, cookie)
 
 	http.Redirect(w, r, "/", http.StatusFound)
@@ -1,14 +1,19 @@
 package handlers
 
 import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/middlware"
 	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
 	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
 	"birthday_congrats/internal/services/congrats_service"
 	"fmt"
 	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -47,6 +52,8 @@ func TestExecErrorTemplate(t *testing.T) {
 	testHandler := NewServiceHandler(
 		tmpl,
 		nil, nil,
+		nil,
+		false,
 		zap.NewNop().Sugar(),
 	)
 
@@ -74,6 +81,8 @@ func TestErrorPage(t *testing.T) {
 	testHandler := NewServiceHandler(
 		tmpl,
 		nil, nil,
+		nil,
+		false,
 		zap.NewNop().Sugar(),
 	)
 
@@ -98,6 +107,8 @@ func TestUsers(t *testing.T) {
 		tmpl,
 		service,
 		nil,
+		nil,
+		false,
 		zap.NewNop().Sugar(),
 	)
 
@@ -110,11 +121,125 @@ func TestUsers(t *testing.T) {
 	r := httptest.NewRequest(http.MethodGet, "/users", nil)
 
 	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return(usersSent, nil)
+	service.EXPECT().GetPrivacy(r.Context()).Return(&user.Privacy{}, nil)
 
 	testHandler.Users(w, r)
 
 	assert.EqualValues(t, statusExpected, w.Code)
 
+	// ближайший день рождения выводится в списке
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/users", nil)
+
+	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return([]*user.User{
+		{
+			ID:           1,
+			Username:     "leap",
+			Birthday:     birthday.Birthday{Year: 2000, Month: time.February, Day: 29},
+			NextBirthday: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:           2,
+			Username:     "no_year",
+			Birthday:     birthday.Birthday{Month: time.December, Day: 31},
+			NextBirthday: time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}, nil)
+	service.EXPECT().GetPrivacy(r.Context()).Return(&user.Privacy{}, nil)
+
+	testHandler.Users(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "29.02.2000")
+	assert.Contains(t, w.Body.String(), "01.03.2025")
+	assert.Contains(t, w.Body.String(), "<td>31.12</td>") // год скрыт
+
+	// у подписки выводится каждое напоминание с кнопкой удаления
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/users", nil)
+
+	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return([]*user.User{
+		{
+			ID:           7,
+			Username:     "seven",
+			Subscription: true,
+			DaysAlert:    []int{0, 7},
+		},
+	}, nil)
+	service.EXPECT().GetPrivacy(r.Context()).Return(&user.Privacy{}, nil)
+
+	testHandler.Users(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="/unsubscribe/7"`)
+	assert.Contains(t, w.Body.String(), `action="/unsubscribe/7/0"`)
+	assert.Contains(t, w.Body.String(), `action="/unsubscribe/7/7"`)
+	assert.Contains(t, w.Body.String(), `value="Добавить"`)
+	assert.Contains(t, w.Body.String(), `action="/update/7"`)
+	assert.Contains(t, w.Body.String(), `value="0, 7"`)
+
+	// на того, кто запретил подписываться, подписаться нельзя; свои настройки отмечены в форме
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/users", nil)
+
+	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return([]*user.User{
+		{ID: 8, Username: "eight"},
+		{ID: 9, Username: "nine", Privacy: user.Privacy{NotSubscribable: true}},
+	}, nil)
+	service.EXPECT().GetPrivacy(r.Context()).Return(&user.Privacy{HideYear: true}, nil)
+
+	testHandler.Users(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="/subscribe/8"`)
+	assert.NotContains(t, w.Body.String(), `action="/subscribe/9"`)
+	assert.Contains(t, w.Body.String(), `name="hide_year" checked`)
+	assert.NotContains(t, w.Body.String(), `name="not_subscribable" checked`)
+
+	// почта текущего пользователя не подтверждена - предлагаем отправить письмо еще раз
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/users", nil)
+	r = r.WithContext(session.ContextWithSession(r.Context(), &session.Session{SessID: "some_sess_id", UserID: 8}))
+
+	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return([]*user.User{
+		{ID: 8, Username: "eight"},
+		{ID: 9, Username: "nine", EmailVerified: true},
+	}, nil)
+	service.EXPECT().GetPrivacy(r.Context()).Return(&user.Privacy{}, nil)
+
+	testHandler.Users(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="/verify/resend"`)
+
+	// подтверждена - не предлагаем
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/users", nil)
+	r = r.WithContext(session.ContextWithSession(r.Context(), &session.Session{SessID: "some_sess_id", UserID: 9}))
+
+	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return([]*user.User{
+		{ID: 8, Username: "eight"},
+		{ID: 9, Username: "nine", EmailVerified: true},
+	}, nil)
+	service.EXPECT().GetPrivacy(r.Context()).Return(&user.Privacy{}, nil)
+
+	testHandler.Users(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `action="/verify/resend"`)
+
+	// ошибка получения настроек приватности -> редирект на /error
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/users", nil)
+
+	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return(usersSent, nil)
+	service.EXPECT().GetPrivacy(r.Context()).Return(nil, fmt.Errorf("service error"))
+
+	testHandler.Users(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
 	// ошибка сервиса -> редирект на /error
 	statusExpected = http.StatusFound
 	w = httptest.NewRecorder()
@@ -132,6 +257,7 @@ func TestUsers(t *testing.T) {
 	r = httptest.NewRequest(http.MethodGet, "/users", nil)
 
 	service.EXPECT().GetSubscriptionsByUser(r.Context()).Return(usersSent, nil)
+	service.EXPECT().GetPrivacy(r.Context()).Return(&user.Privacy{}, nil)
 
 	testHandler.Users(wErr, r)
 
@@ -150,6 +276,8 @@ func TestIndex(t *testing.T) {
 		tmpl,
 		nil,
 		sessManager,
+		nil,
+		false,
 		zap.NewNop().Sugar(),
 	)
 
@@ -192,6 +320,19 @@ func TestIndex(t *testing.T) {
 	testHandler.Index(wErr, r)
 
 	assert.EqualValues(t, statusExpected, wErr.Code)
+
+	// нет сессии: в каждую форму страницы входа попадает CSRF-токен
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	sessManager.EXPECT().Check(gomock.Any()).Return(nil, session.ErrNoSession)
+
+	middlware.CSRF(false, zap.NewNop().Sugar(), http.HandlerFunc(testHandler.Index)).ServeHTTP(w, r)
+
+	token := w.Result().Cookies()[0].Value
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, 3, strings.Count(w.Body.String(), `name="csrf_token" value="`+token+`"`))
 }
 
 func TestRegister(t *testing.T) {
@@ -206,6 +347,8 @@ func TestRegister(t *testing.T) {
 		tmpl,
 		service,
 		nil,
+		nil,
+		false,
 		zap.NewNop().Sugar(),
 	)
 
@@ -214,6 +357,7 @@ func TestRegister(t *testing.T) {
 	password := "some_password"
 	email := "some@email.com"
 	birth := "2006-01-02"
+	timezone := "Europe/Moscow"
 
 	sessExpected := &session.Session{
 		SessID:  "some_sess_id",
@@ -241,6 +385,7 @@ func TestRegister(t *testing.T) {
 	r.Form.Set("password", password)
 	r.Form.Set("email", email)
 	r.Form.Set("birth", birth)
+	r.Form.Set("timezone", timezone)
 
 	service.EXPECT().Register(
 		r.Context(),
@@ -248,6 +393,7 @@ func TestRegister(t *testing.T) {
 		password,
 		email,
 		birth,
+		timezone,
 	).Return(sessExpected, nil)
 
 	testHandler.Register(w, r)
@@ -280,6 +426,7 @@ func TestRegister(t *testing.T) {
 	r.Form.Set("password", password)
 	r.Form.Set("email", email)
 	r.Form.Set("birth", birth)
+	r.Form.Set("timezone", timezone)
 
 	service.EXPECT().Register(
 		r.Context(),
@@ -287,6 +434,7 @@ func TestRegister(t *testing.T) {
 		password,
 		email,
 		birth,
+		timezone,
 	).Return(nil, fmt.Errorf("service error"))
 
 	testHandler.Register(w, r)
@@ -315,6 +463,7 @@ func TestRegister(t *testing.T) {
 	r.Form.Set("password", password)
 	r.Form.Set("email", email)
 	r.Form.Set("birth", birth)
+	r.Form.Set("timezone", timezone)
 
 	service.EXPECT().Register(
 		r.Context(),
@@ -322,6 +471,7 @@ func TestRegister(t *testing.T) {
 		password,
 		email,
 		birth,
+		timezone,
 	).Return(nil, user.ErrUserExists)
 
 	testHandler.Register(w, r)
@@ -335,6 +485,106 @@ func TestRegister(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error closing body: %v", err)
 	}
+
+	// неизвестный часовой пояс
+	statusExpected = http.StatusBadRequest
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/register", nil)
+
+	err = r.ParseForm()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	r.Form.Set("username", username)
+	r.Form.Set("password", password)
+	r.Form.Set("email", email)
+	r.Form.Set("birth", birth)
+	r.Form.Set("timezone", timezone)
+
+	service.EXPECT().Register(
+		r.Context(),
+		username,
+		password,
+		email,
+		birth,
+		timezone,
+	).Return(nil, user.ErrBadTimezone)
+
+	testHandler.Register(w, r)
+
+	result = w.Result()
+
+	assert.EqualValues(t, statusExpected, w.Code)
+	assert.EqualValues(t, 0, len(result.Cookies()))
+
+	err = result.Body.Close()
+	if err != nil {
+		t.Fatalf("error closing body: %v", err)
+	}
+
+	// год рождения скрыт
+	statusExpected = http.StatusFound
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/register", nil)
+
+	err = r.ParseForm()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	r.Form.Set("username", username)
+	r.Form.Set("password", password)
+	r.Form.Set("email", email)
+	r.Form.Set("birth", birth)
+	r.Form.Set("hide_year", "1")
+	r.Form.Set("timezone", timezone)
+
+	service.EXPECT().Register(
+		r.Context(),
+		username,
+		password,
+		email,
+		"--01-02",
+		timezone,
+	).Return(sessExpected, nil)
+
+	testHandler.Register(w, r)
+
+	assert.EqualValues(t, statusExpected, w.Code)
+	assert.EqualValues(t, 1, len(w.Result().Cookies()))
+
+	// некорректная дата рождения
+	for _, errSent := range []error{congrats_service.ErrBadDateFormat, birthday.ErrBirthdayInFuture, birthday.ErrBadAge} {
+		statusExpected = http.StatusBadRequest
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPost, "/register", nil)
+
+		err = r.ParseForm()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		r.Form.Set("username", username)
+		r.Form.Set("password", password)
+		r.Form.Set("email", email)
+		r.Form.Set("birth", birth)
+		r.Form.Set("timezone", timezone)
+
+		service.EXPECT().Register(
+			r.Context(),
+			username,
+			password,
+			email,
+			birth,
+			timezone,
+		).Return(nil, errSent)
+
+		testHandler.Register(w, r)
+
+		assert.EqualValues(t, statusExpected, w.Code, errSent)
+		assert.EqualValues(t, 0, len(w.Result().Cookies()), errSent)
+	}
 }
 
 func TestLogin(t *testing.T) {
@@ -349,6 +599,8 @@ func TestLogin(t *testing.T) {
 		tmpl,
 		service,
 		nil,
+		nil,
+		false,
 		zap.NewNop().Sugar(),
 	)
 
@@ -396,6 +648,8 @@ func TestLogin(t *testing.T) {
 	assert.EqualValues(t, cookieExpected.Name, result.Cookies()[0].Name)
 	assert.EqualValues(t, cookieExpected.Value, result.Cookies()[0].Value)
 	assert.EqualValues(t, cookieExpected.Expires, result.Cookies()[0].Expires)
+	assert.True(t, result.Cookies()[0].HttpOnly)
+	assert.EqualValues(t, http.SameSiteLaxMode, result.Cookies()[0].SameSite)
 
 	err = result.Body.Close()
 	if err != nil {
@@ -477,6 +731,8 @@ func TestSubscribe(t *testing.T) {
 		tmpl,
 		service,
 		nil,
+		nil,
+		false,
 		zap.NewNop().Sugar(),
 	)
 
@@ -537,6 +793,25 @@ func TestSubscribe(t *testing.T) {
 
 	assert.EqualValues(t, statusExpected, w.Code)
 
+	// daysAlert вне допустимого диапазона: до сервиса не доходит
+	for _, bad := range []string{"-1", strconv.Itoa(maxDaysAlert + 1)} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPost, "/subscribe/", nil)
+		r = mux.SetURLVars(r, map[string]string{"user_id": strconv.Itoa(int(userID))})
+
+		err = r.ParseForm()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		r.Form.Set("days_alert", bad)
+
+		testHandler.Subscribe(w, r)
+
+		assert.EqualValues(t, http.StatusFound, w.Code, bad)
+		assert.EqualValues(t, "/error", w.Header().Get("Location"), bad)
+	}
+
 	// ошибка сервиса
 	statusExpected = http.StatusFound
 	w = httptest.NewRecorder()
@@ -555,6 +830,141 @@ func TestSubscribe(t *testing.T) {
 	testHandler.Subscribe(w, r)
 
 	assert.EqualValues(t, statusExpected, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// форму отправили дважды - напоминание уже есть, это не ошибка
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/subscribe/", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": strconv.Itoa(int(userID))})
+
+	err = r.ParseForm()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	r.Form.Set("days_alert", strconv.Itoa(daysAlert))
+
+	service.EXPECT().Subscribe(r.Context(), userID, daysAlert).Return(subscription.ErrAddSubscription)
+
+	testHandler.Subscribe(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/users", w.Header().Get("Location"))
+
+	// на сотрудника нельзя подписаться
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/subscribe/", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": strconv.Itoa(int(userID))})
+
+	err = r.ParseForm()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	r.Form.Set("days_alert", strconv.Itoa(daysAlert))
+
+	service.EXPECT().Subscribe(r.Context(), userID, daysAlert).Return(congrats_service.ErrNotSubscribable)
+
+	testHandler.Subscribe(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "запретил подписываться")
+}
+
+func TestParseDaysAlert(t *testing.T) {
+	// нормальная работа
+	days, err := parseDaysAlert("0, 7,30  365")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int{0, 7, 30, 365}, days)
+
+	// пустой список
+	_, err = parseDaysAlert(" , ")
+
+	assert.Error(t, err)
+
+	// не число
+	_, err = parseDaysAlert("0, week")
+
+	assert.Error(t, err)
+
+	// вне диапазона
+	_, err = parseDaysAlert("-1")
+
+	assert.Error(t, err)
+
+	_, err = parseDaysAlert("366")
+
+	assert.Error(t, err)
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// данные для теста
+	userID := uint32(42)
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/update/42", strings.NewReader("days_alert=0%2C+7"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = mux.SetURLVars(r, map[string]string{"user_id": strconv.Itoa(int(userID))})
+
+	service.EXPECT().UpdateSubscription(r.Context(), userID, []int{0, 7}).Return(nil)
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/users", w.Header().Get("Location"))
+
+	// некорректный id
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/update/bad_id", strings.NewReader("days_alert=7"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = mux.SetURLVars(r, map[string]string{"user_id": "bad_id"})
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// некорректный список
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/update/42", strings.NewReader("days_alert=week"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = mux.SetURLVars(r, map[string]string{"user_id": strconv.Itoa(int(userID))})
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/update/42", strings.NewReader("days_alert=7"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = mux.SetURLVars(r, map[string]string{"user_id": strconv.Itoa(int(userID))})
+
+	service.EXPECT().UpdateSubscription(r.Context(), userID, []int{7}).Return(subscription.ErrNoSubscription)
+
+	testHandler.UpdateSubscription(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
 }
 
 func TestUnsubscribe(t *testing.T) {
@@ -569,6 +979,8 @@ func TestUnsubscribe(t *testing.T) {
 		tmpl,
 		service,
 		nil,
+		nil,
+		false,
 		zap.NewNop().Sugar(),
 	)
 
@@ -625,7 +1037,7 @@ func TestUnsubscribe(t *testing.T) {
 	assert.EqualValues(t, statusExpected, w.Code)
 }
 
-func TestLogout(t *testing.T) {
+func TestRemoveDaysAlert(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -637,58 +1049,295 @@ func TestLogout(t *testing.T) {
 		tmpl,
 		service,
 		nil,
+		nil,
+		false,
 		zap.NewNop().Sugar(),
 	)
 
 	// данные для теста
-	cookieSent := &http.Cookie{
-		Name:    "session_id",
-		Value:   "some_sess_id",
-		Expires: time.Now().Add(time.Minute),
-	}
-
-	cookieExpected := &http.Cookie{
-		Name:    "session_id",
-		Value:   "some_sess_id",
-		Expires: time.Now().AddDate(0, 0, -1),
-	}
+	userID := uint32(42)
 
 	// нормальная работа
 	statusExpected := http.StatusFound
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest(http.MethodGet, "/logout", nil)
-
-	r.AddCookie(cookieSent)
+	r := httptest.NewRequest(http.MethodPost, "/unsubscribe/42/0", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": strconv.Itoa(int(userID)), "days_alert": "0"})
 
-	service.EXPECT().Logout(r.Context()).Return(nil)
+	err := r.ParseForm()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
 
-	testHandler.Logout(w, r)
+	service.EXPECT().RemoveDaysAlert(r.Context(), userID, 0).Return(nil)
 
-	result := w.Result()
+	testHandler.RemoveDaysAlert(w, r)
 
 	assert.EqualValues(t, statusExpected, w.Code)
-	assert.EqualValues(t, 1, len(result.Cookies()))
-	assert.EqualValues(t, cookieExpected.Name, result.Cookies()[0].Name)
-	assert.EqualValues(t, cookieExpected.Value, result.Cookies()[0].Value)
-	assert.EqualValues(t, cookieExpected.Expires.Local().Day(), result.Cookies()[0].Expires.Local().Day())
 
-	err := result.Body.Close()
+	// некорректный id
+	statusExpected = http.StatusFound
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/unsubscribe/42/0", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "bad_id", "days_alert": "0"})
+
+	err = r.ParseForm()
 	if err != nil {
-		t.Fatalf("error closing body: %v", err)
+		t.Fatalf(err.Error())
 	}
 
-	// ошибка сервиса
+	testHandler.RemoveDaysAlert(w, r)
+
+	assert.EqualValues(t, statusExpected, w.Code)
+
+	// некорректный days_alert
 	statusExpected = http.StatusFound
 	w = httptest.NewRecorder()
-	r = httptest.NewRequest(http.MethodGet, "/logout", nil)
+	r = httptest.NewRequest(http.MethodPost, "/unsubscribe/42/0", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": strconv.Itoa(int(userID)), "days_alert": "bad"})
 
-	r.AddCookie(cookieSent)
+	err = r.ParseForm()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
 
-	service.EXPECT().Logout(r.Context()).Return(fmt.Errorf("service error"))
+	testHandler.RemoveDaysAlert(w, r)
 
-	testHandler.Logout(w, r)
+	assert.EqualValues(t, statusExpected, w.Code)
 
-	result = w.Result()
+	// ошибка сервиса
+	statusExpected = http.StatusFound
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/unsubscribe/42/0", nil)
+	r = mux.SetURLVars(r, map[string]string{"user_id": strconv.Itoa(int(userID)), "days_alert": "0"})
+
+	err = r.ParseForm()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	service.EXPECT().RemoveDaysAlert(r.Context(), userID, 0).Return(fmt.Errorf("service error"))
+
+	testHandler.RemoveDaysAlert(w, r)
+
+	assert.EqualValues(t, statusExpected, w.Code)
+}
+
+func TestUpdatePrivacy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// нормальная работа: неотмеченные флажки не приходят
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/privacy", nil)
+
+	err := r.ParseForm()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	r.Form.Set("hide_year", "on")
+	r.Form.Set("not_subscribable", "on")
+
+	service.EXPECT().UpdatePrivacy(r.Context(), user.Privacy{HideYear: true, NotSubscribable: true}).Return(nil)
+
+	testHandler.UpdatePrivacy(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/users", w.Header().Get("Location"))
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/privacy", nil)
+
+	service.EXPECT().UpdatePrivacy(r.Context(), user.Privacy{}).Return(fmt.Errorf("service error"))
+
+	testHandler.UpdatePrivacy(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/verify?token=some_token", nil)
+
+	service.EXPECT().VerifyEmail(r.Context(), "some_token").Return(nil)
+
+	testHandler.VerifyEmail(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/users", w.Header().Get("Location"))
+
+	// недействительная ссылка
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/verify?token=bad_token", nil)
+
+	service.EXPECT().VerifyEmail(r.Context(), "bad_token").Return(verification.ErrBadToken)
+
+	testHandler.VerifyEmail(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "недействительна")
+
+	// устаревшая ссылка
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/verify?token=old_token", nil)
+
+	service.EXPECT().VerifyEmail(r.Context(), "old_token").Return(verification.ErrTokenExpired)
+
+	testHandler.VerifyEmail(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "устарела")
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/verify?token=some_token", nil)
+
+	service.EXPECT().VerifyEmail(r.Context(), "some_token").Return(fmt.Errorf("service error"))
+
+	testHandler.VerifyEmail(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+}
+
+func TestResendVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// нормальная работа
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/verify/resend", nil)
+
+	service.EXPECT().ResendVerification(r.Context()).Return(nil)
+
+	testHandler.ResendVerification(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/users", w.Header().Get("Location"))
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/verify/resend", nil)
+
+	service.EXPECT().ResendVerification(r.Context()).Return(fmt.Errorf("service error"))
+
+	testHandler.ResendVerification(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+}
+
+func TestLogout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// данные для теста
+	cookieSent := &http.Cookie{
+		Name:    "session_id",
+		Value:   "some_sess_id",
+		Expires: time.Now().Add(time.Minute),
+	}
+
+	cookieExpected := &http.Cookie{
+		Name:    "session_id",
+		Value:   "some_sess_id",
+		Expires: time.Now().AddDate(0, 0, -1),
+	}
+
+	// нормальная работа
+	statusExpected := http.StatusFound
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+
+	r.AddCookie(cookieSent)
+
+	service.EXPECT().Logout(r.Context()).Return(nil)
+
+	testHandler.Logout(w, r)
+
+	result := w.Result()
+
+	assert.EqualValues(t, statusExpected, w.Code)
+	assert.EqualValues(t, 1, len(result.Cookies()))
+	assert.EqualValues(t, cookieExpected.Name, result.Cookies()[0].Name)
+	assert.EqualValues(t, cookieExpected.Value, result.Cookies()[0].Value)
+	assert.EqualValues(t, cookieExpected.Expires.Local().Day(), result.Cookies()[0].Expires.Local().Day())
+
+	err := result.Body.Close()
+	if err != nil {
+		t.Fatalf("error closing body: %v", err)
+	}
+
+	// ошибка сервиса
+	statusExpected = http.StatusFound
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/logout", nil)
+
+	r.AddCookie(cookieSent)
+
+	service.EXPECT().Logout(r.Context()).Return(fmt.Errorf("service error"))
+
+	testHandler.Logout(w, r)
+
+	result = w.Result()
 
 	assert.EqualValues(t, statusExpected, w.Code)
 	assert.EqualValues(t, 0, len(result.Cookies()))
@@ -701,7 +1350,7 @@ func TestLogout(t *testing.T) {
 	// сессия не уничтожена на сервере
 	statusExpected = http.StatusFound
 	w = httptest.NewRecorder()
-	r = httptest.NewRequest(http.MethodGet, "/logout", nil)
+	r = httptest.NewRequest(http.MethodPost, "/logout", nil)
 
 	r.AddCookie(cookieSent)
 
@@ -725,7 +1374,7 @@ func TestLogout(t *testing.T) {
 	// в запросе нет куки
 	statusExpected = http.StatusFound
 	w = httptest.NewRecorder()
-	r = httptest.NewRequest(http.MethodGet, "/logout", nil)
+	r = httptest.NewRequest(http.MethodPost, "/logout", nil)
 
 	service.EXPECT().Logout(r.Context()).Return(nil)
 
@@ -741,3 +1390,438 @@ func TestLogout(t *testing.T) {
 		t.Fatalf("error closing body: %v", err)
 	}
 }
+
+func TestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// запрос ссылки: ответ одинаковый для любого имени
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/forgot", nil)
+	r.ParseForm()
+	r.Form.Set("username", "some_user")
+
+	service.EXPECT().RequestPasswordReset(r.Context(), "some_user").Return(nil)
+
+	testHandler.ForgotPassword(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Если такой пользователь есть")
+
+	// запрос ссылки: ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/forgot", nil)
+	r.ParseForm()
+	r.Form.Set("username", "some_user")
+
+	service.EXPECT().RequestPasswordReset(r.Context(), "some_user").Return(fmt.Errorf("service error"))
+
+	testHandler.ForgotPassword(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// форма нового пароля
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/reset?token=some_token", nil)
+
+	testHandler.ResetPasswordForm(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `value="some_token"`)
+
+	// форма без токена
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/reset", nil)
+
+	testHandler.ResetPasswordForm(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// сброс пароля: старая кука больше не нужна
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/reset", nil)
+	r.ParseForm()
+	r.Form.Set("token", "some_token")
+	r.Form.Set("password", "new_pass")
+
+	service.EXPECT().ResetPassword(r.Context(), "some_token", "new_pass").Return(nil)
+
+	testHandler.ResetPassword(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/", w.Header().Get("Location"))
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "session_id=;")
+
+	// недействительная ссылка
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/reset", nil)
+	r.ParseForm()
+	r.Form.Set("token", "bad_token")
+	r.Form.Set("password", "new_pass")
+
+	service.EXPECT().ResetPassword(r.Context(), "bad_token", "new_pass").Return(congrats_service.ErrBadResetToken)
+
+	testHandler.ResetPassword(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "недействительна")
+
+	// пустой пароль
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/reset", nil)
+	r.ParseForm()
+	r.Form.Set("token", "some_token")
+	r.Form.Set("password", "")
+
+	service.EXPECT().ResetPassword(r.Context(), "some_token", "").Return(congrats_service.ErrEmptyPassword)
+
+	testHandler.ResetPassword(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/reset", nil)
+	r.ParseForm()
+	r.Form.Set("token", "some_token")
+	r.Form.Set("password", "new_pass")
+
+	service.EXPECT().ResetPassword(r.Context(), "some_token", "new_pass").Return(fmt.Errorf("service error"))
+
+	testHandler.ResetPassword(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+}
+
+func TestProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// данные для теста
+	us := &user.User{
+		ID:       1,
+		Username: "some_user",
+		Email:    "some@email.net",
+		Birthday: birthday.Birthday{Month: time.March, Day: 2},
+	}
+
+	// страница профиля
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/profile", nil)
+
+	service.EXPECT().GetProfile(r.Context()).Return(us, nil)
+
+	testHandler.Profile(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `value="some@email.net"`)
+	assert.Contains(t, w.Body.String(), `value="--03-02"`)
+	assert.Contains(t, w.Body.String(), "не подтверждена")
+
+	// страница профиля: ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/profile", nil)
+
+	service.EXPECT().GetProfile(r.Context()).Return(nil, fmt.Errorf("service error"))
+
+	testHandler.Profile(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// сохранение профиля
+	username, email, birth := "other_user", "other@email.net", "1990-03-02"
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile", nil)
+	r.ParseForm()
+	r.Form.Set("username", username)
+	r.Form.Set("email", email)
+	r.Form.Set("birthday", birth)
+	r.Form.Set("current_password", "some_pass")
+
+	service.EXPECT().UpdateProfile(r.Context(), congrats_service.ProfileUpdate{
+		Username:        &username,
+		Email:           &email,
+		Birthday:        &birth,
+		CurrentPassword: "some_pass",
+	}).Return(us, nil)
+
+	testHandler.UpdateProfile(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/profile", w.Header().Get("Location"))
+
+	// известные ошибки показываются пользователю
+	for _, tc := range []struct {
+		err    error
+		status int
+	}{
+		{user.ErrBadPassword, http.StatusForbidden},
+		{user.ErrUserExists, http.StatusConflict},
+		{congrats_service.ErrEmptyUsername, http.StatusBadRequest},
+		{congrats_service.ErrBadEmail, http.StatusBadRequest},
+		{congrats_service.ErrBadDateFormat, http.StatusBadRequest},
+		{birthday.ErrBirthdayInFuture, http.StatusBadRequest},
+		{birthday.ErrBadAge, http.StatusBadRequest},
+	} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPost, "/profile", nil)
+
+		service.EXPECT().UpdateProfile(r.Context(), gomock.Any()).Return(nil, tc.err)
+
+		testHandler.UpdateProfile(w, r)
+
+		assert.EqualValues(t, tc.status, w.Code, tc.err.Error())
+	}
+
+	// сохранение профиля: ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile", nil)
+
+	service.EXPECT().UpdateProfile(r.Context(), gomock.Any()).Return(nil, fmt.Errorf("service error"))
+
+	testHandler.UpdateProfile(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// смена пароля: кука заменяется новой сессией
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/password", nil)
+	r.ParseForm()
+	r.Form.Set("current_password", "old_pass")
+	r.Form.Set("new_password", "new_pass")
+
+	service.EXPECT().ChangePassword(r.Context(), "old_pass", "new_pass").Return(&session.Session{
+		SessID:  "new_sess_id",
+		UserID:  1,
+		Expires: time.Now().Unix() + 60,
+	}, nil)
+
+	testHandler.ChangePassword(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/profile", w.Header().Get("Location"))
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "session_id=new_sess_id")
+
+	// неверный текущий пароль
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/password", nil)
+
+	service.EXPECT().ChangePassword(r.Context(), "", "").Return(nil, user.ErrBadPassword)
+
+	testHandler.ChangePassword(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+
+	// пустой новый пароль
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/password", nil)
+
+	service.EXPECT().ChangePassword(r.Context(), "", "").Return(nil, congrats_service.ErrEmptyPassword)
+
+	testHandler.ChangePassword(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// смена пароля: ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/password", nil)
+
+	service.EXPECT().ChangePassword(r.Context(), "", "").Return(nil, fmt.Errorf("service error"))
+
+	testHandler.ChangePassword(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+}
+
+func TestAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := congrats_service.NewMockCongratulationsService(ctrl)
+
+	tmpl := template.Must(template.ParseGlob(templatesPath))
+
+	testHandler := NewServiceHandler(
+		tmpl,
+		service,
+		nil,
+		nil,
+		false,
+		zap.NewNop().Sugar(),
+	)
+
+	// выгрузка данных
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/profile/export", nil)
+
+	service.EXPECT().ExportData(r.Context()).Return(&congrats_service.Export{
+		User: &user.User{ID: 1, Username: "some_user"},
+	}, nil)
+
+	testHandler.ExportData(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"username":"some_user"`)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+
+	// выгрузка: ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/profile/export", nil)
+
+	service.EXPECT().ExportData(r.Context()).Return(nil, fmt.Errorf("service error"))
+
+	testHandler.ExportData(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// удаление аккаунта
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/delete", nil)
+	r.ParseForm()
+	r.Form.Set("password", "some_pass")
+
+	service.EXPECT().DeleteAccount(r.Context(), "some_pass").Return(nil)
+
+	testHandler.DeleteAccount(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/", w.Header().Get("Location"))
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "session_id=;")
+
+	// неверный пароль
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/delete", nil)
+
+	service.EXPECT().DeleteAccount(r.Context(), "").Return(user.ErrBadPassword)
+
+	testHandler.DeleteAccount(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+
+	// удаление: ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/delete", nil)
+
+	service.EXPECT().DeleteAccount(r.Context(), "").Return(fmt.Errorf("service error"))
+
+	testHandler.DeleteAccount(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// ссылка удаления на почту
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/delete/link", nil)
+
+	service.EXPECT().RequestAccountDeletion(r.Context()).Return(nil)
+
+	testHandler.RequestAccountDeletion(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "отправлена на вашу почту")
+
+	// почта не подтверждена
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/delete/link", nil)
+
+	service.EXPECT().RequestAccountDeletion(r.Context()).Return(congrats_service.ErrUnverifiedEmail)
+
+	testHandler.RequestAccountDeletion(w, r)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+
+	// ссылка удаления: ошибка сервиса
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/profile/delete/link", nil)
+
+	service.EXPECT().RequestAccountDeletion(r.Context()).Return(fmt.Errorf("service error"))
+
+	testHandler.RequestAccountDeletion(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/error", w.Header().Get("Location"))
+
+	// переход по ссылке показывает форму и ничего не удаляет
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/account/delete?token=some_token", nil)
+
+	testHandler.DeleteAccountForm(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `value="some_token"`)
+
+	// ссылка без токена
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/account/delete", nil)
+
+	testHandler.DeleteAccountForm(w, r)
+
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+
+	// удаление по ссылке
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/account/delete", nil)
+	r.ParseForm()
+	r.Form.Set("token", "some_token")
+
+	service.EXPECT().DeleteAccountByLink(r.Context(), "some_token").Return(nil)
+
+	testHandler.DeleteAccountByLink(w, r)
+
+	assert.EqualValues(t, http.StatusFound, w.Code)
+	assert.EqualValues(t, "/", w.Header().Get("Location"))
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "session_id=;")
+
+	// ошибки токена и сервиса
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{err: verification.ErrBadToken, status: http.StatusBadRequest},
+		{err: verification.ErrTokenExpired, status: http.StatusBadRequest},
+		{err: fmt.Errorf("service error"), status: http.StatusFound},
+	}
+	for _, tc := range cases {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPost, "/account/delete", nil)
+
+		service.EXPECT().DeleteAccountByLink(r.Context(), "").Return(tc.err)
+
+		testHandler.DeleteAccountByLink(w, r)
+
+		assert.EqualValues(t, tc.status, w.Code, tc.err.Error())
+	}
+}
@@ -0,0 +1,225 @@
+package ldap
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Минимальная реализация BER (X.690) - ровно то, что нужно для простого bind и поиска
+
+// классы и флаг составного элемента в первом байте тега
+const (
+	classApplication = 0x40
+	classContext     = 0x80
+	constructed      = 0x20
+)
+
+// универсальные теги
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x10 | constructed
+	tagSet         = 0x11 | constructed
+)
+
+// maxPacketSize - больше такого ответа от каталога сотрудников не ждем
+const maxPacketSize = 16 << 20
+
+// maxDepth - глубже элементы в сообщениях LDAP не вкладываются; без ограничения
+// сервер мог бы исчерпать стек рекурсией разбора
+const maxDepth = 16
+
+var (
+	ErrBadPacket = errors.New("bad ber packet")
+)
+
+// packet - разобранный элемент BER. У составных элементов заполнены children.
+type packet struct {
+	tag      byte
+	data     []byte
+	children []*packet
+}
+
+func (p *packet) isConstructed() bool {
+	return p.tag&constructed != 0
+}
+
+// str возвращает содержимое примитивного элемента как строку
+func (p *packet) str() string {
+	return string(p.data)
+}
+
+// int возвращает значение INTEGER или ENUMERATED
+func (p *packet) int() (int64, error) {
+	if len(p.data) == 0 || len(p.data) > 8 {
+		return 0, errors.Wrap(ErrBadPacket, "bad integer length")
+	}
+
+	// дополнительный код, старший байт первым
+	n := int64(int8(p.data[0]))
+	for _, b := range p.data[1:] {
+		n = n<<8 | int64(b)
+	}
+
+	return n, nil
+}
+
+// encode собирает элемент tag с содержимым из частей
+func encode(tag byte, parts ...[]byte) []byte {
+	size := 0
+	for _, part := range parts {
+		size += len(part)
+	}
+
+	out := make([]byte, 0, size+6)
+	out = append(out, tag)
+	out = appendLength(out, size)
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+
+	return out
+}
+
+func appendLength(out []byte, n int) []byte {
+	if n < 0x80 {
+		return append(out, byte(n))
+	}
+
+	var buf [4]byte
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = byte(n)
+		n >>= 8
+	}
+
+	out = append(out, 0x80|byte(len(buf)-i))
+	return append(out, buf[i:]...)
+}
+
+func encodeString(tag byte, s string) []byte {
+	return encode(tag, []byte(s))
+}
+
+func encodeInt(tag byte, n int64) []byte {
+	// минимальное число байт дополнительного кода
+	size := 1
+	for size < 8 && (n >= 1<<(8*size-1) || n < -(1<<(8*size-1))) {
+		size++
+	}
+
+	data := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		data[i] = byte(n)
+		n >>= 8
+	}
+
+	return encode(tag, data)
+}
+
+func encodeBool(b bool) []byte {
+	if b {
+		return encode(tagBoolean, []byte{0xff})
+	}
+
+	return encode(tagBoolean, []byte{0x00})
+}
+
+// reader - откуда читаются элементы: соединение (через bufio) или содержимое составного элемента
+type reader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// readPacket читает из r один элемент целиком
+func readPacket(r reader) (*packet, error) {
+	return readNested(r, 0)
+}
+
+// readNested читает элемент, вложенный в другие на глубину depth
+func readNested(r reader, depth int) (*packet, error) {
+	if depth > maxDepth {
+		return nil, errors.Wrap(ErrBadPacket, "packet is nested too deep")
+	}
+
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if tag&0x1f == 0x1f {
+		return nil, errors.Wrap(ErrBadPacket, "multi-byte tags are not supported")
+	}
+
+	size, err := readLength(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, size)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePacket(tag, data, depth)
+}
+
+func readLength(r io.ByteReader) (int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b < 0x80 {
+		return int(b), nil
+	}
+
+	count := int(b & 0x7f)
+	if count == 0 || count > 4 {
+		return 0, errors.Wrap(ErrBadPacket, "unsupported length")
+	}
+
+	size := 0
+	for i := 0; i < count; i++ {
+		b, err = r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		size = size<<8 | int(b)
+	}
+	if size > maxPacketSize {
+		return 0, errors.Wrap(ErrBadPacket, "packet is too large")
+	}
+
+	return size, nil
+}
+
+// parsePacket разбирает содержимое элемента; вложенные элементы составного - рекурсивно
+func parsePacket(tag byte, data []byte, depth int) (*packet, error) {
+	p := &packet{
+		tag:  tag,
+		data: data,
+	}
+	if !p.isConstructed() {
+		return p, nil
+	}
+
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		child, err := readNested(r, depth+1)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errors.Wrap(ErrBadPacket, "truncated packet")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p.children = append(p.children, child)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,97 @@
+package ldap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeInt(t *testing.T) {
+	assert.EqualValues(t, []byte{0x02, 0x01, 0x00}, encodeInt(tagInteger, 0))
+	assert.EqualValues(t, []byte{0x02, 0x01, 0x7f}, encodeInt(tagInteger, 127))
+	assert.EqualValues(t, []byte{0x02, 0x02, 0x00, 0x80}, encodeInt(tagInteger, 128))
+	assert.EqualValues(t, []byte{0x02, 0x01, 0xff}, encodeInt(tagInteger, -1))
+	assert.EqualValues(t, []byte{0x0a, 0x01, 0x02}, encodeInt(tagEnumerated, 2))
+
+	// разбирается обратно
+	for _, n := range []int64{0, 1, 127, 128, 255, 256, 65535, 1 << 31, -1, -128, -129} {
+		p, err := readPacket(bytes.NewReader(encodeInt(tagInteger, n)))
+
+		assert.NoError(t, err)
+
+		got, err := p.int()
+
+		assert.NoError(t, err)
+		assert.EqualValues(t, n, got)
+	}
+}
+
+func TestReadPacket(t *testing.T) {
+	// нормальная работа: длинная форма длины и вложенные элементы
+	long := strings.Repeat("a", 300)
+	data := encode(tagSequence, encodeString(tagOctetString, long), encodeBool(true))
+
+	assert.EqualValues(t, []byte{0x30, 0x82, 0x01, 0x33, 0x04, 0x82, 0x01, 0x2c}, data[:8])
+
+	p, err := readPacket(bytes.NewReader(data))
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, tagSequence, p.tag)
+	assert.Len(t, p.children, 2)
+	assert.EqualValues(t, long, p.children[0].str())
+	assert.EqualValues(t, []byte{0xff}, p.children[1].data)
+
+	// обрезанный элемент
+	_, err = readPacket(bytes.NewReader(data[:100]))
+
+	assert.Error(t, err)
+
+	// вложенный элемент длиннее родителя
+	_, err = readPacket(bytes.NewReader([]byte{0x30, 0x03, 0x04, 0x05, 0x61}))
+
+	assert.ErrorIs(t, err, ErrBadPacket)
+
+	// слишком большой
+	_, err = readPacket(bytes.NewReader([]byte{0x04, 0x84, 0x7f, 0xff, 0xff, 0xff}))
+
+	assert.ErrorIs(t, err, ErrBadPacket)
+
+	// слишком глубокая вложенность
+	nested := encodeBool(true)
+	for i := 0; i <= maxDepth; i++ {
+		nested = encode(tagSequence, nested)
+	}
+
+	_, err = readPacket(bytes.NewReader(nested))
+
+	assert.ErrorIs(t, err, ErrBadPacket)
+
+	_, err = readPacket(bytes.NewReader(nested[2:]))
+
+	assert.NoError(t, err)
+}
+
+// ответы приходят от сервера по сети: любой набор байт должен разбираться без паники
+func FuzzReadPacket(f *testing.F) {
+	f.Add(encode(tagSequence, encodeString(tagOctetString, strings.Repeat("a", 300)), encodeBool(true)))
+	f.Add(encode(opSearchResultEntry,
+		encodeString(tagOctetString, "uid=alice,ou=people"),
+		encode(tagSequence, encode(tagSequence, encodeString(tagOctetString, "mail"), encode(tagSet, encodeString(tagOctetString, "alice@example.com")))),
+	))
+	f.Add(encode(opSearchResultDone, encodeInt(tagEnumerated, 49), encodeString(tagOctetString, ""), encodeString(tagOctetString, "invalid credentials")))
+	f.Add([]byte{0x30, 0x03, 0x04, 0x05, 0x61})
+	f.Add([]byte{0x30, 0x84, 0x00, 0xff, 0xff, 0xff, 0x04, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p, err := readPacket(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+
+		_, _ = p.int()
+		_ = checkResult(p)
+		_, _ = parseEntry(p)
+	})
+}
@@ -0,0 +1,285 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// протокольные операции (RFC 4511, 4.2-4.5)
+const (
+	opBindRequest           = classApplication | constructed | 0
+	opBindResponse          = classApplication | constructed | 1
+	opUnbindRequest         = classApplication | 2
+	opSearchRequest         = classApplication | constructed | 3
+	opSearchResultEntry     = classApplication | constructed | 4
+	opSearchResultDone      = classApplication | constructed | 5
+	opSearchResultReference = classApplication | constructed | 19
+
+	authSimple = classContext | 0
+)
+
+const (
+	protocolVersion   = 3
+	scopeWholeSubtree = 2
+	derefNever        = 0
+
+	resultSuccess            = 0
+	resultInvalidCredentials = 49
+)
+
+var (
+	ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+	ErrBadResponse        = errors.New("ldap: bad response")
+)
+
+// ResultError - сервер выполнил операцию с ошибкой
+type ResultError struct {
+	Code    int64
+	Message string
+}
+
+func (e *ResultError) Error() string {
+	return fmt.Sprintf("ldap: result code %d: %s", e.Code, e.Message)
+}
+
+// Entry - найденная запись каталога
+type Entry struct {
+	DN         string
+	Attributes map[string][]string // имена атрибутов в нижнем регистре
+}
+
+// Get возвращает первое значение атрибута или пустую строку
+func (e *Entry) Get(name string) string {
+	values := e.Attributes[strings.ToLower(name)]
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// Conn - соединение с LDAP-сервером. Поддерживаются только простой bind и поиск;
+// операции выполняются по одной, без параллельных запросов в одном соединении.
+// Клиент свой, а не github.com/go-ldap/ldap: ради двух операций не хочется тянуть
+// в vendor библиотеку с ее зависимостями (asn1-ber, go-ntlmssp и др.). Ответы сервера
+// разбираются без доверия к нему - см. FuzzReadPacket.
+type Conn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	timeout time.Duration // на каждую операцию
+	lastID  int64
+}
+
+// Dial подключается к addr (host:port); с tlsConfig - сразу по TLS (ldaps)
+func Dial(ctx context.Context, addr string, tlsConfig *tls.Config, timeout time.Duration) (*Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conn{
+		conn:    conn,
+		r:       bufio.NewReader(conn),
+		timeout: timeout,
+	}, nil
+}
+
+// Close вежливо завершает сессию и закрывает соединение
+func (c *Conn) Close() error {
+	// ответа на unbind не бывает, ошибка отправки не важна
+	_, _ = c.request(encode(opUnbindRequest))
+
+	return c.conn.Close()
+}
+
+// Bind входит простым bind; пустые dn и пароль - анонимный вход
+func (c *Conn) Bind(dn, password string) error {
+	id, err := c.request(encode(opBindRequest,
+		encodeInt(tagInteger, protocolVersion),
+		encodeString(tagOctetString, dn),
+		encodeString(authSimple, password),
+	))
+	if err != nil {
+		return err
+	}
+
+	op, err := c.response(id)
+	if err != nil {
+		return err
+	}
+	if op.tag != opBindResponse {
+		return errors.Wrap(ErrBadResponse, "expected bind response")
+	}
+
+	return checkResult(op)
+}
+
+// Search ищет записи по фильтру во всем поддереве baseDN и возвращает атрибуты attributes.
+// Если сервер вернул не все записи (например, сработал лимит размера), возвращается ошибка,
+// а не часть результата: по неполному списку нельзя решать, кто уволился.
+func (c *Conn) Search(baseDN, filter string, attributes []string) ([]*Entry, error) {
+	compiled, err := compileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make([][]byte, 0, len(attributes))
+	for _, a := range attributes {
+		attrs = append(attrs, encodeString(tagOctetString, a))
+	}
+
+	id, err := c.request(encode(opSearchRequest,
+		encodeString(tagOctetString, baseDN),
+		encodeInt(tagEnumerated, scopeWholeSubtree),
+		encodeInt(tagEnumerated, derefNever),
+		encodeInt(tagInteger, 0), // без ограничения числа записей
+		encodeInt(tagInteger, 0), // и времени поиска
+		encodeBool(false),
+		compiled,
+		encode(tagSequence, attrs...),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0)
+	for {
+		op, err := c.response(id)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.tag {
+		case opSearchResultEntry:
+			entry, err := parseEntry(op)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, entry)
+		case opSearchResultReference:
+			// ссылки на другие серверы не обходим
+		case opSearchResultDone:
+			err = checkResult(op)
+			if err != nil {
+				return nil, err
+			}
+
+			return entries, nil
+		default:
+			return nil, errors.Wrap(ErrBadResponse, fmt.Sprintf("unexpected operation 0x%x", op.tag))
+		}
+	}
+}
+
+// request отправляет LDAPMessage с операцией op и возвращает его номер
+func (c *Conn) request(op []byte) (int64, error) {
+	c.lastID++
+
+	err := c.send(encode(tagSequence, encodeInt(tagInteger, c.lastID), op))
+	if err != nil {
+		return 0, err
+	}
+
+	return c.lastID, nil
+}
+
+func (c *Conn) send(msg []byte) error {
+	err := c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.conn.Write(msg)
+	return err
+}
+
+// response читает очередное сообщение - ответ на запрос id - и возвращает его операцию
+func (c *Conn) response(id int64) (*packet, error) {
+	err := c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := readPacket(c.r)
+	if err != nil {
+		return nil, err
+	}
+	if msg.tag != tagSequence || len(msg.children) < 2 {
+		return nil, errors.Wrap(ErrBadResponse, "bad message")
+	}
+
+	msgID, err := msg.children[0].int()
+	if err != nil {
+		return nil, err
+	}
+	if msgID != id {
+		// 0 - уведомление сервера, например о разрыве соединения
+		return nil, errors.Wrap(ErrBadResponse, fmt.Sprintf("unexpected message id %d", msgID))
+	}
+
+	return msg.children[1], nil
+}
+
+// checkResult проверяет LDAPResult в ответе
+func checkResult(op *packet) error {
+	if len(op.children) < 3 {
+		return errors.Wrap(ErrBadResponse, "bad result")
+	}
+
+	code, err := op.children[0].int()
+	if err != nil {
+		return err
+	}
+
+	switch code {
+	case resultSuccess:
+		return nil
+	case resultInvalidCredentials:
+		return ErrInvalidCredentials
+	default:
+		return &ResultError{
+			Code:    code,
+			Message: op.children[2].str(),
+		}
+	}
+}
+
+func parseEntry(op *packet) (*Entry, error) {
+	if len(op.children) != 2 {
+		return nil, errors.Wrap(ErrBadResponse, "bad search entry")
+	}
+
+	entry := &Entry{
+		DN:         op.children[0].str(),
+		Attributes: make(map[string][]string),
+	}
+
+	for _, attr := range op.children[1].children {
+		if len(attr.children) != 2 {
+			return nil, errors.Wrap(ErrBadResponse, "bad attribute")
+		}
+
+		name := strings.ToLower(attr.children[0].str())
+		for _, v := range attr.children[1].children {
+			entry.Attributes[name] = append(entry.Attributes[name], v.str())
+		}
+	}
+
+	return entry, nil
+}
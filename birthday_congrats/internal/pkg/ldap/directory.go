@@ -0,0 +1,127 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Person - сотрудник из каталога. Значения не проверяются: это дело сервиса.
+type Person struct {
+	ID         string // неизменный идентификатор записи (например, uid)
+	Name       string
+	Email      string
+	Birthday   string // YYYY-MM-DD или --MM-DD
+	Department string
+	Active     bool
+}
+
+// Mapping - из каких атрибутов записи брать данные сотрудника
+type Mapping struct {
+	ID            string
+	Name          string
+	Email         string
+	Birthday      string
+	Department    string
+	Status        string // пустой - все найденные записи считаются работающими
+	InactiveValue string // значение Status у уволенных (без учета регистра)
+}
+
+// Source - откуда берется список сотрудников
+type Source interface {
+	People(ctx context.Context) ([]Person, error)
+}
+
+// Directory читает сотрудников с LDAP-сервера: на каждый запрос - новое соединение,
+// bind и поиск по фильтру
+type Directory struct {
+	addr         string
+	tlsConfig    *tls.Config // nil - без TLS
+	bindDN       string
+	bindPassword string
+	baseDN       string
+	filter       string
+	mapping      Mapping
+	timeout      time.Duration
+	logger       *zap.SugaredLogger
+}
+
+var _ Source = &Directory{}
+
+func NewDirectory(
+	addr string,
+	tlsConfig *tls.Config,
+	bindDN string,
+	bindPassword string,
+	baseDN string,
+	filter string,
+	mapping Mapping,
+	timeout time.Duration,
+	logger *zap.SugaredLogger,
+) *Directory {
+	return &Directory{
+		addr:         addr,
+		tlsConfig:    tlsConfig,
+		bindDN:       bindDN,
+		bindPassword: bindPassword,
+		baseDN:       baseDN,
+		filter:       filter,
+		mapping:      mapping,
+		timeout:      timeout,
+		logger:       logger,
+	}
+}
+
+// People возвращает всех сотрудников, найденных по фильтру. Записи без идентификатора
+// или почты пропускаются: их не с кем сопоставить.
+func (d *Directory) People(ctx context.Context) ([]Person, error) {
+	conn, err := Dial(ctx, d.addr, d.tlsConfig, d.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = conn.Bind(d.bindDN, d.bindPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := []string{d.mapping.ID, d.mapping.Name, d.mapping.Email, d.mapping.Birthday, d.mapping.Department}
+	if d.mapping.Status != "" {
+		attributes = append(attributes, d.mapping.Status)
+	}
+
+	entries, err := conn.Search(d.baseDN, d.filter, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	people := make([]Person, 0, len(entries))
+	for _, e := range entries {
+		p := Person{
+			ID:         strings.TrimSpace(e.Get(d.mapping.ID)),
+			Name:       strings.TrimSpace(e.Get(d.mapping.Name)),
+			Email:      strings.TrimSpace(e.Get(d.mapping.Email)),
+			Birthday:   strings.TrimSpace(e.Get(d.mapping.Birthday)),
+			Department: strings.TrimSpace(e.Get(d.mapping.Department)),
+			Active:     true,
+		}
+		if p.ID == "" || p.Email == "" {
+			d.logger.Warnf("LDAP entry %q has no %s or %s, skipping", e.DN, d.mapping.ID, d.mapping.Email)
+			continue
+		}
+
+		if d.mapping.Status != "" && strings.EqualFold(e.Get(d.mapping.Status), d.mapping.InactiveValue) {
+			p.Active = false
+		}
+
+		people = append(people, p)
+	}
+
+	d.logger.Infof("Found %d people in LDAP (%d entries)", len(people), len(entries))
+
+	return people, nil
+}
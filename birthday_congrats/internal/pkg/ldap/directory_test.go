@@ -0,0 +1,248 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeServer - LDAP-сервер для тестов: проверяет простой bind и на любой поиск
+// отдает все свои записи, запоминая, что искали
+type fakeServer struct {
+	listener   net.Listener
+	bindDN     string
+	password   string
+	entries    []*Entry
+	mu         sync.Mutex
+	doneCode   int64 // код результата поиска
+	baseDN     string
+	filter     []byte
+	attributes []string
+}
+
+func newFakeServer(t *testing.T, bindDN, password string, entries []*Entry) *fakeServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cant listen: %v", err)
+	}
+
+	s := &fakeServer{
+		listener: listener,
+		bindDN:   bindDN,
+		password: password,
+		entries:  entries,
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		msg, err := readPacket(r)
+		if err != nil {
+			return
+		}
+
+		id, _ := msg.children[0].int()
+		op := msg.children[1]
+
+		switch op.tag {
+		case opBindRequest:
+			code := int64(resultSuccess)
+			if op.children[1].str() != s.bindDN || op.children[2].str() != s.password {
+				code = resultInvalidCredentials
+			}
+
+			conn.Write(fakeMessage(id, fakeResult(opBindResponse, code)))
+		case opSearchRequest:
+			s.mu.Lock()
+			s.baseDN = op.children[0].str()
+			s.filter = encode(op.children[6].tag, op.children[6].data)
+			s.attributes = nil
+			for _, a := range op.children[7].children {
+				s.attributes = append(s.attributes, a.str())
+			}
+
+			doneCode := s.doneCode
+			s.mu.Unlock()
+
+			for _, e := range s.entries {
+				conn.Write(fakeMessage(id, fakeEntry(e)))
+			}
+
+			conn.Write(fakeMessage(id, fakeResult(opSearchResultDone, doneCode)))
+		case opUnbindRequest:
+			return
+		}
+	}
+}
+
+func fakeMessage(id int64, op []byte) []byte {
+	return encode(tagSequence, encodeInt(tagInteger, id), op)
+}
+
+func fakeResult(tag byte, code int64) []byte {
+	return encode(tag,
+		encodeInt(tagEnumerated, code),
+		encodeString(tagOctetString, ""),
+		encodeString(tagOctetString, "fake server says so"),
+	)
+}
+
+func fakeEntry(e *Entry) []byte {
+	// порядок атрибутов - для воспроизводимости
+	names := make([]string, 0, len(e.Attributes))
+	for name := range e.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make([][]byte, 0, len(names))
+	for _, name := range names {
+		values := make([][]byte, 0, len(e.Attributes[name]))
+		for _, v := range e.Attributes[name] {
+			values = append(values, encodeString(tagOctetString, v))
+		}
+
+		attrs = append(attrs, encode(tagSequence, encodeString(tagOctetString, name), encode(tagSet, values...)))
+	}
+
+	return encode(opSearchResultEntry, encodeString(tagOctetString, e.DN), encode(tagSequence, attrs...))
+}
+
+func newTestDirectory(addr, password string) *Directory {
+	return NewDirectory(
+		addr,
+		nil,
+		"cn=sync,dc=example,dc=com",
+		password,
+		"ou=people,dc=example,dc=com",
+		"(objectClass=inetOrgPerson)",
+		Mapping{
+			ID:            "uid",
+			Name:          "displayName",
+			Email:         "mail",
+			Birthday:      "birthDate",
+			Department:    "departmentNumber",
+			Status:        "employeeStatus",
+			InactiveValue: "terminated",
+		},
+		time.Second,
+		zap.NewNop().Sugar(),
+	)
+}
+
+func TestDirectoryPeople(t *testing.T) {
+	// данные для теста: имена атрибутов в ответе могут быть в любом регистре
+	entries := []*Entry{
+		{DN: "uid=alice,ou=people,dc=example,dc=com", Attributes: map[string][]string{
+			"uid":              {"alice"},
+			"displayName":      {"Alice"},
+			"mail":             {"alice@example.com", "a@example.com"},
+			"birthDate":        {"1990-05-10"},
+			"departmentNumber": {"Бухгалтерия"},
+		}},
+		{DN: "uid=bob,ou=people,dc=example,dc=com", Attributes: map[string][]string{
+			"uid":            {"bob"},
+			"displayName":    {"Bob"},
+			"MAIL":           {"bob@example.com"},
+			"employeeStatus": {"Terminated"},
+		}},
+		{DN: "uid=robot,ou=people,dc=example,dc=com", Attributes: map[string][]string{
+			"uid": {"robot"},
+		}},
+	}
+
+	server := newFakeServer(t, "cn=sync,dc=example,dc=com", "secret", entries)
+
+	// нормальная работа: записи без почты пропускаются
+	people, err := newTestDirectory(server.addr(), "secret").People(context.Background())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []Person{
+		{ID: "alice", Name: "Alice", Email: "alice@example.com", Birthday: "1990-05-10", Department: "Бухгалтерия", Active: true},
+		{ID: "bob", Name: "Bob", Email: "bob@example.com", Active: false},
+	}, people)
+
+	server.mu.Lock()
+	expectedFilter, _ := compileFilter("(objectClass=inetOrgPerson)")
+	assert.EqualValues(t, "ou=people,dc=example,dc=com", server.baseDN)
+	assert.EqualValues(t, expectedFilter, server.filter)
+	assert.EqualValues(t, []string{"uid", "displayName", "mail", "birthDate", "departmentNumber", "employeeStatus"}, server.attributes)
+	server.mu.Unlock()
+
+	// неверный пароль
+	_, err = newTestDirectory(server.addr(), "wrong").People(context.Background())
+
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// сервер вернул не все записи
+	server.mu.Lock()
+	server.doneCode = 4 // sizeLimitExceeded
+	server.mu.Unlock()
+
+	_, err = newTestDirectory(server.addr(), "secret").People(context.Background())
+
+	resultErr := &ResultError{}
+	assert.ErrorAs(t, err, &resultErr)
+	assert.EqualValues(t, 4, resultErr.Code)
+
+	// сервер недоступен
+	server.listener.Close()
+
+	_, err = newTestDirectory(server.addr(), "secret").People(context.Background())
+
+	assert.Error(t, err)
+}
+
+func TestSearchBadFilter(t *testing.T) {
+	server := newFakeServer(t, "", "", nil)
+
+	conn, err := Dial(context.Background(), server.addr(), nil, time.Second)
+
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// анонимный вход
+	assert.NoError(t, conn.Bind("", ""))
+
+	_, err = conn.Search("dc=example,dc=com", "objectClass=person", nil)
+
+	assert.ErrorIs(t, err, ErrBadFilter)
+
+	// соединение после этого рабочее
+	entries, err := conn.Search("dc=example,dc=com", "(objectClass=*)", []string{"cn"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,194 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// теги фильтров поиска (RFC 4511, 4.5.1)
+const (
+	filterAnd            = classContext | constructed | 0
+	filterOr             = classContext | constructed | 1
+	filterNot            = classContext | constructed | 2
+	filterEqualityMatch  = classContext | constructed | 3
+	filterSubstrings     = classContext | constructed | 4
+	filterGreaterOrEqual = classContext | constructed | 5
+	filterLessOrEqual    = classContext | constructed | 6
+	filterPresent        = classContext | 7
+	filterApproxMatch    = classContext | constructed | 8
+
+	substringInitial = classContext | 0
+	substringAny     = classContext | 1
+	substringFinal   = classContext | 2
+)
+
+var (
+	ErrBadFilter = errors.New("bad ldap filter")
+)
+
+// compileFilter переводит фильтр в строковой записи RFC 4515, например
+// (&(objectClass=inetOrgPerson)(!(employeeType=contractor))), в BER.
+// Расширенные сравнения (:=) не поддерживаются.
+func compileFilter(s string) ([]byte, error) {
+	f, rest, err := parseFilter(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, errors.Wrap(ErrBadFilter, "unexpected "+rest)
+	}
+
+	return f, nil
+}
+
+// parseFilter разбирает один фильтр в скобках в начале s и возвращает остаток строки
+func parseFilter(s string) ([]byte, string, error) {
+	if !strings.HasPrefix(s, "(") || len(s) < 2 {
+		return nil, "", errors.Wrap(ErrBadFilter, "filter must be in parentheses")
+	}
+	s = s[1:]
+
+	switch s[0] {
+	case '&', '|':
+		tag := byte(filterAnd)
+		if s[0] == '|' {
+			tag = filterOr
+		}
+
+		s = s[1:]
+		parts := make([][]byte, 0)
+		for !strings.HasPrefix(s, ")") {
+			part, rest, err := parseFilter(s)
+			if err != nil {
+				return nil, "", err
+			}
+
+			parts = append(parts, part)
+			s = rest
+		}
+		if len(parts) == 0 {
+			return nil, "", errors.Wrap(ErrBadFilter, "empty filter list")
+		}
+
+		return encode(tag, parts...), s[1:], nil
+	case '!':
+		part, rest, err := parseFilter(s[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", errors.Wrap(ErrBadFilter, "unclosed not")
+		}
+
+		return encode(filterNot, part), rest[1:], nil
+	default:
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return nil, "", errors.Wrap(ErrBadFilter, "unclosed parenthesis")
+		}
+
+		item, err := parseItem(s[:end])
+		if err != nil {
+			return nil, "", err
+		}
+
+		return item, s[end+1:], nil
+	}
+}
+
+// parseItem разбирает простое условие attr=value, attr>=value, attr<=value или attr~=value
+func parseItem(s string) ([]byte, error) {
+	eq := strings.IndexByte(s, '=')
+	if eq <= 0 {
+		return nil, errors.Wrap(ErrBadFilter, "bad condition "+s)
+	}
+
+	attr, value := s[:eq], s[eq+1:]
+
+	tag := byte(filterEqualityMatch)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag = filterGreaterOrEqual
+	case '<':
+		tag = filterLessOrEqual
+	case '~':
+		tag = filterApproxMatch
+	case ':':
+		return nil, errors.Wrap(ErrBadFilter, "extensible match is not supported")
+	}
+	if tag != filterEqualityMatch {
+		attr = attr[:len(attr)-1]
+	}
+	if attr == "" {
+		return nil, errors.Wrap(ErrBadFilter, "bad condition "+s)
+	}
+
+	if tag != filterEqualityMatch || !strings.Contains(value, "*") {
+		v, err := unescapeValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return encode(tag, encodeString(tagOctetString, attr), encodeString(tagOctetString, v)), nil
+	}
+
+	if value == "*" {
+		return encodeString(filterPresent, attr), nil
+	}
+
+	pieces := strings.Split(value, "*")
+	subs := make([][]byte, 0, len(pieces))
+	for i, piece := range pieces {
+		if piece == "" {
+			continue
+		}
+
+		v, err := unescapeValue(piece)
+		if err != nil {
+			return nil, err
+		}
+
+		tag := byte(substringAny)
+		switch i {
+		case 0:
+			tag = substringInitial
+		case len(pieces) - 1:
+			tag = substringFinal
+		}
+
+		subs = append(subs, encodeString(tag, v))
+	}
+
+	return encode(filterSubstrings, encodeString(tagOctetString, attr), encode(tagSequence, subs...)), nil
+}
+
+// unescapeValue раскрывает экранирование \XX (например, \2a - звездочка)
+func unescapeValue(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if i+3 > len(s) {
+			return "", errors.Wrap(ErrBadFilter, "bad escape in "+s)
+		}
+
+		decoded, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", errors.Wrap(ErrBadFilter, "bad escape in "+s)
+		}
+
+		b.Write(decoded)
+		i += 2
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,81 @@
+package ldap
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileFilter(t *testing.T) {
+	// нормальная работа
+	f, err := compileFilter("(cn=a)")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []byte{0xa3, 0x07, 0x04, 0x02, 'c', 'n', 0x04, 0x01, 'a'}, f)
+
+	// наличие атрибута
+	f, err = compileFilter("(mail=*)")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []byte{0x87, 0x04, 'm', 'a', 'i', 'l'}, f)
+
+	// подстроки
+	f, err = compileFilter("(mail=a*b*c)")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []byte{
+		0xa4, 0x11,
+		0x04, 0x04, 'm', 'a', 'i', 'l',
+		0x30, 0x09, 0x80, 0x01, 'a', 0x81, 0x01, 'b', 0x82, 0x01, 'c',
+	}, f)
+
+	// составной фильтр и экранирование
+	f, err = compileFilter(" (&(objectClass=person)(!(cn=\\2a))) ")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, encode(filterAnd,
+		encode(filterEqualityMatch, encodeString(tagOctetString, "objectClass"), encodeString(tagOctetString, "person")),
+		encode(filterNot, encode(filterEqualityMatch, encodeString(tagOctetString, "cn"), encodeString(tagOctetString, "*"))),
+	), f)
+
+	// сравнения
+	f, err = compileFilter("(|(age>=18)(age<=65))")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, encode(filterOr,
+		encode(filterGreaterOrEqual, encodeString(tagOctetString, "age"), encodeString(tagOctetString, "18")),
+		encode(filterLessOrEqual, encodeString(tagOctetString, "age"), encodeString(tagOctetString, "65")),
+	), f)
+
+	// ошибки
+	for _, bad := range []string{"", "cn=a", "(cn=a", "(=a)", "(cn)", "(&)", "(cn=a))", "(!(cn=a)", "(cn=\\2)", "(cn:dn:=a)"} {
+		_, err = compileFilter(bad)
+
+		assert.ErrorIs(t, err, ErrBadFilter, bad)
+	}
+}
+
+// фильтр задается в настройках: любая строка либо отвергается, либо дает корректный BER
+func FuzzCompileFilter(f *testing.F) {
+	for _, seed := range []string{
+		"(cn=a)", "(mail=*)", "(mail=a*b*c)", " (&(objectClass=person)(!(cn=\\2a))) ", "(|(age>=18)(age<=65))",
+		"(cn~=a)", "(cn=a", "(&)", "(cn=\\2)", "(cn:dn:=a)",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		compiled, err := compileFilter(s)
+		if err != nil {
+			assert.ErrorIs(t, err, ErrBadFilter, s)
+			return
+		}
+
+		r := bytes.NewReader(compiled)
+		_, err = readPacket(r)
+
+		assert.NoError(t, err, s)
+		assert.Zero(t, r.Len(), s)
+	})
+}
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: directory.go
+
+// Package ldap is a generated GoMock package.
+package ldap
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSource is a mock of Source interface.
+type MockSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockSourceMockRecorder
+}
+
+// MockSourceMockRecorder is the mock recorder for MockSource.
+type MockSourceMockRecorder struct {
+	mock *MockSource
+}
+
+// NewMockSource creates a new mock instance.
+func NewMockSource(ctrl *gomock.Controller) *MockSource {
+	mock := &MockSource{ctrl: ctrl}
+	mock.recorder = &MockSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSource) EXPECT() *MockSourceMockRecorder {
+	return m.recorder
+}
+
+// People mocks base method.
+func (m *MockSource) People(ctx context.Context) ([]Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "People", ctx)
+	ret0, _ := ret[0].([]Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// People indicates an expected call of People.
+func (mr *MockSourceMockRecorder) People(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "People", reflect.TypeOf((*MockSource)(nil).People), ctx)
+}
@@ -2,7 +2,10 @@ package middlware
 
 import (
 	"birthday_congrats/internal/pkg/session"
+	"context"
+	"crypto/subtle"
 	"net/http"
+	"strings"
 
 	"go.uber.org/zap"
 )
@@ -20,3 +23,109 @@ func Auth(sm session.SessionsManager, logger *zap.SugaredLogger, next http.Handl
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// APIAuth - то же, что Auth, но для JSON API: вместо редиректа отвечает 401.
+// Сессия берется только из заголовка Authorization: проверки CSRF у API нет,
+// и кука, которую браузер подставит в подделанный запрос, здесь не подходит.
+func APIAuth(sm session.SessionsManager, logger *zap.SugaredLogger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := session.BearerToken(r)
+		if !ok {
+			logger.Warnf("auth error: no bearer token")
+			writeAPIError(w, logger, http.StatusUnauthorized, `{"error":{"code":"unauthorized","message":"no valid session"}}`)
+			return
+		}
+
+		sess, err := sm.CheckToken(r.Context(), token)
+		if err != nil {
+			logger.Warnf("auth error: %v", err)
+			writeAPIError(w, logger, http.StatusUnauthorized, `{"error":{"code":"unauthorized","message":"no valid session"}}`)
+			return
+		}
+
+		ctx := session.ContextWithSession(r.Context(), sess)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RoleChecker проверяет роль пользователя текущей сессии
+type RoleChecker interface {
+	HasRole(ctx context.Context, role string) (bool, error)
+}
+
+// RequireRole пропускает только пользователей с ролью role; ставится после Auth.
+// Сервис проверяет права сам, а здесь остальным просто не показываются чужие страницы.
+func RequireRole(checker RoleChecker, role string, logger *zap.SugaredLogger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, err := checker.HasRole(r.Context(), role)
+		if err != nil {
+			logger.Errorf("Error while checking role: %v", err)
+			http.Redirect(w, r, "/error", http.StatusFound)
+			return
+		}
+		if !ok {
+			logger.Warnf("access to %s without role %s", r.URL.Path, role)
+			http.Redirect(w, r, "/error", http.StatusFound)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIRequireRole - то же, что RequireRole, но для JSON API: ставится после APIAuth
+// и вместо редиректа отвечает 403
+func APIRequireRole(checker RoleChecker, role string, logger *zap.SugaredLogger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, err := checker.HasRole(r.Context(), role)
+		if err != nil {
+			logger.Errorf("Error while checking role: %v", err)
+			writeAPIError(w, logger, http.StatusInternalServerError, `{"error":{"code":"internal","message":"internal error"}}`)
+			return
+		}
+		if !ok {
+			logger.Warnf("access to %s without role %s", r.URL.Path, role)
+			writeAPIError(w, logger, http.StatusForbidden, `{"error":{"code":"forbidden","message":"`+role+` role required"}}`)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeAPIError отвечает ошибкой в формате JSON API
+func writeAPIError(w http.ResponseWriter, logger *zap.SugaredLogger, statusCode int, body string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_, err := w.Write([]byte(body + "\n"))
+	if err != nil {
+		logger.Errorf("Error while writing response: %v", err)
+	}
+}
+
+// bearerOK проверяет заголовок Authorization: Bearer <token>
+func bearerOK(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	// сравнение за постоянное время, чтобы токен нельзя было подобрать по времени ответа
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// SCIMAuth пропускает запросы HR-системы к /scim/v2 с заголовком Authorization: Bearer <token>
+func SCIMAuth(token string, logger *zap.SugaredLogger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !bearerOK(r, token) {
+			logger.Warnf("scim auth error from %s", r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.Header().Set("Content-Type", "application/scim+json; charset=utf-8")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, err := w.Write([]byte(`{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"401","detail":"no valid bearer token"}` + "\n"))
+			if err != nil {
+				logger.Errorf("Error while writing response: %v", err)
+			}
+			return
+		}
+
+		// токен есть только у доверенной системы, сервис пропускает ее к административным методам
+		next.ServeHTTP(w, r.WithContext(session.ContextAsSystem(r.Context())))
+	})
+}
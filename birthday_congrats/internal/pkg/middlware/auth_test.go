@@ -0,0 +1,128 @@
+package middlware
+
+import (
+	"birthday_congrats/internal/pkg/session"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// roleChecker - роль пользователя для теста
+type roleChecker struct {
+	role string
+	err  error
+}
+
+func (c *roleChecker) HasRole(ctx context.Context, role string) (bool, error) {
+	return c.role == role, c.err
+}
+
+func TestAPIAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sm := session.NewMockSessionsManager(ctrl)
+
+	var seen *session.Session
+	handler := APIAuth(sm, zap.NewNop().Sugar(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = session.SessionFromContext(r.Context())
+	}))
+
+	// данные для теста
+	sess := &session.Session{SessID: "some_token", UserID: 42}
+
+	// нормальная работа: токен из заголовка
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/me/password", nil)
+	r.Header.Set("Authorization", "Bearer some_token")
+	w := httptest.NewRecorder()
+
+	sm.EXPECT().CheckToken(r.Context(), "some_token").Return(sess, nil)
+
+	handler.ServeHTTP(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, sess, seen)
+
+	// только cookie: браузер подставит ее и в подделанный запрос, поэтому не подходит
+	seen = nil
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/me/password", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: "some_token"})
+	w = httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.EqualValues(t, http.StatusUnauthorized, w.Code)
+	assert.Nil(t, seen)
+
+	// сессии нет
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/me/password", nil)
+	r.Header.Set("Authorization", "Bearer unknown")
+	w = httptest.NewRecorder()
+
+	sm.EXPECT().CheckToken(r.Context(), "unknown").Return(nil, session.ErrNoSession)
+
+	handler.ServeHTTP(w, r)
+
+	assert.EqualValues(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"unauthorized"`)
+	assert.Nil(t, seen)
+}
+
+func TestRequireRole(t *testing.T) {
+	checker := &roleChecker{}
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	html := RequireRole(checker, "admin", zap.NewNop().Sugar(), next)
+	api := APIRequireRole(checker, "admin", zap.NewNop().Sugar(), next)
+
+	cases := []struct {
+		name       string
+		role       string
+		err        error
+		called     bool
+		htmlStatus int
+		apiStatus  int
+		apiBody    string
+	}{
+		{name: "нужная роль", role: "admin", called: true, htmlStatus: http.StatusOK, apiStatus: http.StatusOK},
+		{name: "другая роль", role: "employee", htmlStatus: http.StatusFound, apiStatus: http.StatusForbidden, apiBody: `"code":"forbidden"`},
+		{name: "ошибка сервиса", err: fmt.Errorf("service error"), htmlStatus: http.StatusFound, apiStatus: http.StatusInternalServerError, apiBody: `"code":"internal"`},
+	}
+	for _, tc := range cases {
+		checker.role, checker.err = tc.role, tc.err
+
+		// html: редирект на страницу ошибки
+		called = false
+		w := httptest.NewRecorder()
+
+		html.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+		assert.EqualValues(t, tc.called, called, tc.name)
+		assert.EqualValues(t, tc.htmlStatus, w.Code, tc.name)
+		if !tc.called {
+			assert.EqualValues(t, "/error", w.Header().Get("Location"), tc.name)
+		}
+
+		// API: ошибка в формате JSON
+		called = false
+		w = httptest.NewRecorder()
+
+		api.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/users/1/export", nil))
+
+		assert.EqualValues(t, tc.called, called, tc.name)
+		assert.EqualValues(t, tc.apiStatus, w.Code, tc.name)
+		if !tc.called {
+			assert.Contains(t, w.Header().Get("Content-Type"), "application/json", tc.name)
+			assert.Contains(t, w.Body.String(), tc.apiBody, tc.name)
+		}
+	}
+}
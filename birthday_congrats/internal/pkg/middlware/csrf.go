@@ -0,0 +1,83 @@
+package middlware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	CSRFField  = "csrf_token"   // скрытое поле формы с токеном
+	CSRFHeader = "X-CSRF-Token" // то же для запросов из скриптов
+
+	csrfCookie     = "csrf_token"
+	csrfTokenBytes = 32
+	// формы маленькие, самая большая - загрузка выгрузки сотрудников на /admin/import
+	maxFormBytes = 2 << 20
+)
+
+type csrfKey string
+
+const csrfTokenKey csrfKey = "csrfToken"
+
+// CSRFToken возвращает токен запроса, который нужно положить в поле CSRFField каждой формы
+func CSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey).(string)
+	return token
+}
+
+// CSRF защищает формы от подделки межсайтовых запросов (double submit cookie): у браузера
+// есть cookie со случайным токеном, и изменяющий запрос должен прислать тот же токен в форме
+// или в заголовке. Чужой сайт может заставить браузер отправить cookie, но не может ее прочитать.
+// Токен cookie не привязан к сессии, поэтому защищены и вход с регистрацией.
+func CSRF(secure bool, logger *zap.SugaredLogger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		cookie, err := r.Cookie(csrfCookie)
+		if err == nil && len(cookie.Value) == base64.RawURLEncoding.EncodedLen(csrfTokenBytes) {
+			token = cookie.Value
+		} else {
+			b := make([]byte, csrfTokenBytes)
+			_, err = rand.Read(b)
+			if err != nil {
+				logger.Errorf("Error while generating csrf token: %v", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			// новый токен не совпадет с присланным, изменяющий запрос ниже будет отклонен
+			token = base64.RawURLEncoding.EncodeToString(b)
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrfCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   secure,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			got := r.Header.Get(CSRFHeader)
+			if got == "" {
+				r.Body = http.MaxBytesReader(w, r.Body, maxFormBytes)
+				got = r.PostFormValue(CSRFField)
+			}
+
+			// сравнение за постоянное время, как и для остальных токенов
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				logger.Warnf("csrf check failed for %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+				http.Error(w, "Форма устарела, обновите страницу и отправьте ее снова", http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfTokenKey, token)))
+	})
+}
@@ -0,0 +1,88 @@
+package middlware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCSRF(t *testing.T) {
+	var seen string
+	handler := CSRF(true, zap.NewNop().Sugar(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = CSRFToken(r.Context())
+	}))
+
+	postForm := func(token string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/subscribe/1", strings.NewReader(url.Values{CSRFField: {token}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// первая страница: выдается cookie с токеном, токен доступен шаблону
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, 1, len(w.Result().Cookies()))
+
+	cookie := w.Result().Cookies()[0]
+
+	assert.EqualValues(t, csrfCookie, cookie.Name)
+	assert.EqualValues(t, cookie.Value, seen)
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, cookie.Secure)
+	assert.EqualValues(t, http.SameSiteLaxMode, cookie.SameSite)
+
+	// нормальная работа: токен из формы совпадает с cookie, новая cookie не выдается
+	seen = ""
+	w = postForm(cookie.Value, cookie)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, cookie.Value, seen)
+	assert.EqualValues(t, 0, len(w.Result().Cookies()))
+
+	// токен в заголовке
+	r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	r.Header.Set(CSRFHeader, cookie.Value)
+	r.AddCookie(cookie)
+	w = httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	// чужой токен
+	seen = ""
+	w = postForm("forged", cookie)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, seen)
+
+	// без токена в форме
+	w = postForm("", cookie)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+
+	// без cookie: выдается новая, запрос отклоняется
+	w = postForm(cookie.Value, nil)
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+	assert.EqualValues(t, 1, len(w.Result().Cookies()))
+	assert.NotEqualValues(t, cookie.Value, w.Result().Cookies()[0].Value)
+
+	// пустой токен в форме и в cookie не проходит
+	w = postForm("", &http.Cookie{Name: csrfCookie, Value: ""})
+
+	assert.EqualValues(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,146 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadMigration = errors.New("bad migration")
+	ErrNoMigration  = errors.New("no migration to roll back")
+)
+
+// имя файла миграции: 0001_init.up.sql, 0001_init.down.sql
+var fileNameRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration - одна версия схемы: запросы, которые ее применяют (Up) и откатывают (Down)
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// Load читает миграции из корня fsys. У каждой версии должны быть и up-, и down-файл.
+// Миграции возвращаются по возрастанию версии.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %v", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		parts := fileNameRe.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			return nil, errors.Wrapf(ErrBadMigration, "unexpected file %q", entry.Name())
+		}
+
+		version, err := strconv.Atoi(parts[1])
+		if err != nil || version <= 0 {
+			return nil, errors.Wrapf(ErrBadMigration, "bad version in %q", entry.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %q: %v", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		}
+		if m.Name != parts[2] {
+			return nil, errors.Wrapf(ErrBadMigration, "version %d has two names: %q and %q", version, m.Name, parts[2])
+		}
+
+		statements := splitStatements(string(data))
+		if len(statements) == 0 {
+			return nil, errors.Wrapf(ErrBadMigration, "%q has no statements", entry.Name())
+		}
+
+		if parts[3] == "up" {
+			m.Up = statements
+		} else {
+			m.Down = statements
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil || m.Down == nil {
+			return nil, errors.Wrapf(ErrBadMigration, "version %d must have both up and down files", m.Version)
+		}
+
+		migrations = append(migrations, m)
+	}
+
+	slices.SortFunc(migrations, func(a, b *Migration) int { return a.Version - b.Version })
+
+	return migrations, nil
+}
+
+func (m *Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// splitStatements делит sql-скрипт на отдельные запросы по ";" (драйвер выполняет
+// по одному запросу за вызов). Точки с запятой внутри строк и комментарии "--" учитываются.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      rune // открытая кавычка: ', " или `
+	)
+
+	flush := func() {
+		s := strings.TrimSpace(current.String())
+		if s != "" {
+			statements = append(statements, s)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		switch {
+		case quote != 0:
+			current.WriteRune(c)
+			if c == '\\' && quote != '`' && i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteRune(c)
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			// комментарий до конца строки
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			current.WriteRune('\n')
+		case c == ';':
+			flush()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	flush()
+
+	return statements
+}
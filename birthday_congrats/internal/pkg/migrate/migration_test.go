@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"birthday_congrats/databases"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	// запросы, комментарии и точки с запятой внутри строк
+	script := `-- комментарий; не запрос
+CREATE TABLE a (id int); -- хвост
+INSERT INTO a VALUES ('x;y', "it\"s;", ` + "`c;d`" + `);
+
+;
+DROP TABLE a`
+
+	assert.EqualValues(t, []string{
+		"CREATE TABLE a (id int)",
+		`INSERT INTO a VALUES ('x;y', "it\"s;", ` + "`c;d`" + `)`,
+		"DROP TABLE a",
+	}, splitStatements(script))
+
+	// пустой скрипт
+	assert.Empty(t, splitStatements("-- только комментарий\n\n"))
+}
+
+func TestLoad(t *testing.T) {
+	// нормальная работа: миграции по возрастанию версии
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":   {Data: []byte("CREATE INDEX i ON a (id);")},
+		"0002_add_index.down.sql": {Data: []byte("DROP INDEX i ON a;")},
+		"0001_init.up.sql":        {Data: []byte("CREATE TABLE a (id int);\nCREATE TABLE b (id int);")},
+		"0001_init.down.sql":      {Data: []byte("DROP TABLE b;\nDROP TABLE a;")},
+	}
+
+	migrations, err := Load(fsys)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*Migration{
+		{
+			Version: 1,
+			Name:    "init",
+			Up:      []string{"CREATE TABLE a (id int)", "CREATE TABLE b (id int)"},
+			Down:    []string{"DROP TABLE b", "DROP TABLE a"},
+		},
+		{
+			Version: 2,
+			Name:    "add_index",
+			Up:      []string{"CREATE INDEX i ON a (id)"},
+			Down:    []string{"DROP INDEX i ON a"},
+		},
+	}, migrations)
+	assert.EqualValues(t, "0002_add_index", migrations[1].String())
+
+	// нет down-файла
+	_, err = Load(fstest.MapFS{
+		"0001_init.up.sql": {Data: []byte("CREATE TABLE a (id int);")},
+	})
+
+	assert.ErrorIs(t, err, ErrBadMigration)
+
+	// посторонний файл
+	_, err = Load(fstest.MapFS{
+		"0001_init.up.sql":   {Data: []byte("CREATE TABLE a (id int);")},
+		"0001_init.down.sql": {Data: []byte("DROP TABLE a;")},
+		"README.md":          {Data: []byte("readme")},
+	})
+
+	assert.ErrorIs(t, err, ErrBadMigration)
+
+	// у одной версии два имени
+	_, err = Load(fstest.MapFS{
+		"0001_init.up.sql":    {Data: []byte("CREATE TABLE a (id int);")},
+		"0001_other.down.sql": {Data: []byte("DROP TABLE a;")},
+	})
+
+	assert.ErrorIs(t, err, ErrBadMigration)
+
+	// пустой файл
+	_, err = Load(fstest.MapFS{
+		"0001_init.up.sql":   {Data: []byte("CREATE TABLE a (id int);")},
+		"0001_init.down.sql": {Data: []byte("-- nothing")},
+	})
+
+	assert.ErrorIs(t, err, ErrBadMigration)
+
+	// нулевая версия
+	_, err = Load(fstest.MapFS{
+		"0000_init.up.sql":   {Data: []byte("CREATE TABLE a (id int);")},
+		"0000_init.down.sql": {Data: []byte("DROP TABLE a;")},
+	})
+
+	assert.ErrorIs(t, err, ErrBadMigration)
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	// встроенные миграции читаются, версии идут подряд с 1
+	migrations, err := Load(databases.Migrations())
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.EqualValues(t, i+1, m.Version, m.String())
+	}
+}
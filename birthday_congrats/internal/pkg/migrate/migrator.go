@@ -0,0 +1,209 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Status - состояние одной миграции в базе
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator применяет и откатывает миграции. Примененные версии хранятся в таблице schema_migrations.
+// Запросы миграции выполняются по очереди без транзакции (DDL в MySQL все равно фиксируется сразу),
+// поэтому при ошибке посередине миграции базу нужно поправить вручную.
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+	logger     *zap.SugaredLogger
+	now        func() time.Time // текущее время (подменяется в тестах)
+}
+
+func NewMySQLMigrator(db *sql.DB, migrations []*Migration, logger *zap.SugaredLogger) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(
+		ctx,
+		"CREATE TABLE IF NOT EXISTS schema_migrations ("+
+			"`version` int NOT NULL, "+
+			"`name` varchar(255) NOT NULL, "+
+			"`applied_at` bigint NOT NULL, "+
+			"PRIMARY KEY (`version`)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8",
+	)
+	if err != nil {
+		m.logger.Errorf("Error while creating schema_migrations: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
+
+// applied возвращает примененные версии и время их применения
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	err := m.ensureTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		m.logger.Errorf("Error while SELECT from db: %v", err)
+		return nil, fmt.Errorf("db error: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt int64
+		)
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			m.logger.Errorf("Error while scanning from sql row: %v", err)
+			return nil, fmt.Errorf("db error: %v", err)
+		}
+
+		applied[version] = time.Unix(appliedAt, 0)
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) exec(ctx context.Context, migration *Migration, statements []string) error {
+	for _, stmt := range statements {
+		_, err := m.db.ExecContext(ctx, stmt)
+		if err != nil {
+			m.logger.Errorf("Error in migration %s: %v", migration, err)
+			return fmt.Errorf("migration %s: %v", migration, err)
+		}
+	}
+
+	return nil
+}
+
+// Up применяет все еще не примененные миграции по возрастанию версии и возвращает их
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]*Migration, 0)
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err = m.exec(ctx, migration, migration.Up)
+		if err != nil {
+			return done, err
+		}
+
+		_, err = m.db.ExecContext(
+			ctx,
+			"INSERT INTO schema_migrations (`version`, `name`, `applied_at`) VALUES (?, ?, ?)",
+			migration.Version,
+			migration.Name,
+			m.now().Unix(),
+		)
+		if err != nil {
+			m.logger.Errorf("Error while INSERT into db: %v", err)
+			return done, fmt.Errorf("db error: %v", err)
+		}
+
+		m.logger.Infof("Applied migration %s", migration)
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down откатывает последнюю примененную миграцию и возвращает ее
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	last := 0
+	for version := range applied {
+		last = max(last, version)
+	}
+	if last == 0 {
+		return nil, ErrNoMigration
+	}
+
+	var migration *Migration
+	for _, mg := range m.migrations {
+		if mg.Version == last {
+			migration = mg
+			break
+		}
+	}
+	if migration == nil {
+		return nil, errors.Wrapf(ErrBadMigration, "applied version %d is unknown to this binary", last)
+	}
+
+	err = m.exec(ctx, migration, migration.Down)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = m.db.ExecContext(
+		ctx,
+		"DELETE FROM schema_migrations WHERE version = ?",
+		migration.Version,
+	)
+	if err != nil {
+		m.logger.Errorf("Error while DELETE from db: %v", err)
+		return nil, fmt.Errorf("db error: %v", err)
+	}
+
+	m.logger.Infof("Rolled back migration %s", migration)
+
+	return migration, nil
+}
+
+// Status возвращает состояние всех известных миграций по возрастанию версии
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+
+		st := &Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: ok,
+		}
+		if ok {
+			st.AppliedAt = appliedAt
+		}
+
+		statuses = append(statuses, st)
+	}
+
+	return statuses, nil
+}
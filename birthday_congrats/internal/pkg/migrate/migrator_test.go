@@ -0,0 +1,300 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func testMigrations() []*Migration {
+	return []*Migration{
+		{
+			Version: 1,
+			Name:    "init",
+			Up:      []string{"CREATE TABLE a (id int)", "CREATE TABLE b (id int)"},
+			Down:    []string{"DROP TABLE b", "DROP TABLE a"},
+		},
+		{
+			Version: 2,
+			Name:    "add_index",
+			Up:      []string{"CREATE INDEX i ON a (id)"},
+			Down:    []string{"DROP INDEX i ON a"},
+		},
+	}
+}
+
+// expectApplied описывает чтение примененных версий
+func expectApplied(mock sqlmock.Sqlmock, versions ...int) {
+	mock.
+		ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, v := range versions {
+		rows = rows.AddRow(v, int64(1700000000))
+	}
+
+	mock.
+		ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+		WillReturnRows(rows)
+}
+
+func TestUp(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+
+	testMigrator := NewMySQLMigrator(db, testMigrations(), zap.NewNop().Sugar())
+	testMigrator.now = func() time.Time { return now }
+
+	// нормальная работа: применяются только новые миграции
+	expectApplied(mock, 1)
+	mock.
+		ExpectExec("CREATE INDEX i ON a").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(2, "add_index", now.Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	done, err := testMigrator.Up(ctx)
+
+	assert.NoError(t, err)
+	if assert.Len(t, done, 1) {
+		assert.EqualValues(t, 2, done[0].Version)
+	}
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// все применено
+	expectApplied(mock, 1, 2)
+
+	done, err = testMigrator.Up(ctx)
+
+	assert.NoError(t, err)
+	assert.Empty(t, done)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка в запросе миграции - следующие не применяются
+	expectApplied(mock)
+	mock.
+		ExpectExec("CREATE TABLE a").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec("CREATE TABLE b").
+		WillReturnError(fmt.Errorf("db error"))
+
+	done, err = testMigrator.Up(ctx)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "0001_init")
+	assert.Empty(t, done)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка записи версии
+	expectApplied(mock, 1)
+	mock.
+		ExpectExec("CREATE INDEX i ON a").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(2, "add_index", now.Unix()).
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testMigrator.Up(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка создания schema_migrations
+	mock.
+		ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testMigrator.Up(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка чтения версий
+	mock.
+		ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testMigrator.Up(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка scan
+	mock.
+		ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow("v1", "yesterday"))
+
+	_, err = testMigrator.Up(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestDown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testMigrator := NewMySQLMigrator(db, testMigrations(), zap.NewNop().Sugar())
+
+	// нормальная работа: откатывается последняя примененная миграция
+	expectApplied(mock, 1, 2)
+	mock.
+		ExpectExec("DROP INDEX i ON a").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec("DELETE FROM schema_migrations WHERE").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	migration, err := testMigrator.Down(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, migration.Version)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// откатывать нечего
+	expectApplied(mock)
+
+	_, err = testMigrator.Down(ctx)
+
+	assert.ErrorIs(t, err, ErrNoMigration)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// база новее бинарника
+	expectApplied(mock, 1, 2, 3)
+
+	_, err = testMigrator.Down(ctx)
+
+	assert.ErrorIs(t, err, ErrBadMigration)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка в запросе отката
+	expectApplied(mock, 1)
+	mock.
+		ExpectExec("DROP TABLE b").
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testMigrator.Down(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка удаления версии
+	expectApplied(mock, 1)
+	mock.
+		ExpectExec("DROP TABLE b").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec("DROP TABLE a").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec("DELETE FROM schema_migrations WHERE").
+		WithArgs(1).
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testMigrator.Down(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка чтения версий
+	mock.
+		ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testMigrator.Down(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testMigrator := NewMySQLMigrator(db, testMigrations(), zap.NewNop().Sugar())
+
+	// нормальная работа
+	expectApplied(mock, 1)
+
+	statuses, err := testMigrator.Status(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*Status{
+		{Version: 1, Name: "init", Applied: true, AppliedAt: time.Unix(1700000000, 0)},
+		{Version: 2, Name: "add_index"},
+	}, statuses)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка чтения версий
+	mock.
+		ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testMigrator.Status(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,235 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// maxResponseSize - больше такого ответа от провайдера не ждем
+const maxResponseSize = 1 << 20
+
+var (
+	ErrDiscovery = errors.New("oidc: bad provider configuration")
+	ErrExchange  = errors.New("oidc: code exchange failed")
+)
+
+// Claims - сведения о пользователе из ID-токена
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+	Birthdate         string // YYYY-MM-DD, 0000-MM-DD (без года) или пусто
+	Zoneinfo          string // часовой пояс IANA
+}
+
+// providerConfig - нужная нам часть документа /.well-known/openid-configuration
+type providerConfig struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client - вход через провайдера OpenID Connect по коду авторизации с PKCE.
+// Настройки провайдера запрашиваются при первом входе, а не при запуске:
+// сервис должен работать, даже если провайдер временно недоступен.
+type Client struct {
+	issuer       string
+	clientID     string
+	clientSecret string // пустой - публичный клиент, только PKCE
+	redirectURL  string
+	scopes       []string
+	httpClient   *http.Client
+	logger       *zap.SugaredLogger
+	now          func() time.Time
+
+	mu       sync.Mutex
+	provider *providerConfig
+	keys     map[string]*rsa.PublicKey // ключи подписи провайдера по kid
+}
+
+func NewClient(
+	issuer string,
+	clientID string,
+	clientSecret string,
+	redirectURL string,
+	scopes []string,
+	timeout time.Duration,
+	logger *zap.SugaredLogger,
+) *Client {
+	return &Client{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		scopes:       scopes,
+		httpClient:   &http.Client{Timeout: timeout},
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// NewVerifier возвращает случайный code_verifier для PKCE; им же удобно делать state и nonce
+func NewVerifier() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("rand error: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge возвращает code_challenge для verifier (метод S256)
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL возвращает адрес страницы входа провайдера, куда нужно отправить пользователя
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	provider, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.clientID},
+		"redirect_uri":          {c.redirectURL},
+		"scope":                 {strings.Join(c.scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return provider.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на ID-токен, проверяет его и возвращает сведения о пользователе
+func (c *Client) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	provider, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.redirectURL},
+		"code_verifier": {verifier},
+	}
+	if c.clientSecret == "" {
+		form.Set("client_id", c.clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.clientSecret != "" {
+		// client_secret_basic: идентификатор и секрет кодируются по RFC 6749, 2.3.1
+		req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+	}
+
+	var resp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := c.do(req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || resp.IDToken == "" {
+		return nil, errors.Wrap(ErrExchange, fmt.Sprintf("status %d: %s %s", status, resp.Error, resp.ErrorDescription))
+	}
+
+	return c.verify(ctx, provider, resp.IDToken, nonce)
+}
+
+// discover получает и запоминает настройки провайдера
+func (c *Client) discover(ctx context.Context) (*providerConfig, error) {
+	c.mu.Lock()
+	provider := c.provider
+	c.mu.Unlock()
+	if provider != nil {
+		return provider, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	provider = &providerConfig{}
+	status, err := c.do(req, provider)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, errors.Wrap(ErrDiscovery, fmt.Sprintf("status %d", status))
+	}
+
+	// документ должен описывать именно того провайдера, которому мы доверяем
+	if strings.TrimSuffix(provider.Issuer, "/") != c.issuer {
+		return nil, errors.Wrap(ErrDiscovery, fmt.Sprintf("issuer %q does not match", provider.Issuer))
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, errors.Wrap(ErrDiscovery, "endpoints are missing")
+	}
+
+	c.logger.Infof("Discovered OIDC provider %s", provider.Issuer)
+
+	c.mu.Lock()
+	c.provider = provider
+	c.mu.Unlock()
+
+	return provider, nil
+}
+
+// do выполняет запрос и разбирает JSON-ответ в out, возвращает код ответа
+func (c *Client) do(req *http.Request, out interface{}) (int, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, err
+	}
+
+	// в ответе с ошибкой тоже может быть JSON, но может и не быть
+	err = json.Unmarshal(body, out)
+	if err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("bad response from %s: %v", req.URL.Host, err)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,222 @@
+package oidc
+
+import (
+	"birthday_congrats/internal/pkg/oidctest"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+const (
+	testClientID    = "birthday"
+	testRedirectURL = "http://birthday.example.com/login/oidc/callback"
+)
+
+func newTestClient(issuer, secret string) *Client {
+	return NewClient(issuer, testClientID, secret, testRedirectURL, []string{"openid", "email"}, time.Second, zap.NewNop().Sugar())
+}
+
+// login проходит вход целиком и возвращает код из адреса возврата
+func login(t *testing.T, c *Client, issuer *oidctest.Issuer, state, nonce, verifier string, claims map[string]interface{}) string {
+	t.Helper()
+
+	authURL, err := c.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("cant get auth url: %v", err)
+	}
+
+	redirect, err := url.Parse(issuer.Authorize(t, authURL, claims))
+	if err != nil {
+		t.Fatalf("bad redirect: %v", err)
+	}
+
+	assert.EqualValues(t, state, redirect.Query().Get("state"))
+
+	return redirect.Query().Get("code")
+}
+
+func TestChallenge(t *testing.T) {
+	// пример из RFC 7636, приложение B
+	assert.EqualValues(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := NewVerifier()
+
+	assert.NoError(t, err)
+	assert.Len(t, verifier, 43)
+}
+
+func TestExchange(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, testClientID, "secret")
+	c := newTestClient(issuer.URL(), "secret")
+	ctx := context.Background()
+
+	// нормальная работа
+	code := login(t, c, issuer, "state", "nonce", "verifier-verifier-verifier-verifier-verifier", map[string]interface{}{
+		"sub":            "42",
+		"email":          "alice@example.com",
+		"email_verified": "true",
+		"name":           "Alice",
+		"birthdate":      "0000-05-10",
+	})
+
+	claims, err := c.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &Claims{
+		Subject:       "42",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Name:          "Alice",
+		Birthdate:     "0000-05-10",
+	}, claims)
+
+	// код одноразовый
+	_, err = c.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+
+	assert.ErrorIs(t, err, ErrExchange)
+
+	// чужой code_verifier
+	code = login(t, c, issuer, "state", "nonce", "verifier-verifier-verifier-verifier-verifier", map[string]interface{}{"sub": "42"})
+
+	_, err = c.Exchange(ctx, code, "another-verifier", "nonce")
+
+	assert.ErrorIs(t, err, ErrExchange)
+
+	// неверный секрет клиента
+	wrongSecret := newTestClient(issuer.URL(), "wrong")
+	code = login(t, wrongSecret, issuer, "state", "nonce", "verifier", map[string]interface{}{"sub": "42"})
+
+	_, err = wrongSecret.Exchange(ctx, code, "verifier", "nonce")
+
+	assert.ErrorIs(t, err, ErrExchange)
+
+	// токен не прошел проверку
+	cases := []struct {
+		name   string
+		nonce  string
+		claims map[string]interface{}
+	}{
+		{name: "чужой nonce", nonce: "another", claims: map[string]interface{}{"sub": "42"}},
+		{name: "чужой клиент", nonce: "nonce", claims: map[string]interface{}{"sub": "42", "aud": "another"}},
+		{name: "несколько клиентов без azp", nonce: "nonce", claims: map[string]interface{}{"sub": "42", "aud": []string{testClientID, "another"}}},
+		{name: "чужой провайдер", nonce: "nonce", claims: map[string]interface{}{"sub": "42", "iss": "https://evil.example.com"}},
+		{name: "истек", nonce: "nonce", claims: map[string]interface{}{"sub": "42", "exp": time.Now().Add(-time.Hour).Unix()}},
+		{name: "из будущего", nonce: "nonce", claims: map[string]interface{}{"sub": "42", "iat": time.Now().Add(time.Hour).Unix()}},
+		{name: "без sub", nonce: "nonce", claims: map[string]interface{}{}},
+	}
+	for _, tc := range cases {
+		code = login(t, c, issuer, "state", "nonce", "verifier", tc.claims)
+
+		_, err = c.Exchange(ctx, code, "verifier", tc.nonce)
+
+		assert.ErrorIs(t, err, ErrBadToken, tc.name)
+	}
+
+	// несколько клиентов, но токен выдан нам
+	code = login(t, c, issuer, "state", "nonce", "verifier", map[string]interface{}{
+		"sub": "42",
+		"aud": []string{testClientID, "another"},
+		"azp": testClientID,
+	})
+
+	claims, err = c.Exchange(ctx, code, "verifier", "nonce")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, "42", claims.Subject)
+}
+
+func TestVerify(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, testClientID, "")
+	c := newTestClient(issuer.URL(), "")
+	ctx := context.Background()
+
+	provider, err := c.discover(ctx)
+
+	assert.NoError(t, err)
+
+	// данные для теста
+	claims := map[string]interface{}{
+		"iss":   issuer.URL(),
+		"aud":   testClientID,
+		"sub":   "42",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": "nonce",
+	}
+	token := issuer.Sign(claims)
+	parts := strings.Split(token, ".")
+
+	// нормальная работа
+	_, err = c.verify(ctx, provider, token, "nonce")
+
+	assert.NoError(t, err)
+
+	// подпись от другого содержимого
+	claims["sub"] = "1"
+	forged := strings.Split(issuer.Sign(claims), ".")
+
+	_, err = c.verify(ctx, provider, parts[0]+"."+forged[1]+"."+parts[2], "nonce")
+
+	assert.ErrorIs(t, err, ErrBadToken)
+
+	// без подписи
+	_, err = c.verify(ctx, provider, "eyJhbGciOiJub25lIn0."+parts[1]+".", "nonce")
+
+	assert.ErrorIs(t, err, ErrBadToken)
+
+	// не JWS
+	_, err = c.verify(ctx, provider, "token", "nonce")
+
+	assert.ErrorIs(t, err, ErrBadToken)
+
+	// незнакомый ключ
+	_, err = c.verify(ctx, provider, "eyJhbGciOiJSUzI1NiIsImtpZCI6Im90aGVyIn0."+parts[1]+"."+parts[2], "nonce")
+
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestDiscover(t *testing.T) {
+	// провайдер выдает себя за другого
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"issuer": "https://evil.example.com", "authorization_endpoint": "a", "token_endpoint": "t", "jwks_uri": "j"}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL, "").AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+
+	assert.ErrorIs(t, err, ErrDiscovery)
+
+	// провайдер недоступен
+	server.Close()
+
+	_, err = newTestClient(server.URL, "").AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+
+	assert.Error(t, err)
+
+	// нормальная работа: в адресе есть все для PKCE
+	issuer := oidctest.NewIssuer(t, testClientID, "")
+
+	authURL, err := newTestClient(issuer.URL()+"/", "").AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+
+	assert.NoError(t, err)
+
+	u, _ := url.Parse(authURL)
+	assert.EqualValues(t, issuer.URL()+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.EqualValues(t, url.Values{
+		"response_type":         {"code"},
+		"client_id":             {testClientID},
+		"redirect_uri":          {testRedirectURL},
+		"scope":                 {"openid email"},
+		"state":                 {"state"},
+		"nonce":                 {"nonce"},
+		"code_challenge":        {Challenge("verifier")},
+		"code_challenge_method": {"S256"},
+	}, u.Query())
+}
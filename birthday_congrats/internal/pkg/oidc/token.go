@@ -0,0 +1,265 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// leeway - допустимое расхождение часов с провайдером
+const leeway = time.Minute
+
+var (
+	ErrBadToken   = errors.New("oidc: bad id token")
+	ErrUnknownKey = errors.New("oidc: unknown signing key")
+)
+
+// idToken - поля ID-токена, которые мы проверяем и используем
+type idToken struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	AuthorizedParty   string   `json:"azp"`
+	Expiry            int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Birthdate         string   `json:"birthdate"`
+	Zoneinfo          string   `json:"zoneinfo"`
+}
+
+// audience - aud бывает строкой или массивом строк
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var many []string
+	err := json.Unmarshal(data, &many)
+	if err != nil {
+		return err
+	}
+
+	*a = many
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flexBool - некоторые провайдеры присылают email_verified строкой "true"
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		*b = flexBool(strings.EqualFold(v, "true"))
+	default:
+		*b = false
+	}
+
+	return nil
+}
+
+// verify проверяет подпись и поля ID-токена. Поддерживается только RS256 - алгоритм,
+// который провайдеры OpenID Connect обязаны поддерживать.
+func (c *Client) verify(ctx context.Context, provider *providerConfig, raw, nonce string) (*Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.Wrap(ErrBadToken, "not a jws")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, errors.Wrap(ErrBadToken, fmt.Sprintf("unsupported alg %q", header.Alg))
+	}
+
+	key, err := c.key(ctx, provider, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(ErrBadToken, "bad signature encoding")
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	if err != nil {
+		return nil, errors.Wrap(ErrBadToken, "bad signature")
+	}
+
+	tok := &idToken{}
+	err = decodeSegment(parts[1], tok)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	switch {
+	case strings.TrimSuffix(tok.Issuer, "/") != c.issuer:
+		return nil, errors.Wrap(ErrBadToken, fmt.Sprintf("issuer %q does not match", tok.Issuer))
+	case !tok.Audience.contains(c.clientID):
+		return nil, errors.Wrap(ErrBadToken, "token is issued for another client")
+	case len(tok.Audience) > 1 && tok.AuthorizedParty != c.clientID:
+		return nil, errors.Wrap(ErrBadToken, "token is issued for another client")
+	case now.After(time.Unix(tok.Expiry, 0).Add(leeway)):
+		return nil, errors.Wrap(ErrBadToken, "token expired")
+	case time.Unix(tok.IssuedAt, 0).After(now.Add(leeway)):
+		return nil, errors.Wrap(ErrBadToken, "token is issued in future")
+	case subtle.ConstantTimeCompare([]byte(tok.Nonce), []byte(nonce)) != 1:
+		return nil, errors.Wrap(ErrBadToken, "nonce does not match")
+	case tok.Subject == "":
+		return nil, errors.Wrap(ErrBadToken, "no subject")
+	}
+
+	return &Claims{
+		Subject:           tok.Subject,
+		Email:             tok.Email,
+		EmailVerified:     bool(tok.EmailVerified),
+		Name:              tok.Name,
+		PreferredUsername: tok.PreferredUsername,
+		Birthdate:         tok.Birthdate,
+		Zoneinfo:          tok.Zoneinfo,
+	}, nil
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.Wrap(ErrBadToken, "bad segment encoding")
+	}
+
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return errors.Wrap(ErrBadToken, "bad segment json")
+	}
+
+	return nil
+}
+
+// key возвращает ключ подписи kid. Незнакомый ключ - повод перечитать JWKS:
+// провайдер мог сменить ключи.
+func (c *Client) key(ctx context.Context, provider *providerConfig, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	key, ok := c.lookupKey(kid)
+	c.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	keys, err := c.fetchKeys(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keys = keys
+	key, ok = c.lookupKey(kid)
+	if !ok {
+		return nil, errors.Wrap(ErrUnknownKey, fmt.Sprintf("kid %q", kid))
+	}
+
+	return key, nil
+}
+
+// lookupKey ищет ключ под c.mu. Без kid подходит только единственный ключ.
+func (c *Client) lookupKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+func (c *Client) fetchKeys(ctx context.Context, provider *providerConfig) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	status, err := c.do(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, errors.Wrap(ErrDiscovery, fmt.Sprintf("jwks status %d", status))
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		// ключи шифрования и не-RSA ключи нам не нужны
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			c.logger.Warnf("Skipping bad JWKS key %q", k.Kid)
+			continue
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,107 @@
+package outbox
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OutboxMemoryRepo - очередь исходящих писем в памяти процесса (для разработки без базы данных).
+// Письма теряются при перезапуске.
+type OutboxMemoryRepo struct {
+	mu       *sync.Mutex
+	messages []*Message // по возрастанию id
+	nextID   uint64
+	logger   *zap.SugaredLogger
+}
+
+var _ Outbox = &OutboxMemoryRepo{}
+
+func NewOutboxMemoryRepo(logger *zap.SugaredLogger) *OutboxMemoryRepo {
+	return &OutboxMemoryRepo{
+		mu:       &sync.Mutex{},
+		messages: make([]*Message, 0, 10),
+		nextID:   1,
+		logger:   logger,
+	}
+}
+
+func (repo *OutboxMemoryRepo) Enqueue(ctx context.Context, to []string, subject, body string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.messages = append(repo.messages, &Message{
+		ID:          repo.nextID,
+		Recipients:  slices.Clone(to),
+		Subject:     subject,
+		Body:        body,
+		Status:      StatusPending,
+		NextAttempt: time.Unix(time.Now().Unix(), 0),
+	})
+	repo.nextID++
+
+	return nil
+}
+
+func (repo *OutboxMemoryRepo) Due(ctx context.Context, now time.Time, limit int) ([]*Message, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	messages := make([]*Message, 0, limit)
+	for _, msg := range repo.messages {
+		if len(messages) == limit {
+			break
+		}
+		if msg.Status != StatusPending || msg.NextAttempt.Unix() > now.Unix() {
+			continue
+		}
+
+		cp := *msg
+		cp.Recipients = slices.Clone(msg.Recipients)
+		messages = append(messages, &cp)
+	}
+
+	return messages, nil
+}
+
+func (repo *OutboxMemoryRepo) MarkSent(ctx context.Context, id uint64, attempts int) error {
+	return repo.update(id, func(msg *Message) {
+		msg.Status = StatusSent
+		msg.Attempts = attempts
+		msg.LastError = ""
+	})
+}
+
+func (repo *OutboxMemoryRepo) MarkFailed(ctx context.Context, id uint64, attempts int, nextAttempt time.Time, lastError string) error {
+	return repo.update(id, func(msg *Message) {
+		msg.Attempts = attempts
+		msg.NextAttempt = time.Unix(nextAttempt.Unix(), 0)
+		msg.LastError = lastError
+	})
+}
+
+func (repo *OutboxMemoryRepo) MarkDead(ctx context.Context, id uint64, attempts int, lastError string) error {
+	return repo.update(id, func(msg *Message) {
+		msg.Status = StatusDead
+		msg.Attempts = attempts
+		msg.LastError = lastError
+	})
+}
+
+func (repo *OutboxMemoryRepo) update(id uint64, change func(msg *Message)) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, msg := range repo.messages {
+		if msg.ID == id {
+			change(msg)
+			return nil
+		}
+	}
+
+	repo.logger.Warnf("Message was not updated")
+	return ErrNoMessage
+}
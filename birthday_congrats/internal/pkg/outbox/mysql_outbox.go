@@ -0,0 +1,157 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+type OutboxMySQLRepo struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+var _ Outbox = &OutboxMySQLRepo{}
+
+func NewOutboxMySQLRepo(db *sql.DB, logger *zap.SugaredLogger) *OutboxMySQLRepo {
+	return &OutboxMySQLRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *OutboxMySQLRepo) Enqueue(ctx context.Context, to []string, subject, body string) error {
+	recipients, err := json.Marshal(to)
+	if err != nil {
+		repo.logger.Errorf("Error while marshalling recipients: %v", err)
+		return fmt.Errorf("json error: %v", err)
+	}
+
+	result, err := repo.db.ExecContext(
+		ctx,
+		// у text-колонки last_error нет значения по умолчанию, в строгом режиме MySQL его нужно передать
+		"INSERT INTO alerts_outbox (`recipients`, `subject`, `body`, `status`, `attempts`, `next_attempt_at`, `last_error`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, '', ?)",
+		string(recipients),
+		subject,
+		body,
+		StatusPending,
+		0,
+		time.Now().Unix(),
+		time.Now().Unix(),
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while INSERT into db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	// проверка, что запись добавлена
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.Errorf("Error in RowsAffected(): %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+	if affected == 0 {
+		repo.logger.Errorf("Message was not enqueued")
+		return ErrNotEnqueued
+	}
+
+	return nil
+}
+
+func (repo *OutboxMySQLRepo) Due(ctx context.Context, now time.Time, limit int) ([]*Message, error) {
+	messages := make([]*Message, 0, limit)
+
+	rows, err := repo.db.QueryContext(
+		ctx,
+		"SELECT id, recipients, subject, body, status, attempts, next_attempt_at, last_error FROM alerts_outbox WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ?",
+		StatusPending,
+		now.Unix(),
+		limit,
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while SELECT from db: %v", err)
+		return nil, fmt.Errorf("db error: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		msg := &Message{}
+		recipients := ""
+		nextAttempt := int64(0)
+
+		err = rows.Scan(&msg.ID, &recipients, &msg.Subject, &msg.Body, &msg.Status, &msg.Attempts, &nextAttempt, &msg.LastError)
+		if err != nil {
+			repo.logger.Errorf("Error while scanning from sql row: %v", err)
+			return nil, fmt.Errorf("db error: %v", err)
+		}
+
+		err = json.Unmarshal([]byte(recipients), &msg.Recipients)
+		if err != nil {
+			repo.logger.Errorf("Error while unmarshalling recipients of message %d: %v", msg.ID, err)
+			return nil, fmt.Errorf("json error: %v", err)
+		}
+
+		msg.NextAttempt = time.Unix(nextAttempt, 0)
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+func (repo *OutboxMySQLRepo) MarkSent(ctx context.Context, id uint64, attempts int) error {
+	return repo.update(
+		ctx,
+		"UPDATE alerts_outbox SET status = ?, attempts = ?, last_error = '' WHERE id = ?",
+		StatusSent,
+		attempts,
+		id,
+	)
+}
+
+func (repo *OutboxMySQLRepo) MarkFailed(ctx context.Context, id uint64, attempts int, nextAttempt time.Time, lastError string) error {
+	return repo.update(
+		ctx,
+		"UPDATE alerts_outbox SET attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?",
+		attempts,
+		nextAttempt.Unix(),
+		lastError,
+		id,
+	)
+}
+
+func (repo *OutboxMySQLRepo) MarkDead(ctx context.Context, id uint64, attempts int, lastError string) error {
+	return repo.update(
+		ctx,
+		"UPDATE alerts_outbox SET status = ?, attempts = ?, last_error = ? WHERE id = ?",
+		StatusDead,
+		attempts,
+		lastError,
+		id,
+	)
+}
+
+func (repo *OutboxMySQLRepo) update(ctx context.Context, query string, args ...interface{}) error {
+	result, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		repo.logger.Errorf("Error while UPDATE in db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	// проверка, что запись обновлена
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.Errorf("Error in RowsAffected(): %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+	if affected == 0 {
+		repo.logger.Warnf("Message was not updated")
+		return ErrNoMessage
+	}
+
+	return nil
+}
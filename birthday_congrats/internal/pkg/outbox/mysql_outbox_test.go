@@ -0,0 +1,275 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestEnqueue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewOutboxMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	to := []string{"one@one.net", "two@two.net"}
+	subject := "some subject"
+	body := "some body"
+
+	// нормальная работа
+	mock.
+		ExpectExec("INSERT INTO alerts_outbox").
+		WithArgs(`["one@one.net","two@two.net"]`, subject, body, StatusPending, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = testRepo.Enqueue(ctx, to, subject, body)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectExec("INSERT INTO alerts_outbox").
+		WithArgs(`["one@one.net","two@two.net"]`, subject, body, StatusPending, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.Enqueue(ctx, to, subject, body)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка в RowsAffected
+	mock.
+		ExpectExec("INSERT INTO alerts_outbox").
+		WithArgs(`["one@one.net","two@two.net"]`, subject, body, StatusPending, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("result error")))
+
+	err = testRepo.Enqueue(ctx, to, subject, body)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// запись не добавлена
+	mock.
+		ExpectExec("INSERT INTO alerts_outbox").
+		WithArgs(`["one@one.net","two@two.net"]`, subject, body, StatusPending, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = testRepo.Enqueue(ctx, to, subject, body)
+
+	assert.ErrorIs(t, err, ErrNotEnqueued)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestDue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewOutboxMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	now := time.Unix(1700000000, 0)
+
+	messagesExpected := []*Message{
+		{
+			ID:          1,
+			Recipients:  []string{"one@one.net"},
+			Subject:     "subject 1",
+			Body:        "body 1",
+			Status:      StatusPending,
+			Attempts:    0,
+			NextAttempt: now.Add(-time.Hour),
+		},
+		{
+			ID:          2,
+			Recipients:  []string{"one@one.net", "two@two.net"},
+			Subject:     "subject 2",
+			Body:        "body 2",
+			Status:      StatusPending,
+			Attempts:    3,
+			NextAttempt: now,
+			LastError:   "smtp error",
+		},
+	}
+
+	columns := []string{"id", "recipients", "subject", "body", "status", "attempts", "next_attempt_at", "last_error"}
+
+	// нормальная работа
+	rows := sqlmock.NewRows(columns).
+		AddRow(1, `["one@one.net"]`, "subject 1", "body 1", StatusPending, 0, now.Add(-time.Hour).Unix(), "").
+		AddRow(2, `["one@one.net","two@two.net"]`, "subject 2", "body 2", StatusPending, 3, now.Unix(), "smtp error")
+
+	mock.
+		ExpectQuery("SELECT id, recipients, subject, body, status, attempts, next_attempt_at, last_error FROM alerts_outbox WHERE").
+		WithArgs(StatusPending, now.Unix(), 10).
+		WillReturnRows(rows)
+
+	messagesRecv, err := testRepo.Due(ctx, now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, messagesExpected, messagesRecv)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectQuery("SELECT id, recipients, subject, body, status, attempts, next_attempt_at, last_error FROM alerts_outbox WHERE").
+		WithArgs(StatusPending, now.Unix(), 10).
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testRepo.Due(ctx, now, 10)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка сканирования
+	rows = sqlmock.NewRows([]string{"id"}).AddRow(1)
+
+	mock.
+		ExpectQuery("SELECT id, recipients, subject, body, status, attempts, next_attempt_at, last_error FROM alerts_outbox WHERE").
+		WithArgs(StatusPending, now.Unix(), 10).
+		WillReturnRows(rows)
+
+	_, err = testRepo.Due(ctx, now, 10)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// битый список получателей
+	rows = sqlmock.NewRows(columns).
+		AddRow(1, `one@one.net`, "subject 1", "body 1", StatusPending, 0, now.Unix(), "")
+
+	mock.
+		ExpectQuery("SELECT id, recipients, subject, body, status, attempts, next_attempt_at, last_error FROM alerts_outbox WHERE").
+		WithArgs(StatusPending, now.Unix(), 10).
+		WillReturnRows(rows)
+
+	_, err = testRepo.Due(ctx, now, 10)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestMark(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewOutboxMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	nextAttempt := time.Unix(1700000000, 0)
+
+	// отправлено
+	mock.
+		ExpectExec("UPDATE alerts_outbox SET status = \\?, attempts = \\?, last_error = '' WHERE id = \\?").
+		WithArgs(StatusSent, 2, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = testRepo.MarkSent(ctx, 7, 2)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// неудачная попытка
+	mock.
+		ExpectExec("UPDATE alerts_outbox SET attempts = \\?, next_attempt_at = \\?, last_error = \\? WHERE id = \\?").
+		WithArgs(1, nextAttempt.Unix(), "smtp error", 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = testRepo.MarkFailed(ctx, 7, 1, nextAttempt, "smtp error")
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// попытки исчерпаны
+	mock.
+		ExpectExec("UPDATE alerts_outbox SET status = \\?, attempts = \\?, last_error = \\? WHERE id = \\?").
+		WithArgs(StatusDead, 8, "smtp error", 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = testRepo.MarkDead(ctx, 7, 8, "smtp error")
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectExec("UPDATE alerts_outbox").
+		WithArgs(StatusSent, 2, 7).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.MarkSent(ctx, 7, 2)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка в RowsAffected
+	mock.
+		ExpectExec("UPDATE alerts_outbox").
+		WithArgs(StatusSent, 2, 7).
+		WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("result error")))
+
+	err = testRepo.MarkSent(ctx, 7, 2)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// нет такого сообщения
+	mock.
+		ExpectExec("UPDATE alerts_outbox").
+		WithArgs(StatusSent, 2, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = testRepo.MarkSent(ctx, 7, 2)
+
+	assert.ErrorIs(t, err, ErrNoMessage)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,41 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotEnqueued = errors.New("message was not enqueued")
+	ErrNoMessage   = errors.New("no such message")
+)
+
+// Статусы сообщений в очереди
+const (
+	StatusPending = "pending" // ждет отправки (в том числе повторной)
+	StatusSent    = "sent"    // отправлено
+	StatusDead    = "dead"    // исчерпаны попытки отправки, нужен разбор вручную
+)
+
+type Message struct {
+	ID          uint64
+	Recipients  []string
+	Subject     string
+	Body        string
+	Status      string
+	Attempts    int       // сколько раз пытались отправить
+	NextAttempt time.Time // не раньше какого времени пробовать снова
+	LastError   string
+}
+
+// Outbox - очередь исходящих писем. Сервис только кладет письма в очередь,
+// доставкой с повторами занимается Worker.
+type Outbox interface {
+	Enqueue(ctx context.Context, to []string, subject, body string) error
+	Due(ctx context.Context, now time.Time, limit int) ([]*Message, error)
+	MarkSent(ctx context.Context, id uint64, attempts int) error
+	MarkFailed(ctx context.Context, id uint64, attempts int, nextAttempt time.Time, lastError string) error
+	MarkDead(ctx context.Context, id uint64, attempts int, lastError string) error
+}
@@ -0,0 +1,128 @@
+package outbox
+
+import (
+	alertmanager "birthday_congrats/internal/pkg/alert_manager"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Worker забирает из очереди письма, время отправки которых подошло, и отправляет их.
+// После неудачной попытки следующая откладывается с экспоненциальной задержкой
+// (baseDelay, 2*baseDelay, 4*baseDelay, ... но не больше maxDelay), а после maxAttempts
+// неудачных попыток письмо помечается как dead и больше не отправляется.
+type Worker struct {
+	outbox      Outbox
+	alerts      alertmanager.AlertManager
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	batchSize   int
+	logger      *zap.SugaredLogger
+
+	now func() time.Time // текущее время (подменяется в тестах)
+}
+
+func NewWorker(
+	outbox Outbox,
+	alerts alertmanager.AlertManager,
+	maxAttempts int,
+	baseDelay time.Duration,
+	maxDelay time.Duration,
+	batchSize int,
+	logger *zap.SugaredLogger,
+) *Worker {
+	return &Worker{
+		outbox:      outbox,
+		alerts:      alerts,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		batchSize:   batchSize,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// Run раз в interval отправляет все письма, время которых подошло, пока не отменен ctx
+func (w *Worker) Run(ctx context.Context, interval time.Duration, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	w.logger.Infof("Outbox worker started")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.deliverAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.logger.Infof("Outbox worker was stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverAll разбирает очередь пачками, пока в ней есть письма к отправке
+func (w *Worker) deliverAll(ctx context.Context) {
+	for ctx.Err() == nil {
+		processed, err := w.Deliver(ctx)
+		if err != nil {
+			w.logger.Errorf("Error while delivering messages: %v", err)
+			return
+		}
+
+		if processed < w.batchSize {
+			return
+		}
+	}
+}
+
+// Deliver делает одну попытку отправки для не более чем batchSize писем из очереди
+// и возвращает, сколько писем было обработано
+func (w *Worker) Deliver(ctx context.Context) (int, error) {
+	messages, err := w.outbox.Due(ctx, w.now(), w.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, msg := range messages {
+		attempts := msg.Attempts + 1
+
+		sendErr := w.alerts.Send(msg.Recipients, msg.Subject, msg.Body)
+
+		switch {
+		case sendErr == nil:
+			err = w.outbox.MarkSent(ctx, msg.ID, attempts)
+		case attempts >= w.maxAttempts:
+			w.logger.Errorf("Message %d is dead after %d attempts: %v", msg.ID, attempts, sendErr)
+			err = w.outbox.MarkDead(ctx, msg.ID, attempts, sendErr.Error())
+		default:
+			nextAttempt := w.now().Add(w.backoff(attempts))
+			w.logger.Warnf("Message %d was not sent (attempt %d), next attempt at %v: %v", msg.ID, attempts, nextAttempt, sendErr)
+			err = w.outbox.MarkFailed(ctx, msg.ID, attempts, nextAttempt, sendErr.Error())
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(messages), nil
+}
+
+// backoff - задержка перед следующей попыткой после attempts неудачных
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.baseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= w.maxDelay {
+			return w.maxDelay
+		}
+	}
+
+	return min(delay, w.maxDelay)
+}
@@ -0,0 +1,147 @@
+package outbox
+
+import (
+	alertmanager "birthday_congrats/internal/pkg/alert_manager"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestBackoff(t *testing.T) {
+	testWorker := NewWorker(nil, nil, 10, time.Minute, time.Hour, 10, zap.NewNop().Sugar())
+
+	assert.EqualValues(t, time.Minute, testWorker.backoff(1))
+	assert.EqualValues(t, 2*time.Minute, testWorker.backoff(2))
+	assert.EqualValues(t, 4*time.Minute, testWorker.backoff(3))
+	assert.EqualValues(t, 32*time.Minute, testWorker.backoff(6))
+	assert.EqualValues(t, time.Hour, testWorker.backoff(7))
+	assert.EqualValues(t, time.Hour, testWorker.backoff(100))
+}
+
+func TestDeliver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	outbox := NewMockOutbox(ctrl)
+	alerts := alertmanager.NewMockAlertManager(ctrl)
+
+	testWorker := NewWorker(outbox, alerts, 3, time.Minute, time.Hour, 10, zap.NewNop().Sugar())
+
+	// данные для теста
+	now := time.Unix(1700000000, 0)
+	testWorker.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	messagesSent := []*Message{
+		{
+			ID:         1,
+			Recipients: []string{"one@one.net"},
+			Subject:    "subject",
+			Body:       "sent first time",
+			Status:     StatusPending,
+		},
+		{
+			ID:         2,
+			Recipients: []string{"two@two.net"},
+			Subject:    "subject",
+			Body:       "fails second time",
+			Status:     StatusPending,
+			Attempts:   1,
+		},
+		{
+			ID:         3,
+			Recipients: []string{"three@three.net"},
+			Subject:    "subject",
+			Body:       "fails last time",
+			Status:     StatusPending,
+			Attempts:   2,
+		},
+	}
+
+	// нормальная работа: отправлено, повтор через 2 минуты, в dead
+	outbox.EXPECT().Due(ctx, now, 10).Return(messagesSent, nil)
+
+	alerts.EXPECT().Send([]string{"one@one.net"}, "subject", "sent first time").Return(nil)
+	outbox.EXPECT().MarkSent(ctx, uint64(1), 1).Return(nil)
+
+	alerts.EXPECT().Send([]string{"two@two.net"}, "subject", "fails second time").Return(fmt.Errorf("smtp error"))
+	outbox.EXPECT().MarkFailed(ctx, uint64(2), 2, now.Add(2*time.Minute), "smtp error").Return(nil)
+
+	alerts.EXPECT().Send([]string{"three@three.net"}, "subject", "fails last time").Return(fmt.Errorf("smtp error"))
+	outbox.EXPECT().MarkDead(ctx, uint64(3), 3, "smtp error").Return(nil)
+
+	processed, err := testWorker.Deliver(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, processed)
+
+	// очередь пуста
+	outbox.EXPECT().Due(ctx, now, 10).Return([]*Message{}, nil)
+
+	processed, err = testWorker.Deliver(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, processed)
+
+	// ошибка очереди
+	outbox.EXPECT().Due(ctx, now, 10).Return(nil, fmt.Errorf("db error"))
+
+	_, err = testWorker.Deliver(ctx)
+
+	assert.Error(t, err)
+
+	// ошибка при сохранении результата
+	outbox.EXPECT().Due(ctx, now, 10).Return(messagesSent[:1], nil)
+
+	alerts.EXPECT().Send([]string{"one@one.net"}, "subject", "sent first time").Return(nil)
+	outbox.EXPECT().MarkSent(ctx, uint64(1), 1).Return(fmt.Errorf("db error"))
+
+	_, err = testWorker.Deliver(ctx)
+
+	assert.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	outbox := NewMockOutbox(ctrl)
+	alerts := alertmanager.NewMockAlertManager(ctrl)
+
+	testWorker := NewWorker(outbox, alerts, 3, time.Minute, time.Hour, 1, zap.NewNop().Sugar())
+
+	// данные для теста
+	msg := &Message{
+		ID:         1,
+		Recipients: []string{"one@one.net"},
+		Subject:    "subject",
+		Body:       "body",
+		Status:     StatusPending,
+	}
+
+	// при старте очередь разбирается, пока пачки полные, затем по тикеру
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+
+	gomock.InOrder(
+		outbox.EXPECT().Due(ctx, gomock.Any(), 1).Return([]*Message{msg}, nil),
+		alerts.EXPECT().Send(msg.Recipients, msg.Subject, msg.Body).Return(nil),
+		outbox.EXPECT().MarkSent(ctx, uint64(1), 1).Return(nil),
+		outbox.EXPECT().Due(ctx, gomock.Any(), 1).Return([]*Message{}, nil),
+		outbox.EXPECT().Due(ctx, gomock.Any(), 1).Return(nil, fmt.Errorf("db error")),
+	)
+
+	wg.Add(1)
+	go testWorker.Run(ctx, time.Second, wg)
+
+	time.Sleep(time.Millisecond * 1500)
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,17 @@
+package password
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadHashFormat = errors.New("bad password hash format")
+)
+
+// Hasher хэширует пароли и проверяет их по сохраненному хэшу.
+// Verify помимо результата проверки сообщает, нужно ли перехэшировать пароль
+// (например, если поменялись параметры хэширования или в базе лежит пароль в открытом виде).
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (ok bool, needsRehash bool, err error)
+}
@@ -0,0 +1,160 @@
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+const (
+	pbkdf2Algorithm = "pbkdf2-sha256"
+)
+
+// PBKDF2Hasher хранит пароли в виде `$pbkdf2-sha256$i=<итерации>,l=<длина ключа>$<соль>$<хэш>`
+type PBKDF2Hasher struct {
+	iterations int
+	saltLength int
+	keyLength  int
+}
+
+var _ Hasher = &PBKDF2Hasher{}
+
+func NewPBKDF2Hasher(iterations, saltLength, keyLength int) *PBKDF2Hasher {
+	return &PBKDF2Hasher{
+		iterations: iterations,
+		saltLength: saltLength,
+		keyLength:  keyLength,
+	}
+}
+
+func (h *PBKDF2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.saltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("rand error: %v", err)
+	}
+
+	key := pbkdf2([]byte(password), salt, h.iterations, h.keyLength, sha256.New)
+
+	return encodePBKDF2(h.iterations, salt, key), nil
+}
+
+func (h *PBKDF2Hasher) Verify(password, encoded string) (bool, bool, error) {
+	// пароли, сохраненные до появления хэширования, лежат в базе в открытом виде
+	if !strings.HasPrefix(encoded, "$") {
+		ok := subtle.ConstantTimeCompare([]byte(password), []byte(encoded)) == 1
+		return ok, true, nil
+	}
+
+	iterations, salt, key, err := decodePBKDF2(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	keyRecv := pbkdf2([]byte(password), salt, iterations, len(key), sha256.New)
+	if subtle.ConstantTimeCompare(key, keyRecv) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash := iterations != h.iterations ||
+		len(salt) != h.saltLength ||
+		len(key) != h.keyLength
+
+	return true, needsRehash, nil
+}
+
+func encodePBKDF2(iterations int, salt, key []byte) string {
+	return fmt.Sprintf("$%s$i=%d,l=%d$%s$%s",
+		pbkdf2Algorithm,
+		iterations,
+		len(key),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodePBKDF2(encoded string) (int, []byte, []byte, error) {
+	// "", алгоритм, параметры, соль, хэш
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 || parts[1] != pbkdf2Algorithm {
+		return 0, nil, nil, ErrBadHashFormat
+	}
+
+	var iterations, keyLength int
+	for _, param := range strings.Split(parts[2], ",") {
+		name, value, found := strings.Cut(param, "=")
+		if !found {
+			return 0, nil, nil, ErrBadHashFormat
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return 0, nil, nil, ErrBadHashFormat
+		}
+
+		switch name {
+		case "i":
+			iterations = n
+		case "l":
+			keyLength = n
+		default:
+			return 0, nil, nil, ErrBadHashFormat
+		}
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return 0, nil, nil, ErrBadHashFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(key) != keyLength || iterations == 0 {
+		return 0, nil, nil, ErrBadHashFormat
+	}
+
+	return iterations, salt, key, nil
+}
+
+// pbkdf2 - реализация PBKDF2 из RFC 8018
+func pbkdf2(password, salt []byte, iterations, keyLength int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	hashLength := prf.Size()
+	blocks := (keyLength + hashLength - 1) / hashLength
+
+	key := make([]byte, 0, blocks*hashLength)
+	buf := make([]byte, 4)
+	u := make([]byte, 0, hashLength)
+
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		buf[0] = byte(block >> 24)
+		buf[1] = byte(block >> 16)
+		buf[2] = byte(block >> 8)
+		buf[3] = byte(block)
+		prf.Write(buf)
+
+		u = prf.Sum(u[:0])
+		t := make([]byte, len(u))
+		copy(t, u)
+
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:keyLength]
+}
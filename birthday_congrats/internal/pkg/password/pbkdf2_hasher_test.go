@@ -0,0 +1,97 @@
+package password
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// тестовые векторы PBKDF2-HMAC-SHA256
+	testCases := []struct {
+		iterations int
+		expected   string
+	}{
+		{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+
+	for _, tc := range testCases {
+		key := pbkdf2([]byte("password"), []byte("salt"), tc.iterations, 32, sha256.New)
+		assert.EqualValues(t, tc.expected, hex.EncodeToString(key))
+	}
+
+	// длина ключа больше длины хэша
+	key := pbkdf2([]byte("password"), []byte("salt"), 1, 40, sha256.New)
+	assert.EqualValues(t, 40, len(key))
+	assert.EqualValues(t, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b", hex.EncodeToString(key[:32]))
+}
+
+func TestHashAndVerify(t *testing.T) {
+	hasher := NewPBKDF2Hasher(10, 16, 32)
+
+	// нормальная работа
+	encoded, err := hasher.Hash("some_pass")
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$pbkdf2-sha256$i=10,l=32$"))
+
+	ok, needsRehash, err := hasher.Verify("some_pass", encoded)
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+
+	// соль у каждого хэша своя
+	encodedAgain, err := hasher.Hash("some_pass")
+
+	assert.NoError(t, err)
+	assert.NotEqualValues(t, encoded, encodedAgain)
+
+	// неверный пароль
+	ok, needsRehash, err = hasher.Verify("bad_pass", encoded)
+
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, needsRehash)
+
+	// поменялись параметры хэширования
+	newHasher := NewPBKDF2Hasher(20, 16, 32)
+
+	ok, needsRehash, err = newHasher.Verify("some_pass", encoded)
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+
+	// пароль в открытом виде
+	ok, needsRehash, err = hasher.Verify("12345678", "12345678")
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+
+	ok, _, err = hasher.Verify("bad_pass", "12345678")
+
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// некорректный формат хэша
+	badHashes := []string{
+		"$pbkdf2-sha256$i=10,l=32$salt",
+		"$md5$i=10,l=32$c2FsdA$a2V5",
+		"$pbkdf2-sha256$i=ten,l=32$c2FsdA$a2V5",
+		"$pbkdf2-sha256$x=10,l=32$c2FsdA$a2V5",
+		"$pbkdf2-sha256$i=10,l=32$!!!$a2V5",
+		"$pbkdf2-sha256$i=10,l=32$c2FsdA$a2V5",
+	}
+
+	for _, bad := range badHashes {
+		_, _, err = hasher.Verify("some_pass", bad)
+		assert.ErrorIs(t, err, ErrBadHashFormat)
+	}
+}
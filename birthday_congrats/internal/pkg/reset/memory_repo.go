@@ -0,0 +1,72 @@
+package reset
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+type resetToken struct {
+	userID  uint32
+	expires int64
+}
+
+// ResetsMemoryRepo хранит токены сброса пароля в памяти процесса (для разработки без базы данных)
+type ResetsMemoryRepo struct {
+	mu     *sync.Mutex
+	tokens map[string]resetToken
+	logger *zap.SugaredLogger
+}
+
+var _ ResetsRepo = &ResetsMemoryRepo{}
+
+func NewResetsMemoryRepo(logger *zap.SugaredLogger) *ResetsMemoryRepo {
+	return &ResetsMemoryRepo{
+		mu:     &sync.Mutex{},
+		tokens: make(map[string]resetToken),
+		logger: logger,
+	}
+}
+
+func (repo *ResetsMemoryRepo) Create(ctx context.Context, userID uint32, tokenHash string, expires int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.tokens[tokenHash] = resetToken{
+		userID:  userID,
+		expires: expires,
+	}
+
+	return nil
+}
+
+func (repo *ResetsMemoryRepo) Use(ctx context.Context, tokenHash string, now int64) (uint32, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	token, ok := repo.tokens[tokenHash]
+	if !ok {
+		return 0, ErrNoToken
+	}
+	delete(repo.tokens, tokenHash)
+
+	if token.expires <= now {
+		return 0, ErrNoToken
+	}
+
+	return token.userID, nil
+}
+
+func (repo *ResetsMemoryRepo) RemoveByUser(ctx context.Context, userID uint32) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for tokenHash, token := range repo.tokens {
+		if token.userID == userID {
+			delete(repo.tokens, tokenHash)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package reset
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+type ResetsMySQLRepo struct {
+	db     *sql.DB
+	logger *zap.SugaredLogger
+}
+
+var _ ResetsRepo = &ResetsMySQLRepo{}
+
+func NewResetsMySQLRepo(db *sql.DB, logger *zap.SugaredLogger) *ResetsMySQLRepo {
+	return &ResetsMySQLRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *ResetsMySQLRepo) Create(ctx context.Context, userID uint32, tokenHash string, expires int64) error {
+	_, err := repo.db.ExecContext(
+		ctx,
+		"INSERT INTO password_resets (`token_hash`, `user_id`, `expires`) VALUES (?, ?, ?)",
+		tokenHash,
+		userID,
+		expires,
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while INSERT into db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *ResetsMySQLRepo) Use(ctx context.Context, tokenHash string, now int64) (uint32, error) {
+	userID := uint32(0)
+	expires := int64(0)
+
+	err := repo.db.
+		QueryRowContext(ctx, "SELECT user_id, expires FROM password_resets WHERE token_hash = ?", tokenHash).
+		Scan(&userID, &expires)
+	if err == sql.ErrNoRows {
+		return 0, ErrNoToken
+	}
+	if err != nil {
+		repo.logger.Errorf("Error while SELECT from db: %v", err)
+		return 0, fmt.Errorf("db error: %v", err)
+	}
+
+	// токен удаляется и когда он просрочен: пользоваться им все равно уже нельзя
+	result, err := repo.db.ExecContext(ctx, "DELETE FROM password_resets WHERE token_hash = ?", tokenHash)
+	if err != nil {
+		repo.logger.Errorf("Error while DELETE from db: %v", err)
+		return 0, fmt.Errorf("db error: %v", err)
+	}
+
+	// RowsAffected = 0, если параллельный запрос успел использовать токен раньше
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.Errorf("Error while getting rows affected: %v", err)
+		return 0, fmt.Errorf("db error: %v", err)
+	}
+	if affected == 0 || expires <= now {
+		return 0, ErrNoToken
+	}
+
+	return userID, nil
+}
+
+func (repo *ResetsMySQLRepo) RemoveByUser(ctx context.Context, userID uint32) error {
+	_, err := repo.db.ExecContext(ctx, "DELETE FROM password_resets WHERE user_id = ?", userID)
+	if err != nil {
+		repo.logger.Errorf("Error while DELETE from db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
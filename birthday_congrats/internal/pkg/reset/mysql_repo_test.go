@@ -0,0 +1,205 @@
+package reset
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewResetsMySQLRepo(db, zap.NewNop().Sugar())
+
+	// нормальная работа
+	mock.
+		ExpectExec("INSERT INTO password_resets").
+		WithArgs("some_hash", 1, 1000).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = testRepo.Create(ctx, 1, "some_hash", 1000)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectExec("INSERT INTO password_resets").
+		WithArgs("some_hash", 1, 1000).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.Create(ctx, 1, "some_hash", 1000)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewResetsMySQLRepo(db, zap.NewNop().Sugar())
+
+	// нормальная работа
+	rows := sqlmock.NewRows([]string{"user_id", "expires"}).AddRow(1, 1000)
+
+	mock.
+		ExpectQuery("SELECT user_id, expires FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnRows(rows)
+	mock.
+		ExpectExec("DELETE FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	userID, err := testRepo.Use(ctx, "some_hash", 999)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, userID)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// нет токена
+	mock.
+		ExpectQuery("SELECT user_id, expires FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = testRepo.Use(ctx, "some_hash", 999)
+
+	assert.ErrorIs(t, err, ErrNoToken)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// срок действия истек, токен все равно удаляется
+	rows = sqlmock.NewRows([]string{"user_id", "expires"}).AddRow(1, 1000)
+
+	mock.
+		ExpectQuery("SELECT user_id, expires FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnRows(rows)
+	mock.
+		ExpectExec("DELETE FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = testRepo.Use(ctx, "some_hash", 1000)
+
+	assert.ErrorIs(t, err, ErrNoToken)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// токен успел использовать параллельный запрос
+	rows = sqlmock.NewRows([]string{"user_id", "expires"}).AddRow(1, 1000)
+
+	mock.
+		ExpectQuery("SELECT user_id, expires FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnRows(rows)
+	mock.
+		ExpectExec("DELETE FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = testRepo.Use(ctx, "some_hash", 999)
+
+	assert.ErrorIs(t, err, ErrNoToken)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд при SELECT
+	mock.
+		ExpectQuery("SELECT user_id, expires FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testRepo.Use(ctx, "some_hash", 999)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoToken)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд при DELETE
+	rows = sqlmock.NewRows([]string{"user_id", "expires"}).AddRow(1, 1000)
+
+	mock.
+		ExpectQuery("SELECT user_id, expires FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnRows(rows)
+	mock.
+		ExpectExec("DELETE FROM password_resets WHERE token_hash = ?").
+		WithArgs("some_hash").
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testRepo.Use(ctx, "some_hash", 999)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoToken)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestRemoveByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewResetsMySQLRepo(db, zap.NewNop().Sugar())
+
+	// нормальная работа
+	mock.
+		ExpectExec("DELETE FROM password_resets WHERE user_id = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = testRepo.RemoveByUser(ctx, 1)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectExec("DELETE FROM password_resets WHERE user_id = ?").
+		WithArgs(1).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.RemoveByUser(ctx, 1)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: reset.go
+
+// Package reset is a generated GoMock package.
+package reset
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockResetsRepo is a mock of ResetsRepo interface.
+type MockResetsRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockResetsRepoMockRecorder
+}
+
+// MockResetsRepoMockRecorder is the mock recorder for MockResetsRepo.
+type MockResetsRepoMockRecorder struct {
+	mock *MockResetsRepo
+}
+
+// NewMockResetsRepo creates a new mock instance.
+func NewMockResetsRepo(ctrl *gomock.Controller) *MockResetsRepo {
+	mock := &MockResetsRepo{ctrl: ctrl}
+	mock.recorder = &MockResetsRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResetsRepo) EXPECT() *MockResetsRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockResetsRepo) Create(ctx context.Context, userID uint32, tokenHash string, expires int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, userID, tokenHash, expires)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockResetsRepoMockRecorder) Create(ctx, userID, tokenHash, expires interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockResetsRepo)(nil).Create), ctx, userID, tokenHash, expires)
+}
+
+// RemoveByUser mocks base method.
+func (m *MockResetsRepo) RemoveByUser(ctx context.Context, userID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveByUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveByUser indicates an expected call of RemoveByUser.
+func (mr *MockResetsRepoMockRecorder) RemoveByUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveByUser", reflect.TypeOf((*MockResetsRepo)(nil).RemoveByUser), ctx, userID)
+}
+
+// Use mocks base method.
+func (m *MockResetsRepo) Use(ctx context.Context, tokenHash string, now int64) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, tokenHash, now)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockResetsRepoMockRecorder) Use(ctx, tokenHash, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockResetsRepo)(nil).Use), ctx, tokenHash, now)
+}
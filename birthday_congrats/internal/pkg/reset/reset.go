@@ -0,0 +1,21 @@
+package reset
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoToken = errors.New("no reset token")
+)
+
+// ResetsRepo хранит одноразовые токены сброса пароля. Самих токенов в хранилище нет,
+// только их хэши (session.HashToken): утечка таблицы не позволяет сменить чужой пароль.
+type ResetsRepo interface {
+	Create(ctx context.Context, userID uint32, tokenHash string, expires int64) error
+	// Use удаляет токен и возвращает пользователя, для которого он выпущен; ErrNoToken,
+	// если токена нет, он уже использован или срок его действия (unix-время) к моменту now истек
+	Use(ctx context.Context, tokenHash string, now int64) (uint32, error)
+	RemoveByUser(ctx context.Context, userID uint32) error
+}
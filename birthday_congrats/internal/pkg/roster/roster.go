@@ -0,0 +1,125 @@
+package roster
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadFile = errors.New("bad roster file")
+)
+
+// Колонки CSV; department необязательна
+const (
+	ColumnName       = "name"
+	ColumnEmail      = "email"
+	ColumnBirthday   = "birthday"
+	ColumnDepartment = "department"
+)
+
+// Record - сотрудник из выгрузки HR. Значения не проверяются: это дело сервиса,
+// который сообщает об ошибках по номерам записей.
+type Record struct {
+	Line       int    // номер строки CSV (заголовок - строка 1) или записи JSON (с 1)
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	Birthday   string `json:"birthday"` // YYYY-MM-DD или --MM-DD
+	Department string `json:"department"`
+}
+
+// ParseCSV разбирает CSV с заголовком. Колонки name, email и birthday обязательны,
+// department - нет; порядок колонок любой, лишние колонки - ошибка (скорее всего, опечатка).
+func ParseCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.Wrap(ErrBadFile, "empty csv")
+	}
+	if err != nil {
+		return nil, errors.Wrap(ErrBadFile, err.Error())
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Excel дописывает BOM в начало файла
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+
+		switch name {
+		case ColumnName, ColumnEmail, ColumnBirthday, ColumnDepartment:
+		default:
+			return nil, errors.Wrap(ErrBadFile, fmt.Sprintf("unknown column %q", name))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, errors.Wrap(ErrBadFile, fmt.Sprintf("duplicate column %q", name))
+		}
+
+		columns[name] = i
+	}
+
+	for _, name := range []string{ColumnName, ColumnEmail, ColumnBirthday} {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.Wrap(ErrBadFile, fmt.Sprintf("missing column %q", name))
+		}
+	}
+
+	field := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok {
+			return ""
+		}
+
+		return strings.TrimSpace(row[i])
+	}
+
+	records := make([]Record, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(ErrBadFile, err.Error())
+		}
+
+		line, _ := reader.FieldPos(0)
+		records = append(records, Record{
+			Line:       line,
+			Name:       field(row, ColumnName),
+			Email:      field(row, ColumnEmail),
+			Birthday:   field(row, ColumnBirthday),
+			Department: field(row, ColumnDepartment),
+		})
+	}
+
+	return records, nil
+}
+
+// ParseJSON разбирает JSON-массив объектов с полями name, email, birthday и department
+func ParseJSON(r io.Reader) ([]Record, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	records := make([]Record, 0)
+
+	err := dec.Decode(&records)
+	if err != nil {
+		return nil, errors.Wrap(ErrBadFile, err.Error())
+	}
+
+	for i := range records {
+		records[i].Line = i + 1
+		records[i].Name = strings.TrimSpace(records[i].Name)
+		records[i].Email = strings.TrimSpace(records[i].Email)
+		records[i].Birthday = strings.TrimSpace(records[i].Birthday)
+		records[i].Department = strings.TrimSpace(records[i].Department)
+	}
+
+	return records, nil
+}
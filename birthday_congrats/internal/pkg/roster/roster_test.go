@@ -0,0 +1,88 @@
+package roster
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCSV(t *testing.T) {
+	// нормальная работа: порядок колонок любой, регистр заголовка не важен
+	data := "\ufeffEmail, Name, Birthday, Department\n" +
+		"alice@example.com, alice, 1990-05-10, Бухгалтерия\n" +
+		"bob@example.com,bob,--07-01,\n"
+
+	records, err := ParseCSV(strings.NewReader(data))
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []Record{
+		{Line: 2, Name: "alice", Email: "alice@example.com", Birthday: "1990-05-10", Department: "Бухгалтерия"},
+		{Line: 3, Name: "bob", Email: "bob@example.com", Birthday: "--07-01"},
+	}, records)
+
+	// без отдела
+	records, err = ParseCSV(strings.NewReader("name,email,birthday\nalice,alice@example.com,1990-05-10\n"))
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []Record{
+		{Line: 2, Name: "alice", Email: "alice@example.com", Birthday: "1990-05-10"},
+	}, records)
+
+	// только заголовок
+	records, err = ParseCSV(strings.NewReader("name,email,birthday\n"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	// пустой файл
+	_, err = ParseCSV(strings.NewReader(""))
+
+	assert.ErrorIs(t, err, ErrBadFile)
+
+	// нет обязательной колонки
+	_, err = ParseCSV(strings.NewReader("name,email\nalice,alice@example.com\n"))
+
+	assert.ErrorIs(t, err, ErrBadFile)
+
+	// неизвестная колонка
+	_, err = ParseCSV(strings.NewReader("name,email,birthday,phone\n"))
+
+	assert.ErrorIs(t, err, ErrBadFile)
+
+	// колонка повторяется
+	_, err = ParseCSV(strings.NewReader("name,email,birthday,email\n"))
+
+	assert.ErrorIs(t, err, ErrBadFile)
+
+	// неверное число полей в строке
+	_, err = ParseCSV(strings.NewReader("name,email,birthday\nalice,alice@example.com\n"))
+
+	assert.ErrorIs(t, err, ErrBadFile)
+}
+
+func TestParseJSON(t *testing.T) {
+	// нормальная работа
+	data := `[
+		{"name": " alice ", "email": "alice@example.com", "birthday": "1990-05-10", "department": "Бухгалтерия"},
+		{"name": "bob", "email": "bob@example.com", "birthday": "--07-01"}
+	]`
+
+	records, err := ParseJSON(strings.NewReader(data))
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []Record{
+		{Line: 1, Name: "alice", Email: "alice@example.com", Birthday: "1990-05-10", Department: "Бухгалтерия"},
+		{Line: 2, Name: "bob", Email: "bob@example.com", Birthday: "--07-01"},
+	}, records)
+
+	// неизвестное поле
+	_, err = ParseJSON(strings.NewReader(`[{"name": "alice", "phone": "123"}]`))
+
+	assert.ErrorIs(t, err, ErrBadFile)
+
+	// не массив
+	_, err = ParseJSON(strings.NewReader(`{"name": "alice"}`))
+
+	assert.ErrorIs(t, err, ErrBadFile)
+}
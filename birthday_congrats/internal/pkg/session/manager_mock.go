@@ -50,6 +50,21 @@ func (mr *MockSessionsManagerMockRecorder) Check(r interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockSessionsManager)(nil).Check), r)
 }
 
+// CheckToken mocks base method.
+func (m *MockSessionsManager) CheckToken(ctx context.Context, token string) (*Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckToken", ctx, token)
+	ret0, _ := ret[0].(*Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckToken indicates an expected call of CheckToken.
+func (mr *MockSessionsManagerMockRecorder) CheckToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckToken", reflect.TypeOf((*MockSessionsManager)(nil).CheckToken), ctx, token)
+}
+
 // Create mocks base method.
 func (m *MockSessionsManager) Create(ctx context.Context, userID uint32) (*Session, error) {
 	m.ctrl.T.Helper()
@@ -78,3 +93,17 @@ func (mr *MockSessionsManagerMockRecorder) Destroy(ctx interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Destroy", reflect.TypeOf((*MockSessionsManager)(nil).Destroy), ctx)
 }
+
+// DestroyAll mocks base method.
+func (m *MockSessionsManager) DestroyAll(ctx context.Context, userID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DestroyAll", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DestroyAll indicates an expected call of DestroyAll.
+func (mr *MockSessionsManagerMockRecorder) DestroyAll(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DestroyAll", reflect.TypeOf((*MockSessionsManager)(nil).DestroyAll), ctx, userID)
+}
@@ -0,0 +1,123 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MemorySessionsManager хранит сессии в памяти процесса (для разработки без базы данных).
+// Как и в MySQL, хранится только хэш токена.
+type MemorySessionsManager struct {
+	mu       *sync.RWMutex
+	sessions map[string]Session // хэш токена -> сессия
+	logger   *zap.SugaredLogger
+
+	expiresTime int64
+	tokenBytes  int
+}
+
+var _ SessionsManager = &MemorySessionsManager{}
+
+func NewMemorySessionsManager(logger *zap.SugaredLogger, expiresTime int64, tokenBytes int) *MemorySessionsManager {
+	return &MemorySessionsManager{
+		mu:          &sync.RWMutex{},
+		sessions:    make(map[string]Session),
+		logger:      logger,
+		expiresTime: expiresTime,
+		tokenBytes:  tokenBytes,
+	}
+}
+
+func (sm *MemorySessionsManager) Create(ctx context.Context, userID uint32) (*Session, error) {
+	newSession, err := newSession(sm.tokenBytes, userID, time.Now().Unix()+sm.expiresTime)
+	if err != nil {
+		sm.logger.Errorf("Error while generating session id: %v", err)
+		return nil, ErrSessionNotCreated
+	}
+
+	sm.mu.Lock()
+	sm.sessions[HashToken(newSession.SessID)] = Session{
+		UserID:  newSession.UserID,
+		Expires: newSession.Expires,
+	}
+	sm.mu.Unlock()
+
+	return &newSession, nil
+}
+
+func (sm *MemorySessionsManager) Check(r *http.Request) (*Session, error) {
+	sessID, ok := TokenFromRequest(r)
+	if !ok {
+		sm.logger.Warnf("No session cookie found")
+		return nil, ErrNoSession
+	}
+
+	return sm.CheckToken(r.Context(), sessID)
+}
+
+func (sm *MemorySessionsManager) CheckToken(ctx context.Context, sessID string) (*Session, error) {
+	sm.mu.RLock()
+	stored, ok := sm.sessions[HashToken(sessID)]
+	sm.mu.RUnlock()
+
+	if !ok {
+		sm.logger.Warnf("No session found")
+		return nil, ErrNoSession
+	}
+
+	sess := &Session{
+		SessID:  sessID,
+		UserID:  stored.UserID,
+		Expires: stored.Expires,
+	}
+
+	// проверка, что сессия не истекла
+	if sess.Expires < time.Now().Unix() {
+		err := sm.Destroy(ContextWithSession(ctx, sess))
+		if err != nil {
+			sm.logger.Errorf("Error while destroying session: %v", err)
+			return nil, fmt.Errorf("destroy session error: %v", err)
+		}
+
+		return nil, ErrSessionExpired
+	}
+
+	return sess, nil
+}
+
+func (sm *MemorySessionsManager) Destroy(ctx context.Context) error {
+	sess, err := SessionFromContext(ctx)
+	if err != nil {
+		sm.logger.Errorf("Error getting session from context: %v", err)
+		return ErrNoSession
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	key := HashToken(sess.SessID)
+	if _, ok := sm.sessions[key]; !ok {
+		return ErrNotDestroyed
+	}
+	delete(sm.sessions, key)
+
+	return nil
+}
+
+func (sm *MemorySessionsManager) DestroyAll(ctx context.Context, userID uint32) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	for key, sess := range sm.sessions {
+		if sess.UserID == userID {
+			delete(sm.sessions, key)
+		}
+	}
+
+	return nil
+}
@@ -15,28 +15,34 @@ type MySQLSessionsManager struct {
 	db     *sql.DB
 	logger *zap.SugaredLogger
 
-	expiresTime  int64
-	sessIDLength int
+	expiresTime int64
+	tokenBytes  int
 }
 
 var _ SessionsManager = &MySQLSessionsManager{}
 
-func NewMySQLSessionsManager(db *sql.DB, logger *zap.SugaredLogger, expiresTime int64, sessIDLength int) *MySQLSessionsManager {
+// NewMySQLSessionsManager создает менеджер сессий; tokenBytes - энтропия идентификатора сессии в байтах
+func NewMySQLSessionsManager(db *sql.DB, logger *zap.SugaredLogger, expiresTime int64, tokenBytes int) *MySQLSessionsManager {
 	return &MySQLSessionsManager{
-		db:           db,
-		logger:       logger,
-		expiresTime:  expiresTime,
-		sessIDLength: sessIDLength,
+		db:          db,
+		logger:      logger,
+		expiresTime: expiresTime,
+		tokenBytes:  tokenBytes,
 	}
 }
 
 func (sm *MySQLSessionsManager) Create(ctx context.Context, userID uint32) (*Session, error) {
-	newSession := newSession(sm.sessIDLength, userID, time.Now().Unix()+sm.expiresTime)
+	newSession, err := newSession(sm.tokenBytes, userID, time.Now().Unix()+sm.expiresTime)
+	if err != nil {
+		sm.logger.Errorf("Error while generating session id: %v", err)
+		return nil, ErrSessionNotCreated
+	}
 
+	// в базе храним только хэш токена, чтобы по дампу базы нельзя было войти
 	result, err := sm.db.ExecContext(
 		ctx,
 		"INSERT INTO sessions (`sess_id`, `user_id`, `expires`) VALUES (?, ?, ?)",
-		newSession.SessID,
+		HashToken(newSession.SessID),
 		newSession.UserID,
 		newSession.Expires,
 	)
@@ -60,22 +66,24 @@ func (sm *MySQLSessionsManager) Create(ctx context.Context, userID uint32) (*Ses
 }
 
 func (sm *MySQLSessionsManager) Check(r *http.Request) (*Session, error) {
-	cookie, err := r.Cookie("session_id")
-	if err != nil {
+	sessID, ok := TokenFromRequest(r)
+	if !ok {
 		sm.logger.Warnf("No session cookie found")
 		return nil, ErrNoSession
 	}
 
-	sessID := cookie.Value
+	return sm.CheckToken(r.Context(), sessID)
+}
 
+func (sm *MySQLSessionsManager) CheckToken(ctx context.Context, sessID string) (*Session, error) {
 	// проверка, что сессия существует
 	sess := &Session{
 		SessID: sessID,
 	}
-	err = sm.db.QueryRowContext(
-		r.Context(),
+	err := sm.db.QueryRowContext(
+		ctx,
 		"SELECT user_id, expires FROM sessions WHERE sess_id = ?",
-		sessID,
+		HashToken(sessID),
 	).Scan(
 		&sess.UserID,
 		&sess.Expires,
@@ -91,7 +99,7 @@ func (sm *MySQLSessionsManager) Check(r *http.Request) (*Session, error) {
 
 	// проверка, что сессия не истекла
 	if sess.Expires < time.Now().Unix() {
-		err := sm.Destroy(r.Context())
+		err := sm.Destroy(ContextWithSession(ctx, sess))
 		if err != nil {
 			sm.logger.Errorf("Error while destroying session: %v", err)
 			return nil, fmt.Errorf("destroy session error: %v", err)
@@ -113,7 +121,7 @@ func (sm *MySQLSessionsManager) Destroy(ctx context.Context) error {
 	result, err := sm.db.ExecContext(
 		ctx,
 		"DELETE FROM sessions WHERE sess_id = ?",
-		sess.SessID,
+		HashToken(sess.SessID),
 	)
 	if err != nil {
 		sm.logger.Errorf("Error while DELETE from db: %v", err)
@@ -133,3 +141,18 @@ func (sm *MySQLSessionsManager) Destroy(ctx context.Context) error {
 
 	return nil
 }
+
+func (sm *MySQLSessionsManager) DestroyAll(ctx context.Context, userID uint32) error {
+	// открытых сессий может и не быть, поэтому RowsAffected не проверяем
+	_, err := sm.db.ExecContext(
+		ctx,
+		"DELETE FROM sessions WHERE user_id = ?",
+		userID,
+	)
+	if err != nil {
+		sm.logger.Errorf("Error while DELETE from db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
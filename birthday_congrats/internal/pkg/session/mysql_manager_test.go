@@ -3,6 +3,8 @@ package session
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -30,6 +32,18 @@ func (res *customErrorResult) RowsAffected() (int64, error) {
 	return int64(1), res.errAffected
 }
 
+// captureArg запоминает значение аргумента запроса
+type captureArg struct {
+	value driver.Value
+}
+
+var _ sqlmock.Argument = &captureArg{}
+
+func (a *captureArg) Match(v driver.Value) bool {
+	a.value = v
+	return true
+}
+
 func TestCreate(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -40,12 +54,12 @@ func TestCreate(t *testing.T) {
 	ctx := context.Background()
 
 	expirationTime := 60
-	sessIDlength := 16
+	tokenBytes := 32
 	testManager := NewMySQLSessionsManager(
 		db,
 		zap.NewNop().Sugar(),
 		int64(expirationTime),
-		sessIDlength,
+		tokenBytes,
 	)
 
 	// данные для теста
@@ -55,16 +69,22 @@ func TestCreate(t *testing.T) {
 	}
 
 	// нормальная работа
+	sessIDInDB := &captureArg{}
+
 	mock.
 		ExpectExec("INSERT INTO sessions").
-		WithArgs(sqlmock.AnyArg(), userID, sqlmock.AnyArg()).
+		WithArgs(sessIDInDB, userID, sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	sessRecv, err := testManager.Create(ctx, userID)
 
 	assert.NoError(t, err)
 	assert.EqualValues(t, sessExpected.UserID, sessRecv.UserID)
-	assert.EqualValues(t, sessIDlength, utf8.RuneCountInString(sessRecv.SessID))
+	assert.EqualValues(t, base64.RawURLEncoding.EncodedLen(tokenBytes), utf8.RuneCountInString(sessRecv.SessID))
+
+	// в базу попадает только хэш токена
+	assert.EqualValues(t, HashToken(sessRecv.SessID), sessIDInDB.value)
+	assert.NotEqualValues(t, sessRecv.SessID, sessIDInDB.value)
 
 	actualExpirationTime := sessRecv.Expires - time.Now().Unix()
 	assert.True(t, float64(actualExpirationTime) > 0.5*float64(expirationTime) &&
@@ -139,7 +159,7 @@ func TestDestroy(t *testing.T) {
 
 	mock.
 		ExpectExec("DELETE FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	err = testManager.Destroy(ctx)
@@ -160,7 +180,7 @@ func TestDestroy(t *testing.T) {
 
 	mock.
 		ExpectExec("DELETE FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnError(fmt.Errorf("db error"))
 
 	err = testManager.Destroy(ctx)
@@ -173,7 +193,7 @@ func TestDestroy(t *testing.T) {
 	// rows affected = 0
 	mock.
 		ExpectExec("DELETE FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
 	err = testManager.Destroy(ctx)
@@ -186,7 +206,7 @@ func TestDestroy(t *testing.T) {
 	// ошибка rowsAffected()
 	mock.
 		ExpectExec("DELETE FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnResult(&customErrorResult{errAffected: fmt.Errorf("affected error")})
 
 	err = testManager.Destroy(ctx)
@@ -233,7 +253,7 @@ func TestCheck(t *testing.T) {
 
 	mock.
 		ExpectQuery("SELECT user_id, expires FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnRows(rows)
 
 	sessRecv, err := testManager.Check(req)
@@ -257,7 +277,7 @@ func TestCheck(t *testing.T) {
 
 	mock.
 		ExpectQuery("SELECT user_id, expires FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnError(fmt.Errorf("db error"))
 
 	_, err = testManager.Check(req)
@@ -276,7 +296,7 @@ func TestCheck(t *testing.T) {
 
 	mock.
 		ExpectQuery("SELECT user_id, expires FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnRows(rows)
 
 	_, err = testManager.Check(req)
@@ -294,7 +314,7 @@ func TestCheck(t *testing.T) {
 
 	mock.
 		ExpectQuery("SELECT user_id, expires FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnRows(rows)
 
 	_, err = testManager.Check(req)
@@ -311,20 +331,17 @@ func TestCheck(t *testing.T) {
 	req = httptest.NewRequest(http.MethodGet, "/", nil)
 	req.AddCookie(cookie)
 
-	ctx := ContextWithSession(req.Context(), sessExpected)
-	req = req.WithContext(ctx)
-
 	rows = sqlmock.NewRows([]string{"user_id", "expires"})
 	rows = rows.AddRow(sessExpected.UserID, sessExpected.Expires)
 
 	mock.
 		ExpectQuery("SELECT user_id, expires FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnRows(rows)
 
 	mock.
 		ExpectExec("DELETE FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	_, err = testManager.Check(req)
@@ -346,9 +363,14 @@ func TestCheck(t *testing.T) {
 
 	mock.
 		ExpectQuery("SELECT user_id, expires FROM sessions WHERE").
-		WithArgs(sessExpected.SessID).
+		WithArgs(HashToken(sessExpected.SessID)).
 		WillReturnRows(rows)
 
+	mock.
+		ExpectExec("DELETE FROM sessions WHERE").
+		WithArgs(HashToken(sessExpected.SessID)).
+		WillReturnError(fmt.Errorf("db error"))
+
 	_, err = testManager.Check(req)
 
 	assert.Error(t, err)
@@ -356,3 +378,62 @@ func TestCheck(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
+
+func TestDestroyAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	testManager := NewMySQLSessionsManager(
+		db,
+		zap.NewNop().Sugar(),
+		int64(0),
+		0,
+	)
+
+	ctx := context.Background()
+
+	// данные для теста
+	userID := uint32(42)
+
+	// нормальная работа
+	mock.
+		ExpectExec("DELETE FROM sessions WHERE").
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = testManager.DestroyAll(ctx, userID)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// открытых сессий не было
+	mock.
+		ExpectExec("DELETE FROM sessions WHERE").
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = testManager.DestroyAll(ctx, userID)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ответ с ошибкой
+	mock.
+		ExpectExec("DELETE FROM sessions WHERE").
+		WithArgs(userID).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testManager.DestroyAll(ctx, userID)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
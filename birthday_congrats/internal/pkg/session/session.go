@@ -2,8 +2,13 @@ package session
 
 import (
 	"context"
-	"math/rand"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -24,32 +29,70 @@ type Session struct {
 type SessionsManager interface {
 	Create(ctx context.Context, userID uint32) (*Session, error)
 	Check(r *http.Request) (*Session, error)
+	CheckToken(ctx context.Context, token string) (*Session, error) // проверяет уже извлеченный из запроса токен
 	Destroy(ctx context.Context) error
+	DestroyAll(ctx context.Context, userID uint32) error // завершает все сессии пользователя
 }
 
-func newSession(sessIDLength int, userID uint32, expires int64) Session {
+func newSession(tokenBytes int, userID uint32, expires int64) (Session, error) {
+	token, err := NewToken(tokenBytes)
+	if err != nil {
+		return Session{}, err
+	}
+
 	return Session{
-		SessID:  RandStringRunes(sessIDLength),
+		SessID:  token,
 		UserID:  userID,
 		Expires: expires,
+	}, nil
+}
+
+// NewToken возвращает случайный токен из n байт crypto/rand в кодировке base64url
+func NewToken(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("rand error: %v", err)
 	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-var (
-	letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
-)
+// HashToken возвращает хэш токена, который хранится в базе вместо самого токена
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenFromRequest достает идентификатор сессии из куки session_id,
+// а если ее нет - из заголовка `Authorization: Bearer <токен>`
+func TokenFromRequest(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie("session_id")
+	if err == nil && cookie.Value != "" {
+		return cookie.Value, true
+	}
+
+	return BearerToken(r)
+}
 
-func RandStringRunes(n int) string {
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letterRunes[rand.Intn(len(letterRunes))]
+// BearerToken достает идентификатор сессии только из заголовка `Authorization: Bearer <токен>`.
+// Куку браузер отправляет сам и с запросом, подделанным чужим сайтом, а заголовок - нет,
+// поэтому API, у которого нет проверки CSRF, принимает только его.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") && token != "" {
+		return token, true
 	}
-	return string(b)
+
+	return "", false
 }
 
 type sessKey string
 
-const sessionKey sessKey = "sessionKey"
+const (
+	sessionKey sessKey = "sessionKey"
+	systemKey  sessKey = "systemKey"
+)
 
 func ContextWithSession(ctx context.Context, sess *Session) context.Context {
 	return context.WithValue(ctx, sessionKey, sess)
@@ -63,3 +106,15 @@ func SessionFromContext(ctx context.Context) (*Session, error) {
 
 	return sess, nil
 }
+
+// ContextAsSystem помечает запрос доверенной системы (HR по токену SCIM): сессии пользователя
+// у нее нет, но административные методы сервиса ей доступны
+func ContextAsSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey, true)
+}
+
+// IsSystem сообщает, пришел ли запрос от доверенной системы
+func IsSystem(ctx context.Context) bool {
+	system, _ := ctx.Value(systemKey).(bool)
+	return system
+}
@@ -0,0 +1,109 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewToken(t *testing.T) {
+	// нормальная работа
+	token, err := NewToken(32)
+
+	assert.NoError(t, err)
+
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 32, len(decoded))
+
+	// токены не повторяются
+	other, err := NewToken(32)
+
+	assert.NoError(t, err)
+	assert.NotEqualValues(t, token, other)
+}
+
+func TestHashToken(t *testing.T) {
+	// хэш детерминирован и не совпадает с токеном
+	assert.EqualValues(t, HashToken("some_sess_id"), HashToken("some_sess_id"))
+	assert.NotEqualValues(t, "some_sess_id", HashToken("some_sess_id"))
+	assert.NotEqualValues(t, HashToken("some_sess_id"), HashToken("other_sess_id"))
+	assert.EqualValues(t, 64, len(HashToken("some_sess_id")))
+
+	// sha256("abc")
+	assert.EqualValues(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", HashToken("abc"))
+}
+
+func TestTokenFromRequest(t *testing.T) {
+	// токен из куки
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: "cookie_token"})
+	r.Header.Set("Authorization", "Bearer header_token")
+
+	token, ok := TokenFromRequest(r)
+
+	assert.True(t, ok)
+	assert.EqualValues(t, "cookie_token", token)
+
+	// токен из заголовка
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "bearer header_token")
+
+	token, ok = TokenFromRequest(r)
+
+	assert.True(t, ok)
+	assert.EqualValues(t, "header_token", token)
+
+	// другая схема авторизации
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+
+	_, ok = TokenFromRequest(r)
+
+	assert.False(t, ok)
+
+	// токена нет
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	_, ok = TokenFromRequest(r)
+
+	assert.False(t, ok)
+}
+
+func TestBearerToken(t *testing.T) {
+	// cookie не учитывается
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: "cookie_token"})
+
+	_, ok := BearerToken(r)
+
+	assert.False(t, ok)
+
+	// токен из заголовка, даже если есть cookie
+	r.Header.Set("Authorization", "Bearer header_token")
+
+	token, ok := BearerToken(r)
+
+	assert.True(t, ok)
+	assert.EqualValues(t, "header_token", token)
+}
+
+func TestContextAsSystem(t *testing.T) {
+	// обычный запрос
+	assert.False(t, IsSystem(context.Background()))
+
+	// запрос доверенной системы
+	ctx := ContextAsSystem(context.Background())
+
+	assert.True(t, IsSystem(ctx))
+
+	// пометка не подменяет сессию
+	_, err := SessionFromContext(ctx)
+
+	assert.ErrorIs(t, err, ErrNoSession)
+}
@@ -0,0 +1,57 @@
+package storetest
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/password"
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMemoryUsersRepo(t *testing.T) {
+	UsersRepo(t, func(t *testing.T) user.UsersRepo {
+		return user.NewUsersMemoryRepo(password.NewPBKDF2Hasher(1000, 16, 32), zap.NewNop().Sugar())
+	})
+}
+
+func TestMemorySubscriptionsRepo(t *testing.T) {
+	SubscriptionsRepo(t, func(t *testing.T) subscription.SubscriptionsRepo {
+		return subscription.NewSubscriptionsMemoryRepo(zap.NewNop().Sugar())
+	})
+}
+
+func TestMemorySessionsManager(t *testing.T) {
+	SessionsManager(t, func(t *testing.T, ttl int64) session.SessionsManager {
+		return session.NewMemorySessionsManager(zap.NewNop().Sugar(), ttl, 32)
+	})
+}
+
+func TestMemoryResetsRepo(t *testing.T) {
+	ResetsRepo(t, func(t *testing.T) reset.ResetsRepo {
+		return reset.NewResetsMemoryRepo(zap.NewNop().Sugar())
+	})
+}
+
+func TestMemoryAuditRepo(t *testing.T) {
+	AuditRepo(t, func(t *testing.T) audit.AuditRepo {
+		return audit.NewAuditMemoryRepo(zap.NewNop().Sugar())
+	})
+}
+
+func TestMemoryOutbox(t *testing.T) {
+	Outbox(t, func(t *testing.T) outbox.Outbox {
+		return outbox.NewOutboxMemoryRepo(zap.NewNop().Sugar())
+	})
+}
+
+func TestMemoryDeliveriesRepo(t *testing.T) {
+	DeliveriesRepo(t, func(t *testing.T) delivery.DeliveriesRepo {
+		return delivery.NewDeliveriesMemoryRepo(zap.NewNop().Sugar())
+	})
+}
@@ -0,0 +1,208 @@
+package storetest
+
+import (
+	"birthday_congrats/databases"
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/migrate"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/password"
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// тесты MySQL-хранилищ идут на настоящей базе, например из databases/docker-compose.yml:
+// BIRTHDAY_TEST_MYSQL_DSN='root:root@tcp(localhost:3306)/golang' go test ./internal/pkg/storetest/
+// ВНИМАНИЕ: тесты удаляют все данные из таблиц
+const mysqlDSNEnv = "BIRTHDAY_TEST_MYSQL_DSN"
+
+// openMySQL подключается к тестовой базе, применяет миграции и очищает таблицы
+func openMySQL(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(mysqlDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", mysqlDSNEnv)
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("cant open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := migrate.Load(databases.Migrations())
+	if err != nil {
+		t.Fatalf("cant load migrations: %v", err)
+	}
+
+	_, err = migrate.NewMySQLMigrator(db, migrations, zap.NewNop().Sugar()).Up(context.Background())
+	if err != nil {
+		t.Fatalf("cant apply migrations: %v", err)
+	}
+
+	// подписки, сессии, токены сброса пароля и журнал изменений удаляются каскадно
+	for _, table := range []string{"users", "alerts_outbox", "alert_runs", "alert_deliveries"} {
+		_, err = db.Exec("DELETE FROM " + table)
+		if err != nil {
+			t.Fatalf("cant clean %s: %v", table, err)
+		}
+	}
+
+	return db
+}
+
+// seedUsers создает пользователей SeedUsers
+func seedUsers(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	for _, id := range SeedUsers {
+		_, err := db.Exec(
+			"INSERT INTO users (`id`, `username`, `password`, `email`, `timezone`, `birthday`) VALUES (?, ?, '', ?, 'UTC', '2000-01-01')",
+			id,
+			fmt.Sprintf("seed_%d", id),
+			fmt.Sprintf("seed_%d@example.com", id),
+		)
+		if err != nil {
+			t.Fatalf("cant seed user %d: %v", id, err)
+		}
+	}
+}
+
+func TestMySQLUsersRepo(t *testing.T) {
+	UsersRepo(t, func(t *testing.T) user.UsersRepo {
+		return user.NewUsersMySQLRepo(openMySQL(t), password.NewPBKDF2Hasher(1000, 16, 32), zap.NewNop().Sugar())
+	})
+}
+
+func TestMySQLSubscriptionsRepo(t *testing.T) {
+	SubscriptionsRepo(t, func(t *testing.T) subscription.SubscriptionsRepo {
+		db := openMySQL(t)
+		seedUsers(t, db)
+
+		return subscription.NewSubscriptionsMySQLRepo(db, zap.NewNop().Sugar())
+	})
+}
+
+func TestMySQLSessionsManager(t *testing.T) {
+	SessionsManager(t, func(t *testing.T, ttl int64) session.SessionsManager {
+		db := openMySQL(t)
+		seedUsers(t, db)
+
+		return session.NewMySQLSessionsManager(db, zap.NewNop().Sugar(), ttl, 32)
+	})
+}
+
+func TestMySQLResetsRepo(t *testing.T) {
+	ResetsRepo(t, func(t *testing.T) reset.ResetsRepo {
+		db := openMySQL(t)
+		seedUsers(t, db)
+
+		return reset.NewResetsMySQLRepo(db, zap.NewNop().Sugar())
+	})
+}
+
+func TestMySQLAuditRepo(t *testing.T) {
+	AuditRepo(t, func(t *testing.T) audit.AuditRepo {
+		db := openMySQL(t)
+		seedUsers(t, db)
+
+		return audit.NewAuditMySQLRepo(db, zap.NewNop().Sugar())
+	})
+}
+
+func TestMySQLOutbox(t *testing.T) {
+	Outbox(t, func(t *testing.T) outbox.Outbox {
+		return outbox.NewOutboxMySQLRepo(openMySQL(t), zap.NewNop().Sugar())
+	})
+}
+
+func TestMySQLDeliveriesRepo(t *testing.T) {
+	DeliveriesRepo(t, func(t *testing.T) delivery.DeliveriesRepo {
+		return delivery.NewDeliveriesMySQLRepo(openMySQL(t), zap.NewNop().Sugar())
+	})
+}
+
+// legacySchema - таблицы и данные в том виде, в каком их создавали прежние init-скрипты
+// из databases/sql: без часовых поясов, с паролями и сессиями в открытом виде
+var legacySchema = []string{
+	"CREATE TABLE `users` (`id` int NOT NULL AUTO_INCREMENT, `username` text NOT NULL COLLATE utf8_bin, " +
+		"`password` text NOT NULL COLLATE utf8_bin, `email` text NOT NULL, `year` int NOT NULL, `month` int NOT NULL, " +
+		"`day` int NOT NULL, PRIMARY KEY (`id`)) ENGINE=InnoDB DEFAULT CHARSET=utf8",
+	"CREATE TABLE `sessions` (`sess_id` text NOT NULL COLLATE utf8_bin, `user_id` int NOT NULL, `expires` bigint) ENGINE=InnoDB DEFAULT CHARSET=utf8",
+	"CREATE TABLE `subscriptions` (`subscriber_id` int NOT NULL, `subscription_id` int NOT NULL, `days_alert` int NOT NULL) ENGINE=InnoDB DEFAULT CHARSET=utf8",
+	"INSERT INTO `users` (`id`, `username`, `password`, `email`, `year`, `month`, `day`) VALUES " +
+		"(1, 'sasha', '12345678', 'sashafe5555@gmail.com', 2000, 6, 30), (2, 'admin', 'admin123', 'admin@123.ru', 1970, 1, 1)",
+	"INSERT INTO `sessions` (`sess_id`, `user_id`, `expires`) VALUES ('plain_sess_id', 1, 4102444800)",
+	// дубль напоминания и подписка на пользователя, которого уже нет
+	"INSERT INTO `subscriptions` (`subscriber_id`, `subscription_id`, `days_alert`) VALUES (1, 2, 0), (1, 2, 0), (2, 1, 3), (1, 3, 0)",
+}
+
+// базу, развернутую прежними init-скриптами, миграции принимают с данными
+func TestMySQLLegacyDatabase(t *testing.T) {
+	db := openMySQL(t)
+
+	migrations, err := migrate.Load(databases.Migrations())
+	if err != nil {
+		t.Fatalf("cant load migrations: %v", err)
+	}
+	migrator := migrate.NewMySQLMigrator(db, migrations, zap.NewNop().Sugar())
+
+	// откатываем все миграции и создаем базу так, как это делали init-скрипты
+	for {
+		_, err = migrator.Down(context.Background())
+		if err == migrate.ErrNoMigration {
+			break
+		}
+		if err != nil {
+			t.Fatalf("cant roll back migrations: %v", err)
+		}
+	}
+
+	for _, stmt := range legacySchema {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			t.Fatalf("cant create legacy schema: %v", err)
+		}
+	}
+
+	// нормальная работа
+	_, err = migrator.Up(context.Background())
+
+	assert.NoError(t, err)
+
+	// пользователи на месте, старый пароль подходит
+	users := user.NewUsersMySQLRepo(db, password.NewPBKDF2Hasher(1000, 16, 32), zap.NewNop().Sugar())
+
+	us, err := users.Login(context.Background(), "sasha", "12345678")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, us.ID)
+	assert.EqualValues(t, user.DefaultTimezone, us.Timezone)
+	assert.EqualValues(t, birthday.Birthday{Year: 2000, Month: time.June, Day: 30}, us.Birthday)
+
+	// сессии в открытом виде не переносятся
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&count)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+
+	// дубли и подписки на удаленных пользователей отброшены
+	err = db.QueryRow("SELECT COUNT(*) FROM subscriptions").Scan(&count)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+}
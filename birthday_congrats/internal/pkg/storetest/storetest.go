@@ -0,0 +1,766 @@
+// Package storetest - общий набор тестов для хранилищ. Каждое хранилище (MySQL, в памяти)
+// должно его проходить, чтобы сервис работал одинаково с любым из них.
+package storetest
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// SeedUsers - пользователи, которые должны существовать перед тестами подписок, сессий, токенов и журнала
+// (в MySQL на них ссылаются внешние ключи)
+var SeedUsers = []uint32{1, 2, 3}
+
+// UsersRepo проверяет user.UsersRepo; newRepo должен возвращать пустое хранилище
+func UsersRepo(t *testing.T, newRepo func(t *testing.T) user.UsersRepo) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	// пустое хранилище
+	users, err := repo.GetAll(ctx)
+
+	mustNoError(t, err)
+	assert.Empty(t, users)
+
+	// создание
+	alice, err := repo.Create(ctx, "alice", "alice_pass", "alice@example.com", "Europe/Moscow", birthday.Birthday{Year: 1990, Month: time.February, Day: 28})
+
+	mustNoError(t, err)
+	assert.NotZero(t, alice.ID)
+	assert.EqualValues(t, &user.User{
+		ID:       alice.ID,
+		Username: "alice",
+		Email:    "alice@example.com",
+		Timezone: "Europe/Moscow",
+		Birthday: birthday.Birthday{Year: 1990, Month: time.February, Day: 28},
+		Role:     user.RoleEmployee,
+	}, alice)
+
+	// год рождения скрыт
+	bob, err := repo.Create(ctx, "bob", "bob_pass", "bob@example.com", "UTC", birthday.Birthday{Month: time.February, Day: 29})
+
+	mustNoError(t, err)
+	assert.NotEqual(t, alice.ID, bob.ID)
+	assert.EqualValues(t, birthday.Birthday{Month: time.February, Day: 29}, bob.Birthday)
+
+	// имя занято
+	_, err = repo.Create(ctx, "alice", "other_pass", "other@example.com", "UTC", birthday.Birthday{Year: 2001, Month: time.January, Day: 1})
+
+	assert.ErrorIs(t, err, user.ErrUserExists)
+
+	// получение по id
+	got, err := repo.GetByID(ctx, alice.ID)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, alice, got)
+
+	_, err = repo.GetByID(ctx, alice.ID+bob.ID+100)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// получение по имени
+	got, err = repo.GetByUsername(ctx, "bob")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, bob, got)
+
+	_, err = repo.GetByUsername(ctx, "carol")
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// все пользователи (пароли не отдаются)
+	users, err = repo.GetAll(ctx)
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []*user.User{alice, bob}, users)
+
+	// вход
+	got, err = repo.Login(ctx, "bob", "bob_pass")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, bob, got)
+
+	_, err = repo.Login(ctx, "bob", "alice_pass")
+
+	assert.ErrorIs(t, err, user.ErrBadPassword)
+
+	_, err = repo.Login(ctx, "carol", "bob_pass")
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// обновление (пароль не меняется)
+	updated := *bob
+	updated.Username = "robert"
+	updated.Email = "robert@example.com"
+	updated.Timezone = "Asia/Tokyo"
+	updated.Birthday = birthday.Birthday{Year: 2000, Month: time.July, Day: 1}
+	updated.Deactivated = true
+	updated.ExternalID = "hr-2"
+	updated.Privacy = user.Privacy{HideYear: true, NotSubscribable: true}
+	updated.EmailVerified = true
+	updated.Role = user.RoleAdmin
+	updated.Department = "Бухгалтерия"
+
+	err = repo.Update(ctx, &updated)
+
+	assert.NoError(t, err)
+
+	got, err = repo.GetByID(ctx, bob.ID)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &updated, got)
+
+	got, err = repo.Login(ctx, "robert", "bob_pass")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &updated, got)
+
+	// повторное обновление теми же значениями
+	err = repo.Update(ctx, &updated)
+
+	assert.NoError(t, err)
+
+	// имя занято
+	updated.Username = "alice"
+
+	err = repo.Update(ctx, &updated)
+
+	assert.ErrorIs(t, err, user.ErrUserExists)
+
+	// смена пароля
+	err = repo.SetPassword(ctx, alice.ID, "new_alice_pass")
+
+	assert.NoError(t, err)
+
+	_, err = repo.Login(ctx, "alice", "alice_pass")
+
+	assert.ErrorIs(t, err, user.ErrBadPassword)
+
+	got, err = repo.Login(ctx, "alice", "new_alice_pass")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, alice, got)
+
+	err = repo.SetPassword(ctx, alice.ID+bob.ID+100, "new_pass")
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// удаление
+	err = repo.Delete(ctx, alice.ID)
+
+	assert.NoError(t, err)
+
+	_, err = repo.GetByID(ctx, alice.ID)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	_, err = repo.Login(ctx, "alice", "new_alice_pass")
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	users, err = repo.GetAll(ctx)
+
+	assert.NoError(t, err)
+	if assert.Len(t, users, 1) {
+		assert.EqualValues(t, bob.ID, users[0].ID)
+	}
+
+	err = repo.Delete(ctx, alice.ID)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// имя удаленного пользователя снова свободно
+	_, err = repo.Create(ctx, "alice", "alice_pass", "alice@example.com", "UTC", birthday.Birthday{Year: 1990, Month: time.February, Day: 28})
+
+	assert.NoError(t, err)
+}
+
+// ResetsRepo проверяет reset.ResetsRepo; newRepo должен возвращать пустое хранилище,
+// в котором можно выпускать токены для пользователей SeedUsers
+func ResetsRepo(t *testing.T, newRepo func(t *testing.T) reset.ResetsRepo) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	// выпуск и использование
+	err := repo.Create(ctx, 1, "hash_1", 1000)
+
+	mustNoError(t, err)
+
+	userID, err := repo.Use(ctx, "hash_1", 999)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, userID)
+
+	// токен одноразовый
+	_, err = repo.Use(ctx, "hash_1", 999)
+
+	assert.ErrorIs(t, err, reset.ErrNoToken)
+
+	// неизвестный токен
+	_, err = repo.Use(ctx, "unknown", 999)
+
+	assert.ErrorIs(t, err, reset.ErrNoToken)
+
+	// истекший токен не принимается и удаляется
+	err = repo.Create(ctx, 2, "hash_2", 1000)
+
+	mustNoError(t, err)
+
+	_, err = repo.Use(ctx, "hash_2", 1000)
+
+	assert.ErrorIs(t, err, reset.ErrNoToken)
+
+	_, err = repo.Use(ctx, "hash_2", 999)
+
+	assert.ErrorIs(t, err, reset.ErrNoToken)
+
+	// удаление всех токенов пользователя не трогает чужие
+	for _, hash := range []string{"hash_3", "hash_4"} {
+		err = repo.Create(ctx, 3, hash, 1000)
+
+		mustNoError(t, err)
+	}
+	err = repo.Create(ctx, 1, "hash_5", 1000)
+
+	mustNoError(t, err)
+
+	err = repo.RemoveByUser(ctx, 3)
+
+	assert.NoError(t, err)
+
+	for _, hash := range []string{"hash_3", "hash_4"} {
+		_, err = repo.Use(ctx, hash, 999)
+
+		assert.ErrorIs(t, err, reset.ErrNoToken)
+	}
+
+	userID, err = repo.Use(ctx, "hash_5", 999)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, userID)
+
+	// удалять нечего
+	err = repo.RemoveByUser(ctx, 3)
+
+	assert.NoError(t, err)
+}
+
+// SubscriptionsRepo проверяет subscription.SubscriptionsRepo; newRepo должен возвращать
+// пустое хранилище, в котором можно подписывать друг на друга пользователей SeedUsers
+func SubscriptionsRepo(t *testing.T, newRepo func(t *testing.T) subscription.SubscriptionsRepo) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	// пустое хранилище
+	subscriptions, err := repo.GetAllSubscriptions(ctx)
+
+	mustNoError(t, err)
+	assert.Empty(t, subscriptions)
+
+	// добавление напоминаний
+	mustNoError(t, repo.AddSubscription(ctx, 2, 1, 3))
+	mustNoError(t, repo.AddSubscription(ctx, 1, 2, 7))
+	mustNoError(t, repo.AddSubscription(ctx, 1, 2, 0))
+	mustNoError(t, repo.AddSubscription(ctx, 1, 3, 1))
+
+	// такое напоминание уже есть
+	err = repo.AddSubscription(ctx, 1, 2, 7)
+
+	assert.ErrorIs(t, err, subscription.ErrAddSubscription)
+
+	// подписки по возрастанию подписчика и именинника, напоминания по возрастанию
+	subscriptions, err = repo.GetAllSubscriptions(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*subscription.Subscription{
+		{Subscriber: 1, Subscription: 2, DaysAlert: []int{0, 7}},
+		{Subscriber: 1, Subscription: 3, DaysAlert: []int{1}},
+		{Subscriber: 2, Subscription: 1, DaysAlert: []int{3}},
+	}, subscriptions)
+
+	subscriptions, err = repo.GetSubscriptionsByUser(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*subscription.Subscription{
+		{Subscriber: 1, Subscription: 2, DaysAlert: []int{0, 7}},
+		{Subscriber: 1, Subscription: 3, DaysAlert: []int{1}},
+	}, subscriptions)
+
+	subscriptions, err = repo.GetSubscriptionsByUser(ctx, 3)
+
+	assert.NoError(t, err)
+	assert.Empty(t, subscriptions)
+
+	// удаление одного напоминания
+	err = repo.RemoveDaysAlert(ctx, 1, 2, 7)
+
+	assert.NoError(t, err)
+
+	err = repo.RemoveDaysAlert(ctx, 1, 2, 7)
+
+	assert.ErrorIs(t, err, subscription.ErrRemoveSubscription)
+
+	// после удаления последнего напоминания подписки нет
+	err = repo.RemoveDaysAlert(ctx, 1, 3, 1)
+
+	assert.NoError(t, err)
+
+	subscriptions, err = repo.GetSubscriptionsByUser(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*subscription.Subscription{
+		{Subscriber: 1, Subscription: 2, DaysAlert: []int{0}},
+	}, subscriptions)
+
+	// замена напоминаний (повторы схлопываются)
+	err = repo.UpdateSubscription(ctx, 1, 2, []int{14, 3, 14})
+
+	assert.NoError(t, err)
+
+	subscriptions, err = repo.GetSubscriptionsByUser(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*subscription.Subscription{
+		{Subscriber: 1, Subscription: 2, DaysAlert: []int{3, 14}},
+	}, subscriptions)
+
+	// заменять нечего
+	err = repo.UpdateSubscription(ctx, 1, 3, []int{1})
+
+	assert.ErrorIs(t, err, subscription.ErrNoSubscription)
+
+	// пустой список
+	err = repo.UpdateSubscription(ctx, 1, 2, []int{})
+
+	assert.ErrorIs(t, err, subscription.ErrAddSubscription)
+
+	// удаление подписки целиком
+	err = repo.RemoveSubscription(ctx, 1, 2)
+
+	assert.NoError(t, err)
+
+	err = repo.RemoveSubscription(ctx, 1, 2)
+
+	assert.ErrorIs(t, err, subscription.ErrRemoveSubscription)
+
+	subscriptions, err = repo.GetAllSubscriptions(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*subscription.Subscription{
+		{Subscriber: 2, Subscription: 1, DaysAlert: []int{3}},
+	}, subscriptions)
+
+	// удаление всех подписок пользователя и подписок на него
+	mustNoError(t, repo.AddSubscription(ctx, 1, 2, 5))
+	mustNoError(t, repo.AddSubscription(ctx, 3, 1, 5))
+	mustNoError(t, repo.AddSubscription(ctx, 3, 2, 5))
+
+	err = repo.RemoveByUser(ctx, 1)
+
+	assert.NoError(t, err)
+
+	subscriptions, err = repo.GetAllSubscriptions(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*subscription.Subscription{
+		{Subscriber: 3, Subscription: 2, DaysAlert: []int{5}},
+	}, subscriptions)
+
+	// удалять нечего
+	err = repo.RemoveByUser(ctx, 1)
+
+	assert.NoError(t, err)
+
+	// удаление подписок на пользователя, его собственные остаются
+	mustNoError(t, repo.AddSubscription(ctx, 1, 2, 5))
+	mustNoError(t, repo.AddSubscription(ctx, 2, 3, 5))
+
+	err = repo.RemoveSubscribers(ctx, 2)
+
+	assert.NoError(t, err)
+
+	subscriptions, err = repo.GetAllSubscriptions(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*subscription.Subscription{
+		{Subscriber: 2, Subscription: 3, DaysAlert: []int{5}},
+	}, subscriptions)
+
+	// удалять нечего
+	err = repo.RemoveSubscribers(ctx, 2)
+
+	assert.NoError(t, err)
+}
+
+// SessionsManager проверяет session.SessionsManager; newManager должен возвращать пустой менеджер
+// с временем жизни сессий ttl секунд, в котором можно открывать сессии пользователей SeedUsers
+func SessionsManager(t *testing.T, newManager func(t *testing.T, ttl int64) session.SessionsManager) {
+	ctx := context.Background()
+	sm := newManager(t, 3600)
+
+	// создание
+	sess, err := sm.Create(ctx, 1)
+
+	mustNoError(t, err)
+	assert.NotEmpty(t, sess.SessID)
+	assert.EqualValues(t, 1, sess.UserID)
+	assert.InDelta(t, time.Now().Unix()+3600, sess.Expires, 5)
+
+	other, err := sm.Create(ctx, 1)
+
+	mustNoError(t, err)
+	assert.NotEqual(t, sess.SessID, other.SessID)
+
+	// проверка по куке и по заголовку
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sess.SessID})
+
+	got, err := sm.Check(req)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, sess, got)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+sess.SessID)
+
+	got, err = sm.Check(req)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, sess, got)
+
+	// проверка уже извлеченного токена (API)
+	got, err = sm.CheckToken(ctx, sess.SessID)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, sess, got)
+
+	_, err = sm.CheckToken(ctx, "unknown")
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// нет токена
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	_, err = sm.Check(req)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// неизвестный токен
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "unknown"})
+
+	_, err = sm.Check(req)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// завершение сессии
+	err = sm.Destroy(session.ContextWithSession(ctx, sess))
+
+	assert.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sess.SessID})
+
+	_, err = sm.Check(req)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	err = sm.Destroy(session.ContextWithSession(ctx, sess))
+
+	assert.ErrorIs(t, err, session.ErrNotDestroyed)
+
+	// в контексте нет сессии
+	err = sm.Destroy(ctx)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// другая сессия того же пользователя жива
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: other.SessID})
+
+	_, err = sm.Check(req)
+
+	assert.NoError(t, err)
+
+	// завершение всех сессий пользователя не трогает чужие
+	third, err := sm.Create(ctx, 2)
+
+	mustNoError(t, err)
+
+	err = sm.DestroyAll(ctx, 1)
+
+	assert.NoError(t, err)
+
+	_, err = sm.Check(req)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: third.SessID})
+
+	_, err = sm.Check(req)
+
+	assert.NoError(t, err)
+
+	// истекшая сессия удаляется при проверке
+	sm = newManager(t, -60)
+
+	expired, err := sm.Create(ctx, 2)
+
+	mustNoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: expired.SessID})
+
+	_, err = sm.Check(req)
+
+	assert.ErrorIs(t, err, session.ErrSessionExpired)
+
+	_, err = sm.Check(req)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+}
+
+// AuditRepo проверяет audit.AuditRepo; newRepo должен возвращать пустой журнал,
+// в котором можно записывать изменения пользователей SeedUsers
+func AuditRepo(t *testing.T, newRepo func(t *testing.T) audit.AuditRepo) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	// пустой журнал
+	entries, err := repo.ListByUser(ctx, 1)
+
+	mustNoError(t, err)
+	assert.Empty(t, entries)
+
+	// запись
+	at := time.Unix(1000, 0)
+	first := []audit.Entry{
+		{ActorID: 1, UserID: 1, Field: audit.FieldEmail, At: at},
+		{ActorID: 1, UserID: 1, Field: audit.FieldUsername, At: at},
+		{ActorID: 2, UserID: 2, Field: audit.FieldBirthday, At: at},
+	}
+
+	err = repo.Record(ctx, first)
+
+	mustNoError(t, err)
+
+	// записывать нечего
+	err = repo.Record(ctx, nil)
+
+	assert.NoError(t, err)
+
+	// записи пользователя в порядке добавления, чужие не попадают
+	second := []audit.Entry{
+		{ActorID: 1, UserID: 1, Field: audit.FieldPassword, At: at.Add(time.Second)},
+	}
+
+	err = repo.Record(ctx, second)
+
+	mustNoError(t, err)
+
+	entries, err = repo.ListByUser(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []audit.Entry{first[0], first[1], second[0]}, entries)
+
+	entries, err = repo.ListByUser(ctx, 3)
+
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// удаление записей пользователя не трогает чужие
+	err = repo.RemoveByUser(ctx, 1)
+
+	assert.NoError(t, err)
+
+	entries, err = repo.ListByUser(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	entries, err = repo.ListByUser(ctx, 2)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []audit.Entry{first[2]}, entries)
+}
+
+// Outbox проверяет outbox.Outbox; newOutbox должен возвращать пустую очередь
+func Outbox(t *testing.T, newOutbox func(t *testing.T) outbox.Outbox) {
+	ctx := context.Background()
+	repo := newOutbox(t)
+	now := time.Now()
+
+	// пустая очередь
+	messages, err := repo.Due(ctx, now, 10)
+
+	mustNoError(t, err)
+	assert.Empty(t, messages)
+
+	// постановка в очередь
+	err = repo.Enqueue(ctx, []string{"alice@example.com", "bob@example.com"}, "first", "first body")
+
+	mustNoError(t, err)
+
+	err = repo.Enqueue(ctx, []string{"carol@example.com"}, "second", "second body")
+
+	mustNoError(t, err)
+
+	// письма отдаются в порядке постановки
+	messages, err = repo.Due(ctx, now.Add(time.Second), 10)
+
+	mustNoError(t, err)
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 due messages, got %d", len(messages))
+	}
+
+	first, second := messages[0], messages[1]
+
+	assert.Less(t, first.ID, second.ID)
+	assert.EqualValues(t, []string{"alice@example.com", "bob@example.com"}, first.Recipients)
+	assert.EqualValues(t, "first", first.Subject)
+	assert.EqualValues(t, "first body", first.Body)
+	assert.EqualValues(t, outbox.StatusPending, first.Status)
+	assert.Zero(t, first.Attempts)
+	assert.Empty(t, first.LastError)
+
+	// ограничение количества
+	messages, err = repo.Due(ctx, now.Add(time.Second), 1)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(messages))
+
+	// неудачная попытка откладывает письмо
+	err = repo.MarkFailed(ctx, first.ID, 1, now.Add(time.Hour), "smtp error")
+
+	assert.NoError(t, err)
+
+	messages, err = repo.Due(ctx, now.Add(time.Second), 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(messages))
+	assert.EqualValues(t, second.ID, messages[0].ID)
+
+	messages, err = repo.Due(ctx, now.Add(2*time.Hour), 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(messages))
+	assert.EqualValues(t, 1, messages[0].Attempts)
+	assert.EqualValues(t, "smtp error", messages[0].LastError)
+	assert.EqualValues(t, now.Add(time.Hour).Unix(), messages[0].NextAttempt.Unix())
+
+	// отправленные и мертвые письма больше не отдаются
+	err = repo.MarkSent(ctx, first.ID, 2)
+
+	assert.NoError(t, err)
+
+	err = repo.MarkDead(ctx, second.ID, 5, "mailbox unavailable")
+
+	assert.NoError(t, err)
+
+	messages, err = repo.Due(ctx, now.Add(2*time.Hour), 10)
+
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+
+	// нет такого письма
+	err = repo.MarkSent(ctx, second.ID+100, 1)
+
+	assert.ErrorIs(t, err, outbox.ErrNoMessage)
+}
+
+// DeliveriesRepo проверяет delivery.DeliveriesRepo; newRepo должен возвращать пустой журнал
+func DeliveriesRepo(t *testing.T, newRepo func(t *testing.T) delivery.DeliveriesRepo) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	// рассылок еще не было
+	lastRun, err := repo.LastRun(ctx, "Europe/Moscow")
+
+	mustNoError(t, err)
+	assert.True(t, lastRun.IsZero())
+
+	// хранится только дата, у каждого пояса своя
+	err = repo.SetLastRun(ctx, "Europe/Moscow", time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC))
+
+	mustNoError(t, err)
+
+	lastRun, err = repo.LastRun(ctx, "Europe/Moscow")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), lastRun)
+
+	lastRun, err = repo.LastRun(ctx, "Asia/Tokyo")
+
+	assert.NoError(t, err)
+	assert.True(t, lastRun.IsZero())
+
+	// повторная запись той же даты и перезапись
+	err = repo.SetLastRun(ctx, "Europe/Moscow", time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.NoError(t, err)
+
+	err = repo.SetLastRun(ctx, "Europe/Moscow", time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC))
+
+	assert.NoError(t, err)
+
+	lastRun, err = repo.LastRun(ctx, "Europe/Moscow")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC), lastRun)
+
+	// каждое напоминание записывается один раз
+	key := delivery.Key{Subscriber: 1, Subject: 2, BirthdayYear: 2024, DaysBefore: 7}
+	other := delivery.Key{Subscriber: 1, Subject: 2, BirthdayYear: 2024, DaysBefore: 0}
+
+	recorded, err := repo.Record(ctx, key)
+
+	assert.NoError(t, err)
+	assert.True(t, recorded)
+
+	recorded, err = repo.Record(ctx, key)
+
+	assert.NoError(t, err)
+	assert.False(t, recorded)
+
+	recorded, err = repo.Record(ctx, other)
+
+	assert.NoError(t, err)
+	assert.True(t, recorded)
+
+	// забытое напоминание можно записать снова, остальные не трогаются
+	err = repo.Forget(ctx, []delivery.Key{key})
+
+	assert.NoError(t, err)
+
+	recorded, err = repo.Record(ctx, key)
+
+	assert.NoError(t, err)
+	assert.True(t, recorded)
+
+	recorded, err = repo.Record(ctx, other)
+
+	assert.NoError(t, err)
+	assert.False(t, recorded)
+}
+
+// mustNoError останавливает тест: дальнейшие проверки зависят от успешного результата
+func mustNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package subscription
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+type subscriptionKey struct {
+	subscriber   uint32
+	subscription uint32
+}
+
+// SubscriptionsMemoryRepo хранит подписки в памяти процесса (для разработки без базы данных)
+type SubscriptionsMemoryRepo struct {
+	mu            *sync.RWMutex
+	subscriptions map[subscriptionKey][]int // напоминания подписки по возрастанию
+	logger        *zap.SugaredLogger
+}
+
+var _ SubscriptionsRepo = &SubscriptionsMemoryRepo{}
+
+func NewSubscriptionsMemoryRepo(logger *zap.SugaredLogger) *SubscriptionsMemoryRepo {
+	return &SubscriptionsMemoryRepo{
+		mu:            &sync.RWMutex{},
+		subscriptions: make(map[subscriptionKey][]int),
+		logger:        logger,
+	}
+}
+
+func (repo *SubscriptionsMemoryRepo) GetAllSubscriptions(ctx context.Context) ([]*Subscription, error) {
+	return repo.collect(func(key subscriptionKey) bool { return true }), nil
+}
+
+func (repo *SubscriptionsMemoryRepo) GetSubscriptionsByUser(ctx context.Context, userID uint32) ([]*Subscription, error) {
+	return repo.collect(func(key subscriptionKey) bool { return key.subscriber == userID }), nil
+}
+
+// collect возвращает подписки, подходящие под фильтр, в том же порядке, что и MySQL-хранилище
+func (repo *SubscriptionsMemoryRepo) collect(filter func(key subscriptionKey) bool) []*Subscription {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	subscriptions := make([]*Subscription, 0, 10)
+	for key, daysAlert := range repo.subscriptions {
+		if !filter(key) {
+			continue
+		}
+
+		subscriptions = append(subscriptions, &Subscription{
+			Subscriber:   key.subscriber,
+			Subscription: key.subscription,
+			DaysAlert:    slices.Clone(daysAlert),
+		})
+	}
+
+	slices.SortFunc(subscriptions, func(a, b *Subscription) int {
+		if a.Subscriber != b.Subscriber {
+			return compareIDs(a.Subscriber, b.Subscriber)
+		}
+		return compareIDs(a.Subscription, b.Subscription)
+	})
+
+	return subscriptions
+}
+
+func (repo *SubscriptionsMemoryRepo) AddSubscription(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := subscriptionKey{subscriberID, subscriptionID}
+	current := repo.subscriptions[key]
+
+	// такое напоминание уже есть
+	i, found := slices.BinarySearch(current, daysAlert)
+	if found {
+		repo.logger.Errorf("Subscription was not added")
+		return ErrAddSubscription
+	}
+
+	repo.subscriptions[key] = slices.Insert(current, i, daysAlert)
+
+	return nil
+}
+
+func (repo *SubscriptionsMemoryRepo) RemoveDaysAlert(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := subscriptionKey{subscriberID, subscriptionID}
+	current := repo.subscriptions[key]
+
+	i, found := slices.BinarySearch(current, daysAlert)
+	if !found {
+		repo.logger.Warnf("Days alert was not removed")
+		return ErrRemoveSubscription
+	}
+
+	// подписка без напоминаний не хранится
+	current = slices.Delete(current, i, i+1)
+	if len(current) == 0 {
+		delete(repo.subscriptions, key)
+		return nil
+	}
+	repo.subscriptions[key] = current
+
+	return nil
+}
+
+func (repo *SubscriptionsMemoryRepo) UpdateSubscription(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert []int) error {
+	if len(daysAlert) == 0 {
+		repo.logger.Errorf("No days alert to update subscription with")
+		return ErrAddSubscription
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := subscriptionKey{subscriberID, subscriptionID}
+	if _, ok := repo.subscriptions[key]; !ok {
+		repo.logger.Warnf("Subscription to update not found")
+		return ErrNoSubscription
+	}
+
+	updated := slices.Clone(daysAlert)
+	slices.Sort(updated)
+	repo.subscriptions[key] = slices.Compact(updated)
+
+	return nil
+}
+
+func (repo *SubscriptionsMemoryRepo) RemoveSubscription(ctx context.Context, subscriberID, subscriptionID uint32) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := subscriptionKey{subscriberID, subscriptionID}
+	if _, ok := repo.subscriptions[key]; !ok {
+		repo.logger.Warnf("Subscription was not removed")
+		return ErrRemoveSubscription
+	}
+
+	delete(repo.subscriptions, key)
+
+	return nil
+}
+
+func (repo *SubscriptionsMemoryRepo) RemoveByUser(ctx context.Context, userID uint32) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for key := range repo.subscriptions {
+		if key.subscriber == userID || key.subscription == userID {
+			delete(repo.subscriptions, key)
+		}
+	}
+
+	return nil
+}
+
+func (repo *SubscriptionsMemoryRepo) RemoveSubscribers(ctx context.Context, subscriptionID uint32) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for key := range repo.subscriptions {
+		if key.subscription == subscriptionID {
+			delete(repo.subscriptions, key)
+		}
+	}
+
+	return nil
+}
+
+func compareIDs(a, b uint32) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
 	"go.uber.org/zap"
@@ -28,7 +29,7 @@ func (repo *SubscriptionsMySQLRepo) GetAllSubscriptions(ctx context.Context) ([]
 
 	rows, err := repo.db.QueryContext(
 		ctx,
-		"SELECT subscriber_id, subscription_id, days_alert FROM subscriptions",
+		"SELECT subscriber_id, subscription_id, days_alert FROM subscriptions ORDER BY subscriber_id, subscription_id, days_alert",
 	)
 	if err != nil {
 		repo.logger.Errorf("Error while SELECT from db: %v", err)
@@ -36,14 +37,17 @@ func (repo *SubscriptionsMySQLRepo) GetAllSubscriptions(ctx context.Context) ([]
 	}
 
 	for rows.Next() {
-		subscr := &Subscription{}
-		err = rows.Scan(&subscr.Subscriber, &subscr.Subscription, &subscr.DaysAlert)
+		var (
+			subscriberID, subscriptionID uint32
+			daysAlert                    int
+		)
+		err = rows.Scan(&subscriberID, &subscriptionID, &daysAlert)
 		if err != nil {
 			repo.logger.Errorf("Error while scanning from sql row: %v", err)
 			return nil, fmt.Errorf("db error: %v", err)
 		}
 
-		subscriptions = append(subscriptions, subscr)
+		subscriptions = appendDaysAlert(subscriptions, subscriberID, subscriptionID, daysAlert)
 	}
 
 	return subscriptions, nil
@@ -54,7 +58,7 @@ func (repo *SubscriptionsMySQLRepo) GetSubscriptionsByUser(ctx context.Context,
 
 	rows, err := repo.db.QueryContext(
 		ctx,
-		"SELECT subscription_id, days_alert FROM subscriptions WHERE subscriber_id = ?",
+		"SELECT subscription_id, days_alert FROM subscriptions WHERE subscriber_id = ? ORDER BY subscription_id, days_alert",
 		userID,
 	)
 	if err != nil {
@@ -63,16 +67,17 @@ func (repo *SubscriptionsMySQLRepo) GetSubscriptionsByUser(ctx context.Context,
 	}
 
 	for rows.Next() {
-		subscr := &Subscription{
-			Subscriber: userID,
-		}
-		err = rows.Scan(&subscr.Subscription, &subscr.DaysAlert)
+		var (
+			subscriptionID uint32
+			daysAlert      int
+		)
+		err = rows.Scan(&subscriptionID, &daysAlert)
 		if err != nil {
 			repo.logger.Errorf("Error while scanning from sql row: %v", err)
 			return nil, fmt.Errorf("db error: %v", err)
 		}
 
-		subscriptions = append(subscriptions, subscr)
+		subscriptions = appendDaysAlert(subscriptions, userID, subscriptionID, daysAlert)
 	}
 
 	return subscriptions, nil
@@ -81,7 +86,7 @@ func (repo *SubscriptionsMySQLRepo) GetSubscriptionsByUser(ctx context.Context,
 func (repo *SubscriptionsMySQLRepo) AddSubscription(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert int) error {
 	result, err := repo.db.ExecContext(
 		ctx,
-		"INSERT INTO subscriptions (`subscriber_id`, `subscription_id`, `days_alert`) VALUES (?, ?, ?)",
+		"INSERT IGNORE INTO subscriptions (`subscriber_id`, `subscription_id`, `days_alert`) VALUES (?, ?, ?)",
 		subscriberID,
 		subscriptionID,
 		daysAlert,
@@ -91,7 +96,7 @@ func (repo *SubscriptionsMySQLRepo) AddSubscription(ctx context.Context, subscri
 		return fmt.Errorf("db error: %v", err)
 	}
 
-	// проверка, что запись добавлена
+	// проверка, что запись добавлена (такое напоминание уже могло быть)
 	affected, err := result.RowsAffected()
 	if err != nil {
 		repo.logger.Errorf("Error in RowsAffected(): %v", err)
@@ -105,6 +110,90 @@ func (repo *SubscriptionsMySQLRepo) AddSubscription(ctx context.Context, subscri
 	return nil
 }
 
+func (repo *SubscriptionsMySQLRepo) RemoveDaysAlert(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert int) error {
+	result, err := repo.db.ExecContext(
+		ctx,
+		"DELETE FROM subscriptions WHERE subscriber_id = ? AND subscription_id = ? AND days_alert = ?",
+		subscriberID,
+		subscriptionID,
+		daysAlert,
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while DELETE from db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	// проверка, что запись удалена
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.Errorf("Error in RowsAffected(): %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+	if affected == 0 {
+		repo.logger.Warnf("Days alert was not removed")
+		return ErrRemoveSubscription
+	}
+
+	return nil
+}
+
+func (repo *SubscriptionsMySQLRepo) UpdateSubscription(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert []int) error {
+	if len(daysAlert) == 0 {
+		repo.logger.Errorf("No days alert to update subscription with")
+		return ErrAddSubscription
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		repo.logger.Errorf("Error while starting transaction: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+	defer tx.Rollback() // после Commit ничего не делает
+
+	result, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM subscriptions WHERE subscriber_id = ? AND subscription_id = ?",
+		subscriberID,
+		subscriptionID,
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while DELETE from db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	// проверка, что подписка была
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.Errorf("Error in RowsAffected(): %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+	if affected == 0 {
+		repo.logger.Warnf("Subscription to update not found")
+		return ErrNoSubscription
+	}
+
+	query := "INSERT IGNORE INTO subscriptions (`subscriber_id`, `subscription_id`, `days_alert`) VALUES " +
+		strings.TrimSuffix(strings.Repeat("(?, ?, ?), ", len(daysAlert)), ", ")
+	args := make([]interface{}, 0, 3*len(daysAlert))
+	for _, d := range daysAlert {
+		args = append(args, subscriberID, subscriptionID, d)
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		repo.logger.Errorf("Error while INSERT into db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repo.logger.Errorf("Error while committing transaction: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
+
 func (repo *SubscriptionsMySQLRepo) RemoveSubscription(ctx context.Context, subscriberID, subscriptionID uint32) error {
 	result, err := repo.db.ExecContext(
 		ctx,
@@ -130,3 +219,34 @@ func (repo *SubscriptionsMySQLRepo) RemoveSubscription(ctx context.Context, subs
 
 	return nil
 }
+
+func (repo *SubscriptionsMySQLRepo) RemoveByUser(ctx context.Context, userID uint32) error {
+	// подписок может и не быть, поэтому RowsAffected не проверяем
+	_, err := repo.db.ExecContext(
+		ctx,
+		"DELETE FROM subscriptions WHERE subscriber_id = ? OR subscription_id = ?",
+		userID,
+		userID,
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while DELETE from db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *SubscriptionsMySQLRepo) RemoveSubscribers(ctx context.Context, subscriptionID uint32) error {
+	// подписчиков может и не быть, поэтому RowsAffected не проверяем
+	_, err := repo.db.ExecContext(
+		ctx,
+		"DELETE FROM subscriptions WHERE subscription_id = ?",
+		subscriptionID,
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while DELETE from db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
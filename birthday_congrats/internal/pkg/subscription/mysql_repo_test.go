@@ -37,33 +37,35 @@ func TestGetAllSubscriptions(t *testing.T) {
 
 	testRepo := NewSubscriptionsMySQLRepo(db, zap.NewNop().Sugar())
 
-	// данные для теста
+	// данные для теста: строки одной подписки собираются вместе
 	subsExpected := []*Subscription{
 		{
 			Subscriber:   uint32(0),
 			Subscription: uint32(1),
-			DaysAlert:    42,
+			DaysAlert:    []int{0, 7, 42},
 		},
 		{
 			Subscriber:   uint32(0),
 			Subscription: uint32(2),
-			DaysAlert:    10,
+			DaysAlert:    []int{10},
 		},
 		{
 			Subscriber:   uint32(2),
 			Subscription: uint32(1),
-			DaysAlert:    4,
+			DaysAlert:    []int{4},
 		},
 	}
 
 	// нормальная работа
 	rows := sqlmock.NewRows([]string{"subscriber_id", "subscription_id", "days_alert"})
 	for _, s := range subsExpected {
-		rows = rows.AddRow(
-			s.Subscriber,
-			s.Subscription,
-			s.DaysAlert,
-		)
+		for _, d := range s.DaysAlert {
+			rows = rows.AddRow(
+				s.Subscriber,
+				s.Subscription,
+				d,
+			)
+		}
 	}
 
 	mock.
@@ -123,22 +125,24 @@ func TestGetSubscriptionsByUser(t *testing.T) {
 		{
 			Subscriber:   subscriberID,
 			Subscription: uint32(1),
-			DaysAlert:    42,
+			DaysAlert:    []int{42},
 		},
 		{
 			Subscriber:   subscriberID,
 			Subscription: uint32(2),
-			DaysAlert:    10,
+			DaysAlert:    []int{0, 10},
 		},
 	}
 
 	// нормальная работа
 	rows := sqlmock.NewRows([]string{"subscription_id", "days_alert"})
 	for _, s := range subsExpected {
-		rows = rows.AddRow(
-			s.Subscription,
-			s.DaysAlert,
-		)
+		for _, d := range s.DaysAlert {
+			rows = rows.AddRow(
+				s.Subscription,
+				d,
+			)
+		}
 	}
 
 	mock.
@@ -196,19 +200,17 @@ func TestAddSubscription(t *testing.T) {
 	testRepo := NewSubscriptionsMySQLRepo(db, zap.NewNop().Sugar())
 
 	// данные для теста
-	subExpeceted := &Subscription{
-		Subscriber:   uint32(10),
-		Subscription: uint32(42),
-		DaysAlert:    5,
-	}
+	subscriberID := uint32(10)
+	subscriptionID := uint32(42)
+	daysAlert := 5
 
 	// нормальная работа
 	mock.
-		ExpectExec("INSERT INTO subscriptions").
-		WithArgs(subExpeceted.Subscriber, subExpeceted.Subscription, subExpeceted.DaysAlert).
+		ExpectExec("INSERT IGNORE INTO subscriptions").
+		WithArgs(subscriberID, subscriptionID, daysAlert).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err = testRepo.AddSubscription(ctx, subExpeceted.Subscriber, subExpeceted.Subscription, subExpeceted.DaysAlert)
+	err = testRepo.AddSubscription(ctx, subscriberID, subscriptionID, daysAlert)
 
 	assert.NoError(t, err)
 
@@ -217,24 +219,24 @@ func TestAddSubscription(t *testing.T) {
 
 	// ответ с ошибкой
 	mock.
-		ExpectExec("INSERT INTO subscriptions").
-		WithArgs(subExpeceted.Subscriber, subExpeceted.Subscription, subExpeceted.DaysAlert).
+		ExpectExec("INSERT IGNORE INTO subscriptions").
+		WithArgs(subscriberID, subscriptionID, daysAlert).
 		WillReturnError(fmt.Errorf("db error"))
 
-	err = testRepo.AddSubscription(ctx, subExpeceted.Subscriber, subExpeceted.Subscription, subExpeceted.DaysAlert)
+	err = testRepo.AddSubscription(ctx, subscriberID, subscriptionID, daysAlert)
 
 	assert.Error(t, err)
 
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 
-	// rows affected = 0
+	// rows affected = 0 (такое напоминание уже есть)
 	mock.
-		ExpectExec("INSERT INTO subscriptions").
-		WithArgs(subExpeceted.Subscriber, subExpeceted.Subscription, subExpeceted.DaysAlert).
+		ExpectExec("INSERT IGNORE INTO subscriptions").
+		WithArgs(subscriberID, subscriptionID, daysAlert).
 		WillReturnResult(sqlmock.NewResult(int64(0), 0))
 
-	err = testRepo.AddSubscription(ctx, subExpeceted.Subscriber, subExpeceted.Subscription, subExpeceted.DaysAlert)
+	err = testRepo.AddSubscription(ctx, subscriberID, subscriptionID, daysAlert)
 
 	assert.ErrorIs(t, err, ErrAddSubscription)
 
@@ -243,11 +245,11 @@ func TestAddSubscription(t *testing.T) {
 
 	// ошибка rowsAffected()
 	mock.
-		ExpectExec("INSERT INTO subscriptions").
-		WithArgs(subExpeceted.Subscriber, subExpeceted.Subscription, subExpeceted.DaysAlert).
+		ExpectExec("INSERT IGNORE INTO subscriptions").
+		WithArgs(subscriberID, subscriptionID, daysAlert).
 		WillReturnResult(&customErrorResult{errAffected: fmt.Errorf("affected error")})
 
-	err = testRepo.AddSubscription(ctx, subExpeceted.Subscriber, subExpeceted.Subscription, subExpeceted.DaysAlert)
+	err = testRepo.AddSubscription(ctx, subscriberID, subscriptionID, daysAlert)
 
 	assert.Error(t, err)
 
@@ -324,3 +326,317 @@ func TestRemoveSubscription(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
+
+func TestRemoveDaysAlert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewSubscriptionsMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	subscriberID := uint32(10)
+	subscriptionID := uint32(42)
+	daysAlert := 0
+
+	// нормальная работа
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID, daysAlert).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = testRepo.RemoveDaysAlert(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ответ с ошибкой
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID, daysAlert).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.RemoveDaysAlert(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// rows affected = 0
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID, daysAlert).
+		WillReturnResult(sqlmock.NewResult(int64(0), 0))
+
+	err = testRepo.RemoveDaysAlert(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.ErrorIs(t, err, ErrRemoveSubscription)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка rowsAffected()
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID, daysAlert).
+		WillReturnResult(&customErrorResult{errAffected: fmt.Errorf("affected error")})
+
+	err = testRepo.RemoveDaysAlert(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewSubscriptionsMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	subscriberID := uint32(10)
+	subscriptionID := uint32(42)
+	daysAlert := []int{0, 7}
+
+	// нормальная работа: старые напоминания заменяются новыми в одной транзакции
+	mock.ExpectBegin()
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.
+		ExpectExec(`INSERT IGNORE INTO subscriptions \(.+\) VALUES \(\?, \?, \?\), \(\?, \?, \?\)$`).
+		WithArgs(subscriberID, subscriptionID, 0, subscriberID, subscriptionID, 7).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err = testRepo.UpdateSubscription(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// подписки нет
+	mock.ExpectBegin()
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = testRepo.UpdateSubscription(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.ErrorIs(t, err, ErrNoSubscription)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// пустой список напоминаний
+	err = testRepo.UpdateSubscription(ctx, subscriberID, subscriptionID, nil)
+
+	assert.ErrorIs(t, err, ErrAddSubscription)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка начала транзакции
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.UpdateSubscription(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка удаления
+	mock.ExpectBegin()
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID).
+		WillReturnError(fmt.Errorf("db error"))
+	mock.ExpectRollback()
+
+	err = testRepo.UpdateSubscription(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка rowsAffected()
+	mock.ExpectBegin()
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID).
+		WillReturnResult(&customErrorResult{errAffected: fmt.Errorf("affected error")})
+	mock.ExpectRollback()
+
+	err = testRepo.UpdateSubscription(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка вставки - старые напоминания остаются
+	mock.ExpectBegin()
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.
+		ExpectExec("INSERT IGNORE INTO subscriptions").
+		WithArgs(subscriberID, subscriptionID, 0, subscriberID, subscriptionID, 7).
+		WillReturnError(fmt.Errorf("db error"))
+	mock.ExpectRollback()
+
+	err = testRepo.UpdateSubscription(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка коммита
+	mock.ExpectBegin()
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(subscriberID, subscriptionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.
+		ExpectExec("INSERT IGNORE INTO subscriptions").
+		WithArgs(subscriberID, subscriptionID, 0, subscriberID, subscriptionID, 7).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.UpdateSubscription(ctx, subscriberID, subscriptionID, daysAlert)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestRemoveByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewSubscriptionsMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	userID := uint32(3)
+
+	// нормальная работа
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(userID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	err = testRepo.RemoveByUser(ctx, userID)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// подписок не было
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(userID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = testRepo.RemoveByUser(ctx, userID)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ответ с ошибкой
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(userID, userID).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.RemoveByUser(ctx, userID)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestRemoveSubscribers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewSubscriptionsMySQLRepo(db, zap.NewNop().Sugar())
+
+	// данные для теста
+	userID := uint32(3)
+
+	// нормальная работа
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	err = testRepo.RemoveSubscribers(ctx, userID)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// подписчиков не было
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = testRepo.RemoveSubscribers(ctx, userID)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ответ с ошибкой
+	mock.
+		ExpectExec("DELETE FROM subscriptions WHERE").
+		WithArgs(userID).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.RemoveSubscribers(ctx, userID)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
@@ -78,6 +78,48 @@ func (mr *MockSubscriptionsRepoMockRecorder) GetSubscriptionsByUser(ctx, userID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionsByUser", reflect.TypeOf((*MockSubscriptionsRepo)(nil).GetSubscriptionsByUser), ctx, userID)
 }
 
+// RemoveByUser mocks base method.
+func (m *MockSubscriptionsRepo) RemoveByUser(ctx context.Context, userID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveByUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveByUser indicates an expected call of RemoveByUser.
+func (mr *MockSubscriptionsRepoMockRecorder) RemoveByUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveByUser", reflect.TypeOf((*MockSubscriptionsRepo)(nil).RemoveByUser), ctx, userID)
+}
+
+// RemoveDaysAlert mocks base method.
+func (m *MockSubscriptionsRepo) RemoveDaysAlert(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDaysAlert", ctx, subscriberID, subscriptionID, daysAlert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveDaysAlert indicates an expected call of RemoveDaysAlert.
+func (mr *MockSubscriptionsRepoMockRecorder) RemoveDaysAlert(ctx, subscriberID, subscriptionID, daysAlert interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDaysAlert", reflect.TypeOf((*MockSubscriptionsRepo)(nil).RemoveDaysAlert), ctx, subscriberID, subscriptionID, daysAlert)
+}
+
+// RemoveSubscribers mocks base method.
+func (m *MockSubscriptionsRepo) RemoveSubscribers(ctx context.Context, subscriptionID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveSubscribers", ctx, subscriptionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveSubscribers indicates an expected call of RemoveSubscribers.
+func (mr *MockSubscriptionsRepoMockRecorder) RemoveSubscribers(ctx, subscriptionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSubscribers", reflect.TypeOf((*MockSubscriptionsRepo)(nil).RemoveSubscribers), ctx, subscriptionID)
+}
+
 // RemoveSubscription mocks base method.
 func (m *MockSubscriptionsRepo) RemoveSubscription(ctx context.Context, subscriberID, subscriptionID uint32) error {
 	m.ctrl.T.Helper()
@@ -91,3 +133,17 @@ func (mr *MockSubscriptionsRepoMockRecorder) RemoveSubscription(ctx, subscriberI
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSubscription", reflect.TypeOf((*MockSubscriptionsRepo)(nil).RemoveSubscription), ctx, subscriberID, subscriptionID)
 }
+
+// UpdateSubscription mocks base method.
+func (m *MockSubscriptionsRepo) UpdateSubscription(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSubscription", ctx, subscriberID, subscriptionID, daysAlert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSubscription indicates an expected call of UpdateSubscription.
+func (mr *MockSubscriptionsRepoMockRecorder) UpdateSubscription(ctx, subscriberID, subscriptionID, daysAlert interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSubscription", reflect.TypeOf((*MockSubscriptionsRepo)(nil).UpdateSubscription), ctx, subscriberID, subscriptionID, daysAlert)
+}
@@ -9,17 +9,42 @@ import (
 var (
 	ErrAddSubscription    = errors.New("subscription was not added")
 	ErrRemoveSubscription = errors.New("no subscription to remove")
+	ErrNoSubscription     = errors.New("no subscription to update")
 )
 
+// Subscription - подписка subscriber на дни рождения subscription. DaysAlert - за сколько
+// дней напоминать (по возрастанию, 0 - в сам день рождения); подписка без напоминаний не хранится.
 type Subscription struct {
 	Subscriber   uint32
 	Subscription uint32
-	DaysAlert    int
+	DaysAlert    []int
 }
 
 type SubscriptionsRepo interface {
 	GetAllSubscriptions(ctx context.Context) ([]*Subscription, error)
 	GetSubscriptionsByUser(ctx context.Context, userID uint32) ([]*Subscription, error)
-	AddSubscription(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert int) error
-	RemoveSubscription(ctx context.Context, subscriberID, subscriptionID uint32) error
+	AddSubscription(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert int) error      // добавляет одно напоминание, при необходимости создавая подписку
+	RemoveDaysAlert(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert int) error      // удаляет одно напоминание
+	UpdateSubscription(ctx context.Context, subscriberID, subscriptionID uint32, daysAlert []int) error // заменяет все напоминания существующей подписки
+	RemoveSubscription(ctx context.Context, subscriberID, subscriptionID uint32) error                  // удаляет подписку со всеми напоминаниями
+	RemoveByUser(ctx context.Context, userID uint32) error                                              // удаляет подписки пользователя и подписки на него
+	RemoveSubscribers(ctx context.Context, subscriptionID uint32) error                                 // удаляет подписки коллег на пользователя
+}
+
+// appendDaysAlert добавляет строку таблицы к списку подписок. Строки одной подписки
+// должны идти подряд - тогда они собираются в одну Subscription.
+func appendDaysAlert(subscriptions []*Subscription, subscriberID, subscriptionID uint32, daysAlert int) []*Subscription {
+	if n := len(subscriptions); n > 0 {
+		last := subscriptions[n-1]
+		if last.Subscriber == subscriberID && last.Subscription == subscriptionID {
+			last.DaysAlert = append(last.DaysAlert, daysAlert)
+			return subscriptions
+		}
+	}
+
+	return append(subscriptions, &Subscription{
+		Subscriber:   subscriberID,
+		Subscription: subscriptionID,
+		DaysAlert:    []int{daysAlert},
+	})
 }
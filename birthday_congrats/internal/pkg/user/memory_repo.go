@@ -0,0 +1,218 @@
+package user
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/password"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// UsersMemoryRepo хранит пользователей в памяти процесса (для разработки без базы данных)
+type UsersMemoryRepo struct {
+	mu        *sync.RWMutex
+	users     []*User           // по возрастанию id
+	passwords map[uint32]string // хэши паролей
+	nextID    uint32
+	hasher    password.Hasher
+	logger    *zap.SugaredLogger
+}
+
+var _ UsersRepo = &UsersMemoryRepo{}
+
+func NewUsersMemoryRepo(hasher password.Hasher, logger *zap.SugaredLogger) *UsersMemoryRepo {
+	return &UsersMemoryRepo{
+		mu:        &sync.RWMutex{},
+		users:     make([]*User, 0, 10),
+		passwords: make(map[uint32]string),
+		nextID:    1,
+		hasher:    hasher,
+		logger:    logger,
+	}
+}
+
+func (repo *UsersMemoryRepo) Create(ctx context.Context, username, pass, email, timezone string, birth birthday.Birthday) (*User, error) {
+	passwordHash, err := repo.hasher.Hash(pass)
+	if err != nil {
+		repo.logger.Errorf("Error while hashing password: %v", err)
+		return nil, fmt.Errorf("hasher error: %v", err)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.findByUsername(username) != nil {
+		return nil, ErrUserExists
+	}
+
+	newUser := &User{
+		ID:       repo.nextID,
+		Username: username,
+		Email:    email,
+		Timezone: timezone,
+		Birthday: birth,
+		Role:     RoleEmployee,
+	}
+	repo.nextID++
+
+	repo.users = append(repo.users, newUser)
+	repo.passwords[newUser.ID] = passwordHash
+
+	return copyUser(newUser), nil
+}
+
+func (repo *UsersMemoryRepo) Login(ctx context.Context, username, pass string) (*User, error) {
+	repo.mu.RLock()
+	user := repo.findByUsername(username)
+	var passwordHash string
+	if user != nil {
+		user = copyUser(user)
+		passwordHash = repo.passwords[user.ID]
+	}
+	repo.mu.RUnlock()
+
+	if user == nil {
+		return nil, ErrNoUser
+	}
+
+	ok, needsRehash, err := repo.hasher.Verify(pass, passwordHash)
+	if err != nil {
+		repo.logger.Errorf("Error while verifying password: %v", err)
+		return nil, fmt.Errorf("hasher error: %v", err)
+	}
+	if !ok {
+		return nil, ErrBadPassword
+	}
+
+	// пароль верный, но хранится в устаревшем виде - перехэшируем
+	if needsRehash {
+		newHash, err := repo.hasher.Hash(pass)
+		if err != nil {
+			repo.logger.Errorf("Error while hashing password: %v", err)
+			return user, nil
+		}
+
+		repo.mu.Lock()
+		repo.passwords[user.ID] = newHash
+		repo.mu.Unlock()
+
+		repo.logger.Infof("Password hash was updated for user %d", user.ID)
+	}
+
+	return user, nil
+}
+
+func (repo *UsersMemoryRepo) GetAll(ctx context.Context) ([]*User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	users := make([]*User, 0, len(repo.users))
+	for _, u := range repo.users {
+		users = append(users, copyUser(u))
+	}
+
+	return users, nil
+}
+
+func (repo *UsersMemoryRepo) GetByID(ctx context.Context, userID uint32) (*User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	for _, u := range repo.users {
+		if u.ID == userID {
+			return copyUser(u), nil
+		}
+	}
+
+	return nil, ErrNoUser
+}
+
+func (repo *UsersMemoryRepo) GetByUsername(ctx context.Context, username string) (*User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	u := repo.findByUsername(username)
+	if u == nil {
+		return nil, ErrNoUser
+	}
+
+	return copyUser(u), nil
+}
+
+func (repo *UsersMemoryRepo) Update(ctx context.Context, u *User) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if other := repo.findByUsername(u.Username); other != nil && other.ID != u.ID {
+		return ErrUserExists
+	}
+
+	for i, stored := range repo.users {
+		if stored.ID == u.ID {
+			updated := copyUser(u)
+			updated.Password = ""
+			updated.Subscription = false
+			updated.DaysAlert = nil
+			updated.NextBirthday = time.Time{}
+			repo.users[i] = updated
+			return nil
+		}
+	}
+
+	return ErrNoUser
+}
+
+func (repo *UsersMemoryRepo) SetPassword(ctx context.Context, userID uint32, pass string) error {
+	passwordHash, err := repo.hasher.Hash(pass)
+	if err != nil {
+		repo.logger.Errorf("Error while hashing password: %v", err)
+		return fmt.Errorf("hasher error: %v", err)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.passwords[userID]; !ok {
+		return ErrNoUser
+	}
+	repo.passwords[userID] = passwordHash
+
+	return nil
+}
+
+// Delete удаляет только пользователя: подписки и сессии в памяти хранятся отдельно,
+// их удаляет сервис
+func (repo *UsersMemoryRepo) Delete(ctx context.Context, userID uint32) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i, u := range repo.users {
+		if u.ID == userID {
+			repo.users = append(repo.users[:i], repo.users[i+1:]...)
+			delete(repo.passwords, userID)
+			return nil
+		}
+	}
+
+	return ErrNoUser
+}
+
+// findByUsername ищет пользователя по имени; вызывается под мьютексом
+func (repo *UsersMemoryRepo) findByUsername(username string) *User {
+	for _, u := range repo.users {
+		if u.Username == username {
+			return u
+		}
+	}
+
+	return nil
+}
+
+// copyUser отдает наружу копию, чтобы вызывающий не мог поменять хранилище
+func copyUser(u *User) *User {
+	cp := *u
+	return &cp
+}
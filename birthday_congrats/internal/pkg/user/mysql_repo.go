@@ -1,6 +1,8 @@
 package user
 
 import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/password"
 	"context"
 	"database/sql"
 	"fmt"
@@ -13,25 +15,33 @@ import (
 type UsersMySQLRepo struct {
 	mu     *sync.RWMutex
 	db     *sql.DB
+	hasher password.Hasher
 	logger *zap.SugaredLogger
 }
 
 var _ UsersRepo = &UsersMySQLRepo{}
 
-func NewUsersMySQLRepo(db *sql.DB, logger *zap.SugaredLogger) *UsersMySQLRepo {
+func NewUsersMySQLRepo(db *sql.DB, hasher password.Hasher, logger *zap.SugaredLogger) *UsersMySQLRepo {
 	return &UsersMySQLRepo{
 		mu:     &sync.RWMutex{},
 		db:     db,
+		hasher: hasher,
 		logger: logger,
 	}
 }
 
-func (repo UsersMySQLRepo) Create(ctx context.Context, username, password, email string, year, month, day int) (*User, error) {
+func (repo UsersMySQLRepo) Create(ctx context.Context, username, pass, email, timezone string, birth birthday.Birthday) (*User, error) {
+	passwordHash, err := repo.hasher.Hash(pass)
+	if err != nil {
+		repo.logger.Errorf("Error while hashing password: %v", err)
+		return nil, fmt.Errorf("hasher error: %v", err)
+	}
+
 	// проверка, что пользователя с таким юзернэймом нет
 	// сразу залочимся, чтобы никто не влез между запросами и не создал пользователя с таким же именем
 	repo.mu.Lock()
 	var id uint32
-	err := repo.db.QueryRowContext(
+	err = repo.db.QueryRowContext(
 		ctx,
 		"SELECT id from users WHERE username = ?",
 		username,
@@ -46,15 +56,16 @@ func (repo UsersMySQLRepo) Create(ctx context.Context, username, password, email
 		return nil, ErrUserExists
 	}
 
+	birthDate, yearKnown := birthdayToDB(birth)
 	result, err := repo.db.ExecContext(
 		ctx,
-		"INSERT INTO users (`username`, `password`, `email`, `year`, `month`, `day`) VALUES (?, ?, ?, ?, ?, ?)",
+		"INSERT INTO users (`username`, `password`, `email`, `timezone`, `birthday`, `birth_year_known`) VALUES (?, ?, ?, ?, ?, ?)",
 		username,
-		password,
+		passwordHash,
 		email,
-		year,
-		month,
-		day,
+		timezone,
+		birthDate,
+		yearKnown,
 	)
 	repo.mu.Unlock()
 
@@ -84,30 +95,41 @@ func (repo UsersMySQLRepo) Create(ctx context.Context, username, password, email
 		ID:       uint32(lastID),
 		Username: username,
 		Email:    email,
-		Year:     year,
-		Month:    month,
-		Day:      day,
+		Timezone: timezone,
+		Birthday: birth,
+		Role:     RoleEmployee,
 	}
 
 	return newUser, nil
 }
 
-func (repo *UsersMySQLRepo) Login(ctx context.Context, username, password string) (*User, error) {
+func (repo *UsersMySQLRepo) Login(ctx context.Context, username, pass string) (*User, error) {
 	user := &User{}
-	var passwordInDB string
+	var (
+		passwordInDB, birthDate string
+		yearKnown               bool
+	)
 
 	err := repo.db.QueryRowContext(
 		ctx,
-		"SELECT id, username, password, email, year, month, day FROM users WHERE username = ?",
+		"SELECT id, username, password, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE username = ?",
 		username,
 	).Scan(
 		&user.ID,
 		&user.Username,
 		&passwordInDB,
 		&user.Email,
-		&user.Year,
-		&user.Month,
-		&user.Day,
+		&user.Timezone,
+		&birthDate,
+		&yearKnown,
+		&user.Deactivated,
+		&user.ExternalID,
+		&user.Privacy.HideYear,
+		&user.Privacy.HideFromDirectory,
+		&user.Privacy.NotSubscribable,
+		&user.EmailVerified,
+		&user.Role,
+		&user.Department,
 	)
 	if err != nil && err != sql.ErrNoRows {
 		repo.logger.Errorf("Error while SELECT from db: %v", err)
@@ -117,60 +139,136 @@ func (repo *UsersMySQLRepo) Login(ctx context.Context, username, password string
 		return nil, ErrNoUser
 	}
 
-	if password != passwordInDB {
+	user.Birthday, err = birthdayFromDB(birthDate, yearKnown)
+	if err != nil {
+		repo.logger.Errorf("Bad birthday %q of user %d in db", birthDate, user.ID)
+		return nil, fmt.Errorf("db error: %v", err)
+	}
+
+	ok, needsRehash, err := repo.hasher.Verify(pass, passwordInDB)
+	if err != nil {
+		repo.logger.Errorf("Error while verifying password: %v", err)
+		return nil, fmt.Errorf("hasher error: %v", err)
+	}
+	if !ok {
 		return nil, ErrBadPassword
 	}
 
+	// пароль верный, но хранится в устаревшем виде - перехэшируем
+	if needsRehash {
+		repo.rehash(ctx, user.ID, pass)
+	}
+
 	return user, nil
 }
 
+// rehash обновляет хэш пароля; ошибка не мешает входу, пароль перехэшируется при следующем входе
+func (repo *UsersMySQLRepo) rehash(ctx context.Context, userID uint32, pass string) {
+	passwordHash, err := repo.hasher.Hash(pass)
+	if err != nil {
+		repo.logger.Errorf("Error while hashing password: %v", err)
+		return
+	}
+
+	_, err = repo.db.ExecContext(
+		ctx,
+		"UPDATE users SET password = ? WHERE id = ?",
+		passwordHash,
+		userID,
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while UPDATE in db: %v", err)
+		return
+	}
+
+	repo.logger.Infof("Password hash was updated for user %d", userID)
+}
+
 func (repo *UsersMySQLRepo) GetAll(ctx context.Context) ([]*User, error) {
 	users := make([]*User, 0, 10)
 
 	rows, err := repo.db.QueryContext(
 		ctx,
-		"SELECT id, username, email, year, month, day FROM users",
+		"SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users",
 	)
 	if err != nil {
 		repo.logger.Errorf("Error while SELECT from db: %v", err)
 		return nil, fmt.Errorf("db error: %v", err)
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		user := &User{}
+		var (
+			user      = &User{}
+			birthDate string
+			yearKnown bool
+		)
 		err = rows.Scan(
 			&user.ID,
 			&user.Username,
 			&user.Email,
-			&user.Year,
-			&user.Month,
-			&user.Day,
+			&user.Timezone,
+			&birthDate,
+			&yearKnown,
+			&user.Deactivated,
+			&user.ExternalID,
+			&user.Privacy.HideYear,
+			&user.Privacy.HideFromDirectory,
+			&user.Privacy.NotSubscribable,
+			&user.EmailVerified,
+			&user.Role,
+			&user.Department,
 		)
 		if err != nil {
 			repo.logger.Errorf("Error while scanning from sql row: %v", err)
 			return nil, fmt.Errorf("db error: %v", err)
 		}
 
+		user.Birthday, err = birthdayFromDB(birthDate, yearKnown)
+		if err != nil {
+			repo.logger.Errorf("Bad birthday %q of user %d in db", birthDate, user.ID)
+			return nil, fmt.Errorf("db error: %v", err)
+		}
+
 		users = append(users, user)
 	}
 
+	// Next возвращает false и при обрыве чтения: без проверки список молча окажется неполным
+	err = rows.Err()
+	if err != nil {
+		repo.logger.Errorf("Error while reading sql rows: %v", err)
+		return nil, fmt.Errorf("db error: %v", err)
+	}
+
 	return users, nil
 }
 
 func (repo *UsersMySQLRepo) GetByID(ctx context.Context, userID uint32) (*User, error) {
-	user := &User{}
+	var (
+		user      = &User{}
+		birthDate string
+		yearKnown bool
+	)
 
 	err := repo.db.QueryRowContext(
 		ctx,
-		"SELECT id, username, email, year, month, day FROM users WHERE id = ?",
+		"SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE id = ?",
 		userID,
 	).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
-		&user.Year,
-		&user.Month,
-		&user.Day,
+		&user.Timezone,
+		&birthDate,
+		&yearKnown,
+		&user.Deactivated,
+		&user.ExternalID,
+		&user.Privacy.HideYear,
+		&user.Privacy.HideFromDirectory,
+		&user.Privacy.NotSubscribable,
+		&user.EmailVerified,
+		&user.Role,
+		&user.Department,
 	)
 	if err != nil && err != sql.ErrNoRows {
 		repo.logger.Errorf("Error while SELECT from db: %v", err)
@@ -180,5 +278,188 @@ func (repo *UsersMySQLRepo) GetByID(ctx context.Context, userID uint32) (*User,
 		return nil, ErrNoUser
 	}
 
+	user.Birthday, err = birthdayFromDB(birthDate, yearKnown)
+	if err != nil {
+		repo.logger.Errorf("Bad birthday %q of user %d in db", birthDate, user.ID)
+		return nil, fmt.Errorf("db error: %v", err)
+	}
+
 	return user, nil
 }
+
+func (repo *UsersMySQLRepo) GetByUsername(ctx context.Context, username string) (*User, error) {
+	id := uint32(0)
+
+	err := repo.db.QueryRowContext(ctx, "SELECT id FROM users WHERE username = ?", username).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		repo.logger.Errorf("Error while SELECT from db: %v", err)
+		return nil, fmt.Errorf("db error: %v", err)
+	}
+	if err == sql.ErrNoRows {
+		return nil, ErrNoUser
+	}
+
+	return repo.GetByID(ctx, id)
+}
+
+func (repo *UsersMySQLRepo) Update(ctx context.Context, u *User) error {
+	// как и в Create, лочимся, чтобы между проверкой и обновлением никто не занял имя
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var id uint32
+	err := repo.db.QueryRowContext(
+		ctx,
+		"SELECT id from users WHERE username = ? AND id <> ?",
+		u.Username,
+		u.ID,
+	).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		repo.logger.Errorf("Error while SELECT from db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+	if err == nil {
+		return ErrUserExists
+	}
+
+	// если ничего не изменилось, RowsAffected = 0, поэтому существование пользователя проверяет вызывающий
+	birthDate, yearKnown := birthdayToDB(u.Birthday)
+	_, err = repo.db.ExecContext(
+		ctx,
+		"UPDATE users SET username = ?, email = ?, timezone = ?, birthday = ?, birth_year_known = ?, deactivated = ?, external_id = ?, "+
+			"hide_year = ?, hide_from_directory = ?, not_subscribable = ?, email_verified = ?, role = ?, department = ? WHERE id = ?",
+		u.Username,
+		u.Email,
+		u.Timezone,
+		birthDate,
+		yearKnown,
+		u.Deactivated,
+		u.ExternalID,
+		u.Privacy.HideYear,
+		u.Privacy.HideFromDirectory,
+		u.Privacy.NotSubscribable,
+		u.EmailVerified,
+		u.Role,
+		u.Department,
+		u.ID,
+	)
+	if err != nil {
+		repo.logger.Errorf("Error while UPDATE in db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
+
+// noYear - год, с которым в столбец DATE записывается дата рождения без года
+// (високосный, чтобы 29 февраля было корректной датой)
+const noYear = 2000
+
+// birthdayToDB возвращает значения столбцов birthday и birth_year_known
+func birthdayToDB(b birthday.Birthday) (string, bool) {
+	date := b
+	if !b.HasYear() {
+		date.Year = noYear
+	}
+
+	return date.String(), b.HasYear()
+}
+
+// birthdayFromDB собирает дату рождения из столбцов birthday (без parseTime драйвер отдает DATE строкой) и birth_year_known
+func birthdayFromDB(date string, yearKnown bool) (birthday.Birthday, error) {
+	b, err := birthday.Parse(date)
+	if err != nil {
+		return birthday.Birthday{}, err
+	}
+
+	if !yearKnown {
+		b.Year = 0
+	}
+
+	return b, nil
+}
+
+func (repo *UsersMySQLRepo) SetPassword(ctx context.Context, userID uint32, pass string) error {
+	passwordHash, err := repo.hasher.Hash(pass)
+	if err != nil {
+		repo.logger.Errorf("Error while hashing password: %v", err)
+		return fmt.Errorf("hasher error: %v", err)
+	}
+
+	result, err := repo.db.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", passwordHash, userID)
+	if err != nil {
+		repo.logger.Errorf("Error while UPDATE in db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	// соль каждый раз новая, так что хэш всегда меняется и RowsAffected = 0 только без пользователя
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.Errorf("Error while getting rows affected: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+	if affected == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+// userRefs - запросы, удаляющие строки, которые ссылаются на пользователя (аргументы - его id)
+var userRefs = []struct {
+	query string
+	args  int
+}{
+	{"DELETE FROM subscriptions WHERE subscriber_id = ? OR subscription_id = ?", 2},
+	{"DELETE FROM sessions WHERE user_id = ?", 1},
+	{"DELETE FROM password_resets WHERE user_id = ?", 1},
+	{"DELETE FROM audit_log WHERE user_id = ?", 1},
+	{"DELETE FROM alert_deliveries WHERE subscriber_id = ? OR subject_id = ?", 2}, // без внешних ключей
+}
+
+// Delete удаляет пользователя вместе с подписками, сессиями и прочим одной транзакцией:
+// либо удаляется все, либо ничего
+func (repo *UsersMySQLRepo) Delete(ctx context.Context, userID uint32) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		repo.logger.Errorf("Error while starting transaction: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+	defer tx.Rollback() // после Commit ничего не делает
+
+	for _, ref := range userRefs {
+		args := make([]interface{}, ref.args)
+		for i := range args {
+			args[i] = userID
+		}
+
+		_, err = tx.ExecContext(ctx, ref.query, args...)
+		if err != nil {
+			repo.logger.Errorf("Error while DELETE from db: %v", err)
+			return fmt.Errorf("db error: %v", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = ?", userID)
+	if err != nil {
+		repo.logger.Errorf("Error while DELETE from db: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.Errorf("Error while getting rows affected: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+	if affected == 0 {
+		return ErrNoUser
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repo.logger.Errorf("Error while committing transaction: %v", err)
+		return fmt.Errorf("db error: %v", err)
+	}
+
+	return nil
+}
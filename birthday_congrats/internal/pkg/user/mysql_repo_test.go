@@ -1,11 +1,15 @@
 package user
 
 import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/password"
 	"context"
 	"database/sql"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
@@ -33,29 +37,36 @@ func TestCreate(t *testing.T) {
 	}
 	defer db.Close()
 
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hasher := password.NewMockHasher(ctrl)
+
 	ctx := context.Background()
 
-	testRepo := NewUsersMySQLRepo(db, zap.NewNop().Sugar())
+	testRepo := NewUsersMySQLRepo(db, hasher, zap.NewNop().Sugar())
 
 	// данные для теста
 	userID := uint32(0)
 	username := "some_user"
-	password := "some_pass"
+	pass := "some_pass"
+	passHash := "$pbkdf2-sha256$i=1,l=3$c2FsdA$a2V5"
 	email := "some@email.net"
-	year := 2000
-	month := 1
-	day := 1
+	timezone := "Europe/Moscow"
+	birth := birthday.Birthday{Year: 2000, Month: time.January, Day: 1}
 	userExpected := &User{
 		ID:       userID,
 		Username: username,
 		Password: "", // пароль не возвращается
 		Email:    email,
-		Year:     year,
-		Month:    month,
-		Day:      day,
+		Timezone: timezone,
+		Birthday: birth,
+		Role:     RoleEmployee,
 	}
 
 	// нормальная работа
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
 	rows := sqlmock.NewRows([]string{"id"})
 
 	mock.
@@ -65,10 +76,10 @@ func TestCreate(t *testing.T) {
 
 	mock.
 		ExpectExec("INSERT INTO users").
-		WithArgs(username, password, email, year, month, day).
+		WithArgs(username, passHash, email, timezone, "2000-01-01", true).
 		WillReturnResult(sqlmock.NewResult(int64(userExpected.ID), 1))
 
-	userRecv, err := testRepo.Create(ctx, username, password, email, year, month, day)
+	userRecv, err := testRepo.Create(ctx, username, pass, email, timezone, birth)
 
 	assert.NoError(t, err)
 	assert.EqualValues(t, userExpected, userRecv)
@@ -76,13 +87,38 @@ func TestCreate(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 
+	// год рождения скрыт
+	noYear := birthday.Birthday{Month: time.February, Day: 29}
+
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
+	mock.
+		ExpectQuery("SELECT id from users WHERE").
+		WithArgs(username).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	mock.
+		ExpectExec("INSERT INTO users").
+		WithArgs(username, passHash, email, timezone, "2000-02-29", false).
+		WillReturnResult(sqlmock.NewResult(int64(userExpected.ID), 1))
+
+	userRecv, err = testRepo.Create(ctx, username, pass, email, timezone, noYear)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, noYear, userRecv.Birthday)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
 	// ответ с ошибкой
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
 	mock.
 		ExpectQuery("SELECT id from users WHERE").
 		WithArgs(username).
 		WillReturnError(fmt.Errorf("db error"))
 
-	_, err = testRepo.Create(ctx, username, password, email, year, month, day)
+	_, err = testRepo.Create(ctx, username, pass, email, timezone, birth)
 
 	assert.Error(t, err)
 
@@ -90,6 +126,8 @@ func TestCreate(t *testing.T) {
 	assert.NoError(t, err)
 
 	// ошибка scan
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
 	rows = sqlmock.NewRows([]string{})
 
 	mock.
@@ -97,7 +135,7 @@ func TestCreate(t *testing.T) {
 		WithArgs(username).
 		WillReturnRows(rows)
 
-	_, err = testRepo.Create(ctx, username, password, email, year, month, day)
+	_, err = testRepo.Create(ctx, username, pass, email, timezone, birth)
 
 	assert.Error(t, err)
 
@@ -105,6 +143,8 @@ func TestCreate(t *testing.T) {
 	assert.NoError(t, err)
 
 	// найден пользователь с таким же именем
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
 	rows = sqlmock.NewRows([]string{"id"})
 	rows = rows.AddRow(uint32(0))
 
@@ -113,7 +153,7 @@ func TestCreate(t *testing.T) {
 		WithArgs(username).
 		WillReturnRows(rows)
 
-	_, err = testRepo.Create(ctx, username, password, email, year, month, day)
+	_, err = testRepo.Create(ctx, username, pass, email, timezone, birth)
 
 	assert.ErrorIs(t, err, ErrUserExists)
 
@@ -121,6 +161,8 @@ func TestCreate(t *testing.T) {
 	assert.NoError(t, err)
 
 	// rows affected = 0
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
 	rows = sqlmock.NewRows([]string{"id"})
 
 	mock.
@@ -130,10 +172,10 @@ func TestCreate(t *testing.T) {
 
 	mock.
 		ExpectExec("INSERT INTO users").
-		WithArgs(username, password, email, year, month, day).
+		WithArgs(username, passHash, email, timezone, "2000-01-01", true).
 		WillReturnResult(sqlmock.NewResult(int64(userExpected.ID), 0))
 
-	_, err = testRepo.Create(ctx, username, password, email, year, month, day)
+	_, err = testRepo.Create(ctx, username, pass, email, timezone, birth)
 
 	assert.ErrorIs(t, err, ErrUserNotCreated)
 
@@ -141,6 +183,8 @@ func TestCreate(t *testing.T) {
 	assert.NoError(t, err)
 
 	// ошибка rowsAffected()
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
 	rows = sqlmock.NewRows([]string{"id"})
 
 	mock.
@@ -150,10 +194,10 @@ func TestCreate(t *testing.T) {
 
 	mock.
 		ExpectExec("INSERT INTO users").
-		WithArgs(username, password, email, year, month, day).
+		WithArgs(username, passHash, email, timezone, "2000-01-01", true).
 		WillReturnResult(&customErrorResult{errAffected: fmt.Errorf("affected error")})
 
-	_, err = testRepo.Create(ctx, username, password, email, year, month, day)
+	_, err = testRepo.Create(ctx, username, pass, email, timezone, birth)
 
 	assert.Error(t, err)
 
@@ -161,6 +205,8 @@ func TestCreate(t *testing.T) {
 	assert.NoError(t, err)
 
 	// ошибка LastInsertedId()
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
 	rows = sqlmock.NewRows([]string{"id"})
 
 	mock.
@@ -170,10 +216,20 @@ func TestCreate(t *testing.T) {
 
 	mock.
 		ExpectExec("INSERT INTO users").
-		WithArgs(username, password, email, year, month, day).
+		WithArgs(username, passHash, email, timezone, "2000-01-01", true).
 		WillReturnResult(&customErrorResult{errLastID: fmt.Errorf("lastID error")})
 
-	_, err = testRepo.Create(ctx, username, password, email, year, month, day)
+	_, err = testRepo.Create(ctx, username, pass, email, timezone, birth)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка хэширования пароля
+	hasher.EXPECT().Hash(pass).Return("", fmt.Errorf("hasher error"))
+
+	_, err = testRepo.Create(ctx, username, pass, email, timezone, birth)
 
 	assert.Error(t, err)
 
@@ -188,46 +244,61 @@ func TestLogin(t *testing.T) {
 	}
 	defer db.Close()
 
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hasher := password.NewMockHasher(ctrl)
+
 	ctx := context.Background()
 
-	testRepo := NewUsersMySQLRepo(db, zap.NewNop().Sugar())
+	testRepo := NewUsersMySQLRepo(db, hasher, zap.NewNop().Sugar())
 
 	// данные для теста
 	userID := uint32(0)
 	username := "some_user"
-	password := "some_pass"
+	pass := "some_pass"
+	passHash := "$pbkdf2-sha256$i=1,l=3$c2FsdA$a2V5"
+	newPassHash := "$pbkdf2-sha256$i=2,l=3$c2FsdA$a2V5"
 	email := "some@email.net"
-	year := 2000
-	month := 1
-	day := 1
+	timezone := "Europe/Moscow"
+	birth := birthday.Birthday{Year: 2000, Month: time.January, Day: 1}
 	userExpected := &User{
 		ID:       userID,
 		Username: username,
 		Password: "", // пароль не возвращается
 		Email:    email,
-		Year:     year,
-		Month:    month,
-		Day:      day,
+		Timezone: timezone,
+		Birthday: birth,
 	}
 
 	// нормальная работа
-	rows := sqlmock.NewRows([]string{"id", "username", "password", "email", "year", "month", "day"})
+	rows := sqlmock.NewRows([]string{"id", "username", "password", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
 	rows = rows.AddRow(
 		userExpected.ID,
 		userExpected.Username,
-		password,
+		passHash,
 		userExpected.Email,
-		userExpected.Year,
-		userExpected.Month,
-		userExpected.Day,
+		userExpected.Timezone,
+		userExpected.Birthday.String(),
+		true,
+		userExpected.Deactivated,
+		userExpected.ExternalID,
+		userExpected.Privacy.HideYear,
+		userExpected.Privacy.HideFromDirectory,
+		userExpected.Privacy.NotSubscribable,
+		userExpected.EmailVerified,
+		userExpected.Role,
+		userExpected.Department,
 	)
 
 	mock.
-		ExpectQuery("SELECT id, username, password, email, year, month, day FROM users WHERE").
+		ExpectQuery("SELECT id, username, password, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
 		WithArgs(username).
 		WillReturnRows(rows)
 
-	userRecv, err := testRepo.Login(ctx, username, password)
+	hasher.EXPECT().Verify(pass, passHash).Return(true, false, nil)
+
+	userRecv, err := testRepo.Login(ctx, username, pass)
 
 	assert.NoError(t, err)
 	assert.EqualValues(t, userExpected, userRecv)
@@ -237,11 +308,11 @@ func TestLogin(t *testing.T) {
 
 	// ответ с ошибкой
 	mock.
-		ExpectQuery("SELECT id, username, password, email, year, month, day FROM users WHERE").
+		ExpectQuery("SELECT id, username, password, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
 		WithArgs(username).
 		WillReturnError(fmt.Errorf("db error"))
 
-	_, err = testRepo.Login(ctx, username, password)
+	_, err = testRepo.Login(ctx, username, pass)
 
 	assert.Error(t, err)
 
@@ -252,11 +323,11 @@ func TestLogin(t *testing.T) {
 	rows = sqlmock.NewRows([]string{""})
 
 	mock.
-		ExpectQuery("SELECT id, username, password, email, year, month, day FROM users WHERE").
+		ExpectQuery("SELECT id, username, password, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
 		WithArgs(username).
 		WillReturnRows(rows)
 
-	_, err = testRepo.Login(ctx, username, password)
+	_, err = testRepo.Login(ctx, username, pass)
 
 	assert.Error(t, err)
 
@@ -264,14 +335,14 @@ func TestLogin(t *testing.T) {
 	assert.NoError(t, err)
 
 	// не найден пользователь с таким именем
-	rows = sqlmock.NewRows([]string{"id", "username", "password", "email", "year", "month", "day"})
+	rows = sqlmock.NewRows([]string{"id", "username", "password", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
 
 	mock.
-		ExpectQuery("SELECT id, username, password, email, year, month, day FROM users WHERE").
+		ExpectQuery("SELECT id, username, password, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
 		WithArgs(username).
 		WillReturnRows(rows)
 
-	_, err = testRepo.Login(ctx, username, password)
+	_, err = testRepo.Login(ctx, username, pass)
 
 	assert.ErrorIs(t, err, ErrNoUser)
 
@@ -279,28 +350,154 @@ func TestLogin(t *testing.T) {
 	assert.NoError(t, err)
 
 	// неверный пароль
-	rows = sqlmock.NewRows([]string{"id", "username", "password", "email", "year", "month", "day"})
+	rows = sqlmock.NewRows([]string{"id", "username", "password", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
 	rows = rows.AddRow(
 		userExpected.ID,
 		userExpected.Username,
-		"bad_pass",
+		passHash,
 		userExpected.Email,
-		userExpected.Year,
-		userExpected.Month,
-		userExpected.Day,
+		userExpected.Timezone,
+		userExpected.Birthday.String(),
+		true,
+		userExpected.Deactivated,
+		userExpected.ExternalID,
+		userExpected.Privacy.HideYear,
+		userExpected.Privacy.HideFromDirectory,
+		userExpected.Privacy.NotSubscribable,
+		userExpected.EmailVerified,
+		userExpected.Role,
+		userExpected.Department,
 	)
 
 	mock.
-		ExpectQuery("SELECT id, username, password, email, year, month, day FROM users WHERE").
+		ExpectQuery("SELECT id, username, password, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
 		WithArgs(username).
 		WillReturnRows(rows)
 
-	_, err = testRepo.Login(ctx, username, password)
+	hasher.EXPECT().Verify(pass, passHash).Return(false, false, nil)
+
+	_, err = testRepo.Login(ctx, username, pass)
 
 	assert.ErrorIs(t, err, ErrBadPassword)
 
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
+
+	// пароль верный, но его нужно перехэшировать
+	rows = sqlmock.NewRows([]string{"id", "username", "password", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
+	rows = rows.AddRow(
+		userExpected.ID,
+		userExpected.Username,
+		pass, // пароль в открытом виде
+		userExpected.Email,
+		userExpected.Timezone,
+		userExpected.Birthday.String(),
+		true,
+		userExpected.Deactivated,
+		userExpected.ExternalID,
+		userExpected.Privacy.HideYear,
+		userExpected.Privacy.HideFromDirectory,
+		userExpected.Privacy.NotSubscribable,
+		userExpected.EmailVerified,
+		userExpected.Role,
+		userExpected.Department,
+	)
+
+	mock.
+		ExpectQuery("SELECT id, username, password, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
+		WithArgs(username).
+		WillReturnRows(rows)
+
+	hasher.EXPECT().Verify(pass, pass).Return(true, true, nil)
+	hasher.EXPECT().Hash(pass).Return(newPassHash, nil)
+
+	mock.
+		ExpectExec("UPDATE users SET password").
+		WithArgs(newPassHash, userExpected.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	userRecv, err = testRepo.Login(ctx, username, pass)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, userExpected, userRecv)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка при перехэшировании не мешает входу
+	rows = sqlmock.NewRows([]string{"id", "username", "password", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
+	rows = rows.AddRow(
+		userExpected.ID,
+		userExpected.Username,
+		passHash,
+		userExpected.Email,
+		userExpected.Timezone,
+		userExpected.Birthday.String(),
+		true,
+		userExpected.Deactivated,
+		userExpected.ExternalID,
+		userExpected.Privacy.HideYear,
+		userExpected.Privacy.HideFromDirectory,
+		userExpected.Privacy.NotSubscribable,
+		userExpected.EmailVerified,
+		userExpected.Role,
+		userExpected.Department,
+	)
+
+	mock.
+		ExpectQuery("SELECT id, username, password, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
+		WithArgs(username).
+		WillReturnRows(rows)
+
+	hasher.EXPECT().Verify(pass, passHash).Return(true, true, nil)
+	hasher.EXPECT().Hash(pass).Return(newPassHash, nil)
+
+	mock.
+		ExpectExec("UPDATE users SET password").
+		WithArgs(newPassHash, userExpected.ID).
+		WillReturnError(fmt.Errorf("db error"))
+
+	userRecv, err = testRepo.Login(ctx, username, pass)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, userExpected, userRecv)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка проверки пароля
+	rows = sqlmock.NewRows([]string{"id", "username", "password", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
+	rows = rows.AddRow(
+		userExpected.ID,
+		userExpected.Username,
+		"$broken",
+		userExpected.Email,
+		userExpected.Timezone,
+		userExpected.Birthday.String(),
+		true,
+		userExpected.Deactivated,
+		userExpected.ExternalID,
+		userExpected.Privacy.HideYear,
+		userExpected.Privacy.HideFromDirectory,
+		userExpected.Privacy.NotSubscribable,
+		userExpected.EmailVerified,
+		userExpected.Role,
+		userExpected.Department,
+	)
+
+	mock.
+		ExpectQuery("SELECT id, username, password, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
+		WithArgs(username).
+		WillReturnRows(rows)
+
+	hasher.EXPECT().Verify(pass, "$broken").Return(false, false, password.ErrBadHashFormat)
+
+	_, err = testRepo.Login(ctx, username, pass)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
 }
 
 func TestGetAll(t *testing.T) {
@@ -312,7 +509,7 @@ func TestGetAll(t *testing.T) {
 
 	ctx := context.Background()
 
-	testRepo := NewUsersMySQLRepo(db, zap.NewNop().Sugar())
+	testRepo := NewUsersMySQLRepo(db, nil, zap.NewNop().Sugar())
 
 	// данные для теста
 	usersExpected := []*User{
@@ -320,43 +517,56 @@ func TestGetAll(t *testing.T) {
 			ID:       uint32(0),
 			Username: "first",
 			Email:    "first@first.net",
-			Year:     2000,
-			Month:    1,
-			Day:      2,
+			Timezone: "UTC",
+			Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 2},
 		},
 		{
-			ID:       uint32(1),
-			Username: "second",
-			Email:    "second@second.net",
-			Year:     1990,
-			Month:    4,
-			Day:      3,
+			ID:            uint32(1),
+			Username:      "second",
+			Email:         "second@second.net",
+			Timezone:      "Europe/Moscow",
+			Birthday:      birthday.Birthday{Year: 1990, Month: time.April, Day: 3},
+			Privacy:       Privacy{HideYear: true, NotSubscribable: true},
+			EmailVerified: true,
 		},
 		{
-			ID:       uint32(2),
-			Username: "third",
-			Email:    "third@third.net",
-			Year:     2010,
-			Month:    12,
-			Day:      31,
+			ID:          uint32(2),
+			Username:    "third",
+			Email:       "third@third.net",
+			Timezone:    "America/New_York",
+			Birthday:    birthday.Birthday{Month: time.December, Day: 31}, // год скрыт
+			Deactivated: true,
+			ExternalID:  "hr-3",
 		},
 	}
 
+	// дата рождения в базе; без года хранится с 2000 годом
+	birthDates := []string{"2000-01-02", "1990-04-03", "2000-12-31"}
+	yearKnown := []bool{true, true, false}
+
 	// нормальная работа
-	rows := sqlmock.NewRows([]string{"id", "username", "email", "year", "month", "day"})
-	for _, u := range usersExpected {
+	rows := sqlmock.NewRows([]string{"id", "username", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
+	for i, u := range usersExpected {
 		rows = rows.AddRow(
 			u.ID,
 			u.Username,
 			u.Email,
-			u.Year,
-			u.Month,
-			u.Day,
+			u.Timezone,
+			birthDates[i],
+			yearKnown[i],
+			u.Deactivated,
+			u.ExternalID,
+			u.Privacy.HideYear,
+			u.Privacy.HideFromDirectory,
+			u.Privacy.NotSubscribable,
+			u.EmailVerified,
+			u.Role,
+			u.Department,
 		)
 	}
 
 	mock.
-		ExpectQuery("SELECT id, username, email, year, month, day FROM users").
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users").
 		WillReturnRows(rows)
 
 	usersRecv, err := testRepo.GetAll(ctx)
@@ -369,7 +579,7 @@ func TestGetAll(t *testing.T) {
 
 	// ответ с ошибкой
 	mock.
-		ExpectQuery("SELECT id, username, email, year, month, day FROM users").
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users").
 		WillReturnError(fmt.Errorf("db error"))
 
 	_, err = testRepo.GetAll(ctx)
@@ -384,7 +594,39 @@ func TestGetAll(t *testing.T) {
 	rows = rows.AddRow("")
 
 	mock.
-		ExpectQuery("SELECT id, username, email, year, month, day FROM users").
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users").
+		WillReturnRows(rows)
+
+	_, err = testRepo.GetAll(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// некорректная дата в базе
+	rows = sqlmock.NewRows([]string{"id", "username", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
+	rows = rows.AddRow(uint32(0), "first", "first@first.net", "UTC", "0000-00-00", true, false, "", false, false, false, false, RoleEmployee, "")
+
+	mock.
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users").
+		WillReturnRows(rows)
+
+	_, err = testRepo.GetAll(ctx)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// обрыв чтения строк
+	rows = sqlmock.NewRows([]string{"id", "username", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
+	rows = rows.AddRow(uint32(0), "first", "first@first.net", "UTC", "2000-01-02", true, false, "", false, false, false, false, RoleEmployee, "")
+	rows = rows.AddRow(uint32(1), "second", "second@second.net", "UTC", "2000-01-02", true, false, "", false, false, false, false, RoleEmployee, "")
+	rows = rows.RowError(1, fmt.Errorf("connection lost"))
+
+	mock.
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users").
 		WillReturnRows(rows)
 
 	_, err = testRepo.GetAll(ctx)
@@ -404,31 +646,40 @@ func TestGetByID(t *testing.T) {
 
 	ctx := context.Background()
 
-	testRepo := NewUsersMySQLRepo(db, zap.NewNop().Sugar())
+	testRepo := NewUsersMySQLRepo(db, nil, zap.NewNop().Sugar())
 
 	// данные для теста
 	userExpected := &User{
-		ID:       uint32(0),
-		Username: "some_user",
-		Email:    "some@email.net",
-		Year:     2000,
-		Month:    1,
-		Day:      2,
+		ID:         uint32(0),
+		Username:   "some_user",
+		Email:      "some@email.net",
+		Timezone:   "Asia/Tokyo",
+		Birthday:   birthday.Birthday{Year: 2000, Month: time.January, Day: 2},
+		ExternalID: "hr-42",
+		Department: "Бухгалтерия",
 	}
 
 	// нормальная работа
-	rows := sqlmock.NewRows([]string{"id", "username", "email", "year", "month", "day"})
+	rows := sqlmock.NewRows([]string{"id", "username", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
 	rows = rows.AddRow(
 		userExpected.ID,
 		userExpected.Username,
 		userExpected.Email,
-		userExpected.Year,
-		userExpected.Month,
-		userExpected.Day,
+		userExpected.Timezone,
+		userExpected.Birthday.String(),
+		true,
+		userExpected.Deactivated,
+		userExpected.ExternalID,
+		userExpected.Privacy.HideYear,
+		userExpected.Privacy.HideFromDirectory,
+		userExpected.Privacy.NotSubscribable,
+		userExpected.EmailVerified,
+		userExpected.Role,
+		userExpected.Department,
 	)
 
 	mock.
-		ExpectQuery("SELECT id, username, email, year, month, day FROM users WHERE").
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
 		WithArgs(userExpected.ID).
 		WillReturnRows(rows)
 
@@ -442,7 +693,7 @@ func TestGetByID(t *testing.T) {
 
 	// ответ с ошибкой
 	mock.
-		ExpectQuery("SELECT id, username, email, year, month, day FROM users WHERE").
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
 		WithArgs(userExpected.ID).
 		WillReturnError(fmt.Errorf("db error"))
 
@@ -458,7 +709,7 @@ func TestGetByID(t *testing.T) {
 	rows = rows.AddRow("")
 
 	mock.
-		ExpectQuery("SELECT id, username, email, year, month, day FROM users WHERE").
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
 		WithArgs(userExpected.ID).
 		WillReturnRows(rows)
 
@@ -470,10 +721,10 @@ func TestGetByID(t *testing.T) {
 	assert.NoError(t, err)
 
 	// пользователь не найден
-	rows = sqlmock.NewRows([]string{"id", "username", "password", "email", "year", "month", "day"})
+	rows = sqlmock.NewRows([]string{"id", "username", "password", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
 
 	mock.
-		ExpectQuery("SELECT id, username, email, year, month, day FROM users WHERE").
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
 		WithArgs(userExpected.ID).
 		WillReturnRows(rows)
 
@@ -484,3 +735,336 @@ func TestGetByID(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
+
+func TestUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewUsersMySQLRepo(db, nil, zap.NewNop().Sugar())
+
+	// данные для теста
+	u := &User{
+		ID:          uint32(5),
+		Username:    "some_user",
+		Email:       "new@email.net",
+		Timezone:    "Asia/Tokyo",
+		Birthday:    birthday.Birthday{Year: 2000, Month: time.January, Day: 2},
+		Deactivated: true,
+		ExternalID:  "hr-5",
+	}
+
+	// нормальная работа
+	mock.
+		ExpectQuery("SELECT id from users WHERE").
+		WithArgs(u.Username, u.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	mock.
+		ExpectExec("UPDATE users SET").
+		WithArgs(u.Username, u.Email, u.Timezone, "2000-01-02", true, u.Deactivated, u.ExternalID, u.Privacy.HideYear, u.Privacy.HideFromDirectory, u.Privacy.NotSubscribable, u.EmailVerified, u.Role, u.Department, u.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = testRepo.Update(ctx, u)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// имя занято другим пользователем
+	mock.
+		ExpectQuery("SELECT id from users WHERE").
+		WithArgs(u.Username, u.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uint32(6)))
+
+	err = testRepo.Update(ctx, u)
+
+	assert.ErrorIs(t, err, ErrUserExists)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка при проверке имени
+	mock.
+		ExpectQuery("SELECT id from users WHERE").
+		WithArgs(u.Username, u.ID).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.Update(ctx, u)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка при обновлении
+	mock.
+		ExpectQuery("SELECT id from users WHERE").
+		WithArgs(u.Username, u.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	mock.
+		ExpectExec("UPDATE users SET").
+		WithArgs(u.Username, u.Email, u.Timezone, "2000-01-02", true, u.Deactivated, u.ExternalID, u.Privacy.HideYear, u.Privacy.HideFromDirectory, u.Privacy.NotSubscribable, u.EmailVerified, u.Role, u.Department, u.ID).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.Update(ctx, u)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestGetByUsername(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewUsersMySQLRepo(db, nil, zap.NewNop().Sugar())
+
+	// данные для теста
+	userExpected := &User{
+		ID:       uint32(7),
+		Username: "some_user",
+		Email:    "some@email.net",
+		Timezone: "UTC",
+		Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 2},
+		Role:     RoleAdmin,
+	}
+
+	// нормальная работа
+	mock.
+		ExpectQuery("SELECT id FROM users WHERE username = ?").
+		WithArgs(userExpected.Username).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userExpected.ID))
+
+	rows := sqlmock.NewRows([]string{"id", "username", "email", "timezone", "birthday", "birth_year_known", "deactivated", "external_id", "hide_year", "hide_from_directory", "not_subscribable", "email_verified", "role", "department"})
+	rows = rows.AddRow(
+		userExpected.ID,
+		userExpected.Username,
+		userExpected.Email,
+		userExpected.Timezone,
+		userExpected.Birthday.String(),
+		true,
+		false,
+		"",
+		false,
+		false,
+		false,
+		false,
+		userExpected.Role,
+		userExpected.Department,
+	)
+
+	mock.
+		ExpectQuery("SELECT id, username, email, timezone, birthday, birth_year_known, deactivated, external_id, hide_year, hide_from_directory, not_subscribable, email_verified, role, department FROM users WHERE").
+		WithArgs(userExpected.ID).
+		WillReturnRows(rows)
+
+	userRecv, err := testRepo.GetByUsername(ctx, userExpected.Username)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, userExpected, userRecv)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// пользователь не найден
+	mock.
+		ExpectQuery("SELECT id FROM users WHERE username = ?").
+		WithArgs(userExpected.Username).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = testRepo.GetByUsername(ctx, userExpected.Username)
+
+	assert.ErrorIs(t, err, ErrNoUser)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	mock.
+		ExpectQuery("SELECT id FROM users WHERE username = ?").
+		WithArgs(userExpected.Username).
+		WillReturnError(fmt.Errorf("db error"))
+
+	_, err = testRepo.GetByUsername(ctx, userExpected.Username)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoUser)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestSetPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hasher := password.NewMockHasher(ctrl)
+
+	ctx := context.Background()
+
+	testRepo := NewUsersMySQLRepo(db, hasher, zap.NewNop().Sugar())
+
+	// данные для теста
+	userID := uint32(1)
+	pass := "new_pass"
+	passHash := "$pbkdf2-sha256$i=1,l=3$c2FsdA$a2V5"
+
+	// нормальная работа
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
+	mock.
+		ExpectExec("UPDATE users SET password").
+		WithArgs(passHash, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = testRepo.SetPassword(ctx, userID, pass)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// пользователь не найден
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
+	mock.
+		ExpectExec("UPDATE users SET password").
+		WithArgs(passHash, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = testRepo.SetPassword(ctx, userID, pass)
+
+	assert.ErrorIs(t, err, ErrNoUser)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка бд
+	hasher.EXPECT().Hash(pass).Return(passHash, nil)
+
+	mock.
+		ExpectExec("UPDATE users SET password").
+		WithArgs(passHash, userID).
+		WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.SetPassword(ctx, userID, pass)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoUser)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка хэширования
+	hasher.EXPECT().Hash(pass).Return("", fmt.Errorf("hasher error"))
+
+	err = testRepo.SetPassword(ctx, userID, pass)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testRepo := NewUsersMySQLRepo(db, nil, zap.NewNop().Sugar())
+
+	// данные для теста
+	userID := uint32(1)
+	expectRefs := func() {
+		mock.ExpectExec("DELETE FROM subscriptions WHERE").WithArgs(userID, userID).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("DELETE FROM sessions WHERE").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM password_resets WHERE").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM audit_log WHERE").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("DELETE FROM alert_deliveries WHERE").WithArgs(userID, userID).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	// нормальная работа: все в одной транзакции
+	mock.ExpectBegin()
+	expectRefs()
+	mock.ExpectExec("DELETE FROM users WHERE").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = testRepo.Delete(ctx, userID)
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// пользователя нет - транзакция откатывается
+	mock.ExpectBegin()
+	expectRefs()
+	mock.ExpectExec("DELETE FROM users WHERE").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = testRepo.Delete(ctx, userID)
+
+	assert.ErrorIs(t, err, ErrNoUser)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка начала транзакции
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.Delete(ctx, userID)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка удаления связанных строк - пользователь не удаляется
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM subscriptions WHERE").WithArgs(userID, userID).WillReturnError(fmt.Errorf("db error"))
+	mock.ExpectRollback()
+
+	err = testRepo.Delete(ctx, userID)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoUser)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+
+	// ошибка фиксации
+	mock.ExpectBegin()
+	expectRefs()
+	mock.ExpectExec("DELETE FROM users WHERE").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("db error"))
+
+	err = testRepo.Delete(ctx, userID)
+
+	assert.Error(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
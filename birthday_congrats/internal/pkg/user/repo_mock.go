@@ -5,6 +5,7 @@
 package user
 
 import (
+	birthday "birthday_congrats/internal/pkg/birthday"
 	context "context"
 	reflect "reflect"
 
@@ -35,18 +36,32 @@ func (m *MockUsersRepo) EXPECT() *MockUsersRepoMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockUsersRepo) Create(ctx context.Context, username, password, email string, year, month, day int) (*User, error) {
+func (m *MockUsersRepo) Create(ctx context.Context, username, password, email, timezone string, birth birthday.Birthday) (*User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, username, password, email, year, month, day)
+	ret := m.ctrl.Call(m, "Create", ctx, username, password, email, timezone, birth)
 	ret0, _ := ret[0].(*User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockUsersRepoMockRecorder) Create(ctx, username, password, email, year, month, day interface{}) *gomock.Call {
+func (mr *MockUsersRepoMockRecorder) Create(ctx, username, password, email, timezone, birth interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUsersRepo)(nil).Create), ctx, username, password, email, year, month, day)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUsersRepo)(nil).Create), ctx, username, password, email, timezone, birth)
+}
+
+// Delete mocks base method.
+func (m *MockUsersRepo) Delete(ctx context.Context, userID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUsersRepoMockRecorder) Delete(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUsersRepo)(nil).Delete), ctx, userID)
 }
 
 // GetAll mocks base method.
@@ -79,6 +94,21 @@ func (mr *MockUsersRepoMockRecorder) GetByID(ctx, userID interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUsersRepo)(nil).GetByID), ctx, userID)
 }
 
+// GetByUsername mocks base method.
+func (m *MockUsersRepo) GetByUsername(ctx context.Context, username string) (*User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUsername", ctx, username)
+	ret0, _ := ret[0].(*User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUsername indicates an expected call of GetByUsername.
+func (mr *MockUsersRepoMockRecorder) GetByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUsername", reflect.TypeOf((*MockUsersRepo)(nil).GetByUsername), ctx, username)
+}
+
 // Login mocks base method.
 func (m *MockUsersRepo) Login(ctx context.Context, username, password string) (*User, error) {
 	m.ctrl.T.Helper()
@@ -93,3 +123,31 @@ func (mr *MockUsersRepoMockRecorder) Login(ctx, username, password interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUsersRepo)(nil).Login), ctx, username, password)
 }
+
+// SetPassword mocks base method.
+func (m *MockUsersRepo) SetPassword(ctx context.Context, userID uint32, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPassword", ctx, userID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPassword indicates an expected call of SetPassword.
+func (mr *MockUsersRepoMockRecorder) SetPassword(ctx, userID, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPassword", reflect.TypeOf((*MockUsersRepo)(nil).SetPassword), ctx, userID, password)
+}
+
+// Update mocks base method.
+func (m *MockUsersRepo) Update(ctx context.Context, u *User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, u)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUsersRepoMockRecorder) Update(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUsersRepo)(nil).Update), ctx, u)
+}
@@ -1,7 +1,9 @@
 package user
 
 import (
+	"birthday_congrats/internal/pkg/birthday"
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -11,25 +13,87 @@ var (
 	ErrUserNotCreated = errors.New("user was not created")
 	ErrNoUser         = errors.New("no such user")
 	ErrBadPassword    = errors.New("bad password")
+	ErrBadTimezone    = errors.New("bad timezone")
+	ErrBadRole        = errors.New("bad role")
 )
 
+// DefaultTimezone - часовой пояс пользователей, которые его не указали
+const DefaultTimezone = "UTC"
+
+// роли пользователей
+const (
+	RoleEmployee = "employee" // обычный сотрудник, роль по умолчанию
+	RoleAdmin    = "admin"    // HR/администратор: управляет сотрудниками и рассылкой
+)
+
+// ValidRole сообщает, есть ли такая роль
+func ValidRole(role string) bool {
+	return role == RoleEmployee || role == RoleAdmin
+}
+
 type User struct {
-	ID       uint32 `sql:"AUTO_INCREMENT"`
-	Username string
-	Password string
-	Email    string
-	Year     int
-	Month    int
-	Day      int
+	ID            uint32 `sql:"AUTO_INCREMENT"`
+	Username      string
+	Password      string
+	Email         string
+	EmailVerified bool   // владелец подтвердил почту по ссылке из письма; до этого напоминания на нее не отправляются
+	Timezone      string // часовой пояс IANA, например Europe/Moscow
+	Birthday      birthday.Birthday
+	Deactivated   bool   // уволенные сотрудники не могут войти и не показываются в списке
+	ExternalID    string // идентификатор во внешней системе (HR), заполняется через SCIM или синхронизацией с LDAP
+	Privacy       Privacy
+	Role          string // RoleEmployee или RoleAdmin
+	Department    string // отдел, приходит из импорта или LDAP
 
 	// вспомогательные поле (подписка какого-то пользователя на текущего)
 	Subscription bool
-	DaysAlert    int
+	DaysAlert    []int     // за сколько дней напоминать, по возрастанию
+	NextBirthday time.Time // ближайшая дата празднования (с учетом 29 февраля)
+}
+
+// Privacy - что пользователь разрешает видеть коллегам. Нулевое значение - все открыто.
+type Privacy struct {
+	HideYear          bool // коллеги видят только день и месяц рождения
+	HideFromDirectory bool // не показывать в списке сотрудников (на такого пользователя нельзя подписаться)
+	NotSubscribable   bool // в списке виден, но подписаться и получать напоминания о нем нельзя
+}
+
+// Listed сообщает, показывается ли пользователь коллегам в списке сотрудников
+func (u *User) Listed() bool {
+	return !u.Deactivated && !u.Privacy.HideFromDirectory
+}
+
+// Subscribable сообщает, можно ли подписаться на пользователя и получать напоминания о нем
+func (u *User) Subscribable() bool {
+	return u.Listed() && !u.Privacy.NotSubscribable
+}
+
+// IsAdmin сообщает, может ли пользователь управлять сотрудниками; уволенный администратор - не может
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin && !u.Deactivated
+}
+
+// Location возвращает часовой пояс пользователя; если он не задан или некорректен - UTC
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
 }
 
 type UsersRepo interface {
-	Create(ctx context.Context, username, password, email string, year, month, day int) (*User, error)
+	Create(ctx context.Context, username, password, email, timezone string, birth birthday.Birthday) (*User, error)
 	Login(ctx context.Context, username, password string) (*User, error)
 	GetAll(ctx context.Context) ([]*User, error)
 	GetByID(ctx context.Context, userID uint32) (*User, error)
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	Update(ctx context.Context, u *User) error                             // обновляет все поля, кроме пароля
+	SetPassword(ctx context.Context, userID uint32, password string) error // хэширует и сохраняет новый пароль
+	Delete(ctx context.Context, userID uint32) error                       // удаляет пользователя и все, что на него ссылается
 }
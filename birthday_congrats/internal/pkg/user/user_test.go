@@ -0,0 +1,42 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocation(t *testing.T) {
+	// часовой пояс задан
+	u := &User{Timezone: "Europe/Moscow"}
+
+	assert.EqualValues(t, "Europe/Moscow", u.Location().String())
+
+	// не задан
+	u = &User{}
+
+	assert.EqualValues(t, time.UTC, u.Location())
+
+	// некорректный
+	u = &User{Timezone: "Mars/Olympus_Mons"}
+
+	assert.EqualValues(t, time.UTC, u.Location())
+}
+
+func TestIsAdmin(t *testing.T) {
+	// администратор
+	u := &User{Role: RoleAdmin}
+
+	assert.True(t, u.IsAdmin())
+
+	// уволенный администратор
+	u = &User{Role: RoleAdmin, Deactivated: true}
+
+	assert.False(t, u.IsAdmin())
+
+	// сотрудник
+	u = &User{Role: RoleEmployee}
+
+	assert.False(t, u.IsAdmin())
+}
@@ -0,0 +1,108 @@
+package verification
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Назначения токенов
+const (
+	PurposeEmail         = "email"          // подтверждение почты
+	PurposeDeleteAccount = "delete_account" // удаление аккаунта без пароля
+)
+
+var (
+	ErrBadToken     = errors.New("bad token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Signer выпускает и проверяет подписанные ссылки-токены вида
+// `<base64url(id:срок:значение)>.<base64url(hmac-sha256)>`. В подпись входит назначение
+// токена, поэтому токен, выпущенный для одного действия, не подходит для другого.
+// Хранить токены не нужно: все, что нужно для проверки, лежит в них самих.
+type Signer struct {
+	key     []byte
+	purpose string
+	ttl     time.Duration
+}
+
+func NewSigner(key []byte, purpose string, ttl time.Duration) *Signer {
+	return &Signer{
+		key:     key,
+		purpose: purpose,
+		ttl:     ttl,
+	}
+}
+
+// WithPurpose возвращает подписчика с тем же ключом для другого назначения и срока действия
+func (s *Signer) WithPurpose(purpose string, ttl time.Duration) *Signer {
+	return NewSigner(s.key, purpose, ttl)
+}
+
+// Issue выпускает токен для пользователя userID. value - то, что токен подтверждает
+// (например, адрес почты): при проверке его нужно сравнить с текущим значением.
+func (s *Signer) Issue(userID uint32, value string, now time.Time) string {
+	payload := fmt.Sprintf("%d:%d:%s", userID, now.Add(s.ttl).Unix(), value)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// Check проверяет подпись и срок действия токена и возвращает userID и value, с которыми он выпущен
+func (s *Signer) Check(token string, now time.Time) (uint32, string, error) {
+	encodedPayload, encodedSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", ErrBadToken
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return 0, "", ErrBadToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return 0, "", ErrBadToken
+	}
+
+	payload := string(payloadBytes)
+	if !hmac.Equal(sig, s.sign(payload)) {
+		return 0, "", ErrBadToken
+	}
+
+	// значение идет последним: в нем самом может быть двоеточие
+	parts := strings.SplitN(payload, ":", 3)
+	if len(parts) != 3 {
+		return 0, "", ErrBadToken
+	}
+
+	userID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, "", ErrBadToken
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, "", ErrBadToken
+	}
+
+	if now.Unix() > expires {
+		return 0, "", ErrTokenExpired
+	}
+
+	return uint32(userID), parts[2], nil
+}
+
+func (s *Signer) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(s.purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
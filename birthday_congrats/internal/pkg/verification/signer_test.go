@@ -0,0 +1,85 @@
+package verification
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueAndCheck(t *testing.T) {
+	signer := NewSigner([]byte("some_secret_key_some_secret_key_"), "email", time.Hour)
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+
+	// нормальная работа
+	token := signer.Issue(42, "some@email.net", now)
+
+	userID, value, err := signer.Check(token, now.Add(59*time.Minute))
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 42, userID)
+	assert.EqualValues(t, "some@email.net", value)
+
+	// токен можно вставить в ссылку без экранирования
+	assert.False(t, strings.ContainsAny(token, "+/=?&"))
+
+	// двоеточие в значении
+	userID, value, err = signer.Check(signer.Issue(1, "a:b", now), now)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, userID)
+	assert.EqualValues(t, "a:b", value)
+
+	// срок действия истек
+	_, _, err = signer.Check(token, now.Add(time.Hour+time.Second))
+
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	// другой ключ
+	other := NewSigner([]byte("other_secret_key_other_secret_ke"), "email", time.Hour)
+
+	_, _, err = other.Check(token, now)
+
+	assert.ErrorIs(t, err, ErrBadToken)
+
+	// другое назначение
+	other = NewSigner([]byte("some_secret_key_some_secret_key_"), "password", time.Hour)
+
+	_, _, err = other.Check(token, now)
+
+	assert.ErrorIs(t, err, ErrBadToken)
+
+	// тот же ключ для другого назначения: токены друг другу не подходят, срок свой
+	deletion := signer.WithPurpose(PurposeDeleteAccount, time.Minute)
+
+	_, _, err = deletion.Check(token, now)
+
+	assert.ErrorIs(t, err, ErrBadToken)
+
+	userID, value, err = deletion.Check(deletion.Issue(42, "some@email.net", now), now.Add(59*time.Second))
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 42, userID)
+	assert.EqualValues(t, "some@email.net", value)
+
+	_, _, err = deletion.Check(deletion.Issue(42, "some@email.net", now), now.Add(time.Minute+time.Second))
+
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	// подмена данных
+	payload, sig, _ := strings.Cut(token, ".")
+	forged := signer.Issue(43, "some@email.net", now)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	_, _, err = signer.Check(forgedPayload+"."+sig, now)
+
+	assert.ErrorIs(t, err, ErrBadToken)
+
+	// испорченный токен
+	for _, bad := range []string{"", "no_dot", payload + ".", "." + sig, "!!!." + sig, payload + ".!!!"} {
+		_, _, err = signer.Check(bad, now)
+
+		assert.ErrorIs(t, err, ErrBadToken, bad)
+	}
+}
@@ -0,0 +1,194 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Export - все, что сервис хранит о пользователе (выгрузка по запросу пользователя, GDPR).
+// Хэш пароля и идентификаторы сессий не выгружаются: это секреты, а не данные о человеке.
+type Export struct {
+	User          *user.User
+	Subscriptions []*subscription.Subscription // на кого подписан пользователь
+	Changes       []audit.Entry                // журнал изменений профиля
+}
+
+func (cs *CongratulationsServiceImpl) ExportData(ctx context.Context) (*Export, error) {
+	us, err := cs.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.export(ctx, us)
+}
+
+func (cs *CongratulationsServiceImpl) ExportUser(ctx context.Context, userID uint32) (*Export, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	us, err := cs.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.export(ctx, us)
+}
+
+func (cs *CongratulationsServiceImpl) export(ctx context.Context, us *user.User) (*Export, error) {
+	subscriptions, err := cs.subscriptionsRepo.GetSubscriptionsByUser(ctx, us.ID)
+	if err != nil {
+		cs.logger.Errorf("Error getting subscriptions of user %d: %v", us.ID, err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	changes, err := cs.audit.ListByUser(ctx, us.ID)
+	if err != nil {
+		cs.logger.Errorf("Error getting changes of user %d: %v", us.ID, err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	return &Export{
+		User:          us,
+		Subscriptions: subscriptions,
+		Changes:       changes,
+	}, nil
+}
+
+func (cs *CongratulationsServiceImpl) DeleteAccount(ctx context.Context, password string) error {
+	us, err := cs.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	// по одной украденной сессии аккаунт не удалить
+	_, err = cs.usersRepo.Login(ctx, us.Username, password)
+	if err != nil && err != user.ErrBadPassword {
+		cs.logger.Errorf("Error while checking password: %v", err)
+		return fmt.Errorf("internal error")
+	}
+	if err == user.ErrBadPassword {
+		cs.logger.Warnf("User %d entered wrong password to delete account", us.ID)
+		return err
+	}
+
+	return cs.deleteUser(ctx, us.ID)
+}
+
+// RequestAccountDeletion отправляет текущему пользователю ссылку удаления аккаунта. Это повторная
+// проверка для тех, у кого нет своего пароля: учетные записи, заведенные при входе через провайдера,
+// импорте или синхронизации с каталогом, получают случайный пароль, которого никто не знает.
+// Ссылка уходит только на подтвержденную почту: иначе по украденной сессии можно было бы
+// поменять почту на свою и удалить аккаунт.
+func (cs *CongratulationsServiceImpl) RequestAccountDeletion(ctx context.Context) error {
+	us, err := cs.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !us.EmailVerified {
+		cs.logger.Warnf("User %d requested account deletion with unverified email", us.ID)
+		return ErrUnverifiedEmail
+	}
+
+	// в ссылку зашит адрес: после смены почты старая ссылка не подходит
+	token := cs.deletion.Issue(us.ID, us.Email, cs.now())
+	link := cs.baseURL + "/account/delete?token=" + url.QueryEscape(token)
+
+	err = cs.outbox.Enqueue(ctx, []string{us.Email}, "Удаление аккаунта", deletionText(us.Username, link))
+	if err != nil {
+		cs.logger.Errorf("Error while sending account deletion email: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	cs.logger.Infof("Account deletion requested by user %d", us.ID)
+
+	return nil
+}
+
+func (cs *CongratulationsServiceImpl) DeleteAccountByLink(ctx context.Context, token string) error {
+	userID, email, err := cs.deletion.Check(token, cs.now())
+	if err != nil {
+		cs.logger.Warnf("Bad account deletion token: %v", err)
+		return err
+	}
+
+	us, err := cs.usersRepo.GetByID(ctx, userID)
+	if err != nil && err != user.ErrNoUser {
+		cs.logger.Errorf("Error getting user by id: %v", err)
+		return fmt.Errorf("internal error")
+	}
+	if err == user.ErrNoUser || us.Email != email || us.Deactivated {
+		cs.logger.Warnf("Account deletion token of user %d does not match current user", userID)
+		return verification.ErrBadToken
+	}
+
+	err = cs.deleteUser(ctx, userID)
+	if err == user.ErrNoUser {
+		// удалили параллельно
+		return verification.ErrBadToken
+	}
+
+	return err
+}
+
+func (cs *CongratulationsServiceImpl) DeleteUser(ctx context.Context, userID uint32) error {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cs.deleteUser(ctx, userID)
+}
+
+func (cs *CongratulationsServiceImpl) deleteUser(ctx context.Context, userID uint32) error {
+	err := cs.usersRepo.Delete(ctx, userID)
+	if err != nil && err != user.ErrNoUser {
+		cs.logger.Errorf("Error deleting user %d: %v", userID, err)
+		return fmt.Errorf("internal error")
+	}
+	if err == user.ErrNoUser {
+		return err
+	}
+
+	return cs.cleanupDeleted(ctx, userID)
+}
+
+// cleanupDeleted удаляет то, что ссылалось на удаленного пользователя. MySQL-хранилище
+// удаляет все это в одной транзакции с пользователем, и здесь удалять уже нечего;
+// хранилища в памяти друг о друге не знают, их дочищаем по одному.
+func (cs *CongratulationsServiceImpl) cleanupDeleted(ctx context.Context, userID uint32) error {
+	err := cs.subscriptionsRepo.RemoveByUser(ctx, userID)
+	if err != nil {
+		cs.logger.Errorf("Error removing subscriptions of user %d: %v", userID, err)
+		return fmt.Errorf("internal error")
+	}
+
+	err = cs.resets.RemoveByUser(ctx, userID)
+	if err != nil {
+		cs.logger.Errorf("Error removing reset tokens of user %d: %v", userID, err)
+		return fmt.Errorf("internal error")
+	}
+
+	err = cs.audit.RemoveByUser(ctx, userID)
+	if err != nil {
+		cs.logger.Errorf("Error removing changes of user %d: %v", userID, err)
+		return fmt.Errorf("internal error")
+	}
+
+	err = cs.sm.DestroyAll(ctx, userID)
+	if err != nil {
+		cs.logger.Errorf("Error destroying sessions of user %d: %v", userID, err)
+		return fmt.Errorf("internal error")
+	}
+
+	cs.logger.Infof("User %d was deleted", userID)
+
+	return nil
+}
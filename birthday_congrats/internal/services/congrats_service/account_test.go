@@ -0,0 +1,350 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type accountTestRepos struct {
+	users         *user.MockUsersRepo
+	subscriptions *subscription.MockSubscriptionsRepo
+	sessions      *session.MockSessionsManager
+	outbox        *outbox.MockOutbox
+	resets        *reset.MockResetsRepo
+	audit         *audit.MockAuditRepo
+}
+
+func newAccountTestService(ctrl *gomock.Controller) (*CongratulationsServiceImpl, *accountTestRepos) {
+	repos := &accountTestRepos{
+		users:         user.NewMockUsersRepo(ctrl),
+		subscriptions: subscription.NewMockSubscriptionsRepo(ctrl),
+		sessions:      session.NewMockSessionsManager(ctrl),
+		outbox:        outbox.NewMockOutbox(ctrl),
+		resets:        reset.NewMockResetsRepo(ctrl),
+		audit:         audit.NewMockAuditRepo(ctrl),
+	}
+
+	testService := NewCongratulationsServiceImpl(
+		repos.users,
+		repos.subscriptions,
+		repos.sessions,
+		repos.outbox,
+		delivery.NewMockDeliveriesRepo(ctrl),
+		repos.resets,
+		repos.audit,
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
+		zap.NewNop().Sugar(),
+	)
+
+	return testService, repos
+}
+
+func TestExportData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	us := &user.User{ID: 42, Username: "some_user", Email: "some@email.net"}
+	sess := &session.Session{SessID: "some_sess_id", UserID: us.ID}
+	ctx := session.ContextWithSession(context.Background(), sess)
+	subscriptions := []*subscription.Subscription{
+		{Subscriber: us.ID, Subscription: 7, DaysAlert: []int{3}},
+	}
+	changes := []audit.Entry{
+		{ActorID: us.ID, UserID: us.ID, Field: audit.FieldEmail, At: time.Unix(100, 0)},
+	}
+
+	// нормальная работа
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.subscriptions.EXPECT().GetSubscriptionsByUser(ctx, us.ID).Return(subscriptions, nil)
+	repos.audit.EXPECT().ListByUser(ctx, us.ID).Return(changes, nil)
+
+	got, err := testService.ExportData(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &Export{User: us, Subscriptions: subscriptions, Changes: changes}, got)
+
+	// нет сессии
+	_, err = testService.ExportData(context.Background())
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка получения подписок
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.subscriptions.EXPECT().GetSubscriptionsByUser(ctx, us.ID).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.ExportData(ctx)
+
+	assert.Error(t, err)
+
+	// ошибка получения журнала
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.subscriptions.EXPECT().GetSubscriptionsByUser(ctx, us.ID).Return(subscriptions, nil)
+	repos.audit.EXPECT().ListByUser(ctx, us.ID).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.ExportData(ctx)
+
+	assert.Error(t, err)
+}
+
+func TestExportUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	us := &user.User{ID: 42, Username: "some_user"}
+	ctx := session.ContextAsSystem(context.Background())
+
+	// нормальная работа
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.subscriptions.EXPECT().GetSubscriptionsByUser(ctx, us.ID).Return(nil, nil)
+	repos.audit.EXPECT().ListByUser(ctx, us.ID).Return(nil, nil)
+
+	got, err := testService.ExportUser(ctx, us.ID)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, us, got.User)
+
+	// нет пользователя
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(nil, user.ErrNoUser)
+
+	_, err = testService.ExportUser(ctx, us.ID)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	us := &user.User{ID: 42, Username: "some_user"}
+	sess := &session.Session{SessID: "some_sess_id", UserID: us.ID}
+	ctx := session.ContextWithSession(context.Background(), sess)
+
+	// нормальная работа
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.users.EXPECT().Login(ctx, us.Username, "some_pass").Return(us, nil)
+	repos.users.EXPECT().Delete(ctx, us.ID).Return(nil)
+	repos.subscriptions.EXPECT().RemoveByUser(ctx, us.ID).Return(nil)
+	repos.resets.EXPECT().RemoveByUser(ctx, us.ID).Return(nil)
+	repos.audit.EXPECT().RemoveByUser(ctx, us.ID).Return(nil)
+	repos.sessions.EXPECT().DestroyAll(ctx, us.ID).Return(nil)
+
+	err := testService.DeleteAccount(ctx, "some_pass")
+
+	assert.NoError(t, err)
+
+	// неверный пароль
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.users.EXPECT().Login(ctx, us.Username, "wrong_pass").Return(nil, user.ErrBadPassword)
+
+	err = testService.DeleteAccount(ctx, "wrong_pass")
+
+	assert.ErrorIs(t, err, user.ErrBadPassword)
+
+	// нет сессии
+	err = testService.DeleteAccount(context.Background(), "some_pass")
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка удаления: остальное не трогаем
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.users.EXPECT().Login(ctx, us.Username, "some_pass").Return(us, nil)
+	repos.users.EXPECT().Delete(ctx, us.ID).Return(fmt.Errorf("repo error"))
+
+	err = testService.DeleteAccount(ctx, "some_pass")
+
+	assert.Error(t, err)
+}
+
+func TestRequestAccountDeletion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	us := &user.User{ID: 42, Username: "some_user", Email: "some@email.net", EmailVerified: true}
+	sess := &session.Session{SessID: "some_sess_id", UserID: us.ID}
+	ctx := session.ContextWithSession(context.Background(), sess)
+
+	token := testService.deletion.Issue(us.ID, us.Email, now)
+	link := testBaseURL + "/account/delete?token=" + url.QueryEscape(token)
+
+	// нормальная работа
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.outbox.EXPECT().Enqueue(ctx, []string{us.Email}, "Удаление аккаунта", deletionText(us.Username, link)).Return(nil)
+
+	err := testService.RequestAccountDeletion(ctx)
+
+	assert.NoError(t, err)
+
+	// ссылка удаления не подходит для подтверждения почты
+	_, _, err = testVerifier.Check(token, now)
+
+	assert.ErrorIs(t, err, verification.ErrBadToken)
+
+	// почта не подтверждена
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(&user.User{ID: us.ID, Email: us.Email}, nil)
+
+	err = testService.RequestAccountDeletion(ctx)
+
+	assert.ErrorIs(t, err, ErrUnverifiedEmail)
+
+	// нет сессии
+	err = testService.RequestAccountDeletion(context.Background())
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка очереди писем
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.outbox.EXPECT().Enqueue(ctx, []string{us.Email}, "Удаление аккаунта", deletionText(us.Username, link)).Return(fmt.Errorf("outbox error"))
+
+	err = testService.RequestAccountDeletion(ctx)
+
+	assert.Error(t, err)
+}
+
+func TestDeleteAccountByLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	us := &user.User{ID: 42, Username: "some_user", Email: "some@email.net", EmailVerified: true}
+	token := testService.deletion.Issue(us.ID, us.Email, now.Add(-time.Minute))
+	ctx := context.Background()
+
+	// нормальная работа: сессия не нужна
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.users.EXPECT().Delete(ctx, us.ID).Return(nil)
+	repos.subscriptions.EXPECT().RemoveByUser(ctx, us.ID).Return(nil)
+	repos.resets.EXPECT().RemoveByUser(ctx, us.ID).Return(nil)
+	repos.audit.EXPECT().RemoveByUser(ctx, us.ID).Return(nil)
+	repos.sessions.EXPECT().DestroyAll(ctx, us.ID).Return(nil)
+
+	err := testService.DeleteAccountByLink(ctx, token)
+
+	assert.NoError(t, err)
+
+	// повторный переход: пользователя уже нет
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(nil, user.ErrNoUser)
+
+	err = testService.DeleteAccountByLink(ctx, token)
+
+	assert.ErrorIs(t, err, verification.ErrBadToken)
+
+	// после запроса сменилась почта
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(&user.User{ID: us.ID, Email: "other@email.net"}, nil)
+
+	err = testService.DeleteAccountByLink(ctx, token)
+
+	assert.ErrorIs(t, err, verification.ErrBadToken)
+
+	// ссылка подтверждения почты не удаляет аккаунт
+	err = testService.DeleteAccountByLink(ctx, testVerifier.Issue(us.ID, us.Email, now))
+
+	assert.ErrorIs(t, err, verification.ErrBadToken)
+
+	// ссылка устарела
+	err = testService.DeleteAccountByLink(ctx, testService.deletion.Issue(us.ID, us.Email, now.Add(-2*time.Hour)))
+
+	assert.ErrorIs(t, err, verification.ErrTokenExpired)
+
+	// ошибка хранилища
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(nil, fmt.Errorf("repo error"))
+
+	err = testService.DeleteAccountByLink(ctx, token)
+
+	assert.Error(t, err)
+
+	// ошибка удаления
+	repos.users.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	repos.users.EXPECT().Delete(ctx, us.ID).Return(fmt.Errorf("repo error"))
+
+	err = testService.DeleteAccountByLink(ctx, token)
+
+	assert.Error(t, err)
+}
+
+func TestDeleteUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	userID := uint32(42)
+	ctx := session.ContextAsSystem(context.Background())
+
+	// нормальная работа
+	repos.users.EXPECT().Delete(ctx, userID).Return(nil)
+	repos.subscriptions.EXPECT().RemoveByUser(ctx, userID).Return(nil)
+	repos.resets.EXPECT().RemoveByUser(ctx, userID).Return(nil)
+	repos.audit.EXPECT().RemoveByUser(ctx, userID).Return(nil)
+	repos.sessions.EXPECT().DestroyAll(ctx, userID).Return(nil)
+
+	err := testService.DeleteUser(ctx, userID)
+
+	assert.NoError(t, err)
+
+	// нет пользователя
+	repos.users.EXPECT().Delete(ctx, userID).Return(user.ErrNoUser)
+
+	err = testService.DeleteUser(ctx, userID)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// ошибка дочистки подписок
+	repos.users.EXPECT().Delete(ctx, userID).Return(nil)
+	repos.subscriptions.EXPECT().RemoveByUser(ctx, userID).Return(fmt.Errorf("repo error"))
+
+	err = testService.DeleteUser(ctx, userID)
+
+	assert.Error(t, err)
+
+	// ошибка завершения сессий
+	repos.users.EXPECT().Delete(ctx, userID).Return(nil)
+	repos.subscriptions.EXPECT().RemoveByUser(ctx, userID).Return(nil)
+	repos.resets.EXPECT().RemoveByUser(ctx, userID).Return(nil)
+	repos.audit.EXPECT().RemoveByUser(ctx, userID).Return(nil)
+	repos.sessions.EXPECT().DestroyAll(ctx, userID).Return(fmt.Errorf("session error"))
+
+	err = testService.DeleteUser(ctx, userID)
+
+	assert.Error(t, err)
+}
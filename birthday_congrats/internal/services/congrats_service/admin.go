@@ -0,0 +1,151 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+)
+
+// requireAdmin пропускает доверенные системы (SCIM) и администраторов. Проверка делается
+// в сервисе, а не только в роутинге, чтобы новый хендлер не мог случайно ее обойти.
+func (cs *CongratulationsServiceImpl) requireAdmin(ctx context.Context) error {
+	if session.IsSystem(ctx) {
+		return nil
+	}
+
+	us, err := cs.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !us.IsAdmin() {
+		cs.logger.Warnf("User %d is not an admin", us.ID)
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// actorID - кто выполняет действие: пользователь сессии или 0 для доверенной системы
+func actorID(ctx context.Context) uint32 {
+	sess, err := session.SessionFromContext(ctx)
+	if err != nil {
+		return 0
+	}
+
+	return sess.UserID
+}
+
+func (cs *CongratulationsServiceImpl) HasRole(ctx context.Context, role string) (bool, error) {
+	us, err := cs.currentUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	switch role {
+	case user.RoleAdmin:
+		return us.IsAdmin(), nil
+	case user.RoleEmployee:
+		// права сотрудника есть у всех работающих, в том числе у администраторов
+		return !us.Deactivated, nil
+	default:
+		return false, user.ErrBadRole
+	}
+}
+
+func (cs *CongratulationsServiceImpl) SetRole(ctx context.Context, userID uint32, role string) (*user.User, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.ValidRole(role) {
+		cs.logger.Warnf("Bad role %q", role)
+		return nil, user.ErrBadRole
+	}
+
+	us, err := cs.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if us.Role == role {
+		return us, nil
+	}
+
+	us.Role = role
+
+	err = cs.usersRepo.Update(ctx, us)
+	if err != nil {
+		cs.logger.Errorf("Error while updating user: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	cs.recordChanges(ctx, actorID(ctx), us.ID, []string{audit.FieldRole})
+	cs.logger.Infof("User %d now has role %s", us.ID, role)
+
+	return us, nil
+}
+
+func (cs *CongratulationsServiceImpl) ListSubscriptions(ctx context.Context) ([]*subscription.Subscription, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := cs.subscriptionsRepo.GetAllSubscriptions(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error while getting all subscriptions: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	return subscriptions, nil
+}
+
+func (cs *CongratulationsServiceImpl) ForceLogout(ctx context.Context, userID uint32) error {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = cs.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = cs.sm.DestroyAll(ctx, userID)
+	if err != nil {
+		cs.logger.Errorf("Error destroying sessions of user %d: %v", userID, err)
+		return fmt.Errorf("internal error")
+	}
+
+	cs.logger.Infof("Sessions of user %d were destroyed by %d", userID, actorID(ctx))
+
+	return nil
+}
+
+// RunAlerts запускает рассылку сейчас, не дожидаясь расписания. Уже отправленные
+// напоминания повторно не уходят благодаря журналу отправленных напоминаний.
+func (cs *CongratulationsServiceImpl) RunAlerts(ctx context.Context) error {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	zones, err := cs.zones(ctx)
+	if err != nil {
+		return fmt.Errorf("internal error")
+	}
+
+	cs.logger.Infof("Alerts were started manually by %d", actorID(ctx))
+
+	err = cs.run(ctx, cs.now(), zones)
+	if err != nil {
+		return fmt.Errorf("internal error")
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	admin := &user.User{ID: 1, Role: user.RoleAdmin}
+	employee := &user.User{ID: 2, Role: user.RoleEmployee}
+	ctx := session.ContextWithSession(context.Background(), &session.Session{SessID: "some_sess_id", UserID: admin.ID})
+	subscriptions := []*subscription.Subscription{{Subscriber: 2, Subscription: 3, DaysAlert: []int{0}}}
+
+	// администратор
+	repos.users.EXPECT().GetByID(ctx, admin.ID).Return(admin, nil)
+	repos.subscriptions.EXPECT().GetAllSubscriptions(ctx).Return(subscriptions, nil)
+
+	got, err := testService.ListSubscriptions(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, subscriptions, got)
+
+	// доверенная система: пользователь не загружается
+	systemCtx := session.ContextAsSystem(context.Background())
+
+	repos.subscriptions.EXPECT().GetAllSubscriptions(systemCtx).Return(subscriptions, nil)
+
+	_, err = testService.ListSubscriptions(systemCtx)
+
+	assert.NoError(t, err)
+
+	// обычный сотрудник
+	employeeCtx := session.ContextWithSession(context.Background(), &session.Session{SessID: "other_sess_id", UserID: employee.ID})
+
+	repos.users.EXPECT().GetByID(employeeCtx, employee.ID).Return(employee, nil)
+
+	_, err = testService.ListSubscriptions(employeeCtx)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// уволенный администратор
+	repos.users.EXPECT().GetByID(ctx, admin.ID).Return(&user.User{ID: admin.ID, Role: user.RoleAdmin, Deactivated: true}, nil)
+
+	_, err = testService.ListSubscriptions(ctx)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// нет сессии
+	_, err = testService.ListSubscriptions(context.Background())
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка бд
+	repos.users.EXPECT().GetByID(ctx, admin.ID).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.ListSubscriptions(ctx)
+
+	assert.Error(t, err)
+
+	// проверяются и методы справочника сотрудников
+	repos.users.EXPECT().GetByID(employeeCtx, employee.ID).Return(employee, nil)
+
+	_, err = testService.DeactivateUser(employeeCtx, admin.ID)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	repos.users.EXPECT().GetByID(employeeCtx, employee.ID).Return(employee, nil)
+
+	err = testService.DeleteUser(employeeCtx, admin.ID)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestHasRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	ctx := session.ContextWithSession(context.Background(), &session.Session{SessID: "some_sess_id", UserID: 1})
+
+	// администратор - еще и сотрудник
+	repos.users.EXPECT().GetByID(ctx, uint32(1)).Return(&user.User{ID: 1, Role: user.RoleAdmin}, nil).Times(2)
+
+	ok, err := testService.HasRole(ctx, user.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = testService.HasRole(ctx, user.RoleEmployee)
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// сотрудник
+	repos.users.EXPECT().GetByID(ctx, uint32(1)).Return(&user.User{ID: 1, Role: user.RoleEmployee}, nil)
+
+	ok, err = testService.HasRole(ctx, user.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// неизвестная роль
+	repos.users.EXPECT().GetByID(ctx, uint32(1)).Return(&user.User{ID: 1, Role: user.RoleEmployee}, nil)
+
+	_, err = testService.HasRole(ctx, "superuser")
+
+	assert.ErrorIs(t, err, user.ErrBadRole)
+
+	// нет сессии
+	_, err = testService.HasRole(context.Background(), user.RoleAdmin)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+}
+
+func TestSetRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	// данные для теста
+	admin := &user.User{ID: 1, Role: user.RoleAdmin}
+	ctx := session.ContextWithSession(context.Background(), &session.Session{SessID: "some_sess_id", UserID: admin.ID})
+	employee := func() *user.User {
+		return &user.User{ID: 2, Username: "some_user", Role: user.RoleEmployee}
+	}
+	promoted := employee()
+	promoted.Role = user.RoleAdmin
+
+	// нормальная работа: смена роли записывается в журнал
+	repos.users.EXPECT().GetByID(ctx, admin.ID).Return(admin, nil)
+	repos.users.EXPECT().GetByID(ctx, uint32(2)).Return(employee(), nil)
+	repos.users.EXPECT().Update(ctx, promoted).Return(nil)
+	repos.audit.EXPECT().Record(ctx, []audit.Entry{
+		{ActorID: admin.ID, UserID: 2, Field: audit.FieldRole, At: now},
+	}).Return(nil)
+
+	got, err := testService.SetRole(ctx, 2, user.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, promoted, got)
+
+	// роль не меняется
+	repos.users.EXPECT().GetByID(ctx, admin.ID).Return(admin, nil)
+	repos.users.EXPECT().GetByID(ctx, uint32(2)).Return(employee(), nil)
+
+	_, err = testService.SetRole(ctx, 2, user.RoleEmployee)
+
+	assert.NoError(t, err)
+
+	// неизвестная роль
+	repos.users.EXPECT().GetByID(ctx, admin.ID).Return(admin, nil)
+
+	_, err = testService.SetRole(ctx, 2, "superuser")
+
+	assert.ErrorIs(t, err, user.ErrBadRole)
+
+	// нет пользователя
+	repos.users.EXPECT().GetByID(ctx, admin.ID).Return(admin, nil)
+	repos.users.EXPECT().GetByID(ctx, uint32(2)).Return(nil, user.ErrNoUser)
+
+	_, err = testService.SetRole(ctx, 2, user.RoleAdmin)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// ошибка бд
+	repos.users.EXPECT().GetByID(ctx, admin.ID).Return(admin, nil)
+	repos.users.EXPECT().GetByID(ctx, uint32(2)).Return(employee(), nil)
+	repos.users.EXPECT().Update(ctx, promoted).Return(fmt.Errorf("repo error"))
+
+	_, err = testService.SetRole(ctx, 2, user.RoleAdmin)
+
+	assert.Error(t, err)
+}
+
+func TestForceLogout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	ctx := session.ContextAsSystem(context.Background())
+
+	// нормальная работа
+	repos.users.EXPECT().GetByID(ctx, uint32(2)).Return(&user.User{ID: 2}, nil)
+	repos.sessions.EXPECT().DestroyAll(ctx, uint32(2)).Return(nil)
+
+	err := testService.ForceLogout(ctx, 2)
+
+	assert.NoError(t, err)
+
+	// нет пользователя
+	repos.users.EXPECT().GetByID(ctx, uint32(2)).Return(nil, user.ErrNoUser)
+
+	err = testService.ForceLogout(ctx, 2)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// ошибка завершения сессий
+	repos.users.EXPECT().GetByID(ctx, uint32(2)).Return(&user.User{ID: 2}, nil)
+	repos.sessions.EXPECT().DestroyAll(ctx, uint32(2)).Return(fmt.Errorf("session error"))
+
+	err = testService.ForceLogout(ctx, 2)
+
+	assert.Error(t, err)
+}
+
+func TestRunAlerts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	// данные для теста
+	ctx := session.ContextAsSystem(context.Background())
+
+	// нормальная работа: подписок нет - отправлять нечего
+	repos.users.EXPECT().GetAll(ctx).Return([]*user.User{}, nil)
+	repos.subscriptions.EXPECT().GetAllSubscriptions(ctx).Return(nil, nil)
+
+	err := testService.RunAlerts(ctx)
+
+	assert.NoError(t, err)
+
+	// ошибка получения пользователей
+	repos.users.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	err = testService.RunAlerts(ctx)
+
+	assert.Error(t, err)
+
+	// ошибка получения подписок
+	repos.users.EXPECT().GetAll(ctx).Return([]*user.User{}, nil)
+	repos.subscriptions.EXPECT().GetAllSubscriptions(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	err = testService.RunAlerts(ctx)
+
+	assert.Error(t, err)
+
+	// не администратор
+	err = testService.RunAlerts(context.Background())
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+}
@@ -1,12 +1,18 @@
 package congrats_service
 
 import (
-	alertmanager "birthday_congrats/internal/pkg/alert_manager"
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/cron"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/reset"
 	"birthday_congrats/internal/pkg/session"
 	"birthday_congrats/internal/pkg/subscription"
 	"birthday_congrats/internal/pkg/user"
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -23,13 +29,22 @@ func TestRegister(t *testing.T) {
 	usersRepo := user.NewMockUsersRepo(ctrl)
 	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
 	sessManager := session.NewMockSessionsManager(ctrl)
-	alertManager := alertmanager.NewMockAlertManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	deliveriesRepo := delivery.NewMockDeliveriesRepo(ctrl)
 
 	testService := NewCongratulationsServiceImpl(
 		usersRepo,
 		subscriptionsRepo,
 		sessManager,
-		alertManager,
+		outboxRepo,
+		deliveriesRepo,
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
 		zap.NewNop().Sugar(),
 	)
 
@@ -40,9 +55,8 @@ func TestRegister(t *testing.T) {
 		Username: "some_user",
 		Password: "", // Пароль не возвращается
 		Email:    "some@email.net",
-		Year:     2000,
-		Month:    1,
-		Day:      2,
+		Timezone: "Europe/Moscow",
+		Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 2},
 	}
 
 	sessExpected := &session.Session{
@@ -52,18 +66,24 @@ func TestRegister(t *testing.T) {
 	}
 
 	// нормальная работа
-	birth := fmt.Sprintf("%04d-%02d-%02d", userExpected.Year, userExpected.Month, userExpected.Day)
+	birth := userExpected.Birthday.String()
 
 	usersRepo.EXPECT().Create(
 		context.Background(),
 		userExpected.Username,
 		password,
 		userExpected.Email,
-		userExpected.Year,
-		userExpected.Month,
-		userExpected.Day,
+		userExpected.Timezone,
+		userExpected.Birthday,
 	).Return(userExpected, nil)
 
+	var body string
+	outboxRepo.EXPECT().Enqueue(context.Background(), []string{userExpected.Email}, "Подтверждение почты", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ []string, _, b string) error {
+			body = b
+			return nil
+		})
+
 	sessManager.EXPECT().Create(
 		context.Background(),
 		userExpected.ID,
@@ -75,13 +95,53 @@ func TestRegister(t *testing.T) {
 		password,
 		userExpected.Email,
 		birth,
+		userExpected.Timezone,
+	)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, sessExpected, sessRecv)
+
+	// в письме ссылка подтверждения почты
+	link := testBaseURL + "/verify?token="
+	assert.Contains(t, body, link)
+
+	userID, email, err := testVerifier.Check(body[strings.Index(body, link)+len(link):], time.Now())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, userExpected.ID, userID)
+	assert.EqualValues(t, userExpected.Email, email)
+
+	// письмо не поставлено в очередь - регистрация все равно проходит
+	usersRepo.EXPECT().Create(
+		context.Background(),
+		userExpected.Username,
+		password,
+		userExpected.Email,
+		userExpected.Timezone,
+		userExpected.Birthday,
+	).Return(userExpected, nil)
+
+	outboxRepo.EXPECT().Enqueue(context.Background(), []string{userExpected.Email}, "Подтверждение почты", gomock.Any()).Return(fmt.Errorf("outbox error"))
+
+	sessManager.EXPECT().Create(
+		context.Background(),
+		userExpected.ID,
+	).Return(sessExpected, nil)
+
+	sessRecv, err = testService.Register(
+		context.Background(),
+		userExpected.Username,
+		password,
+		userExpected.Email,
+		birth,
+		userExpected.Timezone,
 	)
 
 	assert.NoError(t, err)
 	assert.EqualValues(t, sessExpected, sessRecv)
 
 	// ошибка в формате даты
-	birth = fmt.Sprintf("%04d-%02d%02d", userExpected.Year, userExpected.Month, userExpected.Day)
+	birth = "2000-0102"
 
 	_, err = testService.Register(
 		context.Background(),
@@ -89,21 +149,21 @@ func TestRegister(t *testing.T) {
 		password,
 		userExpected.Email,
 		birth,
+		userExpected.Timezone,
 	)
 
 	assert.ErrorIs(t, err, ErrBadDateFormat)
 
 	// ошибка хранилища
-	birth = fmt.Sprintf("%04d-%02d-%02d", userExpected.Year, userExpected.Month, userExpected.Day)
+	birth = userExpected.Birthday.String()
 
 	usersRepo.EXPECT().Create(
 		context.Background(),
 		userExpected.Username,
 		password,
 		userExpected.Email,
-		userExpected.Year,
-		userExpected.Month,
-		userExpected.Day,
+		userExpected.Timezone,
+		userExpected.Birthday,
 	).Return(nil, fmt.Errorf("repo error"))
 
 	_, err = testService.Register(
@@ -112,21 +172,21 @@ func TestRegister(t *testing.T) {
 		password,
 		userExpected.Email,
 		birth,
+		userExpected.Timezone,
 	)
 
 	assert.Error(t, err)
 
 	// пользователь уже существует
-	birth = fmt.Sprintf("%04d-%02d-%02d", userExpected.Year, userExpected.Month, userExpected.Day)
+	birth = userExpected.Birthday.String()
 
 	usersRepo.EXPECT().Create(
 		context.Background(),
 		userExpected.Username,
 		password,
 		userExpected.Email,
-		userExpected.Year,
-		userExpected.Month,
-		userExpected.Day,
+		userExpected.Timezone,
+		userExpected.Birthday,
 	).Return(nil, user.ErrUserExists)
 
 	_, err = testService.Register(
@@ -135,23 +195,25 @@ func TestRegister(t *testing.T) {
 		password,
 		userExpected.Email,
 		birth,
+		userExpected.Timezone,
 	)
 
 	assert.ErrorIs(t, err, user.ErrUserExists)
 
 	// ошибка менеджера сессий
-	birth = fmt.Sprintf("%04d-%02d-%02d", userExpected.Year, userExpected.Month, userExpected.Day)
+	birth = userExpected.Birthday.String()
 
 	usersRepo.EXPECT().Create(
 		context.Background(),
 		userExpected.Username,
 		password,
 		userExpected.Email,
-		userExpected.Year,
-		userExpected.Month,
-		userExpected.Day,
+		userExpected.Timezone,
+		userExpected.Birthday,
 	).Return(userExpected, nil)
 
+	outboxRepo.EXPECT().Enqueue(context.Background(), []string{userExpected.Email}, "Подтверждение почты", gomock.Any()).Return(nil)
+
 	sessManager.EXPECT().Create(
 		context.Background(),
 		userExpected.ID,
@@ -163,9 +225,110 @@ func TestRegister(t *testing.T) {
 		password,
 		userExpected.Email,
 		birth,
+		userExpected.Timezone,
 	)
 
 	assert.Error(t, err)
+
+	// некорректный часовой пояс
+	birth = userExpected.Birthday.String()
+
+	_, err = testService.Register(
+		context.Background(),
+		userExpected.Username,
+		password,
+		userExpected.Email,
+		birth,
+		"Mars/Olympus_Mons",
+	)
+
+	assert.ErrorIs(t, err, user.ErrBadTimezone)
+
+	// часовой пояс не указан
+	usersRepo.EXPECT().Create(
+		context.Background(),
+		userExpected.Username,
+		password,
+		userExpected.Email,
+		user.DefaultTimezone,
+		userExpected.Birthday,
+	).Return(userExpected, nil)
+
+	outboxRepo.EXPECT().Enqueue(context.Background(), []string{userExpected.Email}, "Подтверждение почты", gomock.Any()).Return(nil)
+
+	sessManager.EXPECT().Create(
+		context.Background(),
+		userExpected.ID,
+	).Return(sessExpected, nil)
+
+	_, err = testService.Register(
+		context.Background(),
+		userExpected.Username,
+		password,
+		userExpected.Email,
+		birth,
+		"",
+	)
+
+	assert.NoError(t, err)
+
+	// год рождения скрыт
+	usersRepo.EXPECT().Create(
+		context.Background(),
+		userExpected.Username,
+		password,
+		userExpected.Email,
+		userExpected.Timezone,
+		birthday.Birthday{Month: time.January, Day: 2},
+	).Return(userExpected, nil)
+
+	outboxRepo.EXPECT().Enqueue(context.Background(), []string{userExpected.Email}, "Подтверждение почты", gomock.Any()).Return(nil)
+
+	sessManager.EXPECT().Create(
+		context.Background(),
+		userExpected.ID,
+	).Return(sessExpected, nil)
+
+	_, err = testService.Register(
+		context.Background(),
+		userExpected.Username,
+		password,
+		userExpected.Email,
+		"--01-02",
+		userExpected.Timezone,
+	)
+
+	assert.NoError(t, err)
+
+	// дата в будущем по календарю пользователя: в Москве уже 16 октября, в UTC еще 15-е
+	testService.now = func() time.Time {
+		return time.Date(2026, time.October, 15, 22, 0, 0, 0, time.UTC)
+	}
+
+	_, err = testService.Register(
+		context.Background(),
+		userExpected.Username,
+		password,
+		userExpected.Email,
+		"2026-10-17",
+		userExpected.Timezone,
+	)
+
+	assert.ErrorIs(t, err, birthday.ErrBirthdayInFuture)
+
+	// слишком молод или слишком стар
+	for _, birth := range []string{"2026-10-16", "2015-01-01", "1900-01-01", "0001-01-01"} {
+		_, err = testService.Register(
+			context.Background(),
+			userExpected.Username,
+			password,
+			userExpected.Email,
+			birth,
+			userExpected.Timezone,
+		)
+
+		assert.ErrorIs(t, err, birthday.ErrBadAge, birth)
+	}
 }
 
 func TestLogin(t *testing.T) {
@@ -175,13 +338,22 @@ func TestLogin(t *testing.T) {
 	usersRepo := user.NewMockUsersRepo(ctrl)
 	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
 	sessManager := session.NewMockSessionsManager(ctrl)
-	alertManager := alertmanager.NewMockAlertManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	deliveriesRepo := delivery.NewMockDeliveriesRepo(ctrl)
 
 	testService := NewCongratulationsServiceImpl(
 		usersRepo,
 		subscriptionsRepo,
 		sessManager,
-		alertManager,
+		outboxRepo,
+		deliveriesRepo,
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
 		zap.NewNop().Sugar(),
 	)
 
@@ -192,9 +364,7 @@ func TestLogin(t *testing.T) {
 		Username: "some_user",
 		Password: "", // Пароль не возвращается
 		Email:    "some@email.net",
-		Year:     2000,
-		Month:    1,
-		Day:      2,
+		Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 2},
 	}
 
 	sessExpected := &session.Session{
@@ -254,6 +424,21 @@ func TestLogin(t *testing.T) {
 
 	assert.ErrorIs(t, err, user.ErrNoUser)
 
+	// уволенный сотрудник не может войти
+	usersRepo.EXPECT().Login(
+		context.Background(),
+		userExpected.Username,
+		password,
+	).Return(&user.User{ID: 7, Username: userExpected.Username, Deactivated: true}, nil)
+
+	_, err = testService.Login(
+		context.Background(),
+		userExpected.Username,
+		password,
+	)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
 	// ошибка менеджера сессий
 	usersRepo.EXPECT().Login(
 		context.Background(),
@@ -282,13 +467,22 @@ func TestSubscribe(t *testing.T) {
 	usersRepo := user.NewMockUsersRepo(ctrl)
 	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
 	sessManager := session.NewMockSessionsManager(ctrl)
-	alertManager := alertmanager.NewMockAlertManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	deliveriesRepo := delivery.NewMockDeliveriesRepo(ctrl)
 
 	testService := NewCongratulationsServiceImpl(
 		usersRepo,
 		subscriptionsRepo,
 		sessManager,
-		alertManager,
+		outboxRepo,
+		deliveriesRepo,
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
 		zap.NewNop().Sugar(),
 	)
 
@@ -306,6 +500,7 @@ func TestSubscribe(t *testing.T) {
 	// нормальная работа
 	ctx := session.ContextWithSession(context.Background(), sessExpected)
 
+	usersRepo.EXPECT().GetByID(ctx, subscriptionID).Return(&user.User{ID: subscriptionID}, nil)
 	subscriptionsRepo.EXPECT().AddSubscription(
 		ctx,
 		subscriberID,
@@ -335,6 +530,7 @@ func TestSubscribe(t *testing.T) {
 	// ошибка хранилища
 	ctx = session.ContextWithSession(context.Background(), sessExpected)
 
+	usersRepo.EXPECT().GetByID(ctx, subscriptionID).Return(&user.User{ID: subscriptionID}, nil)
 	subscriptionsRepo.EXPECT().AddSubscription(
 		ctx,
 		subscriberID,
@@ -353,6 +549,7 @@ func TestSubscribe(t *testing.T) {
 	// подписка не добавлена
 	ctx = session.ContextWithSession(context.Background(), sessExpected)
 
+	usersRepo.EXPECT().GetByID(ctx, subscriptionID).Return(&user.User{ID: subscriptionID}, nil)
 	subscriptionsRepo.EXPECT().AddSubscription(
 		ctx,
 		subscriberID,
@@ -367,6 +564,46 @@ func TestSubscribe(t *testing.T) {
 	)
 
 	assert.ErrorIs(t, err, subscription.ErrAddSubscription)
+
+	// на пользователя нельзя подписаться
+	for _, subject := range []*user.User{
+		{ID: subscriptionID, Privacy: user.Privacy{NotSubscribable: true}},
+		{ID: subscriptionID, Privacy: user.Privacy{HideFromDirectory: true}},
+		{ID: subscriptionID, Deactivated: true},
+	} {
+		usersRepo.EXPECT().GetByID(ctx, subscriptionID).Return(subject, nil)
+
+		err = testService.Subscribe(
+			ctx,
+			subscriptionID,
+			daysAlert,
+		)
+
+		assert.ErrorIs(t, err, ErrNotSubscribable)
+	}
+
+	// пользователя нет
+	usersRepo.EXPECT().GetByID(ctx, subscriptionID).Return(nil, user.ErrNoUser)
+
+	err = testService.Subscribe(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
+
+	assert.ErrorIs(t, err, ErrNotSubscribable)
+
+	// ошибка хранилища пользователей
+	usersRepo.EXPECT().GetByID(ctx, subscriptionID).Return(nil, fmt.Errorf("repo error"))
+
+	err = testService.Subscribe(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotSubscribable)
 }
 
 func TestUnsubscribe(t *testing.T) {
@@ -376,13 +613,22 @@ func TestUnsubscribe(t *testing.T) {
 	usersRepo := user.NewMockUsersRepo(ctrl)
 	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
 	sessManager := session.NewMockSessionsManager(ctrl)
-	alertManager := alertmanager.NewMockAlertManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	deliveriesRepo := delivery.NewMockDeliveriesRepo(ctrl)
 
 	testService := NewCongratulationsServiceImpl(
 		usersRepo,
 		subscriptionsRepo,
 		sessManager,
-		alertManager,
+		outboxRepo,
+		deliveriesRepo,
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
 		zap.NewNop().Sugar(),
 	)
 
@@ -455,307 +701,612 @@ func TestUnsubscribe(t *testing.T) {
 	assert.ErrorIs(t, err, subscription.ErrRemoveSubscription)
 }
 
-func TestLogout(t *testing.T) {
+func TestRemoveDaysAlert(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	usersRepo := user.NewMockUsersRepo(ctrl)
 	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
 	sessManager := session.NewMockSessionsManager(ctrl)
-	alertManager := alertmanager.NewMockAlertManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	deliveriesRepo := delivery.NewMockDeliveriesRepo(ctrl)
 
 	testService := NewCongratulationsServiceImpl(
 		usersRepo,
 		subscriptionsRepo,
 		sessManager,
-		alertManager,
+		outboxRepo,
+		deliveriesRepo,
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
 		zap.NewNop().Sugar(),
 	)
 
 	// данные для теста
-	userID := uint32(42)
+	subscriberID := uint32(10)
+	subscriptionID := uint32(42)
+	daysAlert := 0
 
 	sessExpected := &session.Session{
 		SessID:  "some_sess_id",
-		UserID:  userID,
+		UserID:  subscriberID,
 		Expires: time.Now().Unix() + 60,
 	}
 
 	// нормальная работа
 	ctx := session.ContextWithSession(context.Background(), sessExpected)
 
-	sessManager.EXPECT().Destroy(ctx).Return(nil)
+	subscriptionsRepo.EXPECT().RemoveDaysAlert(
+		ctx,
+		subscriberID,
+		subscriptionID,
+		daysAlert,
+	).Return(nil)
 
-	err := testService.Logout(ctx)
+	err := testService.RemoveDaysAlert(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
 
 	assert.NoError(t, err)
 
-	// ошибка менеджера сессий
+	// нет сессии
+	ctx = context.Background()
+
+	err = testService.RemoveDaysAlert(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка хранилища
 	ctx = session.ContextWithSession(context.Background(), sessExpected)
 
-	sessManager.EXPECT().Destroy(ctx).Return(fmt.Errorf("sessions error"))
+	subscriptionsRepo.EXPECT().RemoveDaysAlert(
+		ctx,
+		subscriberID,
+		subscriptionID,
+		daysAlert,
+	).Return(fmt.Errorf("repo error"))
 
-	err = testService.Logout(ctx)
+	err = testService.RemoveDaysAlert(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
 
 	assert.Error(t, err)
 
-	// нет сессии или она не уничтожилась
+	// напоминания нет
 	ctx = session.ContextWithSession(context.Background(), sessExpected)
 
-	sessManager.EXPECT().Destroy(ctx).Return(session.ErrNoSession)
+	subscriptionsRepo.EXPECT().RemoveDaysAlert(
+		ctx,
+		subscriberID,
+		subscriptionID,
+		daysAlert,
+	).Return(subscription.ErrRemoveSubscription)
 
-	err = testService.Logout(ctx)
+	err = testService.RemoveDaysAlert(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
 
-	assert.ErrorIs(t, err, session.ErrNotDestroyed)
+	assert.ErrorIs(t, err, subscription.ErrRemoveSubscription)
 }
 
-func TestGetSubscriptionsByUser(t *testing.T) {
+func TestUpdateSubscription(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	usersRepo := user.NewMockUsersRepo(ctrl)
 	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
 	sessManager := session.NewMockSessionsManager(ctrl)
-	alertManager := alertmanager.NewMockAlertManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	deliveriesRepo := delivery.NewMockDeliveriesRepo(ctrl)
 
 	testService := NewCongratulationsServiceImpl(
 		usersRepo,
 		subscriptionsRepo,
 		sessManager,
-		alertManager,
+		outboxRepo,
+		deliveriesRepo,
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
 		zap.NewNop().Sugar(),
 	)
 
 	// данные для теста
-	userID := uint32(42)
+	subscriberID := uint32(10)
+	subscriptionID := uint32(42)
+	daysAlert := []int{7, 0, 7}
+	daysExpected := []int{0, 7} // без повторов, по возрастанию
 
-	sess := &session.Session{
+	sessExpected := &session.Session{
 		SessID:  "some_sess_id",
-		UserID:  userID,
+		UserID:  subscriberID,
 		Expires: time.Now().Unix() + 60,
 	}
 
-	subsSent := []*subscription.Subscription{
-		{
-			Subscriber:   userID,
-			Subscription: 10,
-			DaysAlert:    5,
-		},
-		{
-			Subscriber:   userID,
-			Subscription: 4,
-			DaysAlert:    1,
-		},
-	}
-
-	users := []user.User{
-		{ID: 4},
-		{ID: 5},
-		{ID: 10},
-		{ID: 16},
-		{ID: userID},
-	}
-
-	usersRet := []user.User{
-		{
-			ID:           4,
-			Subscription: true,
-			DaysAlert:    1,
-		},
-		{ID: 5},
-		{
-			ID:           10,
-			Subscription: true,
-			DaysAlert:    5,
-		},
-		{ID: 16},
-		{ID: userID},
-	}
-
-	usersSent := make([]*user.User, 0)
-	for i := range users {
-		usersSent = append(usersSent, &users[i])
-	}
-
-	usersExpected := make([]*user.User, 0)
-	for i := range usersRet {
-		usersExpected = append(usersExpected, &usersRet[i])
-	}
-
 	// нормальная работа
-	ctx := session.ContextWithSession(context.Background(), sess)
+	ctx := session.ContextWithSession(context.Background(), sessExpected)
 
-	usersRepo.EXPECT().GetAll(ctx).Return(usersSent, nil)
-	subscriptionsRepo.EXPECT().GetSubscriptionsByUser(ctx, userID).Return(subsSent, nil)
+	usersRepo.EXPECT().GetByID(ctx, subscriptionID).Return(&user.User{ID: subscriptionID}, nil)
+	subscriptionsRepo.EXPECT().UpdateSubscription(
+		ctx,
+		subscriberID,
+		subscriptionID,
+		daysExpected,
+	).Return(nil)
 
-	usersRecv, err := testService.GetSubscriptionsByUser(ctx)
+	err := testService.UpdateSubscription(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
 
 	assert.NoError(t, err)
-	assert.EqualValues(t, usersExpected, usersRecv)
-
-	// ошибка хранилища пользователей
-	ctx = session.ContextWithSession(context.Background(), sess)
-
-	usersRepo.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
-
-	_, err = testService.GetSubscriptionsByUser(ctx)
-
-	assert.Error(t, err)
+	assert.EqualValues(t, []int{7, 0, 7}, daysAlert) // переданный срез не меняется
 
 	// нет сессии
 	ctx = context.Background()
 
-	_, err = testService.GetSubscriptionsByUser(ctx)
+	err = testService.UpdateSubscription(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
 
 	assert.ErrorIs(t, err, session.ErrNoSession)
 
-	// ошибка хранилища подписок
-	ctx = session.ContextWithSession(context.Background(), sess)
-
-	usersRepo.EXPECT().GetAll(ctx).Return(usersSent, nil)
-	subscriptionsRepo.EXPECT().GetSubscriptionsByUser(ctx, userID).Return(nil, fmt.Errorf("repo error"))
-
-	_, err = testService.GetSubscriptionsByUser(ctx)
+	// ошибка хранилища
+	ctx = session.ContextWithSession(context.Background(), sessExpected)
+
+	usersRepo.EXPECT().GetByID(ctx, subscriptionID).Return(&user.User{ID: subscriptionID}, nil)
+	subscriptionsRepo.EXPECT().UpdateSubscription(
+		ctx,
+		subscriberID,
+		subscriptionID,
+		daysExpected,
+	).Return(fmt.Errorf("repo error"))
+
+	err = testService.UpdateSubscription(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
 
 	assert.Error(t, err)
+
+	// подписки нет
+	ctx = session.ContextWithSession(context.Background(), sessExpected)
+
+	usersRepo.EXPECT().GetByID(ctx, subscriptionID).Return(&user.User{ID: subscriptionID}, nil)
+	subscriptionsRepo.EXPECT().UpdateSubscription(
+		ctx,
+		subscriberID,
+		subscriptionID,
+		daysExpected,
+	).Return(subscription.ErrNoSubscription)
+
+	err = testService.UpdateSubscription(
+		ctx,
+		subscriptionID,
+		daysAlert,
+	)
+
+	assert.ErrorIs(t, err, subscription.ErrNoSubscription)
 }
 
-func TestMakeMessages(t *testing.T) {
+func TestLogout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usersRepo := user.NewMockUsersRepo(ctrl)
+	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
+	sessManager := session.NewMockSessionsManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	deliveriesRepo := delivery.NewMockDeliveriesRepo(ctrl)
+
+	testService := NewCongratulationsServiceImpl(
+		usersRepo,
+		subscriptionsRepo,
+		sessManager,
+		outboxRepo,
+		deliveriesRepo,
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
+		zap.NewNop().Sugar(),
+	)
+
+	// данные для теста
+	userID := uint32(42)
+
+	sessExpected := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  userID,
+		Expires: time.Now().Unix() + 60,
+	}
+
+	// нормальная работа
+	ctx := session.ContextWithSession(context.Background(), sessExpected)
+
+	sessManager.EXPECT().Destroy(ctx).Return(nil)
+
+	err := testService.Logout(ctx)
+
+	assert.NoError(t, err)
+
+	// ошибка менеджера сессий
+	ctx = session.ContextWithSession(context.Background(), sessExpected)
+
+	sessManager.EXPECT().Destroy(ctx).Return(fmt.Errorf("sessions error"))
+
+	err = testService.Logout(ctx)
+
+	assert.Error(t, err)
+
+	// нет сессии или она не уничтожилась
+	ctx = session.ContextWithSession(context.Background(), sessExpected)
+
+	sessManager.EXPECT().Destroy(ctx).Return(session.ErrNoSession)
+
+	err = testService.Logout(ctx)
+
+	assert.ErrorIs(t, err, session.ErrNotDestroyed)
+}
+
+func TestGetSubscriptionsByUser(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	usersRepo := user.NewMockUsersRepo(ctrl)
 	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
 	sessManager := session.NewMockSessionsManager(ctrl)
-	alertManager := alertmanager.NewMockAlertManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	deliveriesRepo := delivery.NewMockDeliveriesRepo(ctrl)
 
 	testService := NewCongratulationsServiceImpl(
 		usersRepo,
 		subscriptionsRepo,
 		sessManager,
-		alertManager,
+		outboxRepo,
+		deliveriesRepo,
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
 		zap.NewNop().Sugar(),
 	)
 
 	// данные для теста
+	userID := uint32(42)
+
+	sess := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  userID,
+		Expires: time.Now().Unix() + 60,
+	}
+
 	subsSent := []*subscription.Subscription{
+		{
+			Subscriber:   userID,
+			Subscription: 10,
+			DaysAlert:    []int{5},
+		},
+		{
+			Subscriber:   userID,
+			Subscription: 4,
+			DaysAlert:    []int{1},
+		},
+		{
+			Subscriber:   userID,
+			Subscription: 8, // подписка на скрывшегося не мешает показать следующие
+			DaysAlert:    []int{3},
+		},
+	}
+
+	// в Токио (часовой пояс текущего пользователя) уже 28 февраля 2025, в UTC еще 27-е
+	now := time.Date(2025, time.February, 27, 20, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	newUsers := func() []*user.User {
+		return []*user.User{
+			{ID: 4, Birthday: birthday.Birthday{Month: time.February, Day: 29}},
+			{ID: 5, Email: "five@five.net", Birthday: birthday.Birthday{Year: 1990, Month: time.January, Day: 1}},         // почта коллег не видна
+			{ID: 7, Birthday: birthday.Birthday{Month: time.May, Day: 5}, Deactivated: true},                              // уволенных в списке нет
+			{ID: 8, Birthday: birthday.Birthday{Month: time.May, Day: 5}, Privacy: user.Privacy{HideFromDirectory: true}}, // скрывшихся тоже
+			{ID: 10, Birthday: birthday.Birthday{Month: time.March, Day: 1}},
+			{ID: 16, Birthday: birthday.Birthday{Year: 1985, Month: time.February, Day: 28}, Privacy: user.Privacy{HideYear: true}},
+			{
+				ID:       userID,
+				Email:    "me@me.net",
+				Timezone: "Asia/Tokyo",
+				Birthday: birthday.Birthday{Year: 1980, Month: time.December, Day: 31},
+				Privacy:  user.Privacy{HideYear: true, HideFromDirectory: true}, // себя пользователь видит всегда и полностью
+			},
+		}
+	}
+
+	usersExpected := []*user.User{
+		{
+			ID:           4,
+			Birthday:     birthday.Birthday{Month: time.February, Day: 29},
+			Subscription: true,
+			DaysAlert:    []int{1},
+			NextBirthday: date(2025, time.February, 28),
+		},
+		{
+			ID:           5,
+			Birthday:     birthday.Birthday{Year: 1990, Month: time.January, Day: 1},
+			NextBirthday: date(2026, time.January, 1),
+		},
+		{
+			ID:           10,
+			Birthday:     birthday.Birthday{Month: time.March, Day: 1},
+			Subscription: true,
+			DaysAlert:    []int{5},
+			NextBirthday: date(2025, time.March, 1),
+		},
+		{
+			ID:           16,
+			Birthday:     birthday.Birthday{Month: time.February, Day: 28},
+			Privacy:      user.Privacy{HideYear: true},
+			NextBirthday: date(2025, time.February, 28),
+		},
+		{
+			ID:           userID,
+			Email:        "me@me.net",
+			Timezone:     "Asia/Tokyo",
+			Birthday:     birthday.Birthday{Year: 1980, Month: time.December, Day: 31},
+			Privacy:      user.Privacy{HideYear: true, HideFromDirectory: true},
+			NextBirthday: date(2025, time.December, 31),
+		},
+	}
+
+	// нормальная работа
+	ctx := session.ContextWithSession(context.Background(), sess)
+
+	usersRepo.EXPECT().GetAll(ctx).Return(newUsers(), nil)
+	subscriptionsRepo.EXPECT().GetSubscriptionsByUser(ctx, userID).Return(subsSent, nil)
+
+	usersRecv, err := testService.GetSubscriptionsByUser(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, usersExpected, usersRecv)
+
+	// 29 февраля отмечается 1 марта
+	testService.leapDay = birthday.LeapDayMar1
+	usersExpected[0].NextBirthday = date(2025, time.March, 1)
+
+	usersRepo.EXPECT().GetAll(ctx).Return(newUsers(), nil)
+	subscriptionsRepo.EXPECT().GetSubscriptionsByUser(ctx, userID).Return(subsSent, nil)
+
+	usersRecv, err = testService.GetSubscriptionsByUser(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, usersExpected, usersRecv)
+
+	// ошибка хранилища пользователей
+	ctx = session.ContextWithSession(context.Background(), sess)
+
+	usersRepo.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.GetSubscriptionsByUser(ctx)
+
+	assert.Error(t, err)
+
+	// нет сессии
+	ctx = context.Background()
+
+	_, err = testService.GetSubscriptionsByUser(ctx)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка хранилища подписок
+	ctx = session.ContextWithSession(context.Background(), sess)
+
+	usersRepo.EXPECT().GetAll(ctx).Return(newUsers(), nil)
+	subscriptionsRepo.EXPECT().GetSubscriptionsByUser(ctx, userID).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.GetSubscriptionsByUser(ctx)
+
+	assert.Error(t, err)
+}
+
+func newTestService(ctrl *gomock.Controller) (
+	*CongratulationsServiceImpl,
+	*user.MockUsersRepo,
+	*subscription.MockSubscriptionsRepo,
+	*outbox.MockOutbox,
+	*delivery.MockDeliveriesRepo,
+) {
+	usersRepo := user.NewMockUsersRepo(ctrl)
+	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
+	sessManager := session.NewMockSessionsManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	deliveriesRepo := delivery.NewMockDeliveriesRepo(ctrl)
+
+	testService := NewCongratulationsServiceImpl(
+		usersRepo,
+		subscriptionsRepo,
+		sessManager,
+		outboxRepo,
+		deliveriesRepo,
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
+		zap.NewNop().Sugar(),
+	)
+
+	return testService, usersRepo, subscriptionsRepo, outboxRepo, deliveriesRepo
+}
+
+// данные для тестов рассылки: на 10 мая 2024 (UTC) напоминания должны получить
+// two - о zero (за 5 дней), two и three - об one (за 1 день)
+func alertTestData(now time.Time) ([]*subscription.Subscription, []*user.User, []*reminder) {
+	subs := []*subscription.Subscription{
 		{
 			Subscriber:   0,
 			Subscription: 1,
-			DaysAlert:    2,
+			DaysAlert:    []int{2},
 		},
 		{
 			Subscriber:   2,
 			Subscription: 1,
-			DaysAlert:    1,
+			DaysAlert:    []int{1},
 		},
 		{
 			Subscriber:   3,
 			Subscription: 1,
-			DaysAlert:    1,
+			DaysAlert:    []int{1},
 		},
 		{
 			Subscriber:   2,
 			Subscription: 0,
-			DaysAlert:    5,
+			DaysAlert:    []int{5},
 		},
 		{
 			Subscriber:   3,
 			Subscription: 2,
-			DaysAlert:    10,
+			DaysAlert:    []int{10},
 		},
 		{
 			Subscriber:   2,
 			Subscription: 3,
-			DaysAlert:    1,
+			DaysAlert:    []int{1},
 		},
 	}
 
-	usersSent := []*user.User{
+	users := []*user.User{
 		{
-			ID:       0,
-			Username: "zero",
-			Email:    "zero@zero.net",
-			Month:    int(time.Now().AddDate(0, 0, 5).Month()),
-			Day:      time.Now().AddDate(0, 0, 5).Day(),
+			ID:            0,
+			Username:      "zero",
+			Email:         "zero@zero.net",
+			EmailVerified: true,
+			Birthday:      birthday.Birthday{Month: now.AddDate(0, 0, 5).Month(), Day: now.AddDate(0, 0, 5).Day()},
 		},
 		{
-			ID:       1,
-			Username: "one",
-			Email:    "one@one.net",
-			Month:    int(time.Now().AddDate(0, 0, 1).Month()),
-			Day:      time.Now().AddDate(0, 0, 1).Day(),
+			ID:            1,
+			Username:      "one",
+			Email:         "one@one.net",
+			EmailVerified: true,
+			Birthday:      birthday.Birthday{Month: now.AddDate(0, 0, 1).Month(), Day: now.AddDate(0, 0, 1).Day()},
 		},
 		{
-			ID:       2,
-			Username: "two",
-			Email:    "two@two.net",
-			Month:    int(time.Now().AddDate(0, 0, 9).Month()),
-			Day:      time.Now().AddDate(0, 0, 9).Day(),
+			ID:            2,
+			Username:      "two",
+			Email:         "two@two.net",
+			EmailVerified: true,
+			Birthday:      birthday.Birthday{Month: now.AddDate(0, 0, 9).Month(), Day: now.AddDate(0, 0, 9).Day()},
 		},
 		{
-			ID:       3,
-			Username: "three",
-			Email:    "three@three.net",
-			Month:    int(time.Now().Month()),
-			Day:      time.Now().Day(),
+			ID:            3,
+			Username:      "three",
+			Email:         "three@three.net",
+			EmailVerified: true,
+			Birthday:      birthday.Birthday{Month: now.Month(), Day: now.Day()},
 		},
 	}
 
-	messagesExpected := []string{
-		"zero празднует свой день рождения через 5 дней!",
-		"one празднует свой день рождения через 1 дней!",
-	}
-
-	recipientsExpected := [][]string{
+	reminders := []*reminder{
 		{
-			"two@two.net",
+			text:       "zero празднует свой день рождения через 5 дней!",
+			recipients: []string{"two@two.net"},
+			keys: []delivery.Key{
+				{Subscriber: 2, Subject: 0, BirthdayYear: now.Year(), DaysBefore: 5},
+			},
 		},
 		{
-			"two@two.net",
-			"three@three.net",
+			text:       "one празднует свой день рождения через 1 дней!",
+			recipients: []string{"two@two.net", "three@three.net"},
+			keys: []delivery.Key{
+				{Subscriber: 2, Subject: 1, BirthdayYear: now.Year(), DaysBefore: 1},
+				{Subscriber: 3, Subject: 1, BirthdayYear: now.Year(), DaysBefore: 1},
+			},
 		},
 	}
 
+	return subs, users, reminders
+}
+
+func TestMakeMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, subscriptionsRepo, _, _ := newTestService(ctrl)
+
+	// данные для теста
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	utc := map[string]int{"UTC": 0}
+
+	subsSent, usersSent, remindersExpected := alertTestData(now)
+
 	// нормальная работа
 	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsSent, nil)
 
+	// каждый пользователь запрашивается из хранилища один раз
 	usersRepo.EXPECT().GetByID(context.Background(), uint32(0)).Return(usersSent[0], nil)
 	usersRepo.EXPECT().GetByID(context.Background(), uint32(2)).Return(usersSent[2], nil)
 	usersRepo.EXPECT().GetByID(context.Background(), uint32(1)).Return(usersSent[1], nil)
-	usersRepo.EXPECT().GetByID(context.Background(), uint32(2)).Return(usersSent[2], nil)
-	usersRepo.EXPECT().GetByID(context.Background(), uint32(3)).Return(usersSent[3], nil)
-	usersRepo.EXPECT().GetByID(context.Background(), uint32(2)).Return(usersSent[2], nil)
 	usersRepo.EXPECT().GetByID(context.Background(), uint32(3)).Return(usersSent[3], nil)
 
-	messagesRecv, recipientsRecv, err := testService.makeMessages(context.Background())
+	remindersRecv, err := testService.makeMessages(context.Background(), now, utc)
 
 	assert.NoError(t, err)
-	assert.EqualValues(t, messagesExpected, messagesRecv)
-	assert.EqualValues(t, recipientsExpected, recipientsRecv)
+	assert.EqualValues(t, remindersExpected, remindersRecv)
 
 	// ошибка хранилища подписок
 	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(nil, fmt.Errorf("repo error"))
 
-	_, _, err = testService.makeMessages(context.Background())
+	_, err = testService.makeMessages(context.Background(), now, utc)
 
 	assert.Error(t, err)
 
 	// подписок нет
 	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return([]*subscription.Subscription{}, nil)
 
-	messagesRecv, recipientsRecv, err = testService.makeMessages(context.Background())
+	remindersRecv, err = testService.makeMessages(context.Background(), now, utc)
 
 	assert.NoError(t, err)
-	assert.Nil(t, messagesRecv)
-	assert.Nil(t, recipientsRecv)
+	assert.Nil(t, remindersRecv)
 
 	// ошибка в хранилище пользователей
 	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsSent, nil)
 
 	usersRepo.EXPECT().GetByID(context.Background(), uint32(0)).Return(nil, fmt.Errorf("repo error"))
 
-	_, _, err = testService.makeMessages(context.Background())
+	_, err = testService.makeMessages(context.Background(), now, utc)
 
 	assert.Error(t, err)
 
@@ -765,170 +1316,674 @@ func TestMakeMessages(t *testing.T) {
 	usersRepo.EXPECT().GetByID(context.Background(), uint32(0)).Return(usersSent[0], nil)
 	usersRepo.EXPECT().GetByID(context.Background(), uint32(2)).Return(nil, fmt.Errorf("repo error"))
 
-	_, _, err = testService.makeMessages(context.Background())
+	_, err = testService.makeMessages(context.Background(), now, utc)
 
 	assert.Error(t, err)
-}
 
-func TestAlert(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+	// у подписчиков в разных часовых поясах разные "сегодня":
+	// в Токио уже 11 мая, в Лос-Анджелесе еще 10 мая
+	now = time.Date(2024, time.May, 10, 20, 0, 0, 0, time.UTC)
 
-	usersRepo := user.NewMockUsersRepo(ctrl)
-	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
-	sessManager := session.NewMockSessionsManager(ctrl)
-	alertManager := alertmanager.NewMockAlertManager(ctrl)
+	subsTZ := []*subscription.Subscription{
+		{
+			Subscriber:   1,
+			Subscription: 0,
+			DaysAlert:    []int{1},
+		},
+		{
+			Subscriber:   2,
+			Subscription: 0,
+			DaysAlert:    []int{1},
+		},
+	}
 
-	testService := NewCongratulationsServiceImpl(
-		usersRepo,
-		subscriptionsRepo,
-		sessManager,
-		alertManager,
-		zap.NewNop().Sugar(),
-	)
+	usersTZ := []*user.User{
+		{
+			ID:            0,
+			Username:      "zero",
+			Email:         "zero@zero.net",
+			EmailVerified: true,
+			Birthday:      birthday.Birthday{Month: time.May, Day: 12},
+		},
+		{
+			ID:            1,
+			Username:      "tokyo",
+			Email:         "tokyo@tokyo.net",
+			EmailVerified: true,
+			Timezone:      "Asia/Tokyo",
+		},
+		{
+			ID:            2,
+			Username:      "la",
+			Email:         "la@la.net",
+			EmailVerified: true,
+			Timezone:      "America/Los_Angeles",
+		},
+	}
 
-	// данные для теста
-	subsSent := []*subscription.Subscription{
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsTZ, nil)
+
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(0)).Return(usersTZ[0], nil)
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(1)).Return(usersTZ[1], nil)
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(2)).Return(usersTZ[2], nil)
+
+	zonesTZ := map[string]int{"Asia/Tokyo": 0, "America/Los_Angeles": 0}
+
+	remindersRecv, err = testService.makeMessages(context.Background(), now, zonesTZ)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*reminder{
 		{
-			Subscriber:   0,
-			Subscription: 1,
-			DaysAlert:    2,
+			text:       "zero празднует свой день рождения через 1 дней!",
+			recipients: []string{"tokyo@tokyo.net"},
+			keys:       []delivery.Key{{Subscriber: 1, Subject: 0, BirthdayYear: 2024, DaysBefore: 1}},
 		},
+	}, remindersRecv)
+
+	// рассылка запущена только для Лос-Анджелеса - подписчик из Токио пропускается
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsTZ, nil)
+
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(0)).Return(usersTZ[0], nil)
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(1)).Return(usersTZ[1], nil)
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(2)).Return(usersTZ[2], nil)
+
+	remindersRecv, err = testService.makeMessages(context.Background(), now, map[string]int{"America/Los_Angeles": 0})
+
+	assert.NoError(t, err)
+	assert.Empty(t, remindersRecv)
+
+	// 29 февраля в невисокосном году
+	now = time.Date(2027, time.February, 27, 12, 0, 0, 0, time.UTC)
+
+	subsLeap := []*subscription.Subscription{
 		{
-			Subscriber:   2,
-			Subscription: 1,
-			DaysAlert:    1,
+			Subscriber:   1,
+			Subscription: 0,
+			DaysAlert:    []int{1},
 		},
+	}
+
+	usersLeap := []*user.User{
 		{
-			Subscriber:   3,
-			Subscription: 1,
-			DaysAlert:    1,
+			ID:            0,
+			Username:      "leap",
+			Email:         "leap@leap.net",
+			EmailVerified: true,
+			Birthday:      birthday.Birthday{Month: time.February, Day: 29},
+		},
+		{
+			ID:            1,
+			Username:      "one",
+			Email:         "one@one.net",
+			EmailVerified: true,
+		},
+	}
+
+	// отмечается 28 февраля - завтра
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsLeap, nil)
+
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(0)).Return(usersLeap[0], nil)
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(1)).Return(usersLeap[1], nil)
+
+	remindersRecv, err = testService.makeMessages(context.Background(), now, utc)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*reminder{
+		{
+			text:       "leap празднует свой день рождения через 1 дней!",
+			recipients: []string{"one@one.net"},
+			keys:       []delivery.Key{{Subscriber: 1, Subject: 0, BirthdayYear: 2027, DaysBefore: 1}},
+		},
+	}, remindersRecv)
+
+	// отмечается 1 марта - через 2 дня
+	testService.leapDay = birthday.LeapDayMar1
+
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsLeap, nil)
+
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(0)).Return(usersLeap[0], nil)
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(1)).Return(usersLeap[1], nil)
+
+	remindersRecv, err = testService.makeMessages(context.Background(), now, utc)
+
+	assert.NoError(t, err)
+	assert.Empty(t, remindersRecv)
+
+	testService.leapDay = birthday.LeapDayFeb28
+
+	// наверстываем 3 пропущенных дня
+	now = time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+
+	subsCatchUp := []*subscription.Subscription{
+		{
+			Subscriber:   1,
+			Subscription: 0,
+			DaysAlert:    []int{7}, // должно было уйти 2 дня назад
 		},
 		{
 			Subscriber:   2,
 			Subscription: 0,
-			DaysAlert:    5,
+			DaysAlert:    []int{9}, // должно было уйти 4 дня назад - раньше пропущенных дней
 		},
 		{
 			Subscriber:   3,
-			Subscription: 2,
-			DaysAlert:    10,
+			Subscription: 0,
+			DaysAlert:    []int{5}, // уходит сегодня
 		},
 		{
-			Subscriber:   2,
-			Subscription: 3,
-			DaysAlert:    1,
+			Subscriber:   1,
+			Subscription: 4,
+			DaysAlert:    []int{1}, // день рождения уже прошел
 		},
 	}
 
-	usersSent := []*user.User{
+	usersCatchUp := []*user.User{
+		{
+			ID:            0,
+			Username:      "zero",
+			Email:         "zero@zero.net",
+			EmailVerified: true,
+			Birthday:      birthday.Birthday{Month: time.May, Day: 15},
+		},
 		{
-			ID:       0,
-			Username: "zero",
-			Email:    "zero@zero.net",
-			Month:    int(time.Now().AddDate(0, 0, 5).Month()),
-			Day:      time.Now().AddDate(0, 0, 5).Day(),
+			ID:            1,
+			Email:         "one@one.net",
+			EmailVerified: true,
 		},
 		{
-			ID:       1,
-			Username: "one",
-			Email:    "one@one.net",
-			Month:    int(time.Now().AddDate(0, 0, 1).Month()),
-			Day:      time.Now().AddDate(0, 0, 1).Day(),
+			ID:            2,
+			Email:         "two@two.net",
+			EmailVerified: true,
 		},
 		{
-			ID:       2,
-			Username: "two",
-			Email:    "two@two.net",
-			Month:    int(time.Now().AddDate(0, 0, 9).Month()),
-			Day:      time.Now().AddDate(0, 0, 9).Day(),
+			ID:            3,
+			Email:         "three@three.net",
+			EmailVerified: true,
 		},
 		{
-			ID:       3,
-			Username: "three",
-			Email:    "three@three.net",
-			Month:    int(time.Now().Month()),
-			Day:      time.Now().Day(),
+			ID:            4,
+			Username:      "four",
+			Email:         "four@four.net",
+			EmailVerified: true,
+			Birthday:      birthday.Birthday{Month: time.May, Day: 9},
 		},
 	}
 
-	messagesExpected := []string{
-		"zero празднует свой день рождения через 5 дней!",
-		"one празднует свой день рождения через 1 дней!",
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsCatchUp, nil)
+
+	for _, us := range usersCatchUp {
+		usersRepo.EXPECT().GetByID(context.Background(), us.ID).Return(us, nil)
 	}
 
-	recipientsExpected := [][]string{
+	remindersRecv, err = testService.makeMessages(context.Background(), now, map[string]int{"UTC": 3})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*reminder{
 		{
-			"two@two.net",
+			text:       "zero празднует свой день рождения через 5 дней!",
+			recipients: []string{"one@one.net", "three@three.net"},
+			keys: []delivery.Key{
+				{Subscriber: 1, Subject: 0, BirthdayYear: 2024, DaysBefore: 7},
+				{Subscriber: 3, Subject: 0, BirthdayYear: 2024, DaysBefore: 5},
+			},
 		},
+	}, remindersRecv)
+
+	// несколько напоминаний в одной подписке
+	subsOffsets := []*subscription.Subscription{
 		{
-			"two@two.net",
-			"three@three.net",
+			Subscriber:   1,
+			Subscription: 0,
+			DaysAlert:    []int{0, 5, 7}, // сегодня уходит напоминание за 5 дней
+		},
+		{
+			Subscriber:   2,
+			Subscription: 4,
+			DaysAlert:    []int{0, 2}, // день рождения через 364 дня
+		},
+		{
+			Subscriber:   3,
+			Subscription: 2,
+			DaysAlert:    []int{0, 30}, // день рождения сегодня
 		},
 	}
 
-	// нормальная работа
-	wg := &sync.WaitGroup{}
-	ctx, cancel := context.WithCancel(context.Background())
+	usersOffsets := []*user.User{
+		usersCatchUp[0],
+		usersCatchUp[1],
+		{
+			ID:            2,
+			Username:      "two",
+			Email:         "two@two.net",
+			EmailVerified: true,
+			Birthday:      birthday.Birthday{Month: time.May, Day: 10},
+		},
+		usersCatchUp[3],
+		usersCatchUp[4],
+	}
+
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsOffsets, nil)
+
+	for _, us := range usersOffsets {
+		usersRepo.EXPECT().GetByID(context.Background(), us.ID).Return(us, nil)
+	}
+
+	remindersRecv, err = testService.makeMessages(context.Background(), now, utc)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*reminder{
+		{
+			text:       "zero празднует свой день рождения через 5 дней!",
+			recipients: []string{"one@one.net"},
+			keys:       []delivery.Key{{Subscriber: 1, Subject: 0, BirthdayYear: 2024, DaysBefore: 5}},
+		},
+		{
+			text:       "two сегодня празднует свой день рождения!",
+			recipients: []string{"three@three.net"},
+			keys:       []delivery.Key{{Subscriber: 3, Subject: 2, BirthdayYear: 2024, DaysBefore: 0}},
+		},
+	}, remindersRecv)
 
-	for i := 0; i < 2; i++ {
-		subscriptionsRepo.EXPECT().GetAllSubscriptions(ctx).Return(subsSent, nil)
+	// в пропущенные дни попало несколько напоминаний - отправляется одно, последнее
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsOffsets[:1], nil)
 
-		usersRepo.EXPECT().GetByID(ctx, uint32(0)).Return(usersSent[0], nil)
-		usersRepo.EXPECT().GetByID(ctx, uint32(2)).Return(usersSent[2], nil)
-		usersRepo.EXPECT().GetByID(ctx, uint32(1)).Return(usersSent[1], nil)
-		usersRepo.EXPECT().GetByID(ctx, uint32(2)).Return(usersSent[2], nil)
-		usersRepo.EXPECT().GetByID(ctx, uint32(3)).Return(usersSent[3], nil)
-		usersRepo.EXPECT().GetByID(ctx, uint32(2)).Return(usersSent[2], nil)
-		usersRepo.EXPECT().GetByID(ctx, uint32(3)).Return(usersSent[3], nil)
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(0)).Return(usersOffsets[0], nil)
+	usersRepo.EXPECT().GetByID(context.Background(), uint32(1)).Return(usersOffsets[1], nil)
 
-		alertManager.EXPECT().Send(recipientsExpected[0], "Напоминание о дне рождения!", messagesExpected[0])
-		alertManager.EXPECT().Send(recipientsExpected[1], "Напоминание о дне рождения!", messagesExpected[1])
+	remindersRecv, err = testService.makeMessages(context.Background(), now, map[string]int{"UTC": 3})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*reminder{
+		{
+			text:       "zero празднует свой день рождения через 5 дней!",
+			recipients: []string{"one@one.net"},
+			keys:       []delivery.Key{{Subscriber: 1, Subject: 0, BirthdayYear: 2024, DaysBefore: 5}},
+		},
+	}, remindersRecv)
+
+	// уволенный подписчик напоминаний не получает
+	subsSent, usersSent, remindersExpected = alertTestData(now)
+	usersSent[3].Deactivated = true
+
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsSent, nil)
+
+	for _, us := range usersSent {
+		usersRepo.EXPECT().GetByID(context.Background(), us.ID).Return(us, nil)
 	}
 
-	wg.Add(1)
-	go testService.alert(ctx, time.Second, wg)
+	remindersRecv, err = testService.makeMessages(context.Background(), now, utc)
 
-	time.Sleep(time.Millisecond * 1500)
-	cancel()
-	wg.Wait()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*reminder{
+		remindersExpected[0],
+		{
+			text:       "one празднует свой день рождения через 1 дней!",
+			recipients: []string{"two@two.net"},
+			keys:       []delivery.Key{{Subscriber: 2, Subject: 1, BirthdayYear: 2024, DaysBefore: 1}},
+		},
+	}, remindersRecv)
 
-	// ошибка при первом создании сообщений
-	wg = &sync.WaitGroup{}
-	ctx, cancel = context.WithCancel(context.Background())
+	// подписчик не подтвердил почту
+	subsSent, usersSent, remindersExpected = alertTestData(now)
+	usersSent[3].EmailVerified = false
 
-	subscriptionsRepo.EXPECT().GetAllSubscriptions(ctx).Return(nil, fmt.Errorf("repo error"))
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsSent, nil)
 
-	wg.Add(1)
-	go testService.alert(ctx, time.Second, wg)
+	for _, us := range usersSent {
+		usersRepo.EXPECT().GetByID(context.Background(), us.ID).Return(us, nil)
+	}
 
-	time.Sleep(time.Millisecond * 1500)
-	cancel()
-	wg.Wait()
+	remindersRecv, err = testService.makeMessages(context.Background(), now, utc)
 
-	// ошибка при создании сообщений по тикеру
-	wg = &sync.WaitGroup{}
-	ctx, cancel = context.WithCancel(context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*reminder{
+		remindersExpected[0],
+		{
+			text:       "one празднует свой день рождения через 1 дней!",
+			recipients: []string{"two@two.net"},
+			keys:       []delivery.Key{{Subscriber: 2, Subject: 1, BirthdayYear: 2024, DaysBefore: 1}},
+		},
+	}, remindersRecv)
+
+	// об уволенном сотруднике не напоминают
+	subsSent, usersSent, remindersExpected = alertTestData(now)
+	usersSent[1].Deactivated = true
+
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsSent, nil)
+
+	for _, us := range usersSent {
+		usersRepo.EXPECT().GetByID(context.Background(), us.ID).Return(us, nil)
+	}
+
+	remindersRecv, err = testService.makeMessages(context.Background(), now, utc)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, remindersExpected[:1], remindersRecv)
+
+	// о тех, кто запретил подписываться или скрылся из списка, тоже не напоминают
+	for _, privacy := range []user.Privacy{{NotSubscribable: true}, {HideFromDirectory: true}} {
+		subsSent, usersSent, remindersExpected = alertTestData(now)
+		usersSent[1].Privacy = privacy
+
+		subscriptionsRepo.EXPECT().GetAllSubscriptions(context.Background()).Return(subsSent, nil)
+
+		for _, us := range usersSent {
+			usersRepo.EXPECT().GetByID(context.Background(), us.ID).Return(us, nil)
+		}
+
+		remindersRecv, err = testService.makeMessages(context.Background(), now, utc)
+
+		assert.NoError(t, err)
+		assert.EqualValues(t, remindersExpected[:1], remindersRecv)
+	}
+}
+
+func TestEnqueueReminders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, _, _, outboxRepo, deliveriesRepo := newTestService(ctrl)
+
+	// данные для теста
+	ctx := context.Background()
+	_, _, reminders := alertTestData(time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC))
+
+	// нормальная работа: three уже получал напоминание об one
+	deliveriesRepo.EXPECT().Record(ctx, reminders[0].keys[0]).Return(true, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{"two@two.net"}, "Напоминание о дне рождения!", reminders[0].text).Return(nil)
+
+	deliveriesRepo.EXPECT().Record(ctx, reminders[1].keys[0]).Return(true, nil)
+	deliveriesRepo.EXPECT().Record(ctx, reminders[1].keys[1]).Return(false, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{"two@two.net"}, "Напоминание о дне рождения!", reminders[1].text).Return(nil)
+
+	testService.enqueue(ctx, reminders)
+
+	// все напоминания уже отправлялись - в очередь ничего не ставится
+	deliveriesRepo.EXPECT().Record(ctx, reminders[0].keys[0]).Return(false, nil)
+	deliveriesRepo.EXPECT().Record(ctx, reminders[1].keys[0]).Return(false, nil)
+	deliveriesRepo.EXPECT().Record(ctx, reminders[1].keys[1]).Return(false, nil)
+
+	testService.enqueue(ctx, reminders)
+
+	// ошибка журнала - получатель пропускается до следующего запуска
+	deliveriesRepo.EXPECT().Record(ctx, reminders[0].keys[0]).Return(false, fmt.Errorf("repo error"))
+	deliveriesRepo.EXPECT().Record(ctx, reminders[1].keys[0]).Return(true, nil)
+	deliveriesRepo.EXPECT().Record(ctx, reminders[1].keys[1]).Return(true, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, reminders[1].recipients, "Напоминание о дне рождения!", reminders[1].text).Return(nil)
+
+	testService.enqueue(ctx, reminders)
+
+	// ошибка очереди - напоминание убирается из журнала
+	deliveriesRepo.EXPECT().Record(ctx, reminders[0].keys[0]).Return(true, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, reminders[0].recipients, "Напоминание о дне рождения!", reminders[0].text).Return(fmt.Errorf("outbox error"))
+	deliveriesRepo.EXPECT().Forget(ctx, reminders[0].keys).Return(nil)
+
+	deliveriesRepo.EXPECT().Record(ctx, reminders[1].keys[0]).Return(true, nil)
+	deliveriesRepo.EXPECT().Record(ctx, reminders[1].keys[1]).Return(true, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, reminders[1].recipients, "Напоминание о дне рождения!", reminders[1].text).Return(fmt.Errorf("outbox error"))
+	deliveriesRepo.EXPECT().Forget(ctx, reminders[1].keys).Return(fmt.Errorf("repo error"))
+
+	testService.enqueue(ctx, reminders)
+}
+
+// expectRun описывает один запуск рассылки на 10 мая 2024 с отправкой двух писем
+func expectRun(
+	ctx context.Context,
+	now time.Time,
+	usersRepo *user.MockUsersRepo,
+	subscriptionsRepo *subscription.MockSubscriptionsRepo,
+	outboxRepo *outbox.MockOutbox,
+	deliveriesRepo *delivery.MockDeliveriesRepo,
+) {
+	subsSent, usersSent, reminders := alertTestData(now)
 
 	subscriptionsRepo.EXPECT().GetAllSubscriptions(ctx).Return(subsSent, nil)
 
 	usersRepo.EXPECT().GetByID(ctx, uint32(0)).Return(usersSent[0], nil)
 	usersRepo.EXPECT().GetByID(ctx, uint32(2)).Return(usersSent[2], nil)
 	usersRepo.EXPECT().GetByID(ctx, uint32(1)).Return(usersSent[1], nil)
-	usersRepo.EXPECT().GetByID(ctx, uint32(2)).Return(usersSent[2], nil)
 	usersRepo.EXPECT().GetByID(ctx, uint32(3)).Return(usersSent[3], nil)
+
+	for _, rem := range reminders {
+		for _, key := range rem.keys {
+			deliveriesRepo.EXPECT().Record(ctx, key).Return(true, nil)
+		}
+		outboxRepo.EXPECT().Enqueue(ctx, rem.recipients, "Напоминание о дне рождения!", rem.text).Return(nil)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, subscriptionsRepo, outboxRepo, deliveriesRepo := newTestService(ctrl)
+
+	// данные для теста
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	today := time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC)
+	zones := []*time.Location{time.UTC}
+	ctx := context.Background()
+
+	// первый запуск
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(time.Time{}, nil)
+	expectRun(ctx, now, usersRepo, subscriptionsRepo, outboxRepo, deliveriesRepo)
+	deliveriesRepo.EXPECT().SetLastRun(ctx, "UTC", today).Return(nil)
+
+	err := testService.run(ctx, now, zones)
+
+	assert.NoError(t, err)
+
+	// повторный запуск в тот же день - письма уже в журнале
+	subsSent, usersSent, reminders := alertTestData(now)
+
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(today, nil)
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(ctx).Return(subsSent, nil)
+	usersRepo.EXPECT().GetByID(ctx, uint32(0)).Return(usersSent[0], nil)
 	usersRepo.EXPECT().GetByID(ctx, uint32(2)).Return(usersSent[2], nil)
+	usersRepo.EXPECT().GetByID(ctx, uint32(1)).Return(usersSent[1], nil)
 	usersRepo.EXPECT().GetByID(ctx, uint32(3)).Return(usersSent[3], nil)
+	for _, rem := range reminders {
+		for _, key := range rem.keys {
+			deliveriesRepo.EXPECT().Record(ctx, key).Return(false, nil)
+		}
+	}
+	deliveriesRepo.EXPECT().SetLastRun(ctx, "UTC", today).Return(nil)
 
-	alertManager.EXPECT().Send(recipientsExpected[0], "Напоминание о дне рождения!", messagesExpected[0])
-	alertManager.EXPECT().Send(recipientsExpected[1], "Напоминание о дне рождения!", messagesExpected[1])
+	err = testService.run(ctx, now, zones)
+
+	assert.NoError(t, err)
 
+	// сервис не работал 2 дня: zero ждал напоминания за 7 дней 2 дня назад
+	subsCatchUp := []*subscription.Subscription{
+		{
+			Subscriber:   2,
+			Subscription: 0,
+			DaysAlert:    []int{7},
+		},
+	}
+
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(today.AddDate(0, 0, -3), nil)
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(ctx).Return(subsCatchUp, nil)
+	usersRepo.EXPECT().GetByID(ctx, uint32(0)).Return(usersSent[0], nil)
+	usersRepo.EXPECT().GetByID(ctx, uint32(2)).Return(usersSent[2], nil)
+	deliveriesRepo.EXPECT().Record(ctx, delivery.Key{Subscriber: 2, Subject: 0, BirthdayYear: 2024, DaysBefore: 7}).Return(true, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{"two@two.net"}, "Напоминание о дне рождения!", "zero празднует свой день рождения через 5 дней!").Return(nil)
+	deliveriesRepo.EXPECT().SetLastRun(ctx, "UTC", today).Return(nil)
+
+	err = testService.run(ctx, now, zones)
+
+	assert.NoError(t, err)
+
+	// несколько поясов: дата запуска у каждого своя, в Токио уже 11 мая
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("cant load location: %v", err)
+	}
+	nowEvening := time.Date(2024, time.May, 10, 20, 0, 0, 0, time.UTC)
+
+	deliveriesRepo.EXPECT().LastRun(ctx, "Asia/Tokyo").Return(today, nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(today.AddDate(0, 0, -1), nil)
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(ctx).Return([]*subscription.Subscription{}, nil)
+	deliveriesRepo.EXPECT().SetLastRun(ctx, "Asia/Tokyo", today.AddDate(0, 0, 1)).Return(nil)
+	deliveriesRepo.EXPECT().SetLastRun(ctx, "UTC", today).Return(nil)
+
+	err = testService.run(ctx, nowEvening, []*time.Location{tokyo, time.UTC})
+
+	assert.NoError(t, err)
+
+	// ошибка при чтении даты последнего запуска
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(time.Time{}, fmt.Errorf("repo error"))
+
+	err = testService.run(ctx, now, zones)
+
+	assert.Error(t, err)
+
+	// ошибка при создании сообщений - дата запуска не сохраняется
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(today.AddDate(0, 0, -1), nil)
 	subscriptionsRepo.EXPECT().GetAllSubscriptions(ctx).Return(nil, fmt.Errorf("repo error"))
 
+	err = testService.run(ctx, now, zones)
+
+	assert.Error(t, err)
+
+	// ошибка при сохранении даты запуска
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(today.AddDate(0, 0, -1), nil)
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(ctx).Return([]*subscription.Subscription{}, nil)
+	deliveriesRepo.EXPECT().SetLastRun(ctx, "UTC", today).Return(fmt.Errorf("repo error"))
+
+	err = testService.run(ctx, now, zones)
+
+	assert.Error(t, err)
+}
+
+func TestZones(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, _, _ := newTestService(ctrl)
+
+	// данные для теста
+	ctx := context.Background()
+
+	usersSent := []*user.User{
+		{ID: 0, Timezone: "Europe/Moscow"},
+		{ID: 1},
+		{ID: 2, Timezone: "Europe/Moscow"},
+		{ID: 3, Timezone: "Asia/Tokyo"},
+		{ID: 4, Timezone: "America/New_York", Deactivated: true},
+	}
+
+	// нормальная работа: каждый пояс один раз, пустой пояс - UTC, пояса уволенных не нужны
+	usersRepo.EXPECT().GetAll(ctx).Return(usersSent, nil)
+
+	zones, err := testService.zones(ctx)
+
+	assert.NoError(t, err)
+	if assert.Len(t, zones, 3) {
+		assert.EqualValues(t, "Europe/Moscow", zones[0].String())
+		assert.EqualValues(t, "UTC", zones[1].String())
+		assert.EqualValues(t, "Asia/Tokyo", zones[2].String())
+	}
+
+	// ошибка хранилища
+	usersRepo.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.zones(ctx)
+
+	assert.Error(t, err)
+}
+
+func TestCatchUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, subscriptionsRepo, _, deliveriesRepo := newTestService(ctrl)
+
+	// данные для теста
+	schedule, err := cron.Parse("0 9 * * *")
+	if err != nil {
+		t.Fatalf("cant parse schedule: %v", err)
+	}
+
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	today := time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	usersSent := []*user.User{
+		{ID: 0},                               // 12:00, рассылки не было 2 дня
+		{ID: 1, Timezone: "Asia/Tokyo"},       // 21:00, вчера рассылка была, сегодня нет
+		{ID: 2, Timezone: "America/New_York"}, // 8:00, время рассылки еще не наступило
+		{ID: 3, Timezone: "Europe/Moscow"},    // 15:00, сегодня рассылка уже была
+		{ID: 4, Timezone: "Europe/London"},    // 13:00, рассылка ни разу не запускалась
+	}
+
+	// нормальная работа: рассылка запускается один раз для пропустивших сегодняшнее время
+	usersRepo.EXPECT().GetAll(ctx).Return(usersSent, nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(today.AddDate(0, 0, -2), nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "Asia/Tokyo").Return(today.AddDate(0, 0, -1), nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "America/New_York").Return(today.AddDate(0, 0, -2), nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "Europe/Moscow").Return(today, nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "Europe/London").Return(time.Time{}, nil)
+
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(today.AddDate(0, 0, -2), nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "Asia/Tokyo").Return(today.AddDate(0, 0, -1), nil)
+	subscriptionsRepo.EXPECT().GetAllSubscriptions(ctx).Return([]*subscription.Subscription{}, nil)
+	deliveriesRepo.EXPECT().SetLastRun(ctx, "UTC", today).Return(nil)
+	deliveriesRepo.EXPECT().SetLastRun(ctx, "Asia/Tokyo", today).Return(nil)
+
+	err = testService.catchUp(ctx, schedule, now)
+
+	assert.NoError(t, err)
+
+	// наверстывать нечего
+	usersRepo.EXPECT().GetAll(ctx).Return(usersSent[2:], nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "America/New_York").Return(today.AddDate(0, 0, -2), nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "Europe/Moscow").Return(today, nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "Europe/London").Return(time.Time{}, nil)
+
+	err = testService.catchUp(ctx, schedule, now)
+
+	assert.NoError(t, err)
+
+	// ошибка при чтении пользователей
+	usersRepo.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	err = testService.catchUp(ctx, schedule, now)
+
+	assert.Error(t, err)
+
+	// ошибка при чтении даты последнего запуска
+	usersRepo.EXPECT().GetAll(ctx).Return(usersSent[:1], nil)
+	deliveriesRepo.EXPECT().LastRun(ctx, "UTC").Return(time.Time{}, fmt.Errorf("repo error"))
+
+	err = testService.catchUp(ctx, schedule, now)
+
+	assert.Error(t, err)
+}
+
+func TestStartAlert(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, _, _ := newTestService(ctrl)
+
+	// данные для теста
+	schedule, err := cron.Parse("0 9 1 1 *")
+	if err != nil {
+		t.Fatalf("cant parse schedule: %v", err)
+	}
+
+	// сервис читает часовые пояса (для пропущенных запусков и для планировщика)
+	// и останавливается по отмене контекста
+	wg := &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	usersRepo.EXPECT().GetAll(ctx).Return([]*user.User{}, nil).Times(2)
+
 	wg.Add(1)
-	go testService.alert(ctx, time.Second, wg)
+	go testService.StartAlert(ctx, schedule, wg)
 
-	time.Sleep(time.Millisecond * 1500)
+	time.Sleep(100 * time.Millisecond)
 	cancel()
 	wg.Wait()
 }
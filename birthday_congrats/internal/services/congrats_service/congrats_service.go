@@ -1,7 +1,12 @@
 package congrats_service
 
 import (
+	"birthday_congrats/internal/pkg/cron"
+	"birthday_congrats/internal/pkg/ldap"
+	"birthday_congrats/internal/pkg/oidc"
+	"birthday_congrats/internal/pkg/roster"
 	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
 	"birthday_congrats/internal/pkg/user"
 	"context"
 	"sync"
@@ -9,11 +14,66 @@ import (
 )
 
 type CongratulationsService interface {
-	Register(ctx context.Context, username, password, email, birth string) (*session.Session, error)
+	Register(ctx context.Context, username, password, email, birth, timezone string) (*session.Session, error)
 	Login(ctx context.Context, username, password string) (*session.Session, error)
-	Subscribe(ctx context.Context, subscriptionID uint32, daysAlert int) error
-	Unsubscribe(ctx context.Context, subscriptionID uint32) error
+	Subscribe(ctx context.Context, subscriptionID uint32, daysAlert int) error            // добавляет напоминание за daysAlert дней
+	RemoveDaysAlert(ctx context.Context, subscriptionID uint32, daysAlert int) error      // удаляет одно напоминание
+	UpdateSubscription(ctx context.Context, subscriptionID uint32, daysAlert []int) error // заменяет все напоминания подписки
+	Unsubscribe(ctx context.Context, subscriptionID uint32) error                         // удаляет подписку со всеми напоминаниями
 	Logout(ctx context.Context) error
 	GetSubscriptionsByUser(ctx context.Context) ([]*user.User, error) // возвращает список всех пользователей с информацией о подписке на каждого
-	StartAlert(ctx context.Context, timeStart time.Time, period time.Duration, wg *sync.WaitGroup)
+	StartAlert(ctx context.Context, schedule *cron.Schedule, wg *sync.WaitGroup)
+
+	// вход через провайдера OpenID Connect: учетная запись ищется по подтвержденной почте,
+	// при первом входе заводится
+	LoginOIDC(ctx context.Context, claims *oidc.Claims) (*session.Session, error)
+
+	// настройки приватности текущего пользователя
+	GetPrivacy(ctx context.Context) (*user.Privacy, error)
+	UpdatePrivacy(ctx context.Context, p user.Privacy) error
+
+	// подтверждение почты
+	VerifyEmail(ctx context.Context, token string) error // по ссылке из письма, сессия не нужна
+	ResendVerification(ctx context.Context) error        // еще раз отправляет ссылку текущему пользователю
+
+	// профиль текущего пользователя; изменения записываются в журнал
+	GetProfile(ctx context.Context) (*user.User, error)
+	UpdateProfile(ctx context.Context, upd ProfileUpdate) (*user.User, error)                  // смена почты - по текущему паролю, затем ее нужно подтвердить заново
+	ChangePassword(ctx context.Context, current, newPassword string) (*session.Session, error) // завершает остальные сессии и возвращает новую
+
+	// выгрузка данных и удаление аккаунта текущего пользователя
+	ExportData(ctx context.Context) (*Export, error)
+	DeleteAccount(ctx context.Context, password string) error    // требует пароль, завершает сессию
+	RequestAccountDeletion(ctx context.Context) error            // отправляет ссылку удаления на подтвержденную почту (для аккаунтов без своего пароля)
+	DeleteAccountByLink(ctx context.Context, token string) error // по ссылке из письма, сессия не нужна
+
+	// восстановление доступа, сессия не нужна
+	RequestPasswordReset(ctx context.Context, username string) error    // отправляет ссылку сброса на подтвержденную почту пользователя
+	ResetPassword(ctx context.Context, token, newPassword string) error // задает новый пароль и завершает все сессии
+
+	HasRole(ctx context.Context, role string) (bool, error) // есть ли роль у пользователя текущей сессии
+
+	// управление сотрудниками: доступно администраторам и доверенным системам (SCIM),
+	// остальным - ErrForbidden
+	CreateUser(ctx context.Context, u *user.User, password string) (*user.User, error) // пустой пароль - войти нельзя, пока пароль не задан
+	GetUser(ctx context.Context, userID uint32) (*user.User, error)
+	ListUsers(ctx context.Context) ([]*user.User, error)                   // все пользователи, включая уволенных
+	UpdateUser(ctx context.Context, u *user.User) (*user.User, error)      // при увольнении удаляет подписки и сессии
+	DeactivateUser(ctx context.Context, userID uint32) (*user.User, error) // увольнение
+	ExportUser(ctx context.Context, userID uint32) (*Export, error)
+	DeleteUser(ctx context.Context, userID uint32) error // удаление насовсем, в отличие от увольнения
+	SetRole(ctx context.Context, userID uint32, role string) (*user.User, error)
+	ListSubscriptions(ctx context.Context) ([]*subscription.Subscription, error) // подписки всех пользователей
+	ForceLogout(ctx context.Context, userID uint32) error                        // завершает все сессии пользователя
+	RunAlerts(ctx context.Context) error                                         // рассылка вне расписания
+	SendVerifications(ctx context.Context) (int, error)                          // ссылки подтверждения всем с неподтвержденной почтой
+
+	// импорт выгрузки HR: сотрудники ищутся по почте, новым отправляется приглашение задать пароль;
+	// при dryRun ничего не меняется, только проверяется
+	ImportUsers(ctx context.Context, records []roster.Record, dryRun bool) (*ImportReport, error)
+
+	// синхронизация с каталогом LDAP: сотрудники ищутся по идентификатору записи, ушедшие из каталога
+	// увольняются; пустой каталог считается ошибкой
+	SyncDirectory(ctx context.Context, people []ldap.Person) (*SyncReport, error)
+	StartDirectorySync(ctx context.Context, source ldap.Source, interval time.Duration, wg *sync.WaitGroup)
 }
@@ -1,13 +1,20 @@
 package congrats_service
 
 import (
-	alertmanager "birthday_congrats/internal/pkg/alert_manager"
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/cron"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/reset"
 	"birthday_congrats/internal/pkg/session"
 	"birthday_congrats/internal/pkg/subscription"
 	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,15 +27,32 @@ const (
 )
 
 var (
-	ErrBadDateFormat = errors.New("bad date format")
+	ErrBadDateFormat   = errors.New("bad date format")
+	ErrNotSubscribable = errors.New("user does not accept subscriptions")
+	ErrBadResetToken   = errors.New("bad password reset token")
+	ErrEmptyPassword   = errors.New("empty password")
+	ErrEmptyUsername   = errors.New("empty username")
+	ErrBadEmail        = errors.New("bad email")
+	ErrForbidden       = errors.New("forbidden")
 )
 
 type CongratulationsServiceImpl struct {
 	usersRepo         user.UsersRepo
 	subscriptionsRepo subscription.SubscriptionsRepo
 	sm                session.SessionsManager
-	alerts            alertmanager.AlertManager
+	outbox            outbox.Outbox
+	deliveries        delivery.DeliveriesRepo
+	resets            reset.ResetsRepo
+	audit             audit.AuditRepo // журнал изменений профилей
+	leapDay           birthday.LeapDayPolicy
+	verifier          *verification.Signer // ссылки подтверждения почты
+	deletion          *verification.Signer // ссылки удаления аккаунта, действуют как ссылки сброса пароля
+	baseURL           string               // внешний адрес сервиса для ссылок в письмах
+	resetTTL          time.Duration        // сколько действует ссылка сброса пароля
+	inviteTTL         time.Duration        // сколько действует приглашение импортированному сотруднику
 	logger            *zap.SugaredLogger
+
+	now func() time.Time // текущее время (подменяется в тестах)
 }
 
 var _ CongratulationsService = &CongratulationsServiceImpl{}
@@ -37,33 +61,67 @@ func NewCongratulationsServiceImpl(
 	usersRepo user.UsersRepo,
 	subscriptionsRepo subscription.SubscriptionsRepo,
 	sm session.SessionsManager,
-	alerts alertmanager.AlertManager,
+	outbox outbox.Outbox,
+	deliveries delivery.DeliveriesRepo,
+	resets reset.ResetsRepo,
+	audit audit.AuditRepo,
+	leapDay birthday.LeapDayPolicy,
+	verifier *verification.Signer,
+	baseURL string,
+	resetTTL time.Duration,
+	inviteTTL time.Duration,
 	logger *zap.SugaredLogger,
 ) *CongratulationsServiceImpl {
 	return &CongratulationsServiceImpl{
 		usersRepo:         usersRepo,
 		subscriptionsRepo: subscriptionsRepo,
 		sm:                sm,
-		alerts:            alerts,
+		outbox:            outbox,
+		deliveries:        deliveries,
+		resets:            resets,
+		audit:             audit,
+		leapDay:           leapDay,
+		verifier:          verifier,
+		deletion:          verifier.WithPurpose(verification.PurposeDeleteAccount, resetTTL),
+		baseURL:           strings.TrimSuffix(baseURL, "/"),
+		resetTTL:          resetTTL,
+		inviteTTL:         inviteTTL,
 		logger:            logger,
+		now:               time.Now,
 	}
 }
 
-func (cs *CongratulationsServiceImpl) Register(ctx context.Context, username, password, email, birth string) (*session.Session, error) {
-	birthday, err := time.Parse(dateLayout, birth)
+func (cs *CongratulationsServiceImpl) Register(ctx context.Context, username, password, email, birthDate, timezone string) (*session.Session, error) {
+	birth, err := birthday.Parse(birthDate)
 	if err != nil {
-		cs.logger.Errorf("Error while parsing date: %v", err)
+		cs.logger.Warnf("Bad birthday %q: %v", birthDate, err)
 		return nil, ErrBadDateFormat
 	}
 
+	if timezone == "" {
+		timezone = user.DefaultTimezone
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		cs.logger.Warnf("Bad timezone %q: %v", timezone, err)
+		return nil, user.ErrBadTimezone
+	}
+
+	// "сегодня" - по календарю пользователя
+	err = birth.Validate(cs.now().In(loc))
+	if err != nil {
+		cs.logger.Warnf("Bad birthday %s: %v", birth, err)
+		return nil, err
+	}
+
 	newUser, err := cs.usersRepo.Create(
 		ctx,
 		username,
 		password,
 		email,
-		birthday.Year(),
-		int(birthday.Month()),
-		birthday.Day(),
+		timezone,
+		birth,
 	)
 	if err != nil && err != user.ErrUserExists {
 		cs.logger.Errorf("Error while creating user: %v", err)
@@ -74,6 +132,12 @@ func (cs *CongratulationsServiceImpl) Register(ctx context.Context, username, pa
 		return nil, err
 	}
 
+	// не получилось - не страшно: пользователь может запросить ссылку еще раз
+	err = cs.sendVerification(ctx, newUser)
+	if err != nil {
+		cs.logger.Errorf("Error while sending verification email: %v", err)
+	}
+
 	sess, err := cs.sm.Create(ctx, newUser.ID)
 	if err != nil {
 		cs.logger.Errorf("Error while creating session")
@@ -93,6 +157,10 @@ func (cs *CongratulationsServiceImpl) Login(ctx context.Context, username, passw
 		cs.logger.Warnf("User not exist: %v", err)
 		return nil, user.ErrNoUser // оставляем только один тип ошибки, чтобы мошеннику было сложнее подобрать пароль
 	}
+	if us.Deactivated {
+		cs.logger.Warnf("Deactivated user %d tried to log in", us.ID)
+		return nil, user.ErrNoUser
+	}
 
 	sess, err := cs.sm.Create(ctx, us.ID)
 	if err != nil {
@@ -110,6 +178,11 @@ func (cs *CongratulationsServiceImpl) Subscribe(ctx context.Context, subscriptio
 		return err
 	}
 
+	err = cs.checkSubscribable(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+
 	err = cs.subscriptionsRepo.AddSubscription(ctx, sess.UserID, subscriptionID, daysAlert)
 	if err != nil && err != subscription.ErrAddSubscription {
 		cs.logger.Errorf("Error adding subscription: %v", err)
@@ -123,6 +196,56 @@ func (cs *CongratulationsServiceImpl) Subscribe(ctx context.Context, subscriptio
 	return nil
 }
 
+func (cs *CongratulationsServiceImpl) RemoveDaysAlert(ctx context.Context, subscriptionID uint32, daysAlert int) error {
+	sess, err := session.SessionFromContext(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error getting session from context: %v", err)
+		return err
+	}
+
+	err = cs.subscriptionsRepo.RemoveDaysAlert(ctx, sess.UserID, subscriptionID, daysAlert)
+	if err != nil && err != subscription.ErrRemoveSubscription {
+		cs.logger.Errorf("Error removing days alert: %v", err)
+		return fmt.Errorf("Internal error")
+	}
+	if err == subscription.ErrRemoveSubscription {
+		cs.logger.Warnf("Days alert was not removed")
+		return err
+	}
+
+	return nil
+}
+
+func (cs *CongratulationsServiceImpl) UpdateSubscription(ctx context.Context, subscriptionID uint32, daysAlert []int) error {
+	sess, err := session.SessionFromContext(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error getting session from context: %v", err)
+		return err
+	}
+
+	// повторы в списке не нужны, храним по возрастанию
+	days := slices.Clone(daysAlert)
+	slices.Sort(days)
+	days = slices.Compact(days)
+
+	err = cs.checkSubscribable(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	err = cs.subscriptionsRepo.UpdateSubscription(ctx, sess.UserID, subscriptionID, days)
+	if err != nil && err != subscription.ErrNoSubscription {
+		cs.logger.Errorf("Error updating subscription: %v", err)
+		return fmt.Errorf("Internal error")
+	}
+	if err == subscription.ErrNoSubscription {
+		cs.logger.Warnf("Subscription was not updated")
+		return err
+	}
+
+	return nil
+}
+
 func (cs *CongratulationsServiceImpl) Unsubscribe(ctx context.Context, subscriptionID uint32) error {
 	sess, err := session.SessionFromContext(ctx)
 	if err != nil {
@@ -176,154 +299,349 @@ func (cs *CongratulationsServiceImpl) GetSubscriptionsByUser(ctx context.Context
 		return nil, fmt.Errorf("internal error")
 	}
 
-	slices.SortFunc(subscriptions, func(a, b *subscription.Subscription) int { return int(a.Subscription) - int(b.Subscription) })
-
-	i := 0
+	// ближайшие дни рождения считаются по календарю текущего пользователя
+	loc := time.UTC
 	for _, u := range users {
-		if i >= len(subscriptions) {
+		if u.ID == sess.UserID {
+			loc = u.Location()
 			break
 		}
+	}
+
+	// уволенных и скрывшихся из списка не показываем, остальным - только то, что они разрешили
+	users = slices.DeleteFunc(users, func(u *user.User) bool { return u.ID != sess.UserID && !u.Listed() })
+	for _, u := range users {
+		if u.ID != sess.UserID {
+			hidePrivate(u)
+		}
+	}
+
+	now := cs.now()
+	for _, u := range users {
+		u.NextBirthday = cs.leapDay.Next(now, loc, u.Birthday.Month, u.Birthday.Day)
+	}
+
+	// подписки на тех, кого нет в списке, просто не показываются
+	subscribed := make(map[uint32]*subscription.Subscription, len(subscriptions))
+	for _, sub := range subscriptions {
+		subscribed[sub.Subscription] = sub
+	}
 
-		if u.ID == subscriptions[i].Subscription {
+	for _, u := range users {
+		if sub, ok := subscribed[u.ID]; ok {
 			u.Subscription = true
-			u.DaysAlert = subscriptions[i].DaysAlert
-			i++
+			u.DaysAlert = sub.DaysAlert
 		}
 	}
 
 	return users, nil
 }
 
-func (cs *CongratulationsServiceImpl) StartAlert(ctx context.Context, timeStart time.Time, period time.Duration, wg *sync.WaitGroup) {
-	if timeStart.After(time.Now()) {
-		ticker := time.NewTicker(time.Until(timeStart))
-		defer ticker.Stop()
+// StartAlert запускает рассылку напоминаний по расписанию schedule. Время в расписании -
+// местное для получателей: при "0 9 * * *" каждый получает напоминания в 9:00 по своему часовому поясу.
+func (cs *CongratulationsServiceImpl) StartAlert(ctx context.Context, schedule *cron.Schedule, wg *sync.WaitGroup) {
+	cs.logger.Infof("Starting alert service")
 
-		cs.logger.Infof("Alert service will start at %v", timeStart)
+	err := cs.catchUp(ctx, schedule, cs.now())
+	if err != nil {
+		cs.logger.Errorf("Error while catching up missed alerts: %v", err)
+	}
 
-		select {
-		case <-ctx.Done():
-			wg.Done()
-			return
-		case <-ticker.C:
-			break
+	scheduler := cron.NewScheduler(schedule, cs.logger)
+	scheduler.Run(ctx, cs.zones, cs.runJob, wg)
+}
+
+// catchUp запускает рассылку при старте сервиса в поясах, где сегодняшнее время рассылки
+// уже прошло, а рассылки сегодня не было (сервис в это время не работал). Планировщик
+// такой запуск не повторит - следующий будет только завтра. Если время рассылки сегодня
+// еще не наступило, пропущенные дни наверстает обычный запуск. Пояса, в которых рассылка
+// ни разу не запускалась, ждут расписания.
+func (cs *CongratulationsServiceImpl) catchUp(ctx context.Context, schedule *cron.Schedule, now time.Time) error {
+	zones, err := cs.zones(ctx)
+	if err != nil {
+		return err
+	}
+
+	missed := make([]*time.Location, 0)
+	for _, loc := range zones {
+		local := now.In(loc)
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+		lastRun, err := cs.deliveries.LastRun(ctx, loc.String())
+		if err != nil {
+			cs.logger.Errorf("Error getting last run date: %v", err)
+			return fmt.Errorf("error getting last run date from repo")
+		}
+
+		if lastRun.IsZero() || !lastRun.Before(today) {
+			continue
 		}
+
+		// первое срабатывание расписания за сегодня
+		midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		first := schedule.Next(midnight.Add(-time.Nanosecond))
+		if first.IsZero() || first.After(now) {
+			continue
+		}
+
+		missed = append(missed, loc)
 	}
 
-	cs.logger.Infof("Starting alert service")
-	go cs.alert(ctx, period, wg)
+	if len(missed) == 0 {
+		return nil
+	}
+
+	cs.logger.Infof("Catching up missed alerts in %d time zones on start", len(missed))
+
+	return cs.run(ctx, now, missed)
 }
 
-func (cs *CongratulationsServiceImpl) alert(ctx context.Context, period time.Duration, wg *sync.WaitGroup) {
-	defer wg.Done()
+// zones возвращает часовые пояса всех пользователей
+func (cs *CongratulationsServiceImpl) zones(ctx context.Context) ([]*time.Location, error) {
+	users, err := cs.usersRepo.GetAll(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error while getting all users: %v", err)
+		return nil, fmt.Errorf("error getting users from repo")
+	}
 
-	cs.logger.Infof("Alert service started")
+	seen := make(map[string]bool)
+	zones := make([]*time.Location, 0)
+	for _, u := range users {
+		if u.Deactivated {
+			continue
+		}
 
-	ticker := time.NewTicker(period)
-	defer ticker.Stop()
+		loc := u.Location()
+		if seen[loc.String()] {
+			continue
+		}
 
-	// первый раз делаем отправку сразу, затем по тикеру
-	messages, recipients, err := cs.makeMessages(ctx)
+		seen[loc.String()] = true
+		zones = append(zones, loc)
+	}
+
+	return zones, nil
+}
+
+func (cs *CongratulationsServiceImpl) runJob(ctx context.Context, now time.Time, zones []*time.Location) {
+	err := cs.run(ctx, now, zones)
+	if err != nil {
+		cs.logger.Errorf("Error while running alerts: %v", err)
+	}
+}
+
+// run - запуск рассылки в момент now для подписчиков из часовых поясов zones. Если с прошлого
+// запуска в поясе прошло несколько дней (сервис не работал), напоминания за пропущенные дни
+// отправляются сейчас. Повторный запуск в тот же день ничего не отправляет повторно
+// благодаря журналу отправленных напоминаний.
+func (cs *CongratulationsServiceImpl) run(ctx context.Context, now time.Time, zones []*time.Location) error {
+	catchUp := make(map[string]int, len(zones))
+	days := make([]time.Time, 0, len(zones))
+
+	for _, loc := range zones {
+		local := now.In(loc)
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+		lastRun, err := cs.deliveries.LastRun(ctx, loc.String())
+		if err != nil {
+			cs.logger.Errorf("Error getting last run date: %v", err)
+			return fmt.Errorf("error getting last run date from repo")
+		}
+
+		// дни между прошлым запуском и сегодняшним, в которые рассылка не запускалась
+		catchUpDays := 0
+		if !lastRun.IsZero() && today.After(lastRun) {
+			catchUpDays = int(today.Sub(lastRun)/(24*time.Hour)) - 1
+		}
+		if catchUpDays > 0 {
+			cs.logger.Infof("Catching up %d days in %s since last run on %s", catchUpDays, loc, lastRun.Format(dateLayout))
+		}
+
+		catchUp[loc.String()] = catchUpDays
+		days = append(days, today)
+	}
+
+	reminders, err := cs.makeMessages(ctx, now, catchUp)
 	if err != nil {
 		cs.logger.Errorf("Error while making messages: %v", err)
-		return
+		return err
 	}
 
-	cs.logger.Infof("Sending %d different messages today", len(messages))
+	cs.enqueue(ctx, reminders)
 
-	for i := range messages {
-		cs.alerts.Send(recipients[i], "Напоминание о дне рождения!", messages[i])
+	for i, loc := range zones {
+		err = cs.deliveries.SetLastRun(ctx, loc.String(), days[i])
+		if err != nil {
+			cs.logger.Errorf("Error saving last run date: %v", err)
+			return fmt.Errorf("error saving last run date to repo")
+		}
 	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			cs.logger.Infof("Alert service was stopped")
-			return
-		case <-ticker.C:
-			messages, recipients, err := cs.makeMessages(ctx)
+	return nil
+}
+
+// enqueue кладет напоминания в очередь исходящих писем, доставкой занимается outbox.Worker.
+// Каждому получателю письмо уходит, только если такого напоминания еще нет в журнале.
+func (cs *CongratulationsServiceImpl) enqueue(ctx context.Context, reminders []*reminder) {
+	sent := 0
+
+	for _, rem := range reminders {
+		to := make([]string, 0, len(rem.recipients))
+		keys := make([]delivery.Key, 0, len(rem.keys))
+
+		for i, key := range rem.keys {
+			isNew, err := cs.deliveries.Record(ctx, key)
 			if err != nil {
-				cs.logger.Errorf("Error while making messages: %v", err)
+				cs.logger.Errorf("Error while recording delivery: %v", err)
 				continue
 			}
+			if !isNew {
+				continue
+			}
+
+			to = append(to, rem.recipients[i])
+			keys = append(keys, key)
+		}
+
+		if len(to) == 0 {
+			continue
+		}
 
-			cs.logger.Infof("Sending %d different messages today", len(messages))
+		err := cs.outbox.Enqueue(ctx, to, "Напоминание о дне рождения!", rem.text)
+		if err != nil {
+			cs.logger.Errorf("Error while enqueueing message: %v", err)
 
-			for i := range messages {
-				cs.alerts.Send(recipients[i], "Напоминание о дне рождения!", messages[i])
+			// письмо не в очереди - убираем из журнала, чтобы отправить при следующем запуске
+			err = cs.deliveries.Forget(ctx, keys)
+			if err != nil {
+				cs.logger.Errorf("Error while forgetting deliveries: %v", err)
 			}
+			continue
 		}
+
+		sent++
 	}
+
+	cs.logger.Infof("Sending %d different messages today", sent)
 }
 
-func (cs *CongratulationsServiceImpl) makeMessages(ctx context.Context) ([]string, [][]string, error) {
+// makeMessages собирает напоминания на момент now для подписчиков из часовых поясов catchUp.
+// "Сегодня" у каждого подписчика свое - дни до дня рождения считаются по календарю в его
+// часовом поясе, поэтому подписчики на одного и того же человека с одинаковым количеством дней
+// получают одно общее письмо. catchUp[пояс] - сколько прошедших дней нужно наверстать: напоминание
+// за daysAlert дней, которое должно было уйти в один из этих дней, отправляется сейчас
+// с актуальным числом дней. Подписчик получает об одном человеке не больше одного письма за запуск.
+func (cs *CongratulationsServiceImpl) makeMessages(ctx context.Context, now time.Time, catchUp map[string]int) ([]*reminder, error) {
 	subscriptions, err := cs.subscriptionsRepo.GetAllSubscriptions(ctx)
 	if err != nil {
 		cs.logger.Errorf("Error getting all subscriptions: %v", err)
-		return nil, nil, fmt.Errorf("error getting subscriptions from repo")
+		return nil, fmt.Errorf("error getting subscriptions from repo")
 	}
 
 	if len(subscriptions) == 0 {
-		return nil, nil, nil
+		return nil, nil
 	}
 
 	slices.SortStableFunc(subscriptions, func(a, b *subscription.Subscription) int { return int(a.Subscription) - int(b.Subscription) })
 
-	messages := make([]string, 0)
-	recipients := make([][]string, 0)
+	reminders := make([]*reminder, 0)
+
+	// один и тот же пользователь может встречаться в нескольких подписках
+	users := make(map[uint32]*user.User)
+	getUser := func(userID uint32) (*user.User, error) {
+		if us, ok := users[userID]; ok {
+			return us, nil
+		}
+
+		us, err := cs.usersRepo.GetByID(ctx, userID)
+		if err != nil {
+			cs.logger.Errorf("Error getting user by id: %v", err)
+			return nil, fmt.Errorf("repo error: %v", err)
+		}
+
+		users[userID] = us
+		return us, nil
+	}
+
+	for start := 0; start < len(subscriptions); {
+		subID := subscriptions[start].Subscription
 
-	var subID uint32
-	var daysBefore int
-	to := make([]string, 0)
+		end := start
+		for end < len(subscriptions) && subscriptions[end].Subscription == subID {
+			end++
+		}
+
+		us, err := getUser(subID)
+		if err != nil {
+			return nil, err
+		}
 
-	for i, sub := range subscriptions {
-		if sub.Subscription != subID || i == 0 {
-			subID = sub.Subscription
-			us, err := cs.usersRepo.GetByID(ctx, subID)
+		// подписки на уволенных удаляются при увольнении, но могли появиться после;
+		// тот, кто запретил подписываться, напоминаний о себе тоже не рассылает
+		if !us.Subscribable() {
+			start = end
+			continue
+		}
+
+		// напоминания по количеству дней до дня рождения
+		byDays := make(map[int]*reminder)
+		for _, sub := range subscriptions[start:end] {
+			subscriber, err := getUser(sub.Subscriber)
 			if err != nil {
-				cs.logger.Errorf("Error getting user by id: %v", err)
-				return nil, nil, fmt.Errorf("repo error: %v", err)
+				return nil, err
+			}
+			// неподтвержденная почта может быть чужой
+			if subscriber.Deactivated || !subscriber.EmailVerified {
+				continue
 			}
 
-			birthday := time.Date(
-				time.Now().Year(),
-				time.Month(us.Month),
-				us.Day,
-				0, 0, 0, 0,
-				time.Local,
-			)
-			if birthday.Before(time.Now()) {
-				birthday = birthday.AddDate(1, 0, 0)
+			// рассылка для пояса подписчика сейчас не запускается
+			catchUpDays, ok := catchUp[subscriber.Location().String()]
+			if !ok {
+				continue
 			}
 
-			if len(to) > 0 {
-				recipients = append(recipients, to)
-			} else if len(messages) > 0 {
-				messages = messages[:len(messages)-1]
+			daysBefore := cs.leapDay.DaysUntil(now, subscriber.Location(), us.Birthday.Month, us.Birthday.Day)
+
+			// из напоминаний, попавших в пропущенные дни, отправляется только последнее
+			daysAlert := -1
+			for _, d := range sub.DaysAlert {
+				if daysBefore <= d && daysBefore >= d-catchUpDays {
+					daysAlert = d
+					break
+				}
+			}
+			if daysAlert < 0 {
+				continue
 			}
 
-			daysBefore = int(time.Until(birthday).Hours())/24 + 1
-			messages = append(messages, fmt.Sprintf("%s празднует свой день рождения через %d дней!", us.Username, daysBefore))
+			rem, ok := byDays[daysBefore]
+			if !ok {
+				rem = &reminder{text: reminderText(us.Username, daysBefore)}
+				byDays[daysBefore] = rem
+			}
 
-			to = make([]string, 0)
+			rem.recipients = append(rem.recipients, subscriber.Email)
+			rem.keys = append(rem.keys, delivery.Key{
+				Subscriber:   sub.Subscriber,
+				Subject:      sub.Subscription,
+				BirthdayYear: cs.leapDay.Next(now, subscriber.Location(), us.Birthday.Month, us.Birthday.Day).Year(),
+				DaysBefore:   daysAlert,
+			})
 		}
 
-		if daysBefore == sub.DaysAlert {
-			subscriber, err := cs.usersRepo.GetByID(ctx, sub.Subscriber)
-			if err != nil {
-				cs.logger.Errorf("Error getting user by id: %v", err)
-				return nil, nil, fmt.Errorf("repo error: %v", err)
-			}
+		days := make([]int, 0, len(byDays))
+		for d := range byDays {
+			days = append(days, d)
+		}
+		slices.Sort(days)
 
-			to = append(to, subscriber.Email)
+		for _, d := range days {
+			reminders = append(reminders, byDays[d])
 		}
-	}
 
-	if len(to) > 0 {
-		recipients = append(recipients, to)
-	} else if len(messages) > 0 {
-		messages = messages[:len(messages)-1]
+		start = end
 	}
 
-	return messages, recipients, nil
+	return reminders, nil
 }
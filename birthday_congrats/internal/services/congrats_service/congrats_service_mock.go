@@ -5,7 +5,12 @@
 package congrats_service
 
 import (
+	cron "birthday_congrats/internal/pkg/cron"
+	ldap "birthday_congrats/internal/pkg/ldap"
+	oidc "birthday_congrats/internal/pkg/oidc"
+	roster "birthday_congrats/internal/pkg/roster"
 	session "birthday_congrats/internal/pkg/session"
+	subscription "birthday_congrats/internal/pkg/subscription"
 	user "birthday_congrats/internal/pkg/user"
 	context "context"
 	reflect "reflect"
@@ -38,6 +43,167 @@ func (m *MockCongratulationsService) EXPECT() *MockCongratulationsServiceMockRec
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockCongratulationsService) ChangePassword(ctx context.Context, current, newPassword string) (*session.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, current, newPassword)
+	ret0, _ := ret[0].(*session.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockCongratulationsServiceMockRecorder) ChangePassword(ctx, current, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockCongratulationsService)(nil).ChangePassword), ctx, current, newPassword)
+}
+
+// CreateUser mocks base method.
+func (m *MockCongratulationsService) CreateUser(ctx context.Context, u *user.User, password string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, u, password)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockCongratulationsServiceMockRecorder) CreateUser(ctx, u, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockCongratulationsService)(nil).CreateUser), ctx, u, password)
+}
+
+// DeactivateUser mocks base method.
+func (m *MockCongratulationsService) DeactivateUser(ctx context.Context, userID uint32) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateUser", ctx, userID)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeactivateUser indicates an expected call of DeactivateUser.
+func (mr *MockCongratulationsServiceMockRecorder) DeactivateUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateUser", reflect.TypeOf((*MockCongratulationsService)(nil).DeactivateUser), ctx, userID)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockCongratulationsService) DeleteAccount(ctx context.Context, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockCongratulationsServiceMockRecorder) DeleteAccount(ctx, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockCongratulationsService)(nil).DeleteAccount), ctx, password)
+}
+
+// DeleteAccountByLink mocks base method.
+func (m *MockCongratulationsService) DeleteAccountByLink(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccountByLink", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccountByLink indicates an expected call of DeleteAccountByLink.
+func (mr *MockCongratulationsServiceMockRecorder) DeleteAccountByLink(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccountByLink", reflect.TypeOf((*MockCongratulationsService)(nil).DeleteAccountByLink), ctx, token)
+}
+
+// DeleteUser mocks base method.
+func (m *MockCongratulationsService) DeleteUser(ctx context.Context, userID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockCongratulationsServiceMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockCongratulationsService)(nil).DeleteUser), ctx, userID)
+}
+
+// ExportData mocks base method.
+func (m *MockCongratulationsService) ExportData(ctx context.Context) (*Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportData", ctx)
+	ret0, _ := ret[0].(*Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportData indicates an expected call of ExportData.
+func (mr *MockCongratulationsServiceMockRecorder) ExportData(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportData", reflect.TypeOf((*MockCongratulationsService)(nil).ExportData), ctx)
+}
+
+// ExportUser mocks base method.
+func (m *MockCongratulationsService) ExportUser(ctx context.Context, userID uint32) (*Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUser", ctx, userID)
+	ret0, _ := ret[0].(*Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUser indicates an expected call of ExportUser.
+func (mr *MockCongratulationsServiceMockRecorder) ExportUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUser", reflect.TypeOf((*MockCongratulationsService)(nil).ExportUser), ctx, userID)
+}
+
+// ForceLogout mocks base method.
+func (m *MockCongratulationsService) ForceLogout(ctx context.Context, userID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceLogout", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceLogout indicates an expected call of ForceLogout.
+func (mr *MockCongratulationsServiceMockRecorder) ForceLogout(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceLogout", reflect.TypeOf((*MockCongratulationsService)(nil).ForceLogout), ctx, userID)
+}
+
+// GetPrivacy mocks base method.
+func (m *MockCongratulationsService) GetPrivacy(ctx context.Context) (*user.Privacy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrivacy", ctx)
+	ret0, _ := ret[0].(*user.Privacy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPrivacy indicates an expected call of GetPrivacy.
+func (mr *MockCongratulationsServiceMockRecorder) GetPrivacy(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivacy", reflect.TypeOf((*MockCongratulationsService)(nil).GetPrivacy), ctx)
+}
+
+// GetProfile mocks base method.
+func (m *MockCongratulationsService) GetProfile(ctx context.Context) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", ctx)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockCongratulationsServiceMockRecorder) GetProfile(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockCongratulationsService)(nil).GetProfile), ctx)
+}
+
 // GetSubscriptionsByUser mocks base method.
 func (m *MockCongratulationsService) GetSubscriptionsByUser(ctx context.Context) ([]*user.User, error) {
 	m.ctrl.T.Helper()
@@ -53,6 +219,81 @@ func (mr *MockCongratulationsServiceMockRecorder) GetSubscriptionsByUser(ctx int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionsByUser", reflect.TypeOf((*MockCongratulationsService)(nil).GetSubscriptionsByUser), ctx)
 }
 
+// GetUser mocks base method.
+func (m *MockCongratulationsService) GetUser(ctx context.Context, userID uint32) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, userID)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockCongratulationsServiceMockRecorder) GetUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockCongratulationsService)(nil).GetUser), ctx, userID)
+}
+
+// HasRole mocks base method.
+func (m *MockCongratulationsService) HasRole(ctx context.Context, role string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasRole", ctx, role)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasRole indicates an expected call of HasRole.
+func (mr *MockCongratulationsServiceMockRecorder) HasRole(ctx, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasRole", reflect.TypeOf((*MockCongratulationsService)(nil).HasRole), ctx, role)
+}
+
+// ImportUsers mocks base method.
+func (m *MockCongratulationsService) ImportUsers(ctx context.Context, records []roster.Record, dryRun bool) (*ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportUsers", ctx, records, dryRun)
+	ret0, _ := ret[0].(*ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportUsers indicates an expected call of ImportUsers.
+func (mr *MockCongratulationsServiceMockRecorder) ImportUsers(ctx, records, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportUsers", reflect.TypeOf((*MockCongratulationsService)(nil).ImportUsers), ctx, records, dryRun)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockCongratulationsService) ListSubscriptions(ctx context.Context) ([]*subscription.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", ctx)
+	ret0, _ := ret[0].([]*subscription.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockCongratulationsServiceMockRecorder) ListSubscriptions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockCongratulationsService)(nil).ListSubscriptions), ctx)
+}
+
+// ListUsers mocks base method.
+func (m *MockCongratulationsService) ListUsers(ctx context.Context) ([]*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx)
+	ret0, _ := ret[0].([]*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockCongratulationsServiceMockRecorder) ListUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockCongratulationsService)(nil).ListUsers), ctx)
+}
+
 // Login mocks base method.
 func (m *MockCongratulationsService) Login(ctx context.Context, username, password string) (*session.Session, error) {
 	m.ctrl.T.Helper()
@@ -68,6 +309,21 @@ func (mr *MockCongratulationsServiceMockRecorder) Login(ctx, username, password
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockCongratulationsService)(nil).Login), ctx, username, password)
 }
 
+// LoginOIDC mocks base method.
+func (m *MockCongratulationsService) LoginOIDC(ctx context.Context, claims *oidc.Claims) (*session.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginOIDC", ctx, claims)
+	ret0, _ := ret[0].(*session.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginOIDC indicates an expected call of LoginOIDC.
+func (mr *MockCongratulationsServiceMockRecorder) LoginOIDC(ctx, claims interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginOIDC", reflect.TypeOf((*MockCongratulationsService)(nil).LoginOIDC), ctx, claims)
+}
+
 // Logout mocks base method.
 func (m *MockCongratulationsService) Logout(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -83,30 +339,156 @@ func (mr *MockCongratulationsServiceMockRecorder) Logout(ctx interface{}) *gomoc
 }
 
 // Register mocks base method.
-func (m *MockCongratulationsService) Register(ctx context.Context, username, password, email, birth string) (*session.Session, error) {
+func (m *MockCongratulationsService) Register(ctx context.Context, username, password, email, birth, timezone string) (*session.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Register", ctx, username, password, email, birth)
+	ret := m.ctrl.Call(m, "Register", ctx, username, password, email, birth, timezone)
 	ret0, _ := ret[0].(*session.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Register indicates an expected call of Register.
-func (mr *MockCongratulationsServiceMockRecorder) Register(ctx, username, password, email, birth interface{}) *gomock.Call {
+func (mr *MockCongratulationsServiceMockRecorder) Register(ctx, username, password, email, birth, timezone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockCongratulationsService)(nil).Register), ctx, username, password, email, birth, timezone)
+}
+
+// RemoveDaysAlert mocks base method.
+func (m *MockCongratulationsService) RemoveDaysAlert(ctx context.Context, subscriptionID uint32, daysAlert int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDaysAlert", ctx, subscriptionID, daysAlert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveDaysAlert indicates an expected call of RemoveDaysAlert.
+func (mr *MockCongratulationsServiceMockRecorder) RemoveDaysAlert(ctx, subscriptionID, daysAlert interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDaysAlert", reflect.TypeOf((*MockCongratulationsService)(nil).RemoveDaysAlert), ctx, subscriptionID, daysAlert)
+}
+
+// RequestAccountDeletion mocks base method.
+func (m *MockCongratulationsService) RequestAccountDeletion(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestAccountDeletion", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestAccountDeletion indicates an expected call of RequestAccountDeletion.
+func (mr *MockCongratulationsServiceMockRecorder) RequestAccountDeletion(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestAccountDeletion", reflect.TypeOf((*MockCongratulationsService)(nil).RequestAccountDeletion), ctx)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockCongratulationsService) RequestPasswordReset(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockCongratulationsServiceMockRecorder) RequestPasswordReset(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockCongratulationsService)(nil).RequestPasswordReset), ctx, username)
+}
+
+// ResendVerification mocks base method.
+func (m *MockCongratulationsService) ResendVerification(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockCongratulationsServiceMockRecorder) ResendVerification(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockCongratulationsService)(nil).ResendVerification), ctx)
+}
+
+// ResetPassword mocks base method.
+func (m *MockCongratulationsService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, token, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockCongratulationsServiceMockRecorder) ResetPassword(ctx, token, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockCongratulationsService)(nil).ResetPassword), ctx, token, newPassword)
+}
+
+// RunAlerts mocks base method.
+func (m *MockCongratulationsService) RunAlerts(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunAlerts", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunAlerts indicates an expected call of RunAlerts.
+func (mr *MockCongratulationsServiceMockRecorder) RunAlerts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunAlerts", reflect.TypeOf((*MockCongratulationsService)(nil).RunAlerts), ctx)
+}
+
+// SendVerifications mocks base method.
+func (m *MockCongratulationsService) SendVerifications(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerifications", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendVerifications indicates an expected call of SendVerifications.
+func (mr *MockCongratulationsServiceMockRecorder) SendVerifications(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerifications", reflect.TypeOf((*MockCongratulationsService)(nil).SendVerifications), ctx)
+}
+
+// SetRole mocks base method.
+func (m *MockCongratulationsService) SetRole(ctx context.Context, userID uint32, role string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", ctx, userID, role)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockCongratulationsServiceMockRecorder) SetRole(ctx, userID, role interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockCongratulationsService)(nil).Register), ctx, username, password, email, birth)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockCongratulationsService)(nil).SetRole), ctx, userID, role)
 }
 
 // StartAlert mocks base method.
-func (m *MockCongratulationsService) StartAlert(ctx context.Context, timeStart time.Time, period time.Duration, wg *sync.WaitGroup) {
+func (m *MockCongratulationsService) StartAlert(ctx context.Context, schedule *cron.Schedule, wg *sync.WaitGroup) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "StartAlert", ctx, timeStart, period, wg)
+	m.ctrl.Call(m, "StartAlert", ctx, schedule, wg)
 }
 
 // StartAlert indicates an expected call of StartAlert.
-func (mr *MockCongratulationsServiceMockRecorder) StartAlert(ctx, timeStart, period, wg interface{}) *gomock.Call {
+func (mr *MockCongratulationsServiceMockRecorder) StartAlert(ctx, schedule, wg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartAlert", reflect.TypeOf((*MockCongratulationsService)(nil).StartAlert), ctx, schedule, wg)
+}
+
+// StartDirectorySync mocks base method.
+func (m *MockCongratulationsService) StartDirectorySync(ctx context.Context, source ldap.Source, interval time.Duration, wg *sync.WaitGroup) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StartDirectorySync", ctx, source, interval, wg)
+}
+
+// StartDirectorySync indicates an expected call of StartDirectorySync.
+func (mr *MockCongratulationsServiceMockRecorder) StartDirectorySync(ctx, source, interval, wg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartAlert", reflect.TypeOf((*MockCongratulationsService)(nil).StartAlert), ctx, timeStart, period, wg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartDirectorySync", reflect.TypeOf((*MockCongratulationsService)(nil).StartDirectorySync), ctx, source, interval, wg)
 }
 
 // Subscribe mocks base method.
@@ -123,6 +505,21 @@ func (mr *MockCongratulationsServiceMockRecorder) Subscribe(ctx, subscriptionID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockCongratulationsService)(nil).Subscribe), ctx, subscriptionID, daysAlert)
 }
 
+// SyncDirectory mocks base method.
+func (m *MockCongratulationsService) SyncDirectory(ctx context.Context, people []ldap.Person) (*SyncReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncDirectory", ctx, people)
+	ret0, _ := ret[0].(*SyncReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncDirectory indicates an expected call of SyncDirectory.
+func (mr *MockCongratulationsServiceMockRecorder) SyncDirectory(ctx, people interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncDirectory", reflect.TypeOf((*MockCongratulationsService)(nil).SyncDirectory), ctx, people)
+}
+
 // Unsubscribe mocks base method.
 func (m *MockCongratulationsService) Unsubscribe(ctx context.Context, subscriptionID uint32) error {
 	m.ctrl.T.Helper()
@@ -136,3 +533,75 @@ func (mr *MockCongratulationsServiceMockRecorder) Unsubscribe(ctx, subscriptionI
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockCongratulationsService)(nil).Unsubscribe), ctx, subscriptionID)
 }
+
+// UpdatePrivacy mocks base method.
+func (m *MockCongratulationsService) UpdatePrivacy(ctx context.Context, p user.Privacy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePrivacy", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePrivacy indicates an expected call of UpdatePrivacy.
+func (mr *MockCongratulationsServiceMockRecorder) UpdatePrivacy(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePrivacy", reflect.TypeOf((*MockCongratulationsService)(nil).UpdatePrivacy), ctx, p)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockCongratulationsService) UpdateProfile(ctx context.Context, upd ProfileUpdate) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, upd)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockCongratulationsServiceMockRecorder) UpdateProfile(ctx, upd interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockCongratulationsService)(nil).UpdateProfile), ctx, upd)
+}
+
+// UpdateSubscription mocks base method.
+func (m *MockCongratulationsService) UpdateSubscription(ctx context.Context, subscriptionID uint32, daysAlert []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSubscription", ctx, subscriptionID, daysAlert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSubscription indicates an expected call of UpdateSubscription.
+func (mr *MockCongratulationsServiceMockRecorder) UpdateSubscription(ctx, subscriptionID, daysAlert interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSubscription", reflect.TypeOf((*MockCongratulationsService)(nil).UpdateSubscription), ctx, subscriptionID, daysAlert)
+}
+
+// UpdateUser mocks base method.
+func (m *MockCongratulationsService) UpdateUser(ctx context.Context, u *user.User) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, u)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockCongratulationsServiceMockRecorder) UpdateUser(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockCongratulationsService)(nil).UpdateUser), ctx, u)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockCongratulationsService) VerifyEmail(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockCongratulationsServiceMockRecorder) VerifyEmail(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockCongratulationsService)(nil).VerifyEmail), ctx, token)
+}
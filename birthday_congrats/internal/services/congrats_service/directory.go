@@ -0,0 +1,205 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"time"
+)
+
+// randomPasswordBytes - энтропия пароля, который ставится пользователям, созданным без пароля
+const randomPasswordBytes = 32
+
+// validateUser проверяет и нормализует поля, которые приходят из внешних систем
+func (cs *CongratulationsServiceImpl) validateUser(u *user.User) error {
+	if u.Timezone == "" {
+		u.Timezone = user.DefaultTimezone
+	}
+
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		cs.logger.Warnf("Bad timezone %q: %v", u.Timezone, err)
+		return user.ErrBadTimezone
+	}
+
+	err = u.Birthday.Validate(cs.now().In(loc))
+	if err == birthday.ErrBadBirthday {
+		cs.logger.Warnf("Bad birthday %s", u.Birthday)
+		return ErrBadDateFormat
+	}
+	if err != nil {
+		cs.logger.Warnf("Bad birthday %s: %v", u.Birthday, err)
+		return err
+	}
+
+	return nil
+}
+
+func (cs *CongratulationsServiceImpl) CreateUser(ctx context.Context, u *user.User, password string) (*user.User, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.createUser(ctx, u, password)
+}
+
+func (cs *CongratulationsServiceImpl) createUser(ctx context.Context, u *user.User, password string) (*user.User, error) {
+	err := cs.validateUser(u)
+	if err != nil {
+		return nil, err
+	}
+
+	// пароля, который никто не знает, все равно что нет
+	if password == "" {
+		password, err = session.NewToken(randomPasswordBytes)
+		if err != nil {
+			cs.logger.Errorf("Error while generating password: %v", err)
+			return nil, fmt.Errorf("internal error")
+		}
+	}
+
+	newUser, err := cs.usersRepo.Create(ctx, u.Username, password, u.Email, u.Timezone, u.Birthday)
+	if err != nil && err != user.ErrUserExists {
+		cs.logger.Errorf("Error while creating user: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+	if err == user.ErrUserExists {
+		cs.logger.Warnf("User already exists")
+		return nil, err
+	}
+
+	// Create заводит активного пользователя без внешнего идентификатора, отдела и с неподтвержденной почтой
+	if u.ExternalID == "" && !u.Deactivated && !u.EmailVerified && u.Department == "" {
+		return newUser, nil
+	}
+
+	newUser.ExternalID = u.ExternalID
+	newUser.Deactivated = u.Deactivated
+	newUser.EmailVerified = u.EmailVerified
+	newUser.Department = u.Department
+
+	err = cs.usersRepo.Update(ctx, newUser)
+	if err != nil {
+		cs.logger.Errorf("Error while updating user: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	return newUser, nil
+}
+
+func (cs *CongratulationsServiceImpl) GetUser(ctx context.Context, userID uint32) (*user.User, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.getUser(ctx, userID)
+}
+
+func (cs *CongratulationsServiceImpl) getUser(ctx context.Context, userID uint32) (*user.User, error) {
+	us, err := cs.usersRepo.GetByID(ctx, userID)
+	if err != nil && err != user.ErrNoUser {
+		cs.logger.Errorf("Error getting user by id: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+	if err == user.ErrNoUser {
+		return nil, err
+	}
+
+	return us, nil
+}
+
+func (cs *CongratulationsServiceImpl) ListUsers(ctx context.Context) ([]*user.User, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := cs.usersRepo.GetAll(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error while getting all users: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	return users, nil
+}
+
+func (cs *CongratulationsServiceImpl) UpdateUser(ctx context.Context, u *user.User) (*user.User, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.updateUser(ctx, u)
+}
+
+func (cs *CongratulationsServiceImpl) updateUser(ctx context.Context, u *user.User) (*user.User, error) {
+	// MySQL-хранилище не сообщает об отсутствии пользователя при обновлении
+	_, err := cs.getUser(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cs.validateUser(u)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cs.usersRepo.Update(ctx, u)
+	if err != nil && err != user.ErrUserExists {
+		cs.logger.Errorf("Error while updating user: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+	if err == user.ErrUserExists {
+		cs.logger.Warnf("Username %q is taken", u.Username)
+		return nil, err
+	}
+
+	// повторно тоже: если в прошлый раз удалить подписки не получилось, дочищаем сейчас
+	if u.Deactivated {
+		err = cs.offboard(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return u, nil
+}
+
+func (cs *CongratulationsServiceImpl) DeactivateUser(ctx context.Context, userID uint32) (*user.User, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	us, err := cs.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	us.Deactivated = true
+
+	return cs.updateUser(ctx, us)
+}
+
+// offboard удаляет подписки уволенного сотрудника и подписки на него, завершает его сессии
+func (cs *CongratulationsServiceImpl) offboard(ctx context.Context, userID uint32) error {
+	err := cs.subscriptionsRepo.RemoveByUser(ctx, userID)
+	if err != nil {
+		cs.logger.Errorf("Error removing subscriptions of user %d: %v", userID, err)
+		return fmt.Errorf("internal error")
+	}
+
+	err = cs.sm.DestroyAll(ctx, userID)
+	if err != nil {
+		cs.logger.Errorf("Error destroying sessions of user %d: %v", userID, err)
+		return fmt.Errorf("internal error")
+	}
+
+	cs.logger.Infof("User %d was deactivated", userID)
+
+	return nil
+}
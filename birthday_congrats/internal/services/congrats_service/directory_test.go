@@ -0,0 +1,364 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newDirectoryTestService(ctrl *gomock.Controller) (
+	*CongratulationsServiceImpl,
+	*user.MockUsersRepo,
+	*subscription.MockSubscriptionsRepo,
+	*session.MockSessionsManager,
+) {
+	usersRepo := user.NewMockUsersRepo(ctrl)
+	subscriptionsRepo := subscription.NewMockSubscriptionsRepo(ctrl)
+	sessManager := session.NewMockSessionsManager(ctrl)
+
+	testService := NewCongratulationsServiceImpl(
+		usersRepo,
+		subscriptionsRepo,
+		sessManager,
+		outbox.NewMockOutbox(ctrl),
+		delivery.NewMockDeliveriesRepo(ctrl),
+		reset.NewMockResetsRepo(ctrl),
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
+		zap.NewNop().Sugar(),
+	)
+
+	return testService, usersRepo, subscriptionsRepo, sessManager
+}
+
+func TestCreateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, _ := newDirectoryTestService(ctrl)
+
+	// запросы HR-системы по токену SCIM
+	ctx := session.ContextAsSystem(context.Background())
+
+	// данные для теста
+	newUser := func() *user.User {
+		return &user.User{
+			Username: "some_user",
+			Email:    "some@email.net",
+			Birthday: birthday.Birthday{Year: 2000, Month: time.February, Day: 29},
+		}
+	}
+	created := &user.User{
+		ID:       7,
+		Username: "some_user",
+		Email:    "some@email.net",
+		Timezone: user.DefaultTimezone,
+		Birthday: birthday.Birthday{Year: 2000, Month: time.February, Day: 29},
+	}
+
+	// нормальная работа: пустой часовой пояс - UTC
+	usersRepo.EXPECT().Create(ctx, "some_user", "some_pass", "some@email.net", user.DefaultTimezone, birthday.Birthday{Year: 2000, Month: time.February, Day: 29}).Return(created, nil)
+
+	userRecv, err := testService.CreateUser(ctx, newUser(), "some_pass")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, created, userRecv)
+
+	// без пароля ставится случайный
+	usersRepo.EXPECT().Create(ctx, "some_user", gomock.Not(""), "some@email.net", user.DefaultTimezone, birthday.Birthday{Year: 2000, Month: time.February, Day: 29}).Return(created, nil)
+
+	_, err = testService.CreateUser(ctx, newUser(), "")
+
+	assert.NoError(t, err)
+
+	// внешний идентификатор и статус записываются отдельным обновлением
+	u := newUser()
+	u.ExternalID = "hr-7"
+	u.Deactivated = true
+
+	usersRepo.EXPECT().Create(ctx, "some_user", "some_pass", "some@email.net", user.DefaultTimezone, birthday.Birthday{Year: 2000, Month: time.February, Day: 29}).
+		Return(&user.User{ID: 7, Username: "some_user"}, nil)
+	usersRepo.EXPECT().Update(ctx, &user.User{ID: 7, Username: "some_user", ExternalID: "hr-7", Deactivated: true}).Return(nil)
+
+	userRecv, err = testService.CreateUser(ctx, u, "some_pass")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, "hr-7", userRecv.ExternalID)
+
+	// почта из HR-системы считается подтвержденной
+	u = newUser()
+	u.EmailVerified = true
+
+	usersRepo.EXPECT().Create(ctx, "some_user", "some_pass", "some@email.net", user.DefaultTimezone, birthday.Birthday{Year: 2000, Month: time.February, Day: 29}).
+		Return(&user.User{ID: 7, Username: "some_user"}, nil)
+	usersRepo.EXPECT().Update(ctx, &user.User{ID: 7, Username: "some_user", EmailVerified: true}).Return(nil)
+
+	userRecv, err = testService.CreateUser(ctx, u, "some_pass")
+
+	assert.NoError(t, err)
+	assert.True(t, userRecv.EmailVerified)
+
+	u = newUser()
+	u.ExternalID = "hr-7"
+	u.Deactivated = true
+
+	// ошибка при обновлении
+	usersRepo.EXPECT().Create(ctx, "some_user", "some_pass", "some@email.net", user.DefaultTimezone, birthday.Birthday{Year: 2000, Month: time.February, Day: 29}).
+		Return(&user.User{ID: 7, Username: "some_user"}, nil)
+	usersRepo.EXPECT().Update(ctx, gomock.Any()).Return(fmt.Errorf("repo error"))
+
+	_, err = testService.CreateUser(ctx, u, "some_pass")
+
+	assert.Error(t, err)
+
+	// имя занято
+	usersRepo.EXPECT().Create(ctx, "some_user", "some_pass", "some@email.net", user.DefaultTimezone, birthday.Birthday{Year: 2000, Month: time.February, Day: 29}).Return(nil, user.ErrUserExists)
+
+	_, err = testService.CreateUser(ctx, newUser(), "some_pass")
+
+	assert.ErrorIs(t, err, user.ErrUserExists)
+
+	// ошибка хранилища
+	usersRepo.EXPECT().Create(ctx, "some_user", "some_pass", "some@email.net", user.DefaultTimezone, birthday.Birthday{Year: 2000, Month: time.February, Day: 29}).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.CreateUser(ctx, newUser(), "some_pass")
+
+	assert.Error(t, err)
+
+	// неизвестный часовой пояс
+	u = newUser()
+	u.Timezone = "Mars/Olympus_Mons"
+
+	_, err = testService.CreateUser(ctx, u, "some_pass")
+
+	assert.ErrorIs(t, err, user.ErrBadTimezone)
+
+	// несуществующая дата
+	u = newUser()
+	u.Birthday.Year = 2001
+
+	_, err = testService.CreateUser(ctx, u, "some_pass")
+
+	assert.ErrorIs(t, err, ErrBadDateFormat)
+
+	u = newUser()
+	u.Birthday = birthday.Birthday{}
+
+	_, err = testService.CreateUser(ctx, u, "some_pass")
+
+	assert.ErrorIs(t, err, ErrBadDateFormat)
+
+	// дата в будущем
+	u = newUser()
+	u.Birthday.Year = 2096
+
+	_, err = testService.CreateUser(ctx, u, "some_pass")
+
+	assert.ErrorIs(t, err, birthday.ErrBirthdayInFuture)
+
+	// слишком давно
+	u = newUser()
+	u.Birthday.Year = 1804
+
+	_, err = testService.CreateUser(ctx, u, "some_pass")
+
+	assert.ErrorIs(t, err, birthday.ErrBadAge)
+}
+
+func TestGetUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, _ := newDirectoryTestService(ctrl)
+
+	// запросы HR-системы по токену SCIM
+	ctx := session.ContextAsSystem(context.Background())
+
+	// нормальная работа
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(&user.User{ID: 7}, nil)
+
+	userRecv, err := testService.GetUser(ctx, 7)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 7, userRecv.ID)
+
+	// пользователя нет
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(nil, user.ErrNoUser)
+
+	_, err = testService.GetUser(ctx, 7)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// ошибка хранилища
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.GetUser(ctx, 7)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, user.ErrNoUser)
+}
+
+func TestListUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, _ := newDirectoryTestService(ctrl)
+
+	// запросы HR-системы по токену SCIM
+	ctx := session.ContextAsSystem(context.Background())
+
+	// уволенные тоже возвращаются
+	usersSent := []*user.User{{ID: 1}, {ID: 2, Deactivated: true}}
+
+	usersRepo.EXPECT().GetAll(ctx).Return(usersSent, nil)
+
+	usersRecv, err := testService.ListUsers(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, usersSent, usersRecv)
+
+	// ошибка хранилища
+	usersRepo.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.ListUsers(ctx)
+
+	assert.Error(t, err)
+}
+
+func TestUpdateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, subscriptionsRepo, sessManager := newDirectoryTestService(ctrl)
+
+	// запросы HR-системы по токену SCIM
+	ctx := session.ContextAsSystem(context.Background())
+
+	// данные для теста
+	stored := &user.User{ID: 7, Username: "some_user", Timezone: "UTC", Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 2}}
+	updated := func() *user.User {
+		return &user.User{ID: 7, Username: "new_name", Timezone: "Asia/Tokyo", Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 3}}
+	}
+
+	// нормальная работа
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(stored, nil)
+	usersRepo.EXPECT().Update(ctx, updated()).Return(nil)
+
+	userRecv, err := testService.UpdateUser(ctx, updated())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, updated(), userRecv)
+
+	// увольнение: удаляются подписки и сессии
+	u := updated()
+	u.Deactivated = true
+
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(stored, nil)
+	usersRepo.EXPECT().Update(ctx, u).Return(nil)
+	subscriptionsRepo.EXPECT().RemoveByUser(ctx, uint32(7)).Return(nil)
+	sessManager.EXPECT().DestroyAll(ctx, uint32(7)).Return(nil)
+
+	_, err = testService.UpdateUser(ctx, u)
+
+	assert.NoError(t, err)
+
+	// ошибка удаления подписок
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(stored, nil)
+	usersRepo.EXPECT().Update(ctx, u).Return(nil)
+	subscriptionsRepo.EXPECT().RemoveByUser(ctx, uint32(7)).Return(fmt.Errorf("repo error"))
+
+	_, err = testService.UpdateUser(ctx, u)
+
+	assert.Error(t, err)
+
+	// ошибка завершения сессий
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(stored, nil)
+	usersRepo.EXPECT().Update(ctx, u).Return(nil)
+	subscriptionsRepo.EXPECT().RemoveByUser(ctx, uint32(7)).Return(nil)
+	sessManager.EXPECT().DestroyAll(ctx, uint32(7)).Return(fmt.Errorf("sessions error"))
+
+	_, err = testService.UpdateUser(ctx, u)
+
+	assert.Error(t, err)
+
+	// пользователя нет
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(nil, user.ErrNoUser)
+
+	_, err = testService.UpdateUser(ctx, updated())
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// имя занято
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(stored, nil)
+	usersRepo.EXPECT().Update(ctx, updated()).Return(user.ErrUserExists)
+
+	_, err = testService.UpdateUser(ctx, updated())
+
+	assert.ErrorIs(t, err, user.ErrUserExists)
+
+	// ошибка хранилища
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(stored, nil)
+	usersRepo.EXPECT().Update(ctx, updated()).Return(fmt.Errorf("repo error"))
+
+	_, err = testService.UpdateUser(ctx, updated())
+
+	assert.Error(t, err)
+
+	// неизвестный часовой пояс
+	u = updated()
+	u.Timezone = "Mars/Olympus_Mons"
+
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(stored, nil)
+
+	_, err = testService.UpdateUser(ctx, u)
+
+	assert.ErrorIs(t, err, user.ErrBadTimezone)
+}
+
+func TestDeactivateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, subscriptionsRepo, sessManager := newDirectoryTestService(ctrl)
+
+	// запросы HR-системы по токену SCIM
+	ctx := session.ContextAsSystem(context.Background())
+
+	// нормальная работа
+	deactivated := &user.User{ID: 7, Timezone: "UTC", Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 2}, Deactivated: true}
+
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(&user.User{ID: 7, Timezone: "UTC", Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 2}}, nil)
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(&user.User{ID: 7, Timezone: "UTC", Birthday: birthday.Birthday{Year: 2000, Month: time.January, Day: 2}}, nil)
+	usersRepo.EXPECT().Update(ctx, deactivated).Return(nil)
+	subscriptionsRepo.EXPECT().RemoveByUser(ctx, uint32(7)).Return(nil)
+	sessManager.EXPECT().DestroyAll(ctx, uint32(7)).Return(nil)
+
+	userRecv, err := testService.DeactivateUser(ctx, 7)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, deactivated, userRecv)
+
+	// пользователя нет
+	usersRepo.EXPECT().GetByID(ctx, uint32(7)).Return(nil, user.ErrNoUser)
+
+	_, err = testService.DeactivateUser(ctx, 7)
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+}
@@ -0,0 +1,269 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/roster"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Что импорт сделал (или сделает при пробном прогоне) с записью
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportFailed    = "failed"
+)
+
+var (
+	ErrDuplicateEmail = errors.New("email repeats in import")
+	ErrAmbiguousEmail = errors.New("email belongs to several users")
+)
+
+// ImportResult - итог импорта одной записи
+type ImportResult struct {
+	Line   int
+	Email  string
+	Action string
+	UserID uint32 // 0 для новых сотрудников при пробном прогоне
+	Err    error  // почему запись не импортирована (Action == ImportFailed)
+}
+
+// ImportReport - отчет импорта. При пробном прогоне ничего не меняется, но отчет тот же.
+type ImportReport struct {
+	DryRun    bool
+	Results   []ImportResult
+	Created   int
+	Updated   int
+	Unchanged int
+	Failed    int
+}
+
+// importIndex - пользователи, с которыми сверяется выгрузка. Обновляется по ходу импорта,
+// чтобы записи файла проверялись и друг с другом.
+type importIndex struct {
+	byEmail    map[string][]*user.User // почта в нижнем регистре
+	byUsername map[string]*user.User
+	seen       map[string]bool // почты, уже встретившиеся в файле
+}
+
+// ImportUsers заводит и обновляет сотрудников по выгрузке HR. Сотрудник ищется по почте:
+// у найденного обновляются имя, день рождения и отдел, остальным заводится учетная запись
+// и отправляется приглашение задать пароль. Ошибочные записи пропускаются и попадают в отчет.
+func (cs *CongratulationsServiceImpl) ImportUsers(ctx context.Context, records []roster.Record, dryRun bool) (*ImportReport, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := cs.usersRepo.GetAll(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error while getting all users: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	index := &importIndex{
+		byEmail:    make(map[string][]*user.User, len(users)),
+		byUsername: make(map[string]*user.User, len(users)),
+		seen:       make(map[string]bool, len(records)),
+	}
+	for _, u := range users {
+		email := strings.ToLower(u.Email)
+		index.byEmail[email] = append(index.byEmail[email], u)
+		index.byUsername[u.Username] = u
+	}
+
+	report := &ImportReport{
+		DryRun:  dryRun,
+		Results: make([]ImportResult, 0, len(records)),
+	}
+
+	for _, rec := range records {
+		// ошибка хранилища прерывает импорт; уже примененные записи при повторе окажутся неизмененными
+		res, err := cs.importRecord(ctx, index, rec, dryRun)
+		if err != nil {
+			return nil, err
+		}
+
+		switch res.Action {
+		case ImportCreated:
+			report.Created++
+		case ImportUpdated:
+			report.Updated++
+		case ImportUnchanged:
+			report.Unchanged++
+		case ImportFailed:
+			report.Failed++
+		}
+
+		report.Results = append(report.Results, res)
+	}
+
+	cs.logger.Infof("Imported %d records (dry run: %v): %d created, %d updated, %d unchanged, %d failed",
+		len(records), dryRun, report.Created, report.Updated, report.Unchanged, report.Failed)
+
+	return report, nil
+}
+
+// importRecord импортирует одну запись. Ошибки записи возвращаются в результате,
+// а ошибкой - только сбои хранилища.
+func (cs *CongratulationsServiceImpl) importRecord(ctx context.Context, index *importIndex, rec roster.Record, dryRun bool) (ImportResult, error) {
+	res := ImportResult{
+		Line:  rec.Line,
+		Email: rec.Email,
+	}
+	fail := func(err error) (ImportResult, error) {
+		cs.logger.Warnf("Import record %d (%q) failed: %v", rec.Line, rec.Email, err)
+		res.Action = ImportFailed
+		res.Err = err
+		return res, nil
+	}
+
+	if rec.Name == "" {
+		return fail(ErrEmptyUsername)
+	}
+
+	addr, err := mail.ParseAddress(rec.Email)
+	if err != nil || addr.Address != rec.Email {
+		return fail(ErrBadEmail)
+	}
+
+	birth, err := birthday.Parse(rec.Birthday)
+	if err != nil {
+		return fail(ErrBadDateFormat)
+	}
+
+	email := strings.ToLower(rec.Email)
+	if index.seen[email] {
+		return fail(ErrDuplicateEmail)
+	}
+	index.seen[email] = true
+
+	existing := index.byEmail[email]
+	if len(existing) > 1 {
+		return fail(ErrAmbiguousEmail)
+	}
+
+	if owner, ok := index.byUsername[rec.Name]; ok && (len(existing) == 0 || owner != existing[0]) {
+		return fail(user.ErrUserExists)
+	}
+
+	if len(existing) == 0 {
+		return cs.importCreate(ctx, index, rec, birth, dryRun, fail)
+	}
+
+	us := existing[0]
+
+	// при регистрации почту можно указать чужую: без подтверждения учетная запись может
+	// принадлежать не сотруднику из выгрузки
+	if !us.EmailVerified {
+		return fail(ErrUnverifiedEmail)
+	}
+
+	res.UserID = us.ID
+
+	upd := *us
+	upd.Username = rec.Name
+	upd.Birthday = birth
+	upd.Department = rec.Department
+
+	changed := make([]string, 0, 3)
+	if upd.Username != us.Username {
+		changed = append(changed, audit.FieldUsername)
+	}
+	if upd.Birthday != us.Birthday {
+		changed = append(changed, audit.FieldBirthday)
+	}
+	if upd.Department != us.Department {
+		changed = append(changed, audit.FieldDepartment)
+	}
+
+	if len(changed) == 0 {
+		res.Action = ImportUnchanged
+		return res, nil
+	}
+
+	err = cs.validateUser(&upd)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !dryRun {
+		err = cs.usersRepo.Update(ctx, &upd)
+		if err != nil && err != user.ErrUserExists {
+			cs.logger.Errorf("Error while updating user: %v", err)
+			return res, fmt.Errorf("internal error")
+		}
+		if err == user.ErrUserExists {
+			return fail(err)
+		}
+
+		cs.recordChanges(ctx, actorID(ctx), us.ID, changed)
+	}
+
+	delete(index.byUsername, us.Username)
+	index.byUsername[upd.Username] = us
+	*us = upd
+
+	res.Action = ImportUpdated
+	return res, nil
+}
+
+// importCreate заводит нового сотрудника и приглашает его задать пароль
+func (cs *CongratulationsServiceImpl) importCreate(
+	ctx context.Context,
+	index *importIndex,
+	rec roster.Record,
+	birth birthday.Birthday,
+	dryRun bool,
+	fail func(err error) (ImportResult, error),
+) (ImportResult, error) {
+	res := ImportResult{
+		Line:   rec.Line,
+		Email:  rec.Email,
+		Action: ImportCreated,
+	}
+
+	u := &user.User{
+		Username:   rec.Name,
+		Email:      rec.Email,
+		Birthday:   birth,
+		Department: rec.Department,
+	}
+
+	err := cs.validateUser(u)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !dryRun {
+		// пароль сотрудник задаст сам по ссылке из приглашения
+		newUser, err := cs.createUser(ctx, u, "")
+		if err == user.ErrUserExists {
+			return fail(err)
+		}
+		if err != nil {
+			return res, err
+		}
+
+		// не получилось - не страшно: сотрудник может сбросить пароль сам
+		err = cs.sendPasswordLink(ctx, newUser, cs.inviteTTL, "Приглашение", inviteText)
+		if err != nil {
+			cs.logger.Errorf("Error while sending invitation to user %d: %v", newUser.ID, err)
+		}
+
+		u = newUser
+		res.UserID = newUser.ID
+	}
+
+	index.byEmail[strings.ToLower(u.Email)] = []*user.User{u}
+	index.byUsername[u.Username] = u
+
+	return res, nil
+}
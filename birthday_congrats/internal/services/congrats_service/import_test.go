@@ -0,0 +1,235 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/roster"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type importTestRepos struct {
+	users         *user.MockUsersRepo
+	subscriptions *subscription.MockSubscriptionsRepo
+	sessions      *session.MockSessionsManager
+	outbox        *outbox.MockOutbox
+	resets        *reset.MockResetsRepo
+	audit         *audit.MockAuditRepo
+}
+
+func newImportTestService(ctrl *gomock.Controller) (*CongratulationsServiceImpl, *importTestRepos) {
+	repos := &importTestRepos{
+		users:         user.NewMockUsersRepo(ctrl),
+		subscriptions: subscription.NewMockSubscriptionsRepo(ctrl),
+		sessions:      session.NewMockSessionsManager(ctrl),
+		outbox:        outbox.NewMockOutbox(ctrl),
+		resets:        reset.NewMockResetsRepo(ctrl),
+		audit:         audit.NewMockAuditRepo(ctrl),
+	}
+
+	testService := NewCongratulationsServiceImpl(
+		repos.users,
+		repos.subscriptions,
+		repos.sessions,
+		repos.outbox,
+		delivery.NewMockDeliveriesRepo(ctrl),
+		repos.resets,
+		repos.audit,
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
+		zap.NewNop().Sugar(),
+	)
+
+	return testService, repos
+}
+
+func TestImportUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newImportTestService(ctrl)
+
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	ctx := session.ContextAsSystem(context.Background())
+
+	// данные для теста: импорт меняет пользователей из хранилища, поэтому каждый раз новые
+	users := func() []*user.User {
+		return []*user.User{
+			{ID: 1, Username: "alice", Email: "alice@example.com", EmailVerified: true, Timezone: user.DefaultTimezone, Birthday: birthday.Birthday{Year: 1990, Month: time.May, Day: 10}},
+			{ID: 2, Username: "bob", Email: "Bob@Example.com", EmailVerified: true, Timezone: user.DefaultTimezone, Birthday: birthday.Birthday{Month: time.July, Day: 1}, Department: "Склад"},
+			{ID: 3, Username: "twin1", Email: "twins@example.com", Timezone: user.DefaultTimezone},
+			{ID: 4, Username: "twin2", Email: "twins@example.com", Timezone: user.DefaultTimezone},
+			{ID: 6, Username: "judy", Email: "judy@example.com", Timezone: user.DefaultTimezone},
+		}
+	}
+	records := []roster.Record{
+		{Line: 2, Name: "alice", Email: "alice@example.com", Birthday: "1990-05-10", Department: "Бухгалтерия"},
+		{Line: 3, Name: "bob", Email: "bob@example.com", Birthday: "--07-01", Department: "Склад"},
+		{Line: 4, Name: "carol", Email: "carol@example.com", Birthday: "1995-01-20", Department: "Склад"},
+		{Line: 5, Name: "alice2", Email: "ALICE@example.com", Birthday: "1990-05-10"},
+		{Line: 6, Name: "dave", Email: "dave at example.com", Birthday: "1990-05-10"},
+		{Line: 7, Name: "erin", Email: "erin@example.com", Birthday: "10.05.1990"},
+		{Line: 8, Name: "twin", Email: "twins@example.com", Birthday: "1990-05-10"},
+		{Line: 9, Name: "bob", Email: "frank@example.com", Birthday: "1990-05-10"},
+		{Line: 10, Name: "", Email: "grace@example.com", Birthday: "1990-05-10"},
+		{Line: 11, Name: "carol", Email: "heidi@example.com", Birthday: "1990-05-10"},
+		{Line: 12, Name: "ivan", Email: "ivan@example.com", Birthday: "2030-01-01"},
+		{Line: 13, Name: "judy", Email: "judy@example.com", Birthday: "1990-05-10"},
+	}
+	failed := []ImportResult{
+		{Line: 5, Email: "ALICE@example.com", Action: ImportFailed, Err: ErrDuplicateEmail},
+		{Line: 6, Email: "dave at example.com", Action: ImportFailed, Err: ErrBadEmail},
+		{Line: 7, Email: "erin@example.com", Action: ImportFailed, Err: ErrBadDateFormat},
+		{Line: 8, Email: "twins@example.com", Action: ImportFailed, Err: ErrAmbiguousEmail},
+		{Line: 9, Email: "frank@example.com", Action: ImportFailed, Err: user.ErrUserExists},
+		{Line: 10, Email: "grace@example.com", Action: ImportFailed, Err: ErrEmptyUsername},
+		{Line: 11, Email: "heidi@example.com", Action: ImportFailed, Err: user.ErrUserExists},
+		{Line: 12, Email: "ivan@example.com", Action: ImportFailed, Err: birthday.ErrBirthdayInFuture},
+		{Line: 13, Email: "judy@example.com", Action: ImportFailed, Err: ErrUnverifiedEmail},
+	}
+
+	// пробный прогон: отчет полный, но ничего не пишется
+	repos.users.EXPECT().GetAll(ctx).Return(users(), nil)
+
+	report, err := testService.ImportUsers(ctx, records, true)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ImportReport{
+		DryRun: true,
+		Results: append([]ImportResult{
+			{Line: 2, Email: "alice@example.com", Action: ImportUpdated, UserID: 1},
+			{Line: 3, Email: "bob@example.com", Action: ImportUnchanged, UserID: 2},
+			{Line: 4, Email: "carol@example.com", Action: ImportCreated},
+		}, failed...),
+		Created:   1,
+		Updated:   1,
+		Unchanged: 1,
+		Failed:    9,
+	}, report)
+
+	// нормальная работа: отдел новому сотруднику записывается отдельным обновлением
+	body := ""
+
+	repos.users.EXPECT().GetAll(ctx).Return(users(), nil)
+	repos.users.EXPECT().Update(ctx, &user.User{
+		ID:            1,
+		Username:      "alice",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Timezone:      user.DefaultTimezone,
+		Birthday:      birthday.Birthday{Year: 1990, Month: time.May, Day: 10},
+		Department:    "Бухгалтерия",
+	}).Return(nil)
+	repos.audit.EXPECT().Record(ctx, []audit.Entry{{ActorID: 0, UserID: 1, Field: audit.FieldDepartment, At: now}}).Return(nil)
+	repos.users.EXPECT().Create(ctx, "carol", gomock.Not(""), "carol@example.com", user.DefaultTimezone, birthday.Birthday{Year: 1995, Month: time.January, Day: 20}).
+		Return(&user.User{ID: 5, Username: "carol", Email: "carol@example.com", Timezone: user.DefaultTimezone}, nil)
+	repos.users.EXPECT().Update(ctx, &user.User{ID: 5, Username: "carol", Email: "carol@example.com", Timezone: user.DefaultTimezone, Department: "Склад"}).Return(nil)
+	repos.resets.EXPECT().RemoveByUser(ctx, uint32(5)).Return(nil)
+	repos.resets.EXPECT().Create(ctx, uint32(5), gomock.Any(), now.Add(24*time.Hour).Unix()).Return(nil)
+	repos.outbox.EXPECT().Enqueue(ctx, []string{"carol@example.com"}, "Приглашение", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ []string, _, text string) error {
+			body = text
+			return nil
+		})
+
+	report, err = testService.ImportUsers(ctx, records, false)
+
+	assert.NoError(t, err)
+	assert.False(t, report.DryRun)
+	assert.EqualValues(t, 1, report.Created)
+	assert.EqualValues(t, 1, report.Updated)
+	assert.EqualValues(t, 9, report.Failed)
+	assert.EqualValues(t, ImportResult{Line: 4, Email: "carol@example.com", Action: ImportCreated, UserID: 5}, report.Results[2])
+	assert.True(t, strings.HasPrefix(body, "carol, "))
+	assert.Contains(t, body, testBaseURL+"/reset?token=")
+
+	// смена имени пишется в журнал, старое имя освобождается
+	repos.users.EXPECT().GetAll(ctx).Return(users(), nil)
+	repos.users.EXPECT().Update(ctx, gomock.Any()).Return(nil).Times(2)
+	repos.audit.EXPECT().Record(ctx, []audit.Entry{{ActorID: 0, UserID: 1, Field: audit.FieldUsername, At: now}}).Return(nil)
+	repos.audit.EXPECT().Record(ctx, []audit.Entry{{ActorID: 0, UserID: 2, Field: audit.FieldUsername, At: now}}).Return(nil)
+
+	report, err = testService.ImportUsers(ctx, []roster.Record{
+		{Line: 2, Name: "alice_s", Email: "alice@example.com", Birthday: "1990-05-10"},
+		{Line: 3, Name: "alice", Email: "bob@example.com", Birthday: "--07-01", Department: "Склад"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, report.Updated)
+
+	// приглашение не отправилось - сотрудник все равно заведен
+	repos.users.EXPECT().GetAll(ctx).Return(users(), nil)
+	repos.users.EXPECT().Create(ctx, "carol", gomock.Any(), "carol@example.com", user.DefaultTimezone, gomock.Any()).
+		Return(&user.User{ID: 5, Username: "carol", Email: "carol@example.com"}, nil)
+	repos.resets.EXPECT().RemoveByUser(ctx, uint32(5)).Return(fmt.Errorf("repo error"))
+
+	report, err = testService.ImportUsers(ctx, []roster.Record{
+		{Line: 2, Name: "carol", Email: "carol@example.com", Birthday: "1995-01-20"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, report.Created)
+
+	// имя заняли между чтением и записью
+	repos.users.EXPECT().GetAll(ctx).Return(users(), nil)
+	repos.users.EXPECT().Create(ctx, "carol", gomock.Any(), "carol@example.com", user.DefaultTimezone, gomock.Any()).Return(nil, user.ErrUserExists)
+
+	report, err = testService.ImportUsers(ctx, []roster.Record{
+		{Line: 2, Name: "carol", Email: "carol@example.com", Birthday: "1995-01-20"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, user.ErrUserExists, report.Results[0].Err)
+
+	// ошибка бд при создании
+	repos.users.EXPECT().GetAll(ctx).Return(users(), nil)
+	repos.users.EXPECT().Create(ctx, "carol", gomock.Any(), "carol@example.com", user.DefaultTimezone, gomock.Any()).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.ImportUsers(ctx, []roster.Record{
+		{Line: 2, Name: "carol", Email: "carol@example.com", Birthday: "1995-01-20"},
+	}, false)
+
+	assert.Error(t, err)
+
+	// ошибка бд при обновлении
+	repos.users.EXPECT().GetAll(ctx).Return(users(), nil)
+	repos.users.EXPECT().Update(ctx, gomock.Any()).Return(fmt.Errorf("repo error"))
+
+	_, err = testService.ImportUsers(ctx, records[:1], false)
+
+	assert.Error(t, err)
+
+	// ошибка бд при чтении пользователей
+	repos.users.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.ImportUsers(ctx, records, true)
+
+	assert.Error(t, err)
+
+	// не администратор
+	employeeCtx := session.ContextWithSession(context.Background(), &session.Session{SessID: "some_sess_id", UserID: 2})
+
+	repos.users.EXPECT().GetByID(employeeCtx, uint32(2)).Return(&user.User{ID: 2, Role: user.RoleEmployee}, nil)
+
+	_, err = testService.ImportUsers(employeeCtx, records, true)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
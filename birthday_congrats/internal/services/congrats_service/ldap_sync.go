@@ -0,0 +1,308 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/ldap"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ldapIDPrefix - внешний идентификатор сотрудников из каталога: префикс и идентификатор записи.
+// Синхронизация увольняет только таких сотрудников, заведенных вручную или через SCIM не трогает.
+const ldapIDPrefix = "ldap:"
+
+// что синхронизация сделала с сотрудником
+const (
+	syncCreated     = "created"
+	syncUpdated     = "updated"
+	syncDeactivated = "deactivated" // каталог пометил сотрудника уволенным
+	syncUnchanged   = "unchanged"
+)
+
+var (
+	ErrEmptyDirectory  = errors.New("directory returned no people")
+	ErrLinkedElsewhere = errors.New("user is linked to another external account")
+)
+
+// SyncReport - итог синхронизации с каталогом
+type SyncReport struct {
+	Created     int
+	Updated     int
+	Deactivated int // в том числе ушедшие из каталога
+	Unchanged   int
+	Failed      int
+}
+
+// syncIndex - пользователи, с которыми сверяется каталог
+type syncIndex struct {
+	byExternalID map[string]*user.User
+	byEmail      map[string][]*user.User // почта в нижнем регистре
+	byUsername   map[string]*user.User
+	seen         map[uint32]bool // пользователи, найденные в каталоге
+}
+
+// StartDirectorySync раз в interval забирает сотрудников из каталога и синхронизирует их
+func (cs *CongratulationsServiceImpl) StartDirectorySync(ctx context.Context, source ldap.Source, interval time.Duration, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	cs.logger.Infof("Starting directory sync")
+
+	// синхронизацию выполняет сама система, а не чья-то сессия
+	ctx = session.ContextAsSystem(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cs.syncDirectory(ctx, source)
+
+		select {
+		case <-ctx.Done():
+			cs.logger.Infof("Directory sync was stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cs *CongratulationsServiceImpl) syncDirectory(ctx context.Context, source ldap.Source) {
+	people, err := source.People(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error while getting people from directory: %v", err)
+		return
+	}
+
+	_, err = cs.SyncDirectory(ctx, people)
+	if err != nil {
+		cs.logger.Errorf("Error while syncing directory: %v", err)
+	}
+}
+
+// SyncDirectory приводит сотрудников в соответствие с каталогом. Сотрудник ищется по
+// идентификатору записи, а при первой синхронизации - по почте. Найденным обновляются имя,
+// почта, день рождения, отдел и статус, новым заводится учетная запись и отправляется
+// приглашение задать пароль. Ушедшие из каталога увольняются, их подписки удаляются.
+func (cs *CongratulationsServiceImpl) SyncDirectory(ctx context.Context, people []ldap.Person) (*SyncReport, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// пустой каталог скорее означает ошибку в настройках, чем увольнение всех сразу
+	if len(people) == 0 {
+		cs.logger.Warnf("Directory returned no people, nothing synced")
+		return nil, ErrEmptyDirectory
+	}
+
+	users, err := cs.usersRepo.GetAll(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error while getting all users: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	index := &syncIndex{
+		byExternalID: make(map[string]*user.User, len(users)),
+		byEmail:      make(map[string][]*user.User, len(users)),
+		byUsername:   make(map[string]*user.User, len(users)),
+		seen:         make(map[uint32]bool, len(people)),
+	}
+	for _, u := range users {
+		if u.ExternalID != "" {
+			index.byExternalID[u.ExternalID] = u
+		}
+
+		email := strings.ToLower(u.Email)
+		index.byEmail[email] = append(index.byEmail[email], u)
+		index.byUsername[u.Username] = u
+	}
+
+	report := &SyncReport{}
+
+	for _, p := range people {
+		// ошибка одной записи не мешает остальным: следующая синхронизация попробует снова
+		action, err := cs.syncPerson(ctx, index, p)
+		if err != nil {
+			cs.logger.Warnf("Sync of directory entry %q (%q) failed: %v", p.ID, p.Email, err)
+			report.Failed++
+			continue
+		}
+
+		switch action {
+		case syncCreated:
+			report.Created++
+		case syncUpdated:
+			report.Updated++
+		case syncUnchanged:
+			report.Unchanged++
+		case syncDeactivated:
+			report.Deactivated++
+		}
+	}
+
+	for _, u := range users {
+		if !strings.HasPrefix(u.ExternalID, ldapIDPrefix) || index.seen[u.ID] || u.Deactivated {
+			continue
+		}
+
+		upd := *u
+		upd.Deactivated = true
+
+		_, err = cs.updateUser(ctx, &upd)
+		if err != nil {
+			cs.logger.Warnf("Deactivation of user %d, who left directory, failed: %v", u.ID, err)
+			report.Failed++
+			continue
+		}
+
+		report.Deactivated++
+	}
+
+	cs.logger.Infof("Synced %d people from directory: %d created, %d updated, %d deactivated, %d unchanged, %d failed",
+		len(people), report.Created, report.Updated, report.Deactivated, report.Unchanged, report.Failed)
+
+	return report, nil
+}
+
+// syncPerson синхронизирует одного сотрудника и возвращает, что с ним сделано
+func (cs *CongratulationsServiceImpl) syncPerson(ctx context.Context, index *syncIndex, p ldap.Person) (string, error) {
+	externalID := ldapIDPrefix + p.ID
+	email := strings.ToLower(p.Email)
+
+	us := index.byExternalID[externalID]
+	if us == nil {
+		matches := index.byEmail[email]
+		if len(matches) > 1 {
+			return "", ErrAmbiguousEmail
+		}
+		if len(matches) == 1 {
+			us = matches[0]
+		}
+	}
+
+	// найден - значит, не ушел, даже если запись не удастся применить
+	if us != nil {
+		index.seen[us.ID] = true
+
+		if us.ExternalID != externalID && us.ExternalID != "" {
+			return "", ErrLinkedElsewhere
+		}
+
+		// при регистрации почту можно указать чужую: без подтверждения учетная запись может
+		// принадлежать не сотруднику из каталога
+		if us.ExternalID == "" && !us.EmailVerified {
+			return "", ErrUnverifiedEmail
+		}
+	}
+
+	if p.Name == "" {
+		return "", ErrEmptyUsername
+	}
+
+	addr, err := mail.ParseAddress(p.Email)
+	if err != nil || addr.Address != p.Email {
+		return "", ErrBadEmail
+	}
+
+	birth, err := birthday.Parse(p.Birthday)
+	if err != nil {
+		return "", ErrBadDateFormat
+	}
+
+	if owner, ok := index.byUsername[p.Name]; ok && owner != us {
+		return "", user.ErrUserExists
+	}
+
+	if us == nil {
+		return cs.syncCreate(ctx, index, p, externalID, birth)
+	}
+
+	upd := *us
+	upd.ExternalID = externalID
+	upd.Username = p.Name
+	upd.Email = p.Email
+	upd.Birthday = birth
+	upd.Department = p.Department
+	upd.Deactivated = !p.Active
+
+	changed := make([]string, 0, 4)
+	if upd.Username != us.Username {
+		changed = append(changed, audit.FieldUsername)
+	}
+	if upd.Email != us.Email {
+		// каталогу доверяем: подтверждать почту из него не нужно
+		upd.EmailVerified = true
+		changed = append(changed, audit.FieldEmail)
+	}
+	if upd.Birthday != us.Birthday {
+		changed = append(changed, audit.FieldBirthday)
+	}
+	if upd.Department != us.Department {
+		changed = append(changed, audit.FieldDepartment)
+	}
+
+	if len(changed) == 0 && upd.ExternalID == us.ExternalID && upd.Deactivated == us.Deactivated {
+		return syncUnchanged, nil
+	}
+
+	_, err = cs.updateUser(ctx, &upd)
+	if err != nil {
+		return "", err
+	}
+
+	if len(changed) > 0 {
+		cs.recordChanges(ctx, actorID(ctx), us.ID, changed)
+	}
+
+	action := syncUpdated
+	if upd.Deactivated && !us.Deactivated {
+		action = syncDeactivated
+	}
+
+	delete(index.byUsername, us.Username)
+	index.byUsername[upd.Username] = us
+	*us = upd
+
+	return action, nil
+}
+
+// syncCreate заводит сотрудника, появившегося в каталоге, и приглашает его задать пароль
+func (cs *CongratulationsServiceImpl) syncCreate(ctx context.Context, index *syncIndex, p ldap.Person, externalID string, birth birthday.Birthday) (string, error) {
+	// уволенных, которых у нас никогда не было, заводить незачем
+	if !p.Active {
+		return syncUnchanged, nil
+	}
+
+	newUser, err := cs.CreateUser(ctx, &user.User{
+		Username:      p.Name,
+		Email:         p.Email,
+		EmailVerified: true,
+		Birthday:      birth,
+		ExternalID:    externalID,
+		Department:    p.Department,
+	}, "")
+	if err != nil {
+		return "", err
+	}
+
+	// не получилось - не страшно: сотрудник может сбросить пароль сам
+	err = cs.sendPasswordLink(ctx, newUser, cs.inviteTTL, "Приглашение", inviteText)
+	if err != nil {
+		cs.logger.Errorf("Error while sending invitation to user %d: %v", newUser.ID, err)
+	}
+
+	index.seen[newUser.ID] = true
+	index.byExternalID[externalID] = newUser
+	index.byEmail[strings.ToLower(newUser.Email)] = []*user.User{newUser}
+	index.byUsername[newUser.Username] = newUser
+
+	return syncCreated, nil
+}
@@ -0,0 +1,210 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/ldap"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncDirectory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newImportTestService(ctrl)
+
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	ctx := session.ContextAsSystem(context.Background())
+
+	// данные для теста: синхронизация меняет пользователей из хранилища, поэтому каждый раз новые
+	born := birthday.Birthday{Year: 1990, Month: time.May, Day: 10}
+	users := func() []*user.User {
+		return []*user.User{
+			{ID: 1, Username: "alice", Email: "alice@example.com", Timezone: user.DefaultTimezone, Birthday: born, ExternalID: "ldap:alice"},
+			{ID: 2, Username: "bob", Email: "bob@example.com", EmailVerified: true, Timezone: user.DefaultTimezone, Birthday: born},
+			{ID: 3, Username: "carol", Email: "carol@example.com", Timezone: user.DefaultTimezone, Birthday: born, ExternalID: "ldap:carol"},
+			{ID: 4, Username: "dave", Email: "dave@example.com", Timezone: user.DefaultTimezone, Birthday: born, ExternalID: "scim-4"},
+			{ID: 5, Username: "erin", Email: "erin@example.com", Timezone: user.DefaultTimezone, Birthday: born, ExternalID: "ldap:erin", Deactivated: true},
+			{ID: 6, Username: "frank", Email: "frank@example.com", Timezone: user.DefaultTimezone, Birthday: born, ExternalID: "ldap:frank"},
+		}
+	}
+	people := []ldap.Person{
+		{ID: "alice", Name: "alice", Email: "alice@example.com", Birthday: "1990-05-10", Department: "Бухгалтерия", Active: true},
+		{ID: "bob", Name: "bob", Email: "bob@example.com", Birthday: "1990-05-10", Active: true},
+		{ID: "frank", Name: "frank", Email: "frank@example.com", Birthday: "1990-05-10", Active: false},
+		{ID: "grace", Name: "grace", Email: "grace@example.com", Birthday: "1995-01-20", Department: "Склад", Active: true},
+		{ID: "heidi", Name: "heidi", Email: "heidi@example.com", Birthday: "1990-05-10", Active: false},
+		{ID: "nameless", Email: "nameless@example.com", Birthday: "1990-05-10", Active: true},
+		{ID: "dave", Name: "dave", Email: "dave@example.com", Birthday: "1990-05-10", Active: true},
+	}
+
+	// нормальная работа: bob узнается по почте, carol ушла из каталога, frank уволен в каталоге,
+	// dave заведен через SCIM и синхронизацией не трогается
+	repos.users.EXPECT().GetAll(ctx).Return(users(), nil)
+	repos.users.EXPECT().GetByID(ctx, gomock.Any()).Return(&user.User{}, nil).Times(4)
+
+	repos.users.EXPECT().Update(ctx, &user.User{
+		ID:         1,
+		Username:   "alice",
+		Email:      "alice@example.com",
+		Timezone:   user.DefaultTimezone,
+		Birthday:   born,
+		ExternalID: "ldap:alice",
+		Department: "Бухгалтерия",
+	}).Return(nil)
+	repos.audit.EXPECT().Record(ctx, []audit.Entry{{ActorID: 0, UserID: 1, Field: audit.FieldDepartment, At: now}}).Return(nil)
+
+	repos.users.EXPECT().Update(ctx, &user.User{ID: 2, Username: "bob", Email: "bob@example.com", EmailVerified: true, Timezone: user.DefaultTimezone, Birthday: born, ExternalID: "ldap:bob"}).Return(nil)
+
+	for _, deactivated := range []*user.User{users()[2], users()[5]} {
+		deactivated.Deactivated = true
+
+		repos.users.EXPECT().Update(ctx, deactivated).Return(nil)
+		repos.subscriptions.EXPECT().RemoveByUser(ctx, deactivated.ID).Return(nil)
+		repos.sessions.EXPECT().DestroyAll(ctx, deactivated.ID).Return(nil)
+	}
+
+	graceBirthday := birthday.Birthday{Year: 1995, Month: time.January, Day: 20}
+	repos.users.EXPECT().Create(ctx, "grace", gomock.Not(""), "grace@example.com", user.DefaultTimezone, graceBirthday).
+		Return(&user.User{ID: 7, Username: "grace", Email: "grace@example.com", Timezone: user.DefaultTimezone, Birthday: graceBirthday}, nil)
+	repos.users.EXPECT().Update(ctx, &user.User{
+		ID:            7,
+		Username:      "grace",
+		Email:         "grace@example.com",
+		EmailVerified: true,
+		Timezone:      user.DefaultTimezone,
+		Birthday:      graceBirthday,
+		ExternalID:    "ldap:grace",
+		Department:    "Склад",
+	}).Return(nil)
+	repos.resets.EXPECT().RemoveByUser(ctx, uint32(7)).Return(nil)
+	repos.resets.EXPECT().Create(ctx, uint32(7), gomock.Any(), now.Add(24*time.Hour).Unix()).Return(nil)
+	repos.outbox.EXPECT().Enqueue(ctx, []string{"grace@example.com"}, "Приглашение", gomock.Any()).Return(nil)
+
+	report, err := testService.SyncDirectory(ctx, people)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &SyncReport{
+		Created:     1,
+		Updated:     2,
+		Deactivated: 2,
+		Unchanged:   1,
+		Failed:      2,
+	}, report)
+
+	// смена почты в каталоге: подтверждать ее не нужно
+	repos.users.EXPECT().GetAll(ctx).Return(users()[:1], nil)
+	repos.users.EXPECT().GetByID(ctx, uint32(1)).Return(&user.User{}, nil)
+	repos.users.EXPECT().Update(ctx, &user.User{
+		ID:            1,
+		Username:      "alice",
+		Email:         "alice.smith@example.com",
+		EmailVerified: true,
+		Timezone:      user.DefaultTimezone,
+		Birthday:      born,
+		ExternalID:    "ldap:alice",
+	}).Return(nil)
+	repos.audit.EXPECT().Record(ctx, []audit.Entry{{ActorID: 0, UserID: 1, Field: audit.FieldEmail, At: now}}).Return(nil)
+
+	report, err = testService.SyncDirectory(ctx, []ldap.Person{
+		{ID: "alice", Name: "alice", Email: "alice.smith@example.com", Birthday: "1990-05-10", Active: true},
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, report.Updated)
+
+	// почта не подтверждена: учетная запись может принадлежать не сотруднику из каталога
+	unverified := users()[1]
+	unverified.EmailVerified = false
+
+	repos.users.EXPECT().GetAll(ctx).Return([]*user.User{unverified}, nil)
+
+	report, err = testService.SyncDirectory(ctx, people[1:2])
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &SyncReport{Failed: 1}, report)
+
+	// ничего не изменилось
+	repos.users.EXPECT().GetAll(ctx).Return(users()[:1], nil)
+
+	report, err = testService.SyncDirectory(ctx, []ldap.Person{
+		{ID: "alice", Name: "alice", Email: "alice@example.com", Birthday: "1990-05-10", Active: true},
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &SyncReport{Unchanged: 1}, report)
+
+	// не удалось удалить подписки ушедшего - он попадает в отчет ошибкой, как и dave
+	repos.users.EXPECT().GetAll(ctx).Return(users()[2:4], nil)
+	repos.users.EXPECT().GetByID(ctx, uint32(3)).Return(&user.User{}, nil)
+	repos.users.EXPECT().Update(ctx, &user.User{ID: 3, Username: "carol", Email: "carol@example.com", Timezone: user.DefaultTimezone, Birthday: born, ExternalID: "ldap:carol", Deactivated: true}).Return(nil)
+	repos.subscriptions.EXPECT().RemoveByUser(ctx, uint32(3)).Return(fmt.Errorf("repo error"))
+
+	report, err = testService.SyncDirectory(ctx, people[6:])
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &SyncReport{Failed: 2}, report)
+
+	// пустой каталог - никого не увольняем
+	_, err = testService.SyncDirectory(ctx, nil)
+
+	assert.ErrorIs(t, err, ErrEmptyDirectory)
+
+	// ошибка бд при чтении пользователей
+	repos.users.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.SyncDirectory(ctx, people)
+
+	assert.Error(t, err)
+
+	// не администратор
+	employeeCtx := session.ContextWithSession(context.Background(), &session.Session{SessID: "some_sess_id", UserID: 2})
+
+	repos.users.EXPECT().GetByID(employeeCtx, uint32(2)).Return(&user.User{ID: 2, Role: user.RoleEmployee}, nil)
+
+	_, err = testService.SyncDirectory(employeeCtx, people)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestStartDirectorySync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newImportTestService(ctrl)
+	source := ldap.NewMockSource(ctrl)
+
+	// данные для теста: остановлена сразу, успевает один проход
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// нормальная работа: синхронизация идет от имени системы
+	source.EXPECT().People(gomock.Any()).Return([]ldap.Person{{ID: "alice", Name: "alice", Email: "alice@example.com", Birthday: "1990-05-10", Active: true}}, nil)
+	repos.users.EXPECT().GetAll(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]*user.User, error) {
+		assert.True(t, session.IsSystem(ctx))
+		return []*user.User{{ID: 1, Username: "alice", Email: "alice@example.com", Timezone: user.DefaultTimezone, Birthday: birthday.Birthday{Year: 1990, Month: time.May, Day: 10}, ExternalID: "ldap:alice"}}, nil
+	})
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	testService.StartDirectorySync(ctx, source, time.Hour, wg)
+	wg.Wait()
+
+	// каталог недоступен - ошибка только логируется
+	source.EXPECT().People(gomock.Any()).Return(nil, fmt.Errorf("ldap error"))
+
+	wg.Add(1)
+	testService.StartDirectorySync(ctx, source, time.Hour, wg)
+	wg.Wait()
+}
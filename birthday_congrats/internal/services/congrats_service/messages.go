@@ -0,0 +1,38 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/delivery"
+	"fmt"
+)
+
+// reminder - одно письмо с напоминанием. keys[i] - запись журнала отправленных
+// напоминаний для получателя recipients[i].
+type reminder struct {
+	text       string
+	recipients []string
+	keys       []delivery.Key
+}
+
+func reminderText(username string, daysBefore int) string {
+	if daysBefore == 0 {
+		return fmt.Sprintf("%s сегодня празднует свой день рождения!", username)
+	}
+
+	return fmt.Sprintf("%s празднует свой день рождения через %d дней!", username, daysBefore)
+}
+
+func verificationText(username, link string) string {
+	return fmt.Sprintf("%s, чтобы получать напоминания о днях рождения коллег, подтвердите свою почту: %s", username, link)
+}
+
+func resetText(username, link string) string {
+	return fmt.Sprintf("%s, чтобы задать новый пароль, перейдите по ссылке: %s\nСсылка одноразовая и действует ограниченное время. Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.", username, link)
+}
+
+func inviteText(username, link string) string {
+	return fmt.Sprintf("%s, для вас создана учетная запись в сервисе напоминаний о днях рождения коллег. Чтобы задать пароль, перейдите по ссылке: %s\nСсылка одноразовая и действует ограниченное время.", username, link)
+}
+
+func deletionText(username, link string) string {
+	return fmt.Sprintf("%s, чтобы удалить свой аккаунт в сервисе напоминаний о днях рождения насовсем, перейдите по ссылке: %s\nСсылка действует ограниченное время. Если вы не запрашивали удаление, смените пароль через \"Забыли пароль?\".", username, link)
+}
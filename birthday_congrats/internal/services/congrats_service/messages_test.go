@@ -0,0 +1,12 @@
+package congrats_service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReminderText(t *testing.T) {
+	assert.EqualValues(t, "sasha сегодня празднует свой день рождения!", reminderText("sasha", 0))
+	assert.EqualValues(t, "sasha празднует свой день рождения через 3 дней!", reminderText("sasha", 3))
+}
@@ -0,0 +1,134 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/oidc"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxUsernameAttempts - сколько вариантов имени пробовать новому сотруднику, если имя занято
+const maxUsernameAttempts = 10
+
+var (
+	ErrUnverifiedEmail = errors.New("email is not verified")
+	ErrNoBirthday      = errors.New("identity provider did not send birthday")
+)
+
+// LoginOIDC входит по сведениям от провайдера OpenID Connect. Учетная запись ищется по почте,
+// которую подтвердили и провайдер, и сервис. Если такой нет (первый вход или почта указана только
+// в неподтвержденных учетных записях), она заводится с датой рождения и часовым поясом
+// от провайдера, пароль можно будет задать через сброс.
+func (cs *CongratulationsServiceImpl) LoginOIDC(ctx context.Context, claims *oidc.Claims) (*session.Session, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		cs.logger.Warnf("OIDC user %q has no verified email", claims.Subject)
+		return nil, ErrUnverifiedEmail
+	}
+
+	users, err := cs.usersRepo.GetAll(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error while getting all users: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	// при регистрации почту можно указать чужую: учетная запись с неподтвержденной почтой
+	// может принадлежать не владельцу почты, поэтому такие не учитываются - ни для входа,
+	// ни как помеха заведению новой
+	matches := make([]*user.User, 0, 1)
+	for _, u := range users {
+		if u.EmailVerified && strings.EqualFold(u.Email, claims.Email) {
+			matches = append(matches, u)
+		}
+	}
+
+	var us *user.User
+	switch len(matches) {
+	case 0:
+		us, err = cs.createOIDCUser(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	case 1:
+		us = matches[0]
+	default:
+		cs.logger.Warnf("OIDC user %q matches %d users by email", claims.Subject, len(matches))
+		return nil, ErrAmbiguousEmail
+	}
+
+	if us.Deactivated {
+		cs.logger.Warnf("Deactivated user %d tried to log in via OIDC", us.ID)
+		return nil, user.ErrNoUser
+	}
+
+	sess, err := cs.sm.Create(ctx, us.ID)
+	if err != nil {
+		cs.logger.Errorf("Error while creating session")
+		return nil, fmt.Errorf("internal error")
+	}
+
+	cs.logger.Infof("User %d logged in via OIDC as %q", us.ID, claims.Subject)
+
+	return sess, nil
+}
+
+// createOIDCUser заводит учетную запись при первом входе через провайдера
+func (cs *CongratulationsServiceImpl) createOIDCUser(ctx context.Context, claims *oidc.Claims) (*user.User, error) {
+	// в OpenID Connect неизвестный год обозначается как 0000, у нас - как в ISO 8601: --MM-DD
+	birthdate := claims.Birthdate
+	if rest, ok := strings.CutPrefix(birthdate, "0000-"); ok {
+		birthdate = "--" + rest
+	}
+
+	birth, err := birthday.Parse(birthdate)
+	if err != nil {
+		cs.logger.Warnf("OIDC user %q has bad birthdate %q", claims.Subject, claims.Birthdate)
+		return nil, ErrNoBirthday
+	}
+
+	timezone := claims.Zoneinfo
+	if _, err := time.LoadLocation(timezone); timezone == "" || err != nil {
+		timezone = user.DefaultTimezone
+	}
+
+	base := strings.TrimSpace(claims.PreferredUsername)
+	if base == "" {
+		base = strings.TrimSpace(claims.Name)
+	}
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	for i := 1; i <= maxUsernameAttempts; i++ {
+		username := base
+		if i > 1 {
+			username = fmt.Sprintf("%s%d", base, i)
+		}
+
+		us, err := cs.createUser(ctx, &user.User{
+			Username:      username,
+			Email:         claims.Email,
+			EmailVerified: true,
+			Timezone:      timezone,
+			Birthday:      birth,
+		}, "")
+		if err == user.ErrUserExists {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cs.logger.Infof("User %d was created on first OIDC login of %q", us.ID, claims.Subject)
+
+		return us, nil
+	}
+
+	cs.logger.Warnf("No free username like %q for OIDC user %q", base, claims.Subject)
+	return nil, user.ErrUserExists
+}
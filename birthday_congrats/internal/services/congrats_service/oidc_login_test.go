@@ -0,0 +1,149 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/oidc"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginOIDC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, repos := newAccountTestService(ctrl)
+
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	// данные для теста
+	sess := &session.Session{SessID: "some_sess_id", UserID: 1}
+	claims := &oidc.Claims{
+		Subject:           "42",
+		Email:             "Alice@Example.com",
+		EmailVerified:     true,
+		PreferredUsername: "alice",
+		Birthdate:         "0000-05-10",
+		Zoneinfo:          "Europe/Moscow",
+	}
+	users := []*user.User{
+		{ID: 1, Username: "alice_s", Email: "alice@example.com", EmailVerified: true},
+		{ID: 2, Username: "bob", Email: "bob@example.com"},
+		{ID: 3, Username: "carol", Email: "carol@example.com", EmailVerified: true, Deactivated: true},
+		{ID: 4, Username: "twin1", Email: "twins@example.com", EmailVerified: true},
+		{ID: 5, Username: "twin2", Email: "twins@example.com", EmailVerified: true},
+		{ID: 8, Username: "alice_fake", Email: "ALICE@example.com"},
+	}
+
+	// нормальная работа: почта сравнивается без учета регистра,
+	// учетная запись с той же неподтвержденной почтой не мешает
+	repos.users.EXPECT().GetAll(ctx).Return(users, nil)
+	repos.sessions.EXPECT().Create(ctx, uint32(1)).Return(sess, nil)
+
+	result, err := testService.LoginOIDC(ctx, claims)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, sess, result)
+
+	// первый вход: имя занято, берется следующее
+	newUser := &user.User{ID: 6, Username: "alice2", Email: "alice@example.org", Timezone: "Europe/Moscow", Birthday: birthday.Birthday{Month: time.May, Day: 10}}
+
+	repos.users.EXPECT().GetAll(ctx).Return(users, nil)
+	repos.users.EXPECT().Create(ctx, "alice", gomock.Not(""), "alice@example.org", "Europe/Moscow", newUser.Birthday).Return(nil, user.ErrUserExists)
+	repos.users.EXPECT().Create(ctx, "alice2", gomock.Not(""), "alice@example.org", "Europe/Moscow", newUser.Birthday).Return(newUser, nil)
+	repos.users.EXPECT().Update(ctx, &user.User{
+		ID:            6,
+		Username:      "alice2",
+		Email:         "alice@example.org",
+		EmailVerified: true,
+		Timezone:      "Europe/Moscow",
+		Birthday:      newUser.Birthday,
+	}).Return(nil)
+	repos.sessions.EXPECT().Create(ctx, uint32(6)).Return(sess, nil)
+
+	_, err = testService.LoginOIDC(ctx, &oidc.Claims{
+		Subject:           "43",
+		Email:             "alice@example.org",
+		EmailVerified:     true,
+		PreferredUsername: "alice",
+		Birthdate:         "0000-05-10",
+		Zoneinfo:          "Europe/Moscow",
+	})
+
+	assert.NoError(t, err)
+
+	// первый вход без имени и часового пояса: имя из почты, пояс по умолчанию
+	repos.users.EXPECT().GetAll(ctx).Return(users, nil)
+	repos.users.EXPECT().Create(ctx, "dave", gomock.Any(), "dave@example.com", user.DefaultTimezone, birthday.Birthday{Year: 1990, Month: time.May, Day: 10}).
+		Return(&user.User{ID: 7}, nil)
+	repos.users.EXPECT().Update(ctx, gomock.Any()).Return(nil)
+	repos.sessions.EXPECT().Create(ctx, uint32(7)).Return(sess, nil)
+
+	_, err = testService.LoginOIDC(ctx, &oidc.Claims{Subject: "44", Email: "dave@example.com", EmailVerified: true, Birthdate: "1990-05-10", Zoneinfo: "Mars/Olympus"})
+
+	assert.NoError(t, err)
+
+	// провайдер не передал дату рождения
+	repos.users.EXPECT().GetAll(ctx).Return(users, nil)
+
+	_, err = testService.LoginOIDC(ctx, &oidc.Claims{Subject: "45", Email: "erin@example.com", EmailVerified: true})
+
+	assert.ErrorIs(t, err, ErrNoBirthday)
+
+	// провайдер не подтвердил почту
+	_, err = testService.LoginOIDC(ctx, &oidc.Claims{Subject: "42", Email: "alice@example.com"})
+
+	assert.ErrorIs(t, err, ErrUnverifiedEmail)
+
+	// почта есть только у неподтвержденной учетной записи: в нее не входят, заводится новая
+	bobBirthday := birthday.Birthday{Year: 1990, Month: time.May, Day: 10}
+
+	repos.users.EXPECT().GetAll(ctx).Return(users, nil)
+	repos.users.EXPECT().Create(ctx, "bob", gomock.Any(), "bob@example.com", user.DefaultTimezone, bobBirthday).Return(nil, user.ErrUserExists)
+	repos.users.EXPECT().Create(ctx, "bob2", gomock.Any(), "bob@example.com", user.DefaultTimezone, bobBirthday).
+		Return(&user.User{ID: 9}, nil)
+	repos.users.EXPECT().Update(ctx, gomock.Any()).Return(nil)
+	repos.sessions.EXPECT().Create(ctx, uint32(9)).Return(sess, nil)
+
+	_, err = testService.LoginOIDC(ctx, &oidc.Claims{Subject: "46", Email: "bob@example.com", EmailVerified: true, PreferredUsername: "bob", Birthdate: "1990-05-10"})
+
+	assert.NoError(t, err)
+
+	// уволенный
+	repos.users.EXPECT().GetAll(ctx).Return(users, nil)
+
+	_, err = testService.LoginOIDC(ctx, &oidc.Claims{Subject: "47", Email: "carol@example.com", EmailVerified: true})
+
+	assert.ErrorIs(t, err, user.ErrNoUser)
+
+	// почта у нескольких пользователей
+	repos.users.EXPECT().GetAll(ctx).Return(users, nil)
+
+	_, err = testService.LoginOIDC(ctx, &oidc.Claims{Subject: "48", Email: "twins@example.com", EmailVerified: true})
+
+	assert.ErrorIs(t, err, ErrAmbiguousEmail)
+
+	// ошибка создания сессии
+	repos.users.EXPECT().GetAll(ctx).Return(users, nil)
+	repos.sessions.EXPECT().Create(ctx, uint32(1)).Return(nil, fmt.Errorf("session error"))
+
+	_, err = testService.LoginOIDC(ctx, claims)
+
+	assert.Error(t, err)
+
+	// ошибка бд
+	repos.users.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.LoginOIDC(ctx, claims)
+
+	assert.Error(t, err)
+}
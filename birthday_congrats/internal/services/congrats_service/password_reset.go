@@ -0,0 +1,134 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	resetTokenBytes = 32
+)
+
+// RequestPasswordReset отправляет пользователю username одноразовую ссылку сброса пароля
+// на подтвержденную почту. Ответ не зависит от того, есть ли такой пользователь: иначе по нему можно перебирать имена.
+func (cs *CongratulationsServiceImpl) RequestPasswordReset(ctx context.Context, username string) error {
+	us, err := cs.usersRepo.GetByUsername(ctx, username)
+	if err != nil && err != user.ErrNoUser {
+		cs.logger.Errorf("Error getting user by username: %v", err)
+		return fmt.Errorf("internal error")
+	}
+	if err == user.ErrNoUser || us.Deactivated {
+		cs.logger.Warnf("Password reset requested for unknown or deactivated user %q", username)
+		return nil
+	}
+
+	// ссылка дает доступ к учетной записи, а неподтвержденную почту мог указать кто угодно,
+	// например завладев чужой сессией
+	if !us.EmailVerified {
+		cs.logger.Warnf("Password reset requested for user %d with unverified email", us.ID)
+		return nil
+	}
+
+	err = cs.sendPasswordLink(ctx, us, cs.resetTTL, "Сброс пароля", resetText)
+	if err != nil {
+		return err
+	}
+
+	cs.logger.Infof("Password reset requested for user %d", us.ID)
+
+	return nil
+}
+
+// sendPasswordLink отправляет пользователю одноразовую ссылку на страницу установки пароля.
+// Ссылка действует ttl, прежние ссылки пользователя перестают действовать.
+func (cs *CongratulationsServiceImpl) sendPasswordLink(ctx context.Context, us *user.User, ttl time.Duration, subject string, text func(username, link string) string) error {
+	// действует только последняя ссылка
+	err := cs.resets.RemoveByUser(ctx, us.ID)
+	if err != nil {
+		cs.logger.Errorf("Error removing reset tokens: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	token, err := session.NewToken(resetTokenBytes)
+	if err != nil {
+		cs.logger.Errorf("Error generating reset token: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	err = cs.resets.Create(ctx, us.ID, session.HashToken(token), cs.now().Add(ttl).Unix())
+	if err != nil {
+		cs.logger.Errorf("Error saving reset token: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	link := cs.baseURL + "/reset?token=" + url.QueryEscape(token)
+
+	err = cs.outbox.Enqueue(ctx, []string{us.Email}, subject, text(us.Username, link))
+	if err != nil {
+		cs.logger.Errorf("Error while sending password email: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	return nil
+}
+
+func (cs *CongratulationsServiceImpl) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if newPassword == "" {
+		return ErrEmptyPassword
+	}
+
+	userID, err := cs.resets.Use(ctx, session.HashToken(token), cs.now().Unix())
+	if err != nil && err != reset.ErrNoToken {
+		cs.logger.Errorf("Error using reset token: %v", err)
+		return fmt.Errorf("internal error")
+	}
+	if err == reset.ErrNoToken {
+		cs.logger.Warnf("Bad password reset token")
+		return ErrBadResetToken
+	}
+
+	us, err := cs.usersRepo.GetByID(ctx, userID)
+	if err != nil && err != user.ErrNoUser {
+		cs.logger.Errorf("Error getting user by id: %v", err)
+		return fmt.Errorf("internal error")
+	}
+	if err == user.ErrNoUser || us.Deactivated {
+		cs.logger.Warnf("Password reset for unknown or deactivated user %d", userID)
+		return ErrBadResetToken
+	}
+
+	err = cs.usersRepo.SetPassword(ctx, userID, newPassword)
+	if err != nil {
+		cs.logger.Errorf("Error setting password: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	// тот, кто знал старый пароль, не должен остаться в системе
+	err = cs.sm.DestroyAll(ctx, userID)
+	if err != nil {
+		cs.logger.Errorf("Error destroying sessions of user %d: %v", userID, err)
+		return fmt.Errorf("internal error")
+	}
+
+	// сброс по запросу уходит только на подтвержденную почту, а ссылки, выданные до смены
+	// почты в профиле, отзываются: неподтвержденной здесь бывает лишь почта из приглашения,
+	// и раз ссылка из него дошла, почта рабочая
+	if !us.EmailVerified {
+		us.EmailVerified = true
+
+		err = cs.usersRepo.Update(ctx, us)
+		if err != nil {
+			cs.logger.Errorf("Error updating user: %v", err)
+			return fmt.Errorf("internal error")
+		}
+	}
+
+	cs.logger.Infof("User %d reset password", userID)
+
+	return nil
+}
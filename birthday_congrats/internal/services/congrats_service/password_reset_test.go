@@ -0,0 +1,231 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newResetTestService(ctrl *gomock.Controller) (
+	*CongratulationsServiceImpl,
+	*user.MockUsersRepo,
+	*session.MockSessionsManager,
+	*outbox.MockOutbox,
+	*reset.MockResetsRepo,
+) {
+	usersRepo := user.NewMockUsersRepo(ctrl)
+	sessManager := session.NewMockSessionsManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	resetsRepo := reset.NewMockResetsRepo(ctrl)
+
+	testService := NewCongratulationsServiceImpl(
+		usersRepo,
+		subscription.NewMockSubscriptionsRepo(ctrl),
+		sessManager,
+		outboxRepo,
+		delivery.NewMockDeliveriesRepo(ctrl),
+		resetsRepo,
+		audit.NewMockAuditRepo(ctrl),
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
+		zap.NewNop().Sugar(),
+	)
+
+	return testService, usersRepo, sessManager, outboxRepo, resetsRepo
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, outboxRepo, resetsRepo := newResetTestService(ctrl)
+
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	// данные для теста
+	us := &user.User{ID: 42, Username: "some_user", Email: "some@email.net", EmailVerified: true}
+	linkRe := regexp.MustCompile(regexp.QuoteMeta(testBaseURL+"/reset?token=") + `(\S+)`)
+
+	// нормальная работа: в хранилище попадает только хэш токена из письма
+	tokenHash := ""
+	body := ""
+
+	usersRepo.EXPECT().GetByUsername(ctx, us.Username).Return(us, nil)
+	resetsRepo.EXPECT().RemoveByUser(ctx, us.ID).Return(nil)
+	resetsRepo.EXPECT().Create(ctx, us.ID, gomock.Any(), now.Add(time.Hour).Unix()).
+		DoAndReturn(func(_ context.Context, _ uint32, hash string, _ int64) error {
+			tokenHash = hash
+			return nil
+		})
+	outboxRepo.EXPECT().Enqueue(ctx, []string{us.Email}, "Сброс пароля", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ []string, _, text string) error {
+			body = text
+			return nil
+		})
+
+	err := testService.RequestPasswordReset(ctx, us.Username)
+
+	assert.NoError(t, err)
+
+	match := linkRe.FindStringSubmatch(body)
+	if assert.Len(t, match, 2) {
+		token, err := url.QueryUnescape(match[1])
+
+		assert.NoError(t, err)
+		assert.EqualValues(t, session.HashToken(token), tokenHash)
+		assert.NotEqual(t, token, tokenHash)
+	}
+
+	// неизвестный пользователь - ошибки нет, письма тоже
+	usersRepo.EXPECT().GetByUsername(ctx, "unknown").Return(nil, user.ErrNoUser)
+
+	err = testService.RequestPasswordReset(ctx, "unknown")
+
+	assert.NoError(t, err)
+
+	// уволенный пользователь
+	usersRepo.EXPECT().GetByUsername(ctx, us.Username).Return(&user.User{ID: us.ID, Deactivated: true}, nil)
+
+	err = testService.RequestPasswordReset(ctx, us.Username)
+
+	assert.NoError(t, err)
+
+	// почта не подтверждена: ее мог указать не владелец учетной записи, письма нет
+	usersRepo.EXPECT().GetByUsername(ctx, us.Username).Return(&user.User{ID: us.ID, Username: us.Username, Email: "attacker@email.net"}, nil)
+
+	err = testService.RequestPasswordReset(ctx, us.Username)
+
+	assert.NoError(t, err)
+
+	// ошибка хранилища пользователей
+	usersRepo.EXPECT().GetByUsername(ctx, us.Username).Return(nil, fmt.Errorf("repo error"))
+
+	err = testService.RequestPasswordReset(ctx, us.Username)
+
+	assert.Error(t, err)
+
+	// ошибка хранилища токенов
+	usersRepo.EXPECT().GetByUsername(ctx, us.Username).Return(us, nil)
+	resetsRepo.EXPECT().RemoveByUser(ctx, us.ID).Return(nil)
+	resetsRepo.EXPECT().Create(ctx, us.ID, gomock.Any(), gomock.Any()).Return(fmt.Errorf("repo error"))
+
+	err = testService.RequestPasswordReset(ctx, us.Username)
+
+	assert.Error(t, err)
+
+	// ошибка очереди
+	usersRepo.EXPECT().GetByUsername(ctx, us.Username).Return(us, nil)
+	resetsRepo.EXPECT().RemoveByUser(ctx, us.ID).Return(nil)
+	resetsRepo.EXPECT().Create(ctx, us.ID, gomock.Any(), gomock.Any()).Return(nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{us.Email}, "Сброс пароля", gomock.Any()).Return(fmt.Errorf("outbox error"))
+
+	err = testService.RequestPasswordReset(ctx, us.Username)
+
+	assert.Error(t, err)
+}
+
+func TestResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, sessManager, _, resetsRepo := newResetTestService(ctrl)
+
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	// данные для теста
+	userID := uint32(42)
+	token := "some_token"
+	pass := "new_pass"
+
+	// нормальная работа: сессии завершаются, почта считается подтвержденной
+	resetsRepo.EXPECT().Use(ctx, session.HashToken(token), now.Unix()).Return(userID, nil)
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID}, nil)
+	usersRepo.EXPECT().SetPassword(ctx, userID, pass).Return(nil)
+	sessManager.EXPECT().DestroyAll(ctx, userID).Return(nil)
+	usersRepo.EXPECT().Update(ctx, &user.User{ID: userID, EmailVerified: true}).Return(nil)
+
+	err := testService.ResetPassword(ctx, token, pass)
+
+	assert.NoError(t, err)
+
+	// почта уже подтверждена
+	resetsRepo.EXPECT().Use(ctx, session.HashToken(token), now.Unix()).Return(userID, nil)
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID, EmailVerified: true}, nil)
+	usersRepo.EXPECT().SetPassword(ctx, userID, pass).Return(nil)
+	sessManager.EXPECT().DestroyAll(ctx, userID).Return(nil)
+
+	err = testService.ResetPassword(ctx, token, pass)
+
+	assert.NoError(t, err)
+
+	// пустой пароль
+	err = testService.ResetPassword(ctx, token, "")
+
+	assert.ErrorIs(t, err, ErrEmptyPassword)
+
+	// токен неизвестен, использован или устарел
+	resetsRepo.EXPECT().Use(ctx, session.HashToken(token), now.Unix()).Return(uint32(0), reset.ErrNoToken)
+
+	err = testService.ResetPassword(ctx, token, pass)
+
+	assert.ErrorIs(t, err, ErrBadResetToken)
+
+	// пользователя уволили после запроса
+	resetsRepo.EXPECT().Use(ctx, session.HashToken(token), now.Unix()).Return(userID, nil)
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID, Deactivated: true}, nil)
+
+	err = testService.ResetPassword(ctx, token, pass)
+
+	assert.ErrorIs(t, err, ErrBadResetToken)
+
+	// ошибка хранилища токенов
+	resetsRepo.EXPECT().Use(ctx, session.HashToken(token), now.Unix()).Return(uint32(0), fmt.Errorf("repo error"))
+
+	err = testService.ResetPassword(ctx, token, pass)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrBadResetToken)
+
+	// ошибка смены пароля
+	resetsRepo.EXPECT().Use(ctx, session.HashToken(token), now.Unix()).Return(userID, nil)
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID}, nil)
+	usersRepo.EXPECT().SetPassword(ctx, userID, pass).Return(fmt.Errorf("repo error"))
+
+	err = testService.ResetPassword(ctx, token, pass)
+
+	assert.Error(t, err)
+
+	// ошибка завершения сессий
+	resetsRepo.EXPECT().Use(ctx, session.HashToken(token), now.Unix()).Return(userID, nil)
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID}, nil)
+	usersRepo.EXPECT().SetPassword(ctx, userID, pass).Return(nil)
+	sessManager.EXPECT().DestroyAll(ctx, userID).Return(fmt.Errorf("session error"))
+
+	err = testService.ResetPassword(ctx, token, pass)
+
+	assert.Error(t, err)
+}
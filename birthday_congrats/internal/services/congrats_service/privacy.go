@@ -0,0 +1,82 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+)
+
+// hidePrivate убирает из данных коллеги то, что видно только ему самому
+func hidePrivate(u *user.User) {
+	u.Email = ""
+	u.EmailVerified = false
+	if u.Privacy.HideYear {
+		u.Birthday.Year = 0
+	}
+}
+
+// checkSubscribable проверяет, что на пользователя userID можно подписаться
+func (cs *CongratulationsServiceImpl) checkSubscribable(ctx context.Context, userID uint32) error {
+	us, err := cs.usersRepo.GetByID(ctx, userID)
+	if err != nil && err != user.ErrNoUser {
+		cs.logger.Errorf("Error getting user by id: %v", err)
+		return fmt.Errorf("Internal error")
+	}
+	if err == user.ErrNoUser || !us.Subscribable() {
+		cs.logger.Warnf("User %d does not accept subscriptions", userID)
+		return ErrNotSubscribable
+	}
+
+	return nil
+}
+
+func (cs *CongratulationsServiceImpl) GetPrivacy(ctx context.Context) (*user.Privacy, error) {
+	sess, err := session.SessionFromContext(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error getting session from context: %v", err)
+		return nil, session.ErrNoSession
+	}
+
+	us, err := cs.usersRepo.GetByID(ctx, sess.UserID)
+	if err != nil {
+		cs.logger.Errorf("Error getting user by id: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	return &us.Privacy, nil
+}
+
+func (cs *CongratulationsServiceImpl) UpdatePrivacy(ctx context.Context, p user.Privacy) error {
+	sess, err := session.SessionFromContext(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error getting session from context: %v", err)
+		return session.ErrNoSession
+	}
+
+	us, err := cs.usersRepo.GetByID(ctx, sess.UserID)
+	if err != nil {
+		cs.logger.Errorf("Error getting user by id: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	us.Privacy = p
+
+	err = cs.usersRepo.Update(ctx, us)
+	if err != nil {
+		cs.logger.Errorf("Error updating user: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	// подписки на скрывшегося или запретившего подписку удаляются: коллеги их больше
+	// не видят и не могут отменить, а если он снова откроется, подписаться можно заново
+	if !us.Subscribable() {
+		err = cs.subscriptionsRepo.RemoveSubscribers(ctx, us.ID)
+		if err != nil {
+			cs.logger.Errorf("Error removing subscriptions: %v", err)
+			return fmt.Errorf("internal error")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPrivacy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, _ := newDirectoryTestService(ctrl)
+
+	// данные для теста
+	userID := uint32(42)
+	sess := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  userID,
+		Expires: time.Now().Unix() + 60,
+	}
+
+	// нормальная работа
+	ctx := session.ContextWithSession(context.Background(), sess)
+
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID, Privacy: user.Privacy{HideYear: true}}, nil)
+
+	privacy, err := testService.GetPrivacy(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &user.Privacy{HideYear: true}, privacy)
+
+	// нет сессии
+	_, err = testService.GetPrivacy(context.Background())
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка хранилища
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.GetPrivacy(ctx)
+
+	assert.Error(t, err)
+}
+
+func TestUpdatePrivacy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, subscriptionsRepo, _ := newDirectoryTestService(ctrl)
+
+	// данные для теста
+	userID := uint32(42)
+	sess := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  userID,
+		Expires: time.Now().Unix() + 60,
+	}
+	privacy := user.Privacy{HideFromDirectory: true, NotSubscribable: true}
+
+	// нормальная работа: остальные поля пользователя не меняются, подписки на него удаляются
+	ctx := session.ContextWithSession(context.Background(), sess)
+
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID, Username: "some_user", Privacy: user.Privacy{HideYear: true}}, nil)
+	usersRepo.EXPECT().Update(ctx, &user.User{ID: userID, Username: "some_user", Privacy: privacy}).Return(nil)
+	subscriptionsRepo.EXPECT().RemoveSubscribers(ctx, userID).Return(nil)
+
+	err := testService.UpdatePrivacy(ctx, privacy)
+
+	assert.NoError(t, err)
+
+	// скрыт только год - подписки остаются
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID}, nil)
+	usersRepo.EXPECT().Update(ctx, &user.User{ID: userID, Privacy: user.Privacy{HideYear: true}}).Return(nil)
+
+	err = testService.UpdatePrivacy(ctx, user.Privacy{HideYear: true})
+
+	assert.NoError(t, err)
+
+	// скрылся из списка, но подписку не запрещал - на скрытого подписаться все равно нельзя
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID}, nil)
+	usersRepo.EXPECT().Update(ctx, &user.User{ID: userID, Privacy: user.Privacy{HideFromDirectory: true}}).Return(nil)
+	subscriptionsRepo.EXPECT().RemoveSubscribers(ctx, userID).Return(nil)
+
+	err = testService.UpdatePrivacy(ctx, user.Privacy{HideFromDirectory: true})
+
+	assert.NoError(t, err)
+
+	// нет сессии
+	err = testService.UpdatePrivacy(context.Background(), privacy)
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка получения пользователя
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(nil, fmt.Errorf("repo error"))
+
+	err = testService.UpdatePrivacy(ctx, privacy)
+
+	assert.Error(t, err)
+
+	// ошибка сохранения
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID}, nil)
+	usersRepo.EXPECT().Update(ctx, &user.User{ID: userID, Privacy: privacy}).Return(fmt.Errorf("repo error"))
+
+	err = testService.UpdatePrivacy(ctx, privacy)
+
+	assert.Error(t, err)
+
+	// ошибка удаления подписок
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID}, nil)
+	usersRepo.EXPECT().Update(ctx, &user.User{ID: userID, Privacy: privacy}).Return(nil)
+	subscriptionsRepo.EXPECT().RemoveSubscribers(ctx, userID).Return(fmt.Errorf("repo error"))
+
+	err = testService.UpdatePrivacy(ctx, privacy)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"net/mail"
+)
+
+// ProfileUpdate - изменения профиля; nil - поле не меняется
+type ProfileUpdate struct {
+	Username *string
+	Email    *string
+	Birthday *string // YYYY-MM-DD или --MM-DD, чтобы скрыть год
+
+	CurrentPassword string // нужен только для смены почты
+}
+
+// recordChanges записывает в журнал, что actorID изменил поля fields пользователя userID.
+// Изменение к этому моменту уже сохранено, поэтому ошибка журнала только логируется.
+func (cs *CongratulationsServiceImpl) recordChanges(ctx context.Context, actorID, userID uint32, fields []string) {
+	entries := make([]audit.Entry, 0, len(fields))
+	for _, field := range fields {
+		entries = append(entries, audit.Entry{
+			ActorID: actorID,
+			UserID:  userID,
+			Field:   field,
+			At:      cs.now(),
+		})
+	}
+
+	err := cs.audit.Record(ctx, entries)
+	if err != nil {
+		cs.logger.Errorf("Error recording changes %v of user %d by %d: %v", fields, userID, actorID, err)
+	}
+}
+
+// currentUser возвращает пользователя текущей сессии
+func (cs *CongratulationsServiceImpl) currentUser(ctx context.Context) (*user.User, error) {
+	sess, err := session.SessionFromContext(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error getting session from context: %v", err)
+		return nil, session.ErrNoSession
+	}
+
+	us, err := cs.usersRepo.GetByID(ctx, sess.UserID)
+	if err != nil {
+		cs.logger.Errorf("Error getting user by id: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	return us, nil
+}
+
+// checkPassword проверяет текущий пароль пользователя перед чувствительным изменением
+func (cs *CongratulationsServiceImpl) checkPassword(ctx context.Context, userID uint32, username, password string) error {
+	_, err := cs.usersRepo.Login(ctx, username, password)
+	if err != nil && err != user.ErrBadPassword {
+		cs.logger.Errorf("Error while checking password: %v", err)
+		return fmt.Errorf("internal error")
+	}
+	if err == user.ErrBadPassword {
+		cs.logger.Warnf("User %d entered wrong current password", userID)
+		return err
+	}
+
+	return nil
+}
+
+func (cs *CongratulationsServiceImpl) GetProfile(ctx context.Context) (*user.User, error) {
+	return cs.currentUser(ctx)
+}
+
+func (cs *CongratulationsServiceImpl) UpdateProfile(ctx context.Context, upd ProfileUpdate) (*user.User, error) {
+	us, err := cs.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// имя может поменяться ниже, а пароль проверяется по прежнему
+	username := us.Username
+	changed := make([]string, 0, 3)
+	emailChanged := false
+
+	if upd.Username != nil && *upd.Username != us.Username {
+		if *upd.Username == "" {
+			return nil, ErrEmptyUsername
+		}
+
+		us.Username = *upd.Username
+		changed = append(changed, audit.FieldUsername)
+	}
+
+	if upd.Email != nil && *upd.Email != us.Email {
+		addr, err := mail.ParseAddress(*upd.Email)
+		if err != nil || addr.Address != *upd.Email {
+			cs.logger.Warnf("Bad email %q: %v", *upd.Email, err)
+			return nil, ErrBadEmail
+		}
+
+		// через почту восстанавливается пароль: без этой проверки украденная сессия
+		// позволила бы увести аккаунт насовсем, как и смена пароля
+		err = cs.checkPassword(ctx, us.ID, username, upd.CurrentPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		// новую почту нужно подтвердить, до этого напоминания на нее не приходят
+		us.Email = *upd.Email
+		us.EmailVerified = false
+		emailChanged = true
+		changed = append(changed, audit.FieldEmail)
+	}
+
+	if upd.Birthday != nil {
+		birth, err := birthday.Parse(*upd.Birthday)
+		if err != nil {
+			cs.logger.Warnf("Bad birthday %q: %v", *upd.Birthday, err)
+			return nil, ErrBadDateFormat
+		}
+
+		if birth != us.Birthday {
+			// "сегодня" - по календарю пользователя
+			err = birth.Validate(cs.now().In(us.Location()))
+			if err != nil {
+				cs.logger.Warnf("Bad birthday %s: %v", birth, err)
+				return nil, err
+			}
+
+			us.Birthday = birth
+			changed = append(changed, audit.FieldBirthday)
+		}
+	}
+
+	if len(changed) == 0 {
+		return us, nil
+	}
+
+	// ссылки сброса пароля, выданные на прежнюю почту, не должны подтвердить новую
+	if emailChanged {
+		err = cs.resets.RemoveByUser(ctx, us.ID)
+		if err != nil {
+			cs.logger.Errorf("Error removing reset tokens: %v", err)
+			return nil, fmt.Errorf("internal error")
+		}
+	}
+
+	err = cs.usersRepo.Update(ctx, us)
+	if err != nil && err != user.ErrUserExists {
+		cs.logger.Errorf("Error updating user: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+	if err == user.ErrUserExists {
+		cs.logger.Warnf("Username %q is taken", us.Username)
+		return nil, err
+	}
+
+	cs.recordChanges(ctx, us.ID, us.ID, changed)
+
+	if emailChanged {
+		// не получилось - не страшно: пользователь может запросить ссылку еще раз
+		err = cs.sendVerification(ctx, us)
+		if err != nil {
+			cs.logger.Errorf("Error while sending verification email: %v", err)
+		}
+	}
+
+	cs.logger.Infof("User %d changed %v", us.ID, changed)
+
+	return us, nil
+}
+
+func (cs *CongratulationsServiceImpl) ChangePassword(ctx context.Context, current, newPassword string) (*session.Session, error) {
+	if newPassword == "" {
+		return nil, ErrEmptyPassword
+	}
+
+	us, err := cs.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cs.checkPassword(ctx, us.ID, us.Username, current)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cs.usersRepo.SetPassword(ctx, us.ID, newPassword)
+	if err != nil {
+		cs.logger.Errorf("Error setting password: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	cs.recordChanges(ctx, us.ID, us.ID, []string{audit.FieldPassword})
+
+	// остальные сессии могли быть открыты тем, кто знал старый пароль
+	err = cs.sm.DestroyAll(ctx, us.ID)
+	if err != nil {
+		cs.logger.Errorf("Error destroying sessions of user %d: %v", us.ID, err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	sess, err := cs.sm.Create(ctx, us.ID)
+	if err != nil {
+		cs.logger.Errorf("Error while creating session: %v", err)
+		return nil, fmt.Errorf("internal error")
+	}
+
+	cs.logger.Infof("User %d changed password", us.ID)
+
+	return sess, nil
+}
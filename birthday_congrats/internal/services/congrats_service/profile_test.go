@@ -0,0 +1,348 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/audit"
+	"birthday_congrats/internal/pkg/birthday"
+	"birthday_congrats/internal/pkg/delivery"
+	"birthday_congrats/internal/pkg/outbox"
+	"birthday_congrats/internal/pkg/reset"
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/subscription"
+	"birthday_congrats/internal/pkg/user"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newProfileTestService(ctrl *gomock.Controller) (
+	*CongratulationsServiceImpl,
+	*user.MockUsersRepo,
+	*session.MockSessionsManager,
+	*outbox.MockOutbox,
+	*audit.MockAuditRepo,
+	*reset.MockResetsRepo,
+) {
+	usersRepo := user.NewMockUsersRepo(ctrl)
+	sessManager := session.NewMockSessionsManager(ctrl)
+	outboxRepo := outbox.NewMockOutbox(ctrl)
+	auditRepo := audit.NewMockAuditRepo(ctrl)
+	resetsRepo := reset.NewMockResetsRepo(ctrl)
+
+	testService := NewCongratulationsServiceImpl(
+		usersRepo,
+		subscription.NewMockSubscriptionsRepo(ctrl),
+		sessManager,
+		outboxRepo,
+		delivery.NewMockDeliveriesRepo(ctrl),
+		resetsRepo,
+		auditRepo,
+		birthday.LeapDayFeb28,
+		testVerifier,
+		testBaseURL,
+		time.Hour,
+		24*time.Hour,
+		zap.NewNop().Sugar(),
+	)
+
+	return testService, usersRepo, sessManager, outboxRepo, auditRepo, resetsRepo
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestUpdateProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, outboxRepo, auditRepo, resetsRepo := newProfileTestService(ctrl)
+
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	// данные для теста
+	userID := uint32(42)
+	sess := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  userID,
+		Expires: now.Unix() + 60,
+	}
+	ctx := session.ContextWithSession(context.Background(), sess)
+
+	current := func() *user.User {
+		return &user.User{
+			ID:            userID,
+			Username:      "some_user",
+			Email:         "some@email.net",
+			EmailVerified: true,
+			Timezone:      "UTC",
+			Birthday:      birthday.Birthday{Year: 1990, Month: time.March, Day: 1},
+		}
+	}
+
+	// смена имени и дня рождения
+	expected := current()
+	expected.Username = "other_user"
+	expected.Birthday = birthday.Birthday{Month: time.March, Day: 2}
+
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+	usersRepo.EXPECT().Update(ctx, expected).Return(nil)
+	auditRepo.EXPECT().Record(ctx, []audit.Entry{
+		{ActorID: userID, UserID: userID, Field: audit.FieldUsername, At: now},
+		{ActorID: userID, UserID: userID, Field: audit.FieldBirthday, At: now},
+	}).Return(nil)
+
+	us, err := testService.UpdateProfile(ctx, ProfileUpdate{
+		Username: strPtr("other_user"),
+		Birthday: strPtr("--03-02"),
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, expected, us)
+
+	// почта не подтверждена, но не менялась - письмо не отправляется
+	unverified := current()
+	unverified.EmailVerified = false
+	expected = current()
+	expected.EmailVerified = false
+	expected.Username = "other_user"
+
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(unverified, nil)
+	usersRepo.EXPECT().Update(ctx, expected).Return(nil)
+	auditRepo.EXPECT().Record(ctx, gomock.Any()).Return(nil)
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{Username: strPtr("other_user")})
+
+	assert.NoError(t, err)
+
+	// смена почты: подтверждение сбрасывается, ссылки сброса пароля отзываются
+	// и приходит письмо на новый адрес
+	expected = current()
+	expected.Email = "new@email.net"
+	expected.EmailVerified = false
+
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+	usersRepo.EXPECT().Login(ctx, "some_user", "some_pass").Return(current(), nil)
+	resetsRepo.EXPECT().RemoveByUser(ctx, userID).Return(nil)
+	usersRepo.EXPECT().Update(ctx, expected).Return(nil)
+	auditRepo.EXPECT().Record(ctx, []audit.Entry{
+		{ActorID: userID, UserID: userID, Field: audit.FieldEmail, At: now},
+	}).Return(nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{"new@email.net"}, "Подтверждение почты", gomock.Any()).Return(nil)
+
+	us, err = testService.UpdateProfile(ctx, ProfileUpdate{
+		Email:           strPtr("new@email.net"),
+		CurrentPassword: "some_pass",
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, expected, us)
+
+	// смена имени и почты вместе: пароль проверяется по прежнему имени
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+	usersRepo.EXPECT().Login(ctx, "some_user", "some_pass").Return(current(), nil)
+	resetsRepo.EXPECT().RemoveByUser(ctx, userID).Return(nil)
+	usersRepo.EXPECT().Update(ctx, gomock.Any()).Return(nil)
+	auditRepo.EXPECT().Record(ctx, gomock.Any()).Return(nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{"new@email.net"}, "Подтверждение почты", gomock.Any()).Return(nil)
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{
+		Username:        strPtr("other_user"),
+		Email:           strPtr("new@email.net"),
+		CurrentPassword: "some_pass",
+	})
+
+	assert.NoError(t, err)
+
+	// смена почты с неверным или без текущего пароля - ничего не сохраняется
+	for _, password := range []string{"", "wrong_pass"} {
+		usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+		usersRepo.EXPECT().Login(ctx, "some_user", password).Return(nil, user.ErrBadPassword)
+
+		_, err = testService.UpdateProfile(ctx, ProfileUpdate{
+			Email:           strPtr("new@email.net"),
+			CurrentPassword: password,
+		})
+
+		assert.ErrorIs(t, err, user.ErrBadPassword, password)
+	}
+
+	// ошибка проверки пароля
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+	usersRepo.EXPECT().Login(ctx, "some_user", "some_pass").Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{
+		Email:           strPtr("new@email.net"),
+		CurrentPassword: "some_pass",
+	})
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, user.ErrBadPassword)
+
+	// те же значения - ничего не сохраняется и не записывается
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+
+	us, err = testService.UpdateProfile(ctx, ProfileUpdate{
+		Username: strPtr("some_user"),
+		Email:    strPtr("some@email.net"),
+		Birthday: strPtr("1990-03-01"),
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, current(), us)
+
+	// ошибка журнала не отменяет изменение
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+	usersRepo.EXPECT().Update(ctx, gomock.Any()).Return(nil)
+	auditRepo.EXPECT().Record(ctx, gomock.Any()).Return(fmt.Errorf("audit error"))
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{Username: strPtr("other_user")})
+
+	assert.NoError(t, err)
+
+	// имя занято
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+	usersRepo.EXPECT().Update(ctx, gomock.Any()).Return(user.ErrUserExists)
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{Username: strPtr("taken")})
+
+	assert.ErrorIs(t, err, user.ErrUserExists)
+
+	// пустое имя
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{Username: strPtr("")})
+
+	assert.ErrorIs(t, err, ErrEmptyUsername)
+
+	// некорректная почта
+	for _, email := range []string{"", "not_an_email", "Name <some@email.net>"} {
+		usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+
+		_, err = testService.UpdateProfile(ctx, ProfileUpdate{Email: strPtr(email)})
+
+		assert.ErrorIs(t, err, ErrBadEmail, email)
+	}
+
+	// некорректная дата
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{Birthday: strPtr("01.03.1990")})
+
+	assert.ErrorIs(t, err, ErrBadDateFormat)
+
+	// дата в будущем
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{Birthday: strPtr("2030-01-01")})
+
+	assert.ErrorIs(t, err, birthday.ErrBirthdayInFuture)
+
+	// нет сессии
+	_, err = testService.UpdateProfile(context.Background(), ProfileUpdate{Username: strPtr("other_user")})
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка хранилища
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+	usersRepo.EXPECT().Update(ctx, gomock.Any()).Return(fmt.Errorf("repo error"))
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{Username: strPtr("other_user")})
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, user.ErrUserExists)
+
+	// не удалось отозвать ссылки сброса - почта не меняется
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(current(), nil)
+	usersRepo.EXPECT().Login(ctx, "some_user", "some_pass").Return(current(), nil)
+	resetsRepo.EXPECT().RemoveByUser(ctx, userID).Return(fmt.Errorf("repo error"))
+
+	_, err = testService.UpdateProfile(ctx, ProfileUpdate{
+		Email:           strPtr("new@email.net"),
+		CurrentPassword: "some_pass",
+	})
+
+	assert.Error(t, err)
+}
+
+func TestChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, sessManager, _, auditRepo, _ := newProfileTestService(ctrl)
+
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	// данные для теста
+	us := &user.User{ID: 42, Username: "some_user"}
+	sess := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  us.ID,
+		Expires: now.Unix() + 60,
+	}
+	newSess := &session.Session{
+		SessID:  "new_sess_id",
+		UserID:  us.ID,
+		Expires: now.Unix() + 60,
+	}
+	ctx := session.ContextWithSession(context.Background(), sess)
+
+	// нормальная работа: остальные сессии завершаются, текущему клиенту - новая
+	usersRepo.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	usersRepo.EXPECT().Login(ctx, us.Username, "old_pass").Return(us, nil)
+	usersRepo.EXPECT().SetPassword(ctx, us.ID, "new_pass").Return(nil)
+	auditRepo.EXPECT().Record(ctx, []audit.Entry{
+		{ActorID: us.ID, UserID: us.ID, Field: audit.FieldPassword, At: now},
+	}).Return(nil)
+	sessManager.EXPECT().DestroyAll(ctx, us.ID).Return(nil)
+	sessManager.EXPECT().Create(ctx, us.ID).Return(newSess, nil)
+
+	got, err := testService.ChangePassword(ctx, "old_pass", "new_pass")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, newSess, got)
+
+	// неверный текущий пароль
+	usersRepo.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	usersRepo.EXPECT().Login(ctx, us.Username, "wrong_pass").Return(nil, user.ErrBadPassword)
+
+	_, err = testService.ChangePassword(ctx, "wrong_pass", "new_pass")
+
+	assert.ErrorIs(t, err, user.ErrBadPassword)
+
+	// пустой новый пароль
+	_, err = testService.ChangePassword(ctx, "old_pass", "")
+
+	assert.ErrorIs(t, err, ErrEmptyPassword)
+
+	// нет сессии
+	_, err = testService.ChangePassword(context.Background(), "old_pass", "new_pass")
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка смены пароля
+	usersRepo.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	usersRepo.EXPECT().Login(ctx, us.Username, "old_pass").Return(us, nil)
+	usersRepo.EXPECT().SetPassword(ctx, us.ID, "new_pass").Return(fmt.Errorf("repo error"))
+
+	_, err = testService.ChangePassword(ctx, "old_pass", "new_pass")
+
+	assert.Error(t, err)
+
+	// ошибка завершения сессий
+	usersRepo.EXPECT().GetByID(ctx, us.ID).Return(us, nil)
+	usersRepo.EXPECT().Login(ctx, us.Username, "old_pass").Return(us, nil)
+	usersRepo.EXPECT().SetPassword(ctx, us.ID, "new_pass").Return(nil)
+	auditRepo.EXPECT().Record(ctx, gomock.Any()).Return(nil)
+	sessManager.EXPECT().DestroyAll(ctx, us.ID).Return(fmt.Errorf("session error"))
+
+	_, err = testService.ChangePassword(ctx, "old_pass", "new_pass")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// sendVerification кладет в очередь письмо со ссылкой подтверждения почты пользователя.
+// В ссылку зашит адрес: если почту поменяют, старая ссылка перестанет подходить.
+func (cs *CongratulationsServiceImpl) sendVerification(ctx context.Context, us *user.User) error {
+	token := cs.verifier.Issue(us.ID, us.Email, cs.now())
+	link := cs.baseURL + "/verify?token=" + url.QueryEscape(token)
+
+	err := cs.outbox.Enqueue(ctx, []string{us.Email}, "Подтверждение почты", verificationText(us.Username, link))
+	if err != nil {
+		return fmt.Errorf("outbox error: %v", err)
+	}
+
+	return nil
+}
+
+func (cs *CongratulationsServiceImpl) VerifyEmail(ctx context.Context, token string) error {
+	userID, email, err := cs.verifier.Check(token, cs.now())
+	if err != nil {
+		cs.logger.Warnf("Bad verification token: %v", err)
+		return err
+	}
+
+	us, err := cs.usersRepo.GetByID(ctx, userID)
+	if err != nil && err != user.ErrNoUser {
+		cs.logger.Errorf("Error getting user by id: %v", err)
+		return fmt.Errorf("internal error")
+	}
+	if err == user.ErrNoUser || us.Email != email {
+		cs.logger.Warnf("Verification token of user %d does not match current email", userID)
+		return verification.ErrBadToken
+	}
+
+	if us.EmailVerified {
+		return nil
+	}
+
+	us.EmailVerified = true
+
+	err = cs.usersRepo.Update(ctx, us)
+	if err != nil {
+		cs.logger.Errorf("Error updating user: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	cs.logger.Infof("User %d verified email", userID)
+
+	return nil
+}
+
+func (cs *CongratulationsServiceImpl) ResendVerification(ctx context.Context) error {
+	sess, err := session.SessionFromContext(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error getting session from context: %v", err)
+		return session.ErrNoSession
+	}
+
+	us, err := cs.usersRepo.GetByID(ctx, sess.UserID)
+	if err != nil {
+		cs.logger.Errorf("Error getting user by id: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	if us.EmailVerified {
+		return nil
+	}
+
+	err = cs.sendVerification(ctx, us)
+	if err != nil {
+		cs.logger.Errorf("Error while sending verification email: %v", err)
+		return fmt.Errorf("internal error")
+	}
+
+	return nil
+}
+
+// SendVerifications рассылает ссылки подтверждения всем активным пользователям с неподтвержденной
+// почтой: например, перенесенным из базы, созданной до появления подтверждения
+func (cs *CongratulationsServiceImpl) SendVerifications(ctx context.Context) (int, error) {
+	err := cs.requireAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	users, err := cs.usersRepo.GetAll(ctx)
+	if err != nil {
+		cs.logger.Errorf("Error while getting all users: %v", err)
+		return 0, fmt.Errorf("internal error")
+	}
+
+	sent := 0
+	for _, us := range users {
+		if us.EmailVerified || us.Deactivated {
+			continue
+		}
+
+		err = cs.sendVerification(ctx, us)
+		if err != nil {
+			cs.logger.Errorf("Error while sending verification email to user %d: %v", us.ID, err)
+			return sent, fmt.Errorf("internal error")
+		}
+
+		sent++
+	}
+
+	cs.logger.Infof("Sent %d verification emails", sent)
+
+	return sent, nil
+}
@@ -0,0 +1,195 @@
+package congrats_service
+
+import (
+	"birthday_congrats/internal/pkg/session"
+	"birthday_congrats/internal/pkg/user"
+	"birthday_congrats/internal/pkg/verification"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testVerifier = verification.NewSigner([]byte("test_verification_key_test_verif"), verification.PurposeEmail, time.Hour)
+	testBaseURL  = "http://birthday.example.com"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, _ := newDirectoryTestService(ctrl)
+
+	now := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+	testService.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	// данные для теста
+	userID := uint32(42)
+	email := "some@email.net"
+	token := testVerifier.Issue(userID, email, now.Add(-time.Minute))
+
+	// нормальная работа
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID, Email: email}, nil)
+	usersRepo.EXPECT().Update(ctx, &user.User{ID: userID, Email: email, EmailVerified: true}).Return(nil)
+
+	err := testService.VerifyEmail(ctx, token)
+
+	assert.NoError(t, err)
+
+	// повторный переход по ссылке
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID, Email: email, EmailVerified: true}, nil)
+
+	err = testService.VerifyEmail(ctx, token)
+
+	assert.NoError(t, err)
+
+	// почту поменяли после отправки ссылки
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID, Email: "other@email.net"}, nil)
+
+	err = testService.VerifyEmail(ctx, token)
+
+	assert.ErrorIs(t, err, verification.ErrBadToken)
+
+	// пользователя уже нет
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(nil, user.ErrNoUser)
+
+	err = testService.VerifyEmail(ctx, token)
+
+	assert.ErrorIs(t, err, verification.ErrBadToken)
+
+	// испорченная ссылка
+	err = testService.VerifyEmail(ctx, token+"x")
+
+	assert.ErrorIs(t, err, verification.ErrBadToken)
+
+	// ссылка устарела
+	err = testService.VerifyEmail(ctx, testVerifier.Issue(userID, email, now.Add(-2*time.Hour)))
+
+	assert.ErrorIs(t, err, verification.ErrTokenExpired)
+
+	// ошибка хранилища
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(nil, fmt.Errorf("repo error"))
+
+	err = testService.VerifyEmail(ctx, token)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, verification.ErrBadToken)
+
+	// ошибка сохранения
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID, Email: email}, nil)
+	usersRepo.EXPECT().Update(ctx, gomock.Any()).Return(fmt.Errorf("repo error"))
+
+	err = testService.VerifyEmail(ctx, token)
+
+	assert.Error(t, err)
+}
+
+func TestResendVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, outboxRepo, _ := newTestService(ctrl)
+
+	// данные для теста
+	userID := uint32(42)
+	sess := &session.Session{
+		SessID:  "some_sess_id",
+		UserID:  userID,
+		Expires: time.Now().Unix() + 60,
+	}
+	us := &user.User{ID: userID, Username: "some_user", Email: "some@email.net"}
+
+	// нормальная работа
+	ctx := session.ContextWithSession(context.Background(), sess)
+
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(us, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{us.Email}, "Подтверждение почты", gomock.Any()).Return(nil)
+
+	err := testService.ResendVerification(ctx)
+
+	assert.NoError(t, err)
+
+	// почта уже подтверждена - письмо не нужно
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(&user.User{ID: userID, EmailVerified: true}, nil)
+
+	err = testService.ResendVerification(ctx)
+
+	assert.NoError(t, err)
+
+	// нет сессии
+	err = testService.ResendVerification(context.Background())
+
+	assert.ErrorIs(t, err, session.ErrNoSession)
+
+	// ошибка хранилища
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(nil, fmt.Errorf("repo error"))
+
+	err = testService.ResendVerification(ctx)
+
+	assert.Error(t, err)
+
+	// ошибка очереди
+	usersRepo.EXPECT().GetByID(ctx, userID).Return(us, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{us.Email}, "Подтверждение почты", gomock.Any()).Return(fmt.Errorf("outbox error"))
+
+	err = testService.ResendVerification(ctx)
+
+	assert.Error(t, err)
+}
+
+func TestSendVerifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testService, usersRepo, _, outboxRepo, _ := newTestService(ctrl)
+
+	ctx := session.ContextAsSystem(context.Background())
+
+	// данные для теста
+	users := []*user.User{
+		{ID: 1, Username: "alice", Email: "alice@example.com"},
+		{ID: 2, Username: "bob", Email: "bob@example.com", EmailVerified: true},
+		{ID: 3, Username: "carol", Email: "carol@example.com", Deactivated: true},
+		{ID: 4, Username: "dave", Email: "dave@example.com"},
+	}
+
+	// нормальная работа: письма только активным с неподтвержденной почтой
+	usersRepo.EXPECT().GetAll(ctx).Return(users, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{"alice@example.com"}, "Подтверждение почты", gomock.Any()).Return(nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{"dave@example.com"}, "Подтверждение почты", gomock.Any()).Return(nil)
+
+	sent, err := testService.SendVerifications(ctx)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, sent)
+
+	// ошибка очереди
+	usersRepo.EXPECT().GetAll(ctx).Return(users, nil)
+	outboxRepo.EXPECT().Enqueue(ctx, []string{"alice@example.com"}, "Подтверждение почты", gomock.Any()).Return(fmt.Errorf("outbox error"))
+
+	_, err = testService.SendVerifications(ctx)
+
+	assert.Error(t, err)
+
+	// ошибка бд
+	usersRepo.EXPECT().GetAll(ctx).Return(nil, fmt.Errorf("repo error"))
+
+	_, err = testService.SendVerifications(ctx)
+
+	assert.Error(t, err)
+
+	// не администратор
+	employeeCtx := session.ContextWithSession(context.Background(), &session.Session{SessID: "some_sess_id", UserID: 2})
+
+	usersRepo.EXPECT().GetByID(employeeCtx, uint32(2)).Return(&user.User{ID: 2, Role: user.RoleEmployee}, nil)
+
+	_, err = testService.SendVerifications(employeeCtx)
+
+	assert.ErrorIs(t, err, ErrForbidden)
+}
@@ -1,2 +1,3 @@
 cd birthday_congrats
-go run -mod=vendor ./... -port=8080
\ No newline at end of file
+go run -mod=vendor ./cmd/birthday_congrats -config=config.yaml migrate up &&
+go run -mod=vendor ./... -config=config.yaml -port=8080
\ No newline at end of file